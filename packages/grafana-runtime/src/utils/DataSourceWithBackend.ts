@@ -103,6 +103,10 @@ export class DataSourceWithBackend<
       body.to = range.to.valueOf().toString();
     }
 
+    if (request.dashboardId) {
+      body.dashboardId = request.dashboardId;
+    }
+
     return getBackendSrv()
       .fetch({
         url: '/api/ds/query',
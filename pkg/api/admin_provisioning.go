@@ -3,11 +3,17 @@ package api
 import (
 	"context"
 	"errors"
+	"io/ioutil"
+	"net/http"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 )
 
+// maxWebhookSize is the maximum size of the body of a dashboard repository webhook.
+const maxWebhookSize = 10 << 20
+
 func (hs *HTTPServer) AdminProvisioningReloadDashboards(c *models.ReqContext) response.Response {
 	err := hs.ProvisioningService.ProvisionDashboards()
 	if err != nil && !errors.Is(err, context.Canceled) {
@@ -39,3 +45,30 @@ func (hs *HTTPServer) AdminProvisioningReloadNotifications(c *models.ReqContext)
 	}
 	return response.Success("Notifications config reloaded")
 }
+
+// DashboardRepositoryWebhook syncs the dashboards of a git provisioner when its repository changes. The
+// webhook is signed with the webhook secret of the provisioner, GitHub style, or sends it as a GitLab token.
+func (hs *HTTPServer) DashboardRepositoryWebhook(c *models.ReqContext) response.Response {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Resp, c.Req.Request.Body, maxWebhookSize))
+	if err != nil {
+		return response.Error(400, "Failed to read webhook", err)
+	}
+
+	err = hs.ProvisioningService.HandleDashboardRepositoryWebhook(
+		c.Params(":name"),
+		body,
+		c.Req.Header.Get("X-Hub-Signature-256"),
+		c.Req.Header.Get("X-Gitlab-Token"),
+	)
+	if err != nil {
+		if errors.Is(err, dashboards.ErrRepositoryNotFound) || errors.Is(err, dashboards.ErrRepositoryWebhookDisabled) {
+			return response.Error(404, "Dashboard repository not found", nil)
+		}
+		if errors.Is(err, dashboards.ErrRepositoryWebhookInvalidSignature) {
+			return response.Error(401, err.Error(), nil)
+		}
+		return response.Error(500, "Failed to sync dashboard repository", err)
+	}
+
+	return response.Respond(202, nil)
+}
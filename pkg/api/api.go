@@ -121,6 +121,9 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/dashboard/snapshot/*", hs.Index)
 	r.Get("/dashboard/snapshots/", reqSignedIn, hs.Index)
 
+	// dashboard repository webhooks, verified with the webhook secret of the provisioner
+	r.Post("/api/provisioning/dashboards/:name/webhook", routing.Wrap(hs.DashboardRepositoryWebhook))
+
 	// api renew session based on cookie
 	r.Get("/api/login/ping", quota("session"), routing.Wrap(hs.LoginAPIPing))
 
@@ -264,6 +267,13 @@ func (hs *HTTPServer) registerRoutes() {
 
 		apiRoute.Get("/datasources/id/:name", routing.Wrap(GetDataSourceIdByName), reqSignedIn)
 
+		// Files of the files data source
+		apiRoute.Group("/datasource-files", func(filesRoute routing.RouteRegister) {
+			filesRoute.Get("/", routing.Wrap(hs.GetDataSourceFiles))
+			filesRoute.Post("/", routing.Wrap(hs.UploadDataSourceFile))
+			filesRoute.Delete("/:name", routing.Wrap(hs.DeleteDataSourceFile))
+		}, reqOrgAdmin)
+
 		apiRoute.Get("/plugins", routing.Wrap(hs.GetPluginList))
 		apiRoute.Get("/plugins/:pluginId/settings", routing.Wrap(GetPluginSettingByID))
 		apiRoute.Get("/plugins/:pluginId/markdown/:name", routing.Wrap(hs.GetPluginMarkdown))
@@ -295,6 +305,7 @@ func (hs *HTTPServer) registerRoutes() {
 				folderUidRoute.Get("/", routing.Wrap(GetFolderByUID))
 				folderUidRoute.Put("/", bind(models.UpdateFolderCommand{}), routing.Wrap(UpdateFolder))
 				folderUidRoute.Delete("/", routing.Wrap(hs.DeleteFolder))
+				folderUidRoute.Post("/move", bind(models.MoveFolderCommand{}), routing.Wrap(MoveFolder))
 
 				folderUidRoute.Group("/permissions", func(folderPermissionRoute routing.RouteRegister) {
 					folderPermissionRoute.Get("/", routing.Wrap(hs.GetFolderPermissionList))
@@ -307,6 +318,8 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Group("/dashboards", func(dashboardRoute routing.RouteRegister) {
 			dashboardRoute.Get("/uid/:uid", routing.Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/uid/:uid", routing.Wrap(hs.DeleteDashboardByUID))
+			dashboardRoute.Post("/uid/:uid/patch", bind(dtos.PatchDashboardCommand{}), routing.Wrap(hs.PatchDashboard))
+			dashboardRoute.Get("/uid/:uid/usage", routing.Wrap(hs.GetDashboardUsage))
 
 			dashboardRoute.Get("/db/:slug", routing.Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/db/:slug", routing.Wrap(hs.DeleteDashboardBySlug))
@@ -318,6 +331,19 @@ func (hs *HTTPServer) registerRoutes() {
 			dashboardRoute.Get("/tags", GetDashboardTags)
 			dashboardRoute.Post("/import", bind(dtos.ImportDashboardCommand{}), routing.Wrap(hs.ImportDashboard))
 
+			dashboardRoute.Group("/trash", func(trashRoute routing.RouteRegister) {
+				trashRoute.Get("/", routing.Wrap(GetDashboardTrash))
+				trashRoute.Post("/:id/restore", routing.Wrap(hs.RestoreDashboardFromTrash))
+				trashRoute.Delete("/:id", routing.Wrap(PurgeDashboardTrashItem))
+			}, reqOrgAdmin)
+
+			dashboardRoute.Group("/bundle", func(bundleRoute routing.RouteRegister) {
+				bundleRoute.Get("/export", routing.Wrap(hs.ExportDashboardBundle))
+				bundleRoute.Post("/import", routing.Wrap(hs.ImportDashboardBundle))
+			}, reqOrgAdmin)
+
+			dashboardRoute.Get("/usage/unused", reqOrgAdmin, routing.Wrap(hs.GetUnusedDashboards))
+
 			dashboardRoute.Group("/id/:dashboardId", func(dashIdRoute routing.RouteRegister) {
 				dashIdRoute.Get("/versions", routing.Wrap(GetDashboardVersions))
 				dashIdRoute.Get("/versions/:id", routing.Wrap(GetDashboardVersion))
@@ -351,12 +377,23 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Get("/search/", routing.Wrap(Search))
 
 		// metrics
-		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetrics))
+		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.recordDashboardQueries(hs.QueryMetrics)))
 		apiRoute.Get("/tsdb/testdata/gensql", reqGrafanaAdmin, routing.Wrap(GenerateSQLTestData))
 		apiRoute.Get("/tsdb/testdata/random-walk", routing.Wrap(hs.GetTestDataRandomWalk))
 
 		// DataSource w/ expressions
-		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), routing.Wrap(hs.recordDashboardQueries(hs.QueryMetricsV2)))
+
+		// Prometheus HTTP API over data sources
+		apiRoute.Group("/datasources/uid/:uid/prometheus/api/v1", func(promRoute routing.RouteRegister) {
+			promRoute.Get("/query_range", routing.Wrap(hs.PrometheusQueryRange))
+			promRoute.Post("/query_range", routing.Wrap(hs.PrometheusQueryRange))
+			promRoute.Get("/series", routing.Wrap(hs.PrometheusSeries))
+			promRoute.Post("/series", routing.Wrap(hs.PrometheusSeries))
+			promRoute.Get("/labels", routing.Wrap(hs.PrometheusLabels))
+			promRoute.Post("/labels", routing.Wrap(hs.PrometheusLabels))
+			promRoute.Get("/label/:name/values", routing.Wrap(hs.PrometheusLabelValues))
+		}, reqSignedIn)
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), routing.Wrap(hs.AlertTest))
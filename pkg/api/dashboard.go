@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/manager"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/util"
@@ -74,6 +76,17 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) response.Response {
 		return dashboardGuardianResponse(err)
 	}
 
+	if !dash.IsFolder {
+		if err := bus.Publish(&events.DashboardViewed{
+			Timestamp:   time.Now(),
+			OrgId:       c.OrgId,
+			DashboardId: dash.Id,
+			UserId:      c.UserId,
+		}); err != nil {
+			hs.log.Warn("Failed to record dashboard view", "dashboard", dash.Uid, "error", err)
+		}
+	}
+
 	canEdit, _ := guardian.CanEdit()
 	canSave, _ := guardian.CanSave()
 	canAdmin, _ := guardian.CanAdmin()
@@ -228,7 +241,7 @@ func (hs *HTTPServer) deleteDashboard(c *models.ReqContext) response.Response {
 	}
 
 	svc := dashboards.NewService()
-	err := svc.DeleteDashboard(dash.Id, c.OrgId)
+	err := svc.MoveDashboardToTrash(dash.Id, c.OrgId, c.UserId)
 	if err != nil {
 		var dashboardErr models.DashboardErr
 		if ok := errors.As(err, &dashboardErr); ok {
@@ -297,6 +310,14 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 		return dashboardSaveErrorToApiResponse(err)
 	}
 
+	if provisioningData != nil {
+		err := hs.ProvisioningService.CommitDashboardToRepository(provisioningData.Name, provisioningData.ExternalId,
+			dashboard, cmd.Message, c.SignedInUser)
+		if err != nil {
+			hs.log.Warn("Failed to commit dashboard to repository", "dashboard", dashboard.Uid, "error", err)
+		}
+	}
+
 	if hs.Cfg.EditorsCanAdmin && newDashboard {
 		inFolder := cmd.FolderId > 0
 		err := dashboards.MakeUserAdmin(hs.Bus, cmd.OrgId, cmd.UserId, dashboard.Id, !inFolder)
@@ -307,10 +328,12 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 
 	// Tell everyone listening that the dashboard changed
 	if hs.Live.IsEnabled() {
-		err := hs.Live.GrafanaScope.Dashboards.DashboardSaved(
-			dashboard.Uid,
-			c.UserId,
-		)
+		var err error
+		if dashItem.Merged {
+			err = hs.Live.GrafanaScope.Dashboards.DashboardMerged(dashboard.Uid, c.UserId, dashboard.Version)
+		} else {
+			err = hs.Live.GrafanaScope.Dashboards.DashboardSaved(dashboard.Uid, c.UserId)
+		}
 		if err != nil {
 			hs.log.Warn("unable to broadcast save event", "uid", dashboard.Uid, "error", err)
 		}
@@ -325,17 +348,27 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 	}
 
 	c.TimeRequest(metrics.MApiDashboardSave)
-	return response.JSON(200, util.DynMap{
+	result := util.DynMap{
 		"status":  "success",
 		"slug":    dashboard.Slug,
 		"version": dashboard.Version,
 		"id":      dashboard.Id,
 		"uid":     dashboard.Uid,
 		"url":     dashboard.GetUrl(),
-	})
+	}
+	if dashItem.Merged {
+		// the saved dashboard contains changes made by someone else that have to be reloaded
+		result["merged"] = true
+	}
+	return response.JSON(200, result)
 }
 
 func dashboardSaveErrorToApiResponse(err error) response.Response {
+	var conflictErr models.DashboardEditConflictError
+	if ok := errors.As(err, &conflictErr); ok {
+		return response.JSON(models.ErrDashboardVersionMismatch.StatusCode, conflictErr.Body())
+	}
+
 	var dashboardErr models.DashboardErr
 	if ok := errors.As(err, &dashboardErr); ok {
 		if body := dashboardErr.Body(); body != nil {
@@ -561,13 +594,54 @@ func CalculateDashboardDiff(c *models.ReqContext, apiOptions dtos.CalculateDiffO
 		return response.Error(500, "Unable to compute diff", err)
 	}
 
-	if options.DiffType == dashdiffs.DiffDelta {
+	if options.DiffType == dashdiffs.DiffDelta || options.DiffType == dashdiffs.DiffJSONPatch {
 		return response.Respond(200, result.Delta).Header("Content-Type", "application/json")
 	}
 
 	return response.Respond(200, result.Delta).Header("Content-Type", "text/html")
 }
 
+// PatchDashboard applies a JSON patch to a dashboard. The patch must be made for the current version of
+// the dashboard, so changes made in the meantime are not overwritten.
+func (hs *HTTPServer) PatchDashboard(c *models.ReqContext, cmd dtos.PatchDashboardCommand) response.Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	if cmd.Version != dash.Version {
+		return dashboardSaveErrorToApiResponse(models.ErrDashboardVersionMismatch)
+	}
+
+	data, err := dashdiffs.ApplyDashboardPatch(dash.Data, cmd.Patch)
+	if err != nil {
+		if errors.Is(err, dashdiffs.ErrPatchConflict) {
+			return response.JSON(409, util.DynMap{"status": "patch-conflict", "message": err.Error()})
+		}
+		if errors.Is(err, dashdiffs.ErrInvalidPatch) {
+			return response.Error(400, err.Error(), nil)
+		}
+		return response.Error(500, "Unable to apply patch", err)
+	}
+
+	data.Set("id", dash.Id)
+	data.Set("uid", dash.Uid)
+	data.Set("version", dash.Version)
+
+	saveCmd := models.SaveDashboardCommand{
+		Dashboard: data,
+		FolderId:  dash.FolderId,
+		Message:   cmd.Message,
+	}
+
+	return hs.PostDashboard(c, saveCmd)
+}
+
 // RestoreDashboardVersion restores a dashboard to the given version.
 func (hs *HTTPServer) RestoreDashboardVersion(c *models.ReqContext, apiCmd dtos.RestoreDashboardVersionCommand) response.Response {
 	dash, rsp := getDashboardHelper(c.OrgId, "", c.ParamsInt64(":dashboardId"), "")
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/bundles"
+)
+
+const (
+	// maxBundleUploadSize is the maximum size of an imported bundle archive.
+	maxBundleUploadSize = 50 << 20
+	// defaultBundleProvisioningPath is where the provisioning files of an exported bundle expect it to be extracted.
+	defaultBundleProvisioningPath = "/var/lib/grafana/bundles"
+)
+
+// GET /api/dashboards/bundle/export?uid=<uid>&format=zip|tar&path=<path> exports dashboards with their folders,
+// library panels, data sources and notification channels as a bundle archive.
+func (hs *HTTPServer) ExportDashboardBundle(c *models.ReqContext) response.Response {
+	uids := c.QueryStrings("uid")
+	if len(uids) == 0 {
+		return response.Error(400, "At least one dashboard uid is required", nil)
+	}
+	format, err := bundles.ParseFormat(c.Query("format"))
+	if err != nil {
+		return response.Error(400, err.Error(), nil)
+	}
+	provisioningPath := c.Query("path")
+	if provisioningPath == "" {
+		provisioningPath = defaultBundleProvisioningPath
+	}
+
+	bundle, err := hs.BundleService.Export(c, uids)
+	if err != nil {
+		return bundleErrorToApiResponse(err, "Failed to export dashboards")
+	}
+
+	var archive bytes.Buffer
+	if err := bundle.Write(&archive, format, provisioningPath); err != nil {
+		return response.Error(500, "Failed to write bundle", err)
+	}
+
+	fileName := fmt.Sprintf("grafana-bundle-%s%s", bundle.Manifest.Exported.Format("20060102-150405"), format.Extension())
+	header := http.Header{}
+	header.Set("Content-Type", format.ContentType())
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	return response.CreateNormalResponse(header, archive.Bytes(), 200)
+}
+
+// POST /api/dashboards/bundle/import?conflicts=fail|skip|overwrite&dryRun=true imports a bundle archive, sent as
+// the request body. Conflicts with existing content are resolved by UID.
+func (hs *HTTPServer) ImportDashboardBundle(c *models.ReqContext) response.Response {
+	conflicts, err := bundles.ParseConflictResolution(c.Query("conflicts"))
+	if err != nil {
+		return response.Error(400, err.Error(), nil)
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Resp, c.Req.Request.Body, maxBundleUploadSize))
+	if err != nil {
+		return response.Error(413, "Bundle is too large", err)
+	}
+	bundle, err := bundles.ReadBundle(body)
+	if err != nil {
+		return bundleErrorToApiResponse(err, "Failed to read bundle")
+	}
+
+	result, err := hs.BundleService.Import(c, bundle, bundles.ImportOptions{
+		Conflicts: conflicts,
+		DryRun:    c.QueryBool("dryRun"),
+	})
+	if err != nil {
+		if errors.Is(err, bundles.ErrBundleConflicts) {
+			return response.JSON(409, result)
+		}
+		resp := bundleErrorToApiResponse(err, "Failed to import bundle")
+		if result != nil {
+			// the content imported before the failure is kept, the result marks the item that failed
+			return response.JSON(resp.Status(), result)
+		}
+		return resp
+	}
+
+	return response.JSON(200, result)
+}
+
+func bundleErrorToApiResponse(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, bundles.ErrInvalidBundle):
+		return response.Error(400, err.Error(), nil)
+	case errors.Is(err, bundles.ErrBundleTooLarge):
+		return response.Error(413, err.Error(), nil)
+	case errors.Is(err, models.ErrDashboardNotFound), errors.Is(err, models.ErrFolderNotFound):
+		return response.Error(404, err.Error(), nil)
+	case errors.Is(err, bundles.ErrDashboardAccessDenied), errors.Is(err, models.ErrFolderAccessDenied):
+		return response.Error(403, err.Error(), nil)
+	}
+
+	var dashboardErr models.DashboardErr
+	if errors.As(err, &dashboardErr) {
+		return dashboardSaveErrorToApiResponse(err)
+	}
+	return response.Error(500, message, err)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
@@ -812,8 +813,9 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				{SaveError: models.ErrDashboardWithSameUIDExists, ExpectedStatusCode: 400},
 				{SaveError: models.ErrDashboardWithSameNameInFolderExists, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardVersionMismatch, ExpectedStatusCode: 412},
+				{SaveError: models.DashboardEditConflictError{Version: 2}, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardTitleEmpty, ExpectedStatusCode: 400},
-				{SaveError: models.ErrDashboardFolderCannotHaveParent, ExpectedStatusCode: 400},
+				{SaveError: models.ErrFolderCannotBeOwnAncestor, ExpectedStatusCode: 400},
 				{SaveError: alerting.ValidationError{Reason: "Mu"}, ExpectedStatusCode: 422},
 				{SaveError: models.ErrDashboardFailedGenerateUniqueUid, ExpectedStatusCode: 500},
 				{SaveError: models.ErrDashboardTypeMismatch, ExpectedStatusCode: 400},
@@ -846,6 +848,36 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 					})
 			}
 		})
+
+		t.Run("Given conflicting changes when saving a dashboard", func(t *testing.T) {
+			cmd := models.SaveDashboardCommand{
+				OrgId: 1,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{
+					"title":   "Dash",
+					"version": 1,
+				}),
+			}
+
+			mock := &dashboards.FakeDashboardService{
+				SaveDashboardError: models.DashboardEditConflictError{
+					Version:   2,
+					Conflicts: []models.DashboardEditConflict{{Path: "/panels/0", PanelId: 4, Title: "CPU"}},
+				},
+			}
+
+			postDashboardScenario(t, "When calling POST on", "/api/dashboards", "/api/dashboards", mock, cmd, func(sc *scenarioContext) {
+				callPostDashboard(sc)
+				assert.Equal(t, 412, sc.resp.Code)
+
+				result := sc.ToJSON()
+				assert.Equal(t, "version-mismatch", result.Get("status").MustString())
+				assert.Equal(t, 2, result.Get("version").MustInt())
+				conflict := result.Get("conflicts").GetIndex(0)
+				assert.Equal(t, "/panels/0", conflict.Get("path").MustString())
+				assert.Equal(t, int64(4), conflict.Get("panelId").MustInt64())
+				assert.Equal(t, "CPU", conflict.Get("title").MustString())
+			})
+		})
 	})
 
 	t.Run("Given two dashboards being compared", func(t *testing.T) {
@@ -1004,6 +1036,93 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 			})
 	})
 
+	t.Run("Given dashboard being patched", func(t *testing.T) {
+		setUp := func() {
+			bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+				dataValue, err := simplejson.NewJson([]byte(`{"id": 2, "uid": "uid", "title": "Dash", "version": 3,
+					"panels": [{"id": 1, "title": "CPU"}, {"id": 2, "title": "Memory"}]}`))
+				require.NoError(t, err)
+				query.Result = &models.Dashboard{Id: 2, Uid: "uid", FolderId: 1, Version: 3, Data: dataValue}
+				return nil
+			})
+		}
+
+		mock := &dashboards.FakeDashboardService{
+			SaveDashboardResult: &models.Dashboard{
+				Id:      2,
+				Uid:     "uid",
+				Title:   "Dash",
+				Slug:    "dash",
+				Version: 4,
+			},
+		}
+
+		patch := []dashdiffs.PatchOperation{
+			{Op: "test", Path: "/panels/1/title", Value: "Memory"},
+			{Op: "replace", Path: "/panels/1/title", Value: "Heap"},
+			{Op: "move", From: "/panels/1", Path: "/panels/0"},
+		}
+
+		cmd := dtos.PatchDashboardCommand{Version: 3, Patch: patch, Message: "Rename panel"}
+		patchDashboardScenario(t, "When calling POST on", "/api/dashboards/uid/uid/patch",
+			"/api/dashboards/uid/:uid/patch", mock, cmd, func(sc *scenarioContext) {
+				setUp()
+
+				callPatchDashboard(sc)
+				assert.Equal(t, 200, sc.resp.Code)
+				dto := mock.SavedDashboards[0]
+				assert.Equal(t, int64(1), dto.Dashboard.FolderId)
+				assert.Equal(t, 3, dto.Dashboard.Version)
+				assert.Equal(t, "Rename panel", dto.Message)
+				assert.Equal(t, "Heap", dto.Dashboard.Data.Get("panels").GetIndex(0).Get("title").MustString())
+			})
+
+		cmd = dtos.PatchDashboardCommand{Version: 2, Patch: patch}
+		patchDashboardScenario(t, "When calling POST with an old version on", "/api/dashboards/uid/uid/patch",
+			"/api/dashboards/uid/:uid/patch", mock, cmd, func(sc *scenarioContext) {
+				setUp()
+
+				callPatchDashboard(sc)
+				assert.Equal(t, 412, sc.resp.Code)
+				assert.Equal(t, "version-mismatch", sc.ToJSON().Get("status").MustString())
+			})
+
+		cmd = dtos.PatchDashboardCommand{Version: 3, Patch: []dashdiffs.PatchOperation{
+			{Op: "test", Path: "/title", Value: "Other"},
+		}}
+		patchDashboardScenario(t, "When calling POST with a failing test on", "/api/dashboards/uid/uid/patch",
+			"/api/dashboards/uid/:uid/patch", mock, cmd, func(sc *scenarioContext) {
+				setUp()
+
+				callPatchDashboard(sc)
+				assert.Equal(t, 409, sc.resp.Code)
+				assert.Equal(t, "patch-conflict", sc.ToJSON().Get("status").MustString())
+			})
+
+		cmd = dtos.PatchDashboardCommand{Version: 3, Patch: []dashdiffs.PatchOperation{
+			{Op: "test", Path: "/panels/5/title", Value: "Removed"},
+		}}
+		patchDashboardScenario(t, "When calling POST with a test of a missing value on", "/api/dashboards/uid/uid/patch",
+			"/api/dashboards/uid/:uid/patch", mock, cmd, func(sc *scenarioContext) {
+				setUp()
+
+				callPatchDashboard(sc)
+				assert.Equal(t, 409, sc.resp.Code)
+				assert.Equal(t, "patch-conflict", sc.ToJSON().Get("status").MustString())
+			})
+
+		cmd = dtos.PatchDashboardCommand{Version: 3, Patch: []dashdiffs.PatchOperation{
+			{Op: "remove", Path: "/panels/5"},
+		}}
+		patchDashboardScenario(t, "When calling POST with an invalid patch on", "/api/dashboards/uid/uid/patch",
+			"/api/dashboards/uid/:uid/patch", mock, cmd, func(sc *scenarioContext) {
+				setUp()
+
+				callPatchDashboard(sc)
+				assert.Equal(t, 400, sc.resp.Code)
+			})
+	})
+
 	t.Run("Given provisioned dashboard", func(t *testing.T) {
 		setUp := func() {
 			bus.AddHandler("test", func(query *models.GetDashboardsBySlugQuery) error {
@@ -1167,6 +1286,10 @@ func callRestoreDashboardVersion(sc *scenarioContext) {
 	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
 }
 
+func callPatchDashboard(sc *scenarioContext) {
+	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+}
+
 func callPostDashboardShouldReturnSuccess(sc *scenarioContext) {
 	callPostDashboard(sc)
 
@@ -1279,6 +1402,49 @@ func restoreDashboardVersionScenario(t *testing.T, desc string, url string, rout
 	})
 }
 
+func patchDashboardScenario(t *testing.T, desc string, url string, routePattern string,
+	mock *dashboards.FakeDashboardService, cmd dtos.PatchDashboardCommand, fn scenarioFunc) {
+	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
+		defer bus.ClearBusHandlers()
+
+		cfg := setting.NewCfg()
+		hs := HTTPServer{
+			Cfg:                 cfg,
+			Bus:                 bus.GetBus(),
+			ProvisioningService: provisioning.NewProvisioningServiceMock(),
+			Live:                &live.GrafanaLive{Cfg: cfg},
+			QuotaService:        &quota.QuotaService{Cfg: cfg},
+		}
+
+		sc := setupScenarioContext(t, url)
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.SignedInUser = &models.SignedInUser{
+				OrgId:  testOrgID,
+				UserId: testUserID,
+			}
+			sc.context.OrgRole = models.ROLE_ADMIN
+
+			return hs.PatchDashboard(c, cmd)
+		})
+
+		origProvisioningService := dashboards.NewProvisioningService
+		origNewDashboardService := dashboards.NewService
+		t.Cleanup(func() {
+			dashboards.NewService = origNewDashboardService
+			dashboards.NewProvisioningService = origProvisioningService
+		})
+		dashboards.NewProvisioningService = func() dashboards.DashboardProvisioningService {
+			return mockDashboardProvisioningService{}
+		}
+		dashboards.MockDashboardService(mock)
+
+		sc.m.Post(routePattern, sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
 func (sc *scenarioContext) ToJSON() *simplejson.Json {
 	var result *simplejson.Json
 	err := json.NewDecoder(sc.resp.Body).Decode(&result)
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/dashboards/trash lists the deleted dashboards and folders of the organization.
+func GetDashboardTrash(c *models.ReqContext) response.Response {
+	query := models.GetDashboardTrashQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return response.Error(500, "Failed to get dashboard trash", err)
+	}
+
+	return response.JSON(200, query.Result)
+}
+
+// POST /api/dashboards/trash/:id/restore restores a deleted dashboard or folder with its content.
+func (hs *HTTPServer) RestoreDashboardFromTrash(c *models.ReqContext) response.Response {
+	svc := dashboards.NewService()
+	restored, err := svc.RestoreDashboardFromTrash(c.ParamsInt64(":id"), c.OrgId, c.SignedInUser)
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardTrashItemNotFound) {
+			return response.Error(404, "Dashboard not found in trash", nil)
+		}
+		if errors.Is(err, models.ErrDashboardTrashLibraryPanelExists) {
+			return response.Error(409, err.Error(), nil)
+		}
+		return dashboardSaveErrorToApiResponse(err)
+	}
+
+	if hs.Cfg.IsPanelLibraryEnabled() {
+		for _, dash := range restored {
+			if dash.IsFolder {
+				continue
+			}
+			if err := hs.LibraryPanelService.ConnectLibraryPanelsForDashboard(c, dash); err != nil {
+				hs.log.Error("Failed to connect library panels", "dashboard", dash.Id, "user", c.SignedInUser.UserId, "error", err)
+			}
+		}
+	}
+
+	dash := restored[0]
+	return response.JSON(200, util.DynMap{
+		"title":   dash.Title,
+		"message": fmt.Sprintf("%s restored", dash.Title),
+		"id":      dash.Id,
+		"uid":     dash.Uid,
+		"url":     dash.GetUrl(),
+	})
+}
+
+// DELETE /api/dashboards/trash/:id permanently deletes a dashboard or folder from the trash.
+func PurgeDashboardTrashItem(c *models.ReqContext) response.Response {
+	cmd := models.PurgeDashboardTrashItemCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if errors.Is(err, models.ErrDashboardTrashItemNotFound) {
+			return response.Error(404, "Dashboard not found in trash", nil)
+		}
+		return response.Error(500, "Failed to delete dashboard from trash", err)
+	}
+
+	return response.Success("Dashboard deleted permanently")
+}
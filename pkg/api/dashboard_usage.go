@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+// defaultUnusedDashboardsDays is the number of days without views after which a dashboard is reported as unused.
+const defaultUnusedDashboardsDays = 90
+
+// dashboardQueriesCanViewTTL is how long the permission of a user to view a dashboard is cached when recording
+// its queries, as a dashboard sends the queries of all its panels at once.
+const dashboardQueriesCanViewTTL = time.Minute
+
+// GET /api/dashboards/uid/:uid/usage returns the views and query load of a dashboard. The views of each user are
+// only returned to org admins.
+func (hs *HTTPServer) GetDashboardUsage(c *models.ReqContext) response.Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return rsp
+	}
+
+	guardian := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := guardian.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	usage, err := hs.DashboardUsageService.GetDashboardUsage(c.Req.Context(), c.OrgId, dash.Id, c.HasRole(models.ROLE_ADMIN))
+	if err != nil {
+		return response.Error(500, "Failed to get dashboard usage", err)
+	}
+	return response.JSON(200, usage)
+}
+
+// GET /api/dashboards/usage/unused?days=<days>&limit=<limit>&page=<page> returns the dashboards that have not been
+// viewed for the given number of days, 90 by default, the longest unused first.
+func (hs *HTTPServer) GetUnusedDashboards(c *models.ReqContext) response.Response {
+	days := c.QueryInt64("days")
+	if days < 0 {
+		return response.Error(400, "The number of days must be positive", nil)
+	}
+	if days == 0 {
+		days = defaultUnusedDashboardsDays
+	}
+
+	result, err := hs.DashboardUsageService.GetUnusedDashboards(c.Req.Context(), dashboardusage.UnusedDashboardsQuery{
+		OrgID: c.OrgId,
+		Since: time.Now().AddDate(0, 0, -int(days)),
+		Limit: c.QueryInt64("limit"),
+		Page:  c.QueryInt64("page"),
+	})
+	if err != nil {
+		return response.Error(500, "Failed to get unused dashboards", err)
+	}
+	return response.JSON(200, result)
+}
+
+// recordDashboardQueries wraps a query handler to record the queries sent by the panels of a dashboard. The
+// dashboard id is sent by the client, so the queries are only recorded for dashboards the user can view.
+func (hs *HTTPServer) recordDashboardQueries(handler func(*models.ReqContext, dtos.MetricRequest) response.Response) func(*models.ReqContext, dtos.MetricRequest) response.Response {
+	return func(c *models.ReqContext, reqDTO dtos.MetricRequest) response.Response {
+		start := time.Now()
+		resp := handler(c, reqDTO)
+		if reqDTO.DashboardId <= 0 {
+			return resp
+		}
+
+		if !hs.canViewQueriedDashboard(c, reqDTO.DashboardId) {
+			return resp
+		}
+
+		if err := bus.Publish(&events.DashboardQueried{
+			Timestamp:   start,
+			OrgId:       c.OrgId,
+			DashboardId: reqDTO.DashboardId,
+			Queries:     len(reqDTO.Queries),
+			Duration:    time.Since(start),
+			Failed:      resp.Status() >= 400,
+		}); err != nil {
+			hs.log.Warn("Failed to record dashboard queries", "dashboard", reqDTO.DashboardId, "error", err)
+		}
+		return resp
+	}
+}
+
+// canViewQueriedDashboard returns whether the user can view the dashboard of recorded queries. The result is cached
+// briefly, so that the permissions are not checked for every query.
+func (hs *HTTPServer) canViewQueriedDashboard(c *models.ReqContext, dashboardID int64) bool {
+	cacheKey := fmt.Sprintf("dashboard-queries-can-view-%d-%d-%s-%d", c.OrgId, c.UserId, c.OrgRole, dashboardID)
+	if cached, found := hs.CacheService.Get(cacheKey); found {
+		return cached.(bool)
+	}
+
+	guardian := guardian.New(dashboardID, c.OrgId, c.SignedInUser)
+	canView, err := guardian.CanView()
+	if err != nil {
+		return false
+	}
+
+	hs.CacheService.Set(cacheKey, canView, dashboardQueriesCanViewTTL)
+	return canView
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordDashboardQueries(t *testing.T) {
+	c := &models.ReqContext{SignedInUser: &models.SignedInUser{OrgId: 1, UserId: 2}}
+	reqDTO := dtos.MetricRequest{DashboardId: 3, Queries: []*simplejson.Json{simplejson.New()}}
+
+	recordQueries := func(t *testing.T, hs *HTTPServer, canView bool) []*events.DashboardQueried {
+		t.Helper()
+		handler := hs.recordDashboardQueries(func(c *models.ReqContext, reqDTO dtos.MetricRequest) response.Response {
+			return response.JSON(200, nil)
+		})
+		origNewGuardian := guardian.New
+		t.Cleanup(func() {
+			guardian.New = origNewGuardian
+			bus.ClearBusHandlers()
+		})
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: canView})
+
+		recorded := []*events.DashboardQueried{}
+		bus.ClearBusHandlers()
+		bus.AddEventListener(func(event *events.DashboardQueried) error {
+			recorded = append(recorded, event)
+			return nil
+		})
+
+		handler(c, reqDTO)
+		return recorded
+	}
+
+	t.Run("The queries of a dashboard the user can view should be recorded", func(t *testing.T) {
+		recorded := recordQueries(t, newRecordingHTTPServer(), true)
+		if assert.Len(t, recorded, 1) {
+			assert.Equal(t, int64(1), recorded[0].OrgId)
+			assert.Equal(t, int64(3), recorded[0].DashboardId)
+			assert.Equal(t, 1, recorded[0].Queries)
+		}
+	})
+
+	t.Run("The queries of a dashboard the user cannot view should not be recorded", func(t *testing.T) {
+		assert.Empty(t, recordQueries(t, newRecordingHTTPServer(), false))
+	})
+
+	t.Run("The permission to view a dashboard should be cached", func(t *testing.T) {
+		hs := newRecordingHTTPServer()
+		assert.Len(t, recordQueries(t, hs, true), 1)
+		// the guardian is not asked again while the permission is cached
+		assert.Len(t, recordQueries(t, hs, false), 1)
+	})
+}
+
+func newRecordingHTTPServer() *HTTPServer {
+	return &HTTPServer{
+		log:          log.New("test"),
+		CacheService: localcache.New(5*time.Minute, 10*time.Minute),
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb/files"
+)
+
+// maxUploadMemory is the part of an uploaded file kept in memory, the rest is buffered on disk.
+const maxUploadMemory = 8 << 20
+
+// GetDataSourceFiles returns the files uploaded for the files data source.
+func (hs *HTTPServer) GetDataSourceFiles(c *models.ReqContext) response.Response {
+	result, err := hs.FileDataSourceService.ListFiles(c.OrgId)
+	if err != nil {
+		return response.Error(500, "Failed to list files", err)
+	}
+
+	return response.JSON(200, result)
+}
+
+// UploadDataSourceFile stores the file of the multipart form field "file" for the files data source.
+func (hs *HTTPServer) UploadDataSourceFile(c *models.ReqContext) response.Response {
+	// leave room for the multipart encoding around the file
+	c.Req.Request.Body = http.MaxBytesReader(c.Resp, c.Req.Request.Body, files.MaxFileSize+maxUploadMemory)
+	if err := c.Req.ParseMultipartForm(maxUploadMemory); err != nil {
+		return response.Error(400, "Failed to parse upload, the file may be too large", err)
+	}
+	defer func() {
+		if err := c.Req.MultipartForm.RemoveAll(); err != nil {
+			hs.log.Warn("Failed to remove uploaded files", "error", err)
+		}
+	}()
+
+	file, header, err := c.Req.FormFile("file")
+	if err != nil {
+		return response.Error(400, "Missing file", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			hs.log.Warn("Failed to close uploaded file", "error", err)
+		}
+	}()
+
+	info, err := hs.FileDataSourceService.SaveFile(c.OrgId, header.Filename, file)
+	if err != nil {
+		if errors.Is(err, files.ErrInvalidFileName) || errors.Is(err, files.ErrFileTooLarge) {
+			return response.Error(400, err.Error(), nil)
+		}
+		return response.Error(500, "Failed to save file", err)
+	}
+
+	return response.JSON(200, info)
+}
+
+// DeleteDataSourceFile removes a file uploaded for the files data source.
+func (hs *HTTPServer) DeleteDataSourceFile(c *models.ReqContext) response.Response {
+	err := hs.FileDataSourceService.DeleteFile(c.OrgId, c.Params(":name"))
+	if err != nil {
+		if errors.Is(err, files.ErrInvalidFileName) {
+			return response.Error(400, err.Error(), nil)
+		}
+		if errors.Is(err, files.ErrFileNotFound) {
+			return response.Error(404, "File not found", nil)
+		}
+		return response.Error(500, "Failed to delete file", err)
+	}
+
+	return response.Success("File deleted")
+}
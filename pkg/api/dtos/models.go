@@ -48,6 +48,8 @@ type MetricRequest struct {
 	To      string             `json:"to"`
 	Queries []*simplejson.Json `json:"queries"`
 	Debug   bool               `json:"debug"`
+	// DashboardId is the dashboard of the panel sending the queries, 0 if the queries are not sent by a dashboard.
+	DashboardId int64 `json:"dashboardId,omitempty"`
 }
 
 func GetGravatarUrl(text string) string {
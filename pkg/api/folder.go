@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -26,9 +27,10 @@ func GetFolders(c *models.ReqContext) response.Response {
 
 	for _, f := range folders {
 		result = append(result, dtos.FolderSearchHit{
-			Id:    f.Id,
-			Uid:   f.Uid,
-			Title: f.Title,
+			Id:        f.Id,
+			Uid:       f.Uid,
+			Title:     f.Title,
+			ParentUid: f.ParentUid,
 		})
 	}
 
@@ -86,10 +88,29 @@ func UpdateFolder(c *models.ReqContext, cmd models.UpdateFolderCommand) response
 	return response.JSON(200, toFolderDto(g, cmd.Result))
 }
 
+// MoveFolder moves a folder into another folder, or to the top level.
+// POST /api/folders/:uid/move
+func MoveFolder(c *models.ReqContext, cmd models.MoveFolderCommand) response.Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+	err := s.MoveFolder(c.Params(":uid"), &cmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	g := guardian.New(cmd.Result.Id, c.OrgId, c.SignedInUser)
+	return response.JSON(200, toFolderDto(g, cmd.Result))
+}
+
 func (hs *HTTPServer) DeleteFolder(c *models.ReqContext) response.Response { // temporarily adding this function to HTTPServer, will be removed from HTTPServer when librarypanels featuretoggle is removed
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
 	if hs.Cfg.IsPanelLibraryEnabled() {
-		err := hs.LibraryPanelService.DeleteLibraryPanelsInFolder(c, c.Params(":uid"))
+		var err error
+		if setting.DashboardTrashRetention > 0 {
+			// the library panels are moved to the trash with the folder
+			err = hs.LibraryPanelService.ValidateDeleteLibraryPanelsInFolder(c, c.Params(":uid"))
+		} else {
+			err = hs.LibraryPanelService.DeleteLibraryPanelsInFolder(c, c.Params(":uid"))
+		}
 		if err != nil {
 			if errors.Is(err, librarypanels.ErrFolderHasConnectedLibraryPanels) {
 				return response.Error(403, "Folder could not be deleted because it contains linked library panels", err)
@@ -129,6 +150,7 @@ func toFolderDto(g guardian.DashboardGuardian, folder *models.Folder) dtos.Folde
 		Uid:       folder.Uid,
 		Title:     folder.Title,
 		Url:       folder.Url,
+		ParentUid: folder.ParentUid,
 		HasAcl:    folder.HasAcl,
 		CanSave:   canSave,
 		CanEdit:   canEdit,
@@ -205,6 +205,8 @@ type fakeFolderService struct {
 	CreateFolderError    error
 	UpdateFolderResult   *models.Folder
 	UpdateFolderError    error
+	MoveFolderResult     *models.Folder
+	MoveFolderError      error
 	DeleteFolderResult   *models.Folder
 	DeleteFolderError    error
 	DeletedFolderUids    []string
@@ -232,6 +234,11 @@ func (s *fakeFolderService) UpdateFolder(existingUID string, cmd *models.UpdateF
 	return s.UpdateFolderError
 }
 
+func (s *fakeFolderService) MoveFolder(uid string, cmd *models.MoveFolderCommand) error {
+	cmd.Result = s.MoveFolderResult
+	return s.MoveFolderError
+}
+
 func (s *fakeFolderService) DeleteFolder(uid string) (*models.Folder, error) {
 	s.DeletedFolderUids = append(s.DeletedFolderUids, uid)
 	return s.DeleteFolderResult, s.DeleteFolderError
@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/files"
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	httpstatic "github.com/grafana/grafana/pkg/api/static"
@@ -34,7 +35,9 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/manager"
 	"github.com/grafana/grafana/pkg/plugins/plugindashboards"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/bundles"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/hooks"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
@@ -64,31 +67,34 @@ type HTTPServer struct {
 	httpSrv     *http.Server
 	middlewares []macaron.Handler
 
-	RouteRegister          routing.RouteRegister              `inject:""`
-	Bus                    bus.Bus                            `inject:""`
-	RenderService          rendering.Service                  `inject:""`
-	Cfg                    *setting.Cfg                       `inject:""`
-	HooksService           *hooks.HooksService                `inject:""`
-	CacheService           *localcache.CacheService           `inject:""`
-	DatasourceCache        datasources.CacheService           `inject:""`
-	AuthTokenService       models.UserTokenService            `inject:""`
-	QuotaService           *quota.QuotaService                `inject:""`
-	RemoteCacheService     *remotecache.RemoteCache           `inject:""`
-	ProvisioningService    provisioning.ProvisioningService   `inject:""`
-	Login                  *login.LoginService                `inject:""`
-	License                models.Licensing                   `inject:""`
-	BackendPluginManager   backendplugin.Manager              `inject:""`
-	PluginRequestValidator models.PluginRequestValidator      `inject:""`
-	PluginManager          *manager.PluginManager             `inject:""`
-	SearchService          *search.SearchService              `inject:""`
-	ShortURLService        *shorturls.ShortURLService         `inject:""`
-	Live                   *live.GrafanaLive                  `inject:""`
-	ContextHandler         *contexthandler.ContextHandler     `inject:""`
-	SQLStore               *sqlstore.SQLStore                 `inject:""`
-	LibraryPanelService    *librarypanels.LibraryPanelService `inject:""`
-	DataService            *tsdb.Service                      `inject:""`
-	PluginDashboardService *plugindashboards.Service          `inject:""`
-	AlertEngine            *alerting.AlertEngine              `inject:""`
+	RouteRegister          routing.RouteRegister                 `inject:""`
+	Bus                    bus.Bus                               `inject:""`
+	RenderService          rendering.Service                     `inject:""`
+	Cfg                    *setting.Cfg                          `inject:""`
+	HooksService           *hooks.HooksService                   `inject:""`
+	CacheService           *localcache.CacheService              `inject:""`
+	DatasourceCache        datasources.CacheService              `inject:""`
+	AuthTokenService       models.UserTokenService               `inject:""`
+	QuotaService           *quota.QuotaService                   `inject:""`
+	RemoteCacheService     *remotecache.RemoteCache              `inject:""`
+	ProvisioningService    provisioning.ProvisioningService      `inject:""`
+	Login                  *login.LoginService                   `inject:""`
+	License                models.Licensing                      `inject:""`
+	BackendPluginManager   backendplugin.Manager                 `inject:""`
+	PluginRequestValidator models.PluginRequestValidator         `inject:""`
+	PluginManager          *manager.PluginManager                `inject:""`
+	SearchService          *search.SearchService                 `inject:""`
+	ShortURLService        *shorturls.ShortURLService            `inject:""`
+	Live                   *live.GrafanaLive                     `inject:""`
+	ContextHandler         *contexthandler.ContextHandler        `inject:""`
+	SQLStore               *sqlstore.SQLStore                    `inject:""`
+	LibraryPanelService    *librarypanels.LibraryPanelService    `inject:""`
+	DataService            *tsdb.Service                         `inject:""`
+	FileDataSourceService  *files.Service                        `inject:""`
+	PluginDashboardService *plugindashboards.Service             `inject:""`
+	AlertEngine            *alerting.AlertEngine                 `inject:""`
+	BundleService          *bundles.Service                      `inject:""`
+	DashboardUsageService  *dashboardusage.DashboardUsageService `inject:""`
 	Listener               net.Listener
 }
 
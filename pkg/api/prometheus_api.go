@@ -0,0 +1,465 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// promMaxPoints is the maximum number of points per series of a range query, as in Prometheus.
+	promMaxPoints = 11000
+	// promDefaultRange is the time range of series and label lookups without start.
+	promDefaultRange = time.Hour
+	// promLookupMaxDataPoints is the resolution of the queries of series and label lookups.
+	promLookupMaxDataPoints = 100
+)
+
+// The Prometheus HTTP API lets tools that only speak Prometheus read any backend data source. Queries are
+// either JSON query models of the data source, or the raw query text of data sources with a text query
+// language. Every numeric field of the resulting time series becomes a Prometheus series, labeled with the
+// labels of the field and named by __name__.
+
+type promResponse struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type promMatrix struct {
+	ResultType string       `json:"resultType"`
+	Result     []promSeries `json:"result"`
+}
+
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]interface{}  `json:"values"`
+}
+
+func promSuccess(result interface{}) response.Response {
+	return response.JSON(http.StatusOK, promResponse{Status: "success", Data: result})
+}
+
+func promError(status int, errorType string, err error) response.Response {
+	return response.JSON(status, promResponse{Status: "error", ErrorType: errorType, Error: err.Error()})
+}
+
+// PrometheusQueryRange evaluates a query over a time range.
+// GET/POST /api/datasources/uid/:uid/prometheus/api/v1/query_range
+func (hs *HTTPServer) PrometheusQueryRange(c *models.ReqContext) response.Response {
+	start, err := parsePromTime(c.Query("start"))
+	if err != nil {
+		return promError(http.StatusBadRequest, "bad_data", fmt.Errorf("invalid parameter \"start\": %w", err))
+	}
+	end, err := parsePromTime(c.Query("end"))
+	if err != nil {
+		return promError(http.StatusBadRequest, "bad_data", fmt.Errorf("invalid parameter \"end\": %w", err))
+	}
+	if end.Before(start) {
+		return promError(http.StatusBadRequest, "bad_data", errors.New("end timestamp must not be before start time"))
+	}
+	step, err := parsePromDuration(c.Query("step"))
+	if err != nil {
+		return promError(http.StatusBadRequest, "bad_data", fmt.Errorf("invalid parameter \"step\": %w", err))
+	}
+	if step <= 0 {
+		return promError(http.StatusBadRequest, "bad_data",
+			errors.New("zero or negative query resolution step widths are not accepted, try a positive integer"))
+	}
+	points := int64(end.Sub(start)/step) + 1
+	if points > promMaxPoints {
+		return promError(http.StatusBadRequest, "bad_data",
+			errors.New("exceeded maximum resolution of 11,000 points per timeseries, try decreasing the query resolution (?step=XX)"))
+	}
+
+	ds, errResp := hs.getPrometheusAPIDataSource(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	series, err := hs.queryPromSeries(c, ds, c.Query("query"), start, end, step, points)
+	if err != nil {
+		return promQueryError(err)
+	}
+
+	return promSuccess(promMatrix{ResultType: "matrix", Result: series})
+}
+
+// PrometheusSeries returns the label sets of the series of the match[] queries.
+// GET/POST /api/datasources/uid/:uid/prometheus/api/v1/series
+func (hs *HTTPServer) PrometheusSeries(c *models.ReqContext) response.Response {
+	series, errResp := hs.lookupPromSeries(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	result := make([]map[string]string, 0, len(series))
+	seen := map[string]bool{}
+	for _, s := range series {
+		key := promLabelsKey(s.Metric)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, s.Metric)
+		}
+	}
+
+	return promSuccess(result)
+}
+
+// PrometheusLabels returns the label names of the series of the match[] queries.
+// GET/POST /api/datasources/uid/:uid/prometheus/api/v1/labels
+func (hs *HTTPServer) PrometheusLabels(c *models.ReqContext) response.Response {
+	series, errResp := hs.lookupPromSeries(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	names := map[string]bool{}
+	for _, s := range series {
+		for name := range s.Metric {
+			names[name] = true
+		}
+	}
+
+	return promSuccess(promSortedKeys(names))
+}
+
+// PrometheusLabelValues returns the values of a label of the series of the match[] queries.
+// GET /api/datasources/uid/:uid/prometheus/api/v1/label/:name/values
+func (hs *HTTPServer) PrometheusLabelValues(c *models.ReqContext) response.Response {
+	series, errResp := hs.lookupPromSeries(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	name := c.Params(":name")
+	values := map[string]bool{}
+	for _, s := range series {
+		if v, ok := s.Metric[name]; ok {
+			values[v] = true
+		}
+	}
+
+	return promSuccess(promSortedKeys(values))
+}
+
+// lookupPromSeries runs the match[] queries of a series or label lookup over its time range.
+func (hs *HTTPServer) lookupPromSeries(c *models.ReqContext) ([]promSeries, response.Response) {
+	matches := c.QueryStrings("match[]")
+	if len(matches) == 0 {
+		return nil, promError(http.StatusBadRequest, "bad_data", errors.New("no match[] parameter provided"))
+	}
+
+	end := time.Now()
+	if c.Query("end") != "" {
+		t, err := parsePromTime(c.Query("end"))
+		if err != nil {
+			return nil, promError(http.StatusBadRequest, "bad_data", fmt.Errorf("invalid parameter \"end\": %w", err))
+		}
+		end = t
+	}
+	start := end.Add(-promDefaultRange)
+	if c.Query("start") != "" {
+		t, err := parsePromTime(c.Query("start"))
+		if err != nil {
+			return nil, promError(http.StatusBadRequest, "bad_data", fmt.Errorf("invalid parameter \"start\": %w", err))
+		}
+		start = t
+	}
+	if end.Before(start) {
+		return nil, promError(http.StatusBadRequest, "bad_data", errors.New("end timestamp must not be before start time"))
+	}
+
+	ds, errResp := hs.getPrometheusAPIDataSource(c)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	step := end.Sub(start) / promLookupMaxDataPoints
+	if step < time.Second {
+		step = time.Second
+	}
+
+	var series []promSeries
+	for _, match := range matches {
+		s, err := hs.queryPromSeries(c, ds, match, start, end, step, promLookupMaxDataPoints)
+		if err != nil {
+			return nil, promQueryError(err)
+		}
+		series = append(series, s...)
+	}
+	return series, nil
+}
+
+func (hs *HTTPServer) getPrometheusAPIDataSource(c *models.ReqContext) (*models.DataSource, response.Response) {
+	ds, err := hs.DatasourceCache.GetDatasourceByUID(c.Params(":uid"), c.SignedInUser, c.SkipCache)
+	if err != nil {
+		if errors.Is(err, models.ErrDataSourceAccessDenied) {
+			return nil, promError(http.StatusForbidden, "bad_data", errors.New("access denied to data source"))
+		}
+		if errors.Is(err, models.ErrDataSourceNotFound) {
+			return nil, promError(http.StatusNotFound, "not_found", errors.New("data source not found"))
+		}
+		hs.log.Error("Unable to load data source", "uid", c.Params(":uid"), "error", err)
+		return nil, promError(http.StatusInternalServerError, "internal", errors.New("unable to load data source"))
+	}
+
+	if err := hs.PluginRequestValidator.Validate(ds.Url, nil); err != nil {
+		return nil, promError(http.StatusForbidden, "bad_data", errors.New("access denied to data source"))
+	}
+
+	return ds, nil
+}
+
+// promBadQueryError is an error in the query itself, rather than in its execution.
+type promBadQueryError struct {
+	err error
+}
+
+func (e promBadQueryError) Error() string {
+	return e.err.Error()
+}
+
+func promQueryError(err error) response.Response {
+	var badQuery promBadQueryError
+	if errors.As(err, &badQuery) {
+		return promError(http.StatusBadRequest, "bad_data", err)
+	}
+	return promError(http.StatusUnprocessableEntity, "execution", err)
+}
+
+// queryPromSeries runs a query against the data source and converts the result to Prometheus series.
+func (hs *HTTPServer) queryPromSeries(c *models.ReqContext, ds *models.DataSource, query string,
+	start, end time.Time, step time.Duration, points int64) ([]promSeries, error) {
+	queryModel, err := promQueryModel(ds, query)
+	if err != nil {
+		return nil, promBadQueryError{err: err}
+	}
+
+	timeRange := plugins.NewDataTimeRange(
+		strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10),
+		strconv.FormatInt(end.UnixNano()/int64(time.Millisecond), 10))
+	request := plugins.DataQuery{
+		TimeRange: &timeRange,
+		User:      c.SignedInUser,
+		Queries: []plugins.DataSubQuery{{
+			RefID:         "A",
+			MaxDataPoints: points,
+			IntervalMS:    step.Milliseconds(),
+			QueryType:     queryModel.Get("queryType").MustString(""),
+			Model:         queryModel,
+			DataSource:    ds,
+		}},
+	}
+
+	resp, err := hs.DataService.HandleRequest(c.Req.Context(), ds, request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Results["A"]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return promSeriesFromResult(result)
+}
+
+// promQueryModel returns the query model of a Prometheus API query. A JSON object is used as the query
+// model, anything else as the query text of data sources with a text query language.
+func promQueryModel(ds *models.DataSource, query string) (*simplejson.Json, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("query must not be empty")
+	}
+
+	var queryModel *simplejson.Json
+	if strings.HasPrefix(query, "{") {
+		var err error
+		queryModel, err = simplejson.NewJson([]byte(query))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query model: %w", err)
+		}
+	} else {
+		queryModel = simplejson.New()
+		switch ds.Type {
+		case models.DS_PROMETHEUS, "loki":
+			queryModel.Set("expr", query)
+		case models.DS_GRAPHITE:
+			queryModel.Set("target", query)
+		case models.DS_INFLUXDB:
+			queryModel.Set("query", query)
+			queryModel.Set("rawQuery", true)
+			queryModel.Set("resultFormat", "time_series")
+		case models.DS_MYSQL, "postgres", "mssql", "sqlite", "clickhouse":
+			queryModel.Set("rawSql", query)
+			queryModel.Set("format", "time_series")
+		default:
+			return nil, fmt.Errorf("queries of %s data sources must be JSON query models", ds.Type)
+		}
+	}
+
+	queryModel.Set("refId", "A")
+	queryModel.Set("datasourceId", ds.Id)
+	return queryModel, nil
+}
+
+// promSeriesFromResult converts the time series and the data frames of a query result to Prometheus series.
+func promSeriesFromResult(result plugins.DataQueryResult) ([]promSeries, error) {
+	series := []promSeries{}
+
+	for _, s := range result.Series {
+		metric := make(map[string]string, len(s.Tags)+1)
+		for k, v := range s.Tags {
+			metric[k] = v
+		}
+		metric[model.MetricNameLabel] = s.Name
+
+		values := make([][2]interface{}, 0, len(s.Points))
+		for _, p := range s.Points {
+			if !p[0].Valid || !p[1].Valid {
+				continue
+			}
+			values = append(values, promSample(time.Unix(0, int64(p[1].Float64)*int64(time.Millisecond)), p[0].Float64))
+		}
+		series = append(series, promSeries{Metric: metric, Values: values})
+	}
+
+	if result.Dataframes == nil {
+		return series, nil
+	}
+	frames, err := result.Dataframes.Decoded()
+	if err != nil {
+		return nil, err
+	}
+	for _, frame := range frames {
+		s, err := promSeriesFromFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s...)
+	}
+	return series, nil
+}
+
+// promSeriesFromFrame converts the numeric fields of a time series frame to Prometheus series. Long frames
+// are converted to wide frames first, and frames that are not time series are skipped.
+func promSeriesFromFrame(frame *data.Frame) ([]promSeries, error) {
+	schema := frame.TimeSeriesSchema()
+	switch schema.Type {
+	case data.TimeSeriesTypeNot:
+		return nil, nil
+	case data.TimeSeriesTypeLong:
+		wide, err := data.LongToWide(frame, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert frame %q to series: %w", frame.Name, err)
+		}
+		frame = wide
+		schema = frame.TimeSeriesSchema()
+	}
+
+	timeField := frame.Fields[schema.TimeIndex]
+	series := make([]promSeries, 0, len(schema.ValueIndices))
+	for _, i := range schema.ValueIndices {
+		field := frame.Fields[i]
+		if !field.Type().Numeric() {
+			continue
+		}
+
+		metric := make(map[string]string, len(field.Labels)+1)
+		for k, v := range field.Labels {
+			metric[k] = v
+		}
+		metric[model.MetricNameLabel] = promSeriesName(frame, field)
+
+		values := make([][2]interface{}, 0, field.Len())
+		for row := 0; row < field.Len(); row++ {
+			ts, ok := timeField.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			if _, ok := field.ConcreteAt(row); !ok {
+				continue
+			}
+			v, err := field.FloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, promSample(ts.(time.Time), v))
+		}
+		series = append(series, promSeries{Metric: metric, Values: values})
+	}
+	return series, nil
+}
+
+// promSeriesName names a series by its field, falling back to the frame name for generic value fields.
+func promSeriesName(frame *data.Frame, field *data.Field) string {
+	name := field.Name
+	if field.Config != nil && field.Config.DisplayNameFromDS != "" {
+		name = field.Config.DisplayNameFromDS
+	}
+	if (name == "" || name == data.TimeSeriesValueFieldName) && frame.Name != "" {
+		name = frame.Name
+	}
+	return name
+}
+
+// promSample returns a sample as a pair of the time in seconds and the value as text, as in Prometheus.
+func promSample(ts time.Time, v float64) [2]interface{} {
+	seconds := float64(ts.UnixNano()/int64(time.Millisecond)) / 1000
+	return [2]interface{}{seconds, strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+func promLabelsKey(labels map[string]string) string {
+	return data.Labels(labels).String()
+}
+
+func promSortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parsePromTime parses a time in seconds since the epoch or in RFC3339 format.
+func parsePromTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+		}
+		seconds, fraction := math.Modf(t)
+		return time.Unix(int64(seconds), int64(math.Round(fraction*1000))*int64(time.Millisecond)).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// parsePromDuration parses a duration in seconds or in Prometheus duration format.
+func parsePromDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		if math.IsNaN(d) || math.IsInf(d, 0) || d*float64(time.Second) > math.MaxInt64 {
+			return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+		}
+		return time.Duration(d * float64(time.Second)), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/manager"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromQueryModel(t *testing.T) {
+	t.Run("uses query text of text query languages", func(t *testing.T) {
+		queryModel, err := promQueryModel(&models.DataSource{Id: 3, Type: "postgres"}, " SELECT 1 ")
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT 1", queryModel.Get("rawSql").MustString())
+		assert.Equal(t, "time_series", queryModel.Get("format").MustString())
+		assert.Equal(t, "A", queryModel.Get("refId").MustString())
+		assert.Equal(t, int64(3), queryModel.Get("datasourceId").MustInt64())
+
+		queryModel, err = promQueryModel(&models.DataSource{Type: models.DS_GRAPHITE}, "apps.*.count")
+		require.NoError(t, err)
+		assert.Equal(t, "apps.*.count", queryModel.Get("target").MustString())
+	})
+
+	t.Run("uses JSON query models", func(t *testing.T) {
+		queryModel, err := promQueryModel(&models.DataSource{Type: "cloudwatch"},
+			`{"namespace":"AWS/EC2","metricName":"CPUUtilization","refId":"B"}`)
+		require.NoError(t, err)
+		assert.Equal(t, "AWS/EC2", queryModel.Get("namespace").MustString())
+		assert.Equal(t, "A", queryModel.Get("refId").MustString())
+	})
+
+	t.Run("fails on query text of other data sources", func(t *testing.T) {
+		_, err := promQueryModel(&models.DataSource{Type: "cloudwatch"}, "CPUUtilization")
+		require.EqualError(t, err, "queries of cloudwatch data sources must be JSON query models")
+
+		_, err = promQueryModel(&models.DataSource{Type: "cloudwatch"}, "{")
+		require.Error(t, err)
+
+		_, err = promQueryModel(&models.DataSource{Type: "prometheus"}, "")
+		require.Error(t, err)
+	})
+}
+
+func TestPromSeriesFromResult(t *testing.T) {
+	t0 := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+
+	t.Run("converts wide frames", func(t *testing.T) {
+		frame := data.NewFrame("cpu",
+			data.NewField("Time", nil, []time.Time{t0, t1}),
+			data.NewField("Value", data.Labels{"host": "a"}, []*float64{float64Ptr(1.5), nil}),
+			data.NewField("count", data.Labels{"host": "a"}, []int64{2, 3}),
+		)
+		series, err := promSeriesFromResult(plugins.DataQueryResult{Dataframes: plugins.NewDecodedDataFrames(data.Frames{frame})})
+		require.NoError(t, err)
+		require.Len(t, series, 2)
+
+		assert.Equal(t, map[string]string{"__name__": "cpu", "host": "a"}, series[0].Metric)
+		assert.Equal(t, [][2]interface{}{{1614592800.0, "1.5"}}, series[0].Values)
+		assert.Equal(t, map[string]string{"__name__": "count", "host": "a"}, series[1].Metric)
+		assert.Equal(t, [][2]interface{}{{1614592800.0, "2"}, {1614592860.0, "3"}}, series[1].Values)
+	})
+
+	t.Run("converts long frames", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("time", nil, []time.Time{t0, t0, t1}),
+			data.NewField("host", nil, []string{"a", "b", "a"}),
+			data.NewField("load", nil, []float64{1, 2, 3}),
+		)
+		series, err := promSeriesFromResult(plugins.DataQueryResult{Dataframes: plugins.NewDecodedDataFrames(data.Frames{frame})})
+		require.NoError(t, err)
+		require.Len(t, series, 2)
+
+		assert.Equal(t, map[string]string{"__name__": "load", "host": "a"}, series[0].Metric)
+		assert.Equal(t, [][2]interface{}{{1614592800.0, "1"}, {1614592860.0, "3"}}, series[0].Values)
+		assert.Equal(t, map[string]string{"__name__": "load", "host": "b"}, series[1].Metric)
+	})
+
+	t.Run("skips frames that are not time series", func(t *testing.T) {
+		frame := data.NewFrame("", data.NewField("name", nil, []string{"a"}))
+		series, err := promSeriesFromResult(plugins.DataQueryResult{Dataframes: plugins.NewDecodedDataFrames(data.Frames{frame})})
+		require.NoError(t, err)
+		assert.Empty(t, series)
+	})
+
+	t.Run("converts time series", func(t *testing.T) {
+		series, err := promSeriesFromResult(plugins.DataQueryResult{Series: plugins.DataTimeSeriesSlice{{
+			Name: "apps.a.count",
+			Tags: map[string]string{"app": "a"},
+			Points: plugins.DataTimeSeriesPoints{
+				{null.FloatFrom(4), null.FloatFrom(1614592800000)},
+				{null.FloatFromPtr(nil), null.FloatFrom(1614592860000)},
+			},
+		}}})
+		require.NoError(t, err)
+		require.Len(t, series, 1)
+		assert.Equal(t, map[string]string{"__name__": "apps.a.count", "app": "a"}, series[0].Metric)
+		assert.Equal(t, [][2]interface{}{{1614592800.0, "4"}}, series[0].Values)
+	})
+}
+
+func TestParsePromParameters(t *testing.T) {
+	ts, err := parsePromTime("1614592800.5")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, int(500*time.Millisecond), time.UTC), ts)
+
+	ts, err = parsePromTime("2021-03-01T10:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), ts)
+
+	_, err = parsePromTime("yesterday")
+	require.Error(t, err)
+
+	d, err := parsePromDuration("15")
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, d)
+
+	d, err = parsePromDuration("1m30s")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+
+	_, err = parsePromDuration("often")
+	require.Error(t, err)
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestPrometheusQueryRangeAPI(t *testing.T) {
+	const routePattern = "/api/datasources/uid/:uid/prometheus/api/v1/query_range"
+	params := map[string]string{"query": "up", "start": "1614592800", "end": "1614592860", "step": "60"}
+
+	newServer := func() (*HTTPServer, *fakePromDataPlugin) {
+		plugin := &fakePromDataPlugin{}
+		dataService := tsdb.NewService()
+		dataService.PluginManager = &manager.PluginManager{BackendPluginManager: fakePromBackendPluginManager{}}
+		dataService.RegisterQueryHandler(models.DS_PROMETHEUS, func(*models.DataSource) (plugins.DataPlugin, error) {
+			return plugin, nil
+		})
+		hs := &HTTPServer{
+			Cfg:         setting.NewCfg(),
+			log:         log.New("test"),
+			DataService: &dataService,
+			DatasourceCache: &fakePromDatasourceCache{dataSources: map[string]*models.DataSource{
+				"prom":    {Id: 1, Uid: "prom", Type: models.DS_PROMETHEUS},
+				"private": {Id: 2, Uid: "private", Type: models.DS_PROMETHEUS},
+			}},
+			PluginRequestValidator: fakePromRequestValidator{},
+		}
+		return hs, plugin
+	}
+
+	loggedInUserScenarioWithRole(t, "When querying a data source without access to its UID", "GET",
+		"/api/datasources/uid/private/prometheus/api/v1/query_range", routePattern, models.ROLE_VIEWER,
+		func(sc *scenarioContext) {
+			hs, plugin := newServer()
+			sc.handlerFunc = hs.PrometheusQueryRange
+			sc.fakeReqWithParams("GET", sc.url, params).exec()
+
+			assert.Equal(t, http.StatusForbidden, sc.resp.Code)
+			assert.JSONEq(t, `{"status": "error", "errorType": "bad_data", "error": "access denied to data source"}`, sc.resp.Body.String())
+			assert.Nil(t, plugin.query)
+		})
+
+	loggedInUserScenarioWithRole(t, "When querying an unknown data source UID", "GET",
+		"/api/datasources/uid/unknown/prometheus/api/v1/query_range", routePattern, models.ROLE_VIEWER,
+		func(sc *scenarioContext) {
+			hs, plugin := newServer()
+			sc.handlerFunc = hs.PrometheusQueryRange
+			sc.fakeReqWithParams("GET", sc.url, params).exec()
+
+			assert.Equal(t, http.StatusNotFound, sc.resp.Code)
+			assert.JSONEq(t, `{"status": "error", "errorType": "not_found", "error": "data source not found"}`, sc.resp.Body.String())
+			assert.Nil(t, plugin.query)
+		})
+
+	loggedInUserScenarioWithRole(t, "When querying a data source", "GET",
+		"/api/datasources/uid/prom/prometheus/api/v1/query_range", routePattern, models.ROLE_VIEWER,
+		func(sc *scenarioContext) {
+			hs, plugin := newServer()
+			t0 := time.Unix(1614592800, 0)
+			plugin.response = plugins.DataQueryResult{Dataframes: plugins.NewDecodedDataFrames(data.Frames{
+				data.NewFrame("up",
+					data.NewField("time", nil, []time.Time{t0, t0.Add(time.Minute)}),
+					data.NewField("Value", data.Labels{"job": "grafana"}, []float64{1, 0}),
+				),
+			})}
+			sc.handlerFunc = hs.PrometheusQueryRange
+			sc.fakeReqWithParams("GET", sc.url, params).exec()
+
+			assert.Equal(t, http.StatusOK, sc.resp.Code)
+			assert.JSONEq(t, `{"status": "success", "data": {"resultType": "matrix", "result": [
+				{"metric": {"__name__": "up", "job": "grafana"}, "values": [[1614592800, "1"], [1614592860, "0"]]}
+			]}}`, sc.resp.Body.String())
+			require.NotNil(t, plugin.query)
+			require.Len(t, plugin.query.Queries, 1)
+			assert.Equal(t, "up", plugin.query.Queries[0].Model.Get("expr").MustString())
+			assert.Equal(t, int64(60000), plugin.query.Queries[0].IntervalMS)
+		})
+}
+
+// fakePromDataPlugin is a data plugin returning a fixed result and recording the query it received.
+type fakePromDataPlugin struct {
+	query    *plugins.DataQuery
+	response plugins.DataQueryResult
+}
+
+func (p *fakePromDataPlugin) DataQuery(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (plugins.DataResponse, error) {
+	p.query = &query
+	p.response.RefID = "A"
+	return plugins.DataResponse{Results: map[string]plugins.DataQueryResult{"A": p.response}}, nil
+}
+
+type fakePromBackendPluginManager struct {
+	backendplugin.Manager
+}
+
+func (pm fakePromBackendPluginManager) GetDataPlugin(string) interface{} {
+	return nil
+}
+
+// fakePromDatasourceCache denies access to the data source with UID "private".
+type fakePromDatasourceCache struct {
+	dataSources map[string]*models.DataSource
+}
+
+func (c *fakePromDatasourceCache) GetDatasource(id int64, user *models.SignedInUser, skipCache bool) (*models.DataSource, error) {
+	for _, ds := range c.dataSources {
+		if ds.Id == id {
+			return c.GetDatasourceByUID(ds.Uid, user, skipCache)
+		}
+	}
+	return nil, models.ErrDataSourceNotFound
+}
+
+func (c *fakePromDatasourceCache) GetDatasourceByUID(uid string, user *models.SignedInUser, skipCache bool) (*models.DataSource, error) {
+	if uid == "private" {
+		return nil, models.ErrDataSourceAccessDenied
+	}
+	ds, ok := c.dataSources[uid]
+	if !ok {
+		return nil, models.ErrDataSourceNotFound
+	}
+	return ds, nil
+}
+
+type fakePromRequestValidator struct{}
+
+func (fakePromRequestValidator) Validate(dsURL string, req *http.Request) error {
+	return nil
+}
@@ -60,6 +60,9 @@ func Search(c *models.ReqContext) response.Response {
 		FolderIds:    folderIDs,
 		Permission:   permission,
 		Sort:         sort,
+
+		IncludeSubfolders: c.Query("includeSubfolders") == "true",
+		SearchContent:     c.Query("content") == "true",
 	}
 
 	err := bus.Dispatch(&searchQuery)
@@ -3,6 +3,7 @@ package dashdiffs
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -24,6 +25,7 @@ const (
 	DiffJSON DiffType = iota
 	DiffBasic
 	DiffDelta
+	DiffJSONPatch
 )
 
 type Options struct {
@@ -51,6 +53,8 @@ func ParseDiffType(diff string) DiffType {
 		return DiffBasic
 	case "delta":
 		return DiffDelta
+	case "jsonpatch":
+		return DiffJSONPatch
 	}
 	return DiffBasic
 }
@@ -58,28 +62,19 @@ func ParseDiffType(diff string) DiffType {
 // CompareDashboardVersionsCommand computes the JSON diff of two versions,
 // assigning the delta of the diff to the `Delta` field.
 func CalculateDiff(options *Options) (*Result, error) {
-	baseVersionQuery := models.GetDashboardVersionQuery{
-		DashboardId: options.Base.DashboardId,
-		Version:     options.Base.Version,
-		OrgId:       options.OrgId,
-	}
-
-	if err := bus.Dispatch(&baseVersionQuery); err != nil {
+	baseData, err := getDiffTargetData(options.OrgId, options.Base)
+	if err != nil {
 		return nil, err
 	}
 
-	newVersionQuery := models.GetDashboardVersionQuery{
-		DashboardId: options.New.DashboardId,
-		Version:     options.New.Version,
-		OrgId:       options.OrgId,
-	}
-
-	if err := bus.Dispatch(&newVersionQuery); err != nil {
+	newData, err := getDiffTargetData(options.OrgId, options.New)
+	if err != nil {
 		return nil, err
 	}
 
-	baseData := baseVersionQuery.Result.Data
-	newData := newVersionQuery.Result.Data
+	if options.DiffType == DiffJSONPatch {
+		return calculatePatch(baseData, newData)
+	}
 
 	left, jsonDiff, err := getDiff(baseData, newData)
 	if err != nil {
@@ -118,6 +113,77 @@ func CalculateDiff(options *Options) (*Result, error) {
 	return result, nil
 }
 
+// getDiffTargetData returns the unsaved dashboard of a diff target, or else its dashboard version.
+func getDiffTargetData(orgId int64, target DiffTarget) (*simplejson.Json, error) {
+	if target.UnsavedDashboard != nil {
+		return target.UnsavedDashboard, nil
+	}
+
+	query := models.GetDashboardVersionQuery{
+		DashboardId: target.DashboardId,
+		Version:     target.Version,
+		OrgId:       orgId,
+	}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	return query.Result.Data, nil
+}
+
+// calculatePatch computes the JSON patch of two dashboards. Unlike the other diff types, identical
+// dashboards result in an empty patch.
+func calculatePatch(baseData, newData *simplejson.Json) (*Result, error) {
+	base, err := decodeJSON(baseData)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := decodeJSON(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := json.Marshal(CreatePatch(base, target))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Delta: patch}, nil
+}
+
+// ApplyDashboardPatch applies a JSON patch to a dashboard and returns the patched dashboard.
+func ApplyDashboardPatch(data *simplejson.Json, patch []PatchOperation) (*simplejson.Json, error) {
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := ApplyPatch(value, patch)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := patched.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%w: the patched dashboard must be an object", ErrInvalidPatch)
+	}
+
+	return simplejson.NewFromAny(patched), nil
+}
+
+// decodeJSON decodes a dashboard into plain JSON values, with numbers as float64.
+func decodeJSON(data *simplejson.Json) (interface{}, error) {
+	bytes, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
 // getDiff computes the diff of two dashboard versions.
 func getDiff(baseData, newData *simplejson.Json) (interface{}, diff.Diff, error) {
 	leftBytes, err := baseData.Encode()
@@ -0,0 +1,413 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch occurs when a JSON patch operation is malformed or its path does not exist.
+	ErrInvalidPatch = errors.New("dashdiff: invalid patch")
+	// ErrPatchConflict occurs when a test operation of a JSON patch fails, its value being different or missing.
+	ErrPatchConflict = errors.New("dashdiff: patch conflict")
+)
+
+// PatchOperation is an operation of a JSON patch as defined by RFC 6902.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps the value of the operations having one, even when it is null.
+func (op PatchOperation) MarshalJSON() ([]byte, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{op.Op, op.Path, op.Value})
+	}
+	return json.Marshal(struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}{op.Op, op.Path, op.From})
+}
+
+// CreatePatch returns the JSON patch transforming the base document into the new one. The documents are
+// decoded JSON values. Panels are matched by their id, so moved panels are moved rather than rewritten.
+func CreatePatch(base, target interface{}) []PatchOperation {
+	patch := []PatchOperation{}
+	return diffValues(patch, "", "", base, target)
+}
+
+func diffValues(patch []PatchOperation, path, key string, base, target interface{}) []PatchOperation {
+	switch baseValue := base.(type) {
+	case map[string]interface{}:
+		if newValue, ok := target.(map[string]interface{}); ok {
+			return diffObjects(patch, path, baseValue, newValue)
+		}
+	case []interface{}:
+		if newValue, ok := target.([]interface{}); ok {
+			if key == "panels" && hasUniquePanelIds(baseValue) && hasUniquePanelIds(newValue) {
+				return diffPanels(patch, path, baseValue, newValue)
+			}
+			return diffArrays(patch, path, baseValue, newValue)
+		}
+	}
+
+	if !reflect.DeepEqual(base, target) {
+		patch = append(patch, PatchOperation{Op: "replace", Path: path, Value: target})
+	}
+	return patch
+}
+
+func diffObjects(patch []PatchOperation, path string, base, target map[string]interface{}) []PatchOperation {
+	keys := make([]string, 0, len(base)+len(target))
+	for key := range base {
+		keys = append(keys, key)
+	}
+	for key := range target {
+		if _, ok := base[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		baseValue, inBase := base[key]
+		newValue, inNew := target[key]
+		keyPath := path + "/" + escapePointerToken(key)
+		switch {
+		case !inNew:
+			patch = append(patch, PatchOperation{Op: "remove", Path: keyPath})
+		case !inBase:
+			patch = append(patch, PatchOperation{Op: "add", Path: keyPath, Value: newValue})
+		default:
+			patch = diffValues(patch, keyPath, key, baseValue, newValue)
+		}
+	}
+	return patch
+}
+
+func diffArrays(patch []PatchOperation, path string, base, target []interface{}) []PatchOperation {
+	common := len(base)
+	if len(target) < common {
+		common = len(target)
+	}
+
+	for i := 0; i < common; i++ {
+		patch = diffValues(patch, indexPath(path, i), "", base[i], target[i])
+	}
+	for i := len(base) - 1; i >= common; i-- {
+		patch = append(patch, PatchOperation{Op: "remove", Path: indexPath(path, i)})
+	}
+	for i := common; i < len(target); i++ {
+		patch = append(patch, PatchOperation{Op: "add", Path: indexPath(path, i), Value: target[i]})
+	}
+	return patch
+}
+
+// diffPanels diffs arrays of panels with unique ids. Removed panels are removed first, then the new order
+// is built from the start of the array by moving the matched panels and adding the new ones.
+func diffPanels(patch []PatchOperation, path string, base, target []interface{}) []PatchOperation {
+	newIds := map[float64]bool{}
+	for _, panel := range target {
+		newIds[panelId(panel)] = true
+	}
+
+	working := make([]interface{}, 0, len(base))
+	for i := len(base) - 1; i >= 0; i-- {
+		if !newIds[panelId(base[i])] {
+			patch = append(patch, PatchOperation{Op: "remove", Path: indexPath(path, i)})
+		}
+	}
+	for _, panel := range base {
+		if newIds[panelId(panel)] {
+			working = append(working, panel)
+		}
+	}
+
+	for i, panel := range target {
+		current := -1
+		for j := i; j < len(working); j++ {
+			if panelId(working[j]) == panelId(panel) {
+				current = j
+				break
+			}
+		}
+
+		if current < 0 {
+			patch = append(patch, PatchOperation{Op: "add", Path: indexPath(path, i), Value: panel})
+			working = append(working[:i], append([]interface{}{panel}, working[i:]...)...)
+			continue
+		}
+
+		if current != i {
+			patch = append(patch, PatchOperation{Op: "move", From: indexPath(path, current), Path: indexPath(path, i)})
+			moved := working[current]
+			working = append(working[:current], working[current+1:]...)
+			working = append(working[:i], append([]interface{}{moved}, working[i:]...)...)
+		}
+		patch = diffValues(patch, indexPath(path, i), "", working[i], panel)
+	}
+	return patch
+}
+
+func hasUniquePanelIds(panels []interface{}) bool {
+	ids := map[float64]bool{}
+	for _, panel := range panels {
+		object, ok := panel.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		id, ok := object["id"].(float64)
+		if !ok || ids[id] {
+			return false
+		}
+		ids[id] = true
+	}
+	return true
+}
+
+func panelId(panel interface{}) float64 {
+	return panel.(map[string]interface{})["id"].(float64)
+}
+
+func indexPath(path string, index int) string {
+	return path + "/" + strconv.Itoa(index)
+}
+
+// ApplyPatch applies a JSON patch to a decoded JSON document and returns the patched document. The
+// document is modified in place. A failed test operation returns ErrPatchConflict.
+func ApplyPatch(doc interface{}, patch []PatchOperation) (interface{}, error) {
+	var err error
+	for i, op := range patch {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	switch op.Op {
+	case "add":
+		return addValue(doc, op.Path, deepCopy(op.Value))
+	case "remove":
+		doc, _, err := removeValue(doc, op.Path)
+		return doc, err
+	case "replace":
+		doc, _, err := removeValue(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, op.Path, deepCopy(op.Value))
+	case "move":
+		if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		doc, value, err := removeValue(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, op.Path, value)
+	case "copy":
+		value, err := getValue(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, op.Path, deepCopy(value))
+	case "test":
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, err
+		}
+		// a value that does not exist anymore was changed by another save, like a different value
+		value, err := getValue(doc, op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: path %q not found", ErrPatchConflict, op.Path)
+		}
+		if !reflect.DeepEqual(value, op.Value) {
+			return nil, ErrPatchConflict
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+}
+
+func getValue(doc interface{}, path string) (interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := doc
+	for _, token := range tokens {
+		switch container := value.(type) {
+		case map[string]interface{}:
+			child, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+			}
+			value = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			value = container[index]
+		default:
+			return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+		}
+	}
+	return value, nil
+}
+
+func addValue(doc interface{}, path string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, path, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index := len(container)
+			if token != "-" {
+				index, err = arrayIndex(token, len(container))
+				if err != nil {
+					return nil, err
+				}
+			}
+			return append(container[:index], append([]interface{}{value}, container[index:]...)...), nil
+		}
+		return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+	})
+}
+
+func removeValue(doc interface{}, path string) (interface{}, interface{}, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	var removed interface{}
+	doc, err = updateParent(doc, path, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+			}
+			removed = value
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[index]
+			return append(container[:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+	})
+	return doc, removed, err
+}
+
+// updateParent replaces the parent of the value at the path with the result of update, as arrays change
+// when values are added or removed.
+func updateParent(value interface{}, path string, tokens []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(value, tokens[0])
+	}
+
+	switch container := value.(type) {
+	case map[string]interface{}:
+		child, ok := container[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+		}
+		child, err := updateParent(child, path, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		container[tokens[0]] = child
+		return container, nil
+	case []interface{}:
+		index, err := arrayIndex(tokens[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := updateParent(container[index], path, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = child
+		return container, nil
+	}
+	return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+}
+
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return index, nil
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+	}
+	return value
+}
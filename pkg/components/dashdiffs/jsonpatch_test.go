@@ -0,0 +1,157 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func decode(t *testing.T, doc string) interface{} {
+	t.Helper()
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &value))
+	return value
+}
+
+func TestCreatePatch(t *testing.T) {
+	t.Run("diffs objects and arrays", func(t *testing.T) {
+		base := decode(t, `{"title": "Dash", "tags": ["a", "b", "c"], "a/b": 1, "time": {"from": "now-6h"}, "old": true}`)
+		target := decode(t, `{"title": "Dash 2", "tags": ["a", "x"], "a/b": 1, "time": {"from": "now-1h", "to": "now"}, "new": null}`)
+
+		patch := CreatePatch(base, target)
+		assert.Equal(t, []PatchOperation{
+			{Op: "add", Path: "/new", Value: nil},
+			{Op: "remove", Path: "/old"},
+			{Op: "replace", Path: "/tags/1", Value: "x"},
+			{Op: "remove", Path: "/tags/2"},
+			{Op: "replace", Path: "/time/from", Value: "now-1h"},
+			{Op: "add", Path: "/time/to", Value: "now"},
+			{Op: "replace", Path: "/title", Value: "Dash 2"},
+		}, patch)
+
+		patched, err := ApplyPatch(base, patch)
+		require.NoError(t, err)
+		assert.Equal(t, target, patched)
+	})
+
+	t.Run("matches panels by id", func(t *testing.T) {
+		base := decode(t, `{"panels": [
+			{"id": 1, "title": "CPU"},
+			{"id": 2, "title": "Memory"},
+			{"id": 3, "title": "Disk"},
+			{"id": 4, "type": "row", "panels": [{"id": 5}, {"id": 6}]}
+		]}`)
+		target := decode(t, `{"panels": [
+			{"id": 3, "title": "Disk"},
+			{"id": 7, "title": "Network"},
+			{"id": 1, "title": "CPU usage"},
+			{"id": 4, "type": "row", "panels": [{"id": 6}, {"id": 5}]}
+		]}`)
+
+		patch := CreatePatch(base, target)
+		assert.Equal(t, []PatchOperation{
+			{Op: "remove", Path: "/panels/1"},
+			{Op: "move", From: "/panels/1", Path: "/panels/0"},
+			{Op: "add", Path: "/panels/1", Value: map[string]interface{}{"id": 7.0, "title": "Network"}},
+			{Op: "replace", Path: "/panels/2/title", Value: "CPU usage"},
+			{Op: "move", From: "/panels/3/panels/1", Path: "/panels/3/panels/0"},
+		}, patch)
+
+		patched, err := ApplyPatch(base, patch)
+		require.NoError(t, err)
+		assert.Equal(t, target, patched)
+	})
+
+	t.Run("diffs panels without unique ids by position", func(t *testing.T) {
+		base := decode(t, `{"panels": [{"id": 1}, {"id": 1}]}`)
+		target := decode(t, `{"panels": [{"id": 1}]}`)
+
+		patch := CreatePatch(base, target)
+		assert.Equal(t, []PatchOperation{{Op: "remove", Path: "/panels/1"}}, patch)
+	})
+
+	t.Run("returns an empty patch for equal documents", func(t *testing.T) {
+		patch := CreatePatch(decode(t, `{"a": [1, 2]}`), decode(t, `{"a": [1, 2]}`))
+		assert.Empty(t, patch)
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	var patch []PatchOperation
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"op": "test", "path": "/title", "value": "Dash"},
+		{"op": "add", "path": "/tags/-", "value": "c"},
+		{"op": "add", "path": "/tags/0", "value": "z"},
+		{"op": "copy", "from": "/time", "path": "/timepicker"},
+		{"op": "replace", "path": "/time/from", "value": "now-1h"},
+		{"op": "move", "from": "/title", "path": "/name"},
+		{"op": "add", "path": "/a~1b~0c", "value": 1}
+	]`), &patch))
+
+	patched, err := ApplyPatch(decode(t, `{"title": "Dash", "tags": ["a", "b"], "time": {"from": "now-6h"}}`), patch)
+	require.NoError(t, err)
+	assert.Equal(t, decode(t, `{
+		"name": "Dash",
+		"tags": ["z", "a", "b", "c"],
+		"time": {"from": "now-1h"},
+		"timepicker": {"from": "now-6h"},
+		"a/b~c": 1
+	}`), patched)
+
+	t.Run("fails on failed tests", func(t *testing.T) {
+		_, err := ApplyPatch(decode(t, `{"title": "Dash"}`), []PatchOperation{{Op: "test", Path: "/title", Value: "Other"}})
+		require.True(t, errors.Is(err, ErrPatchConflict))
+
+		_, err = ApplyPatch(decode(t, `{"title": "Dash"}`), []PatchOperation{{Op: "test", Path: "/panels/0/title", Value: "CPU"}})
+		require.True(t, errors.Is(err, ErrPatchConflict))
+		require.False(t, errors.Is(err, ErrInvalidPatch))
+	})
+
+	t.Run("fails on invalid operations", func(t *testing.T) {
+		for _, op := range []PatchOperation{
+			{Op: "remove", Path: "/missing"},
+			{Op: "replace", Path: "/tags/2", Value: "x"},
+			{Op: "add", Path: "/tags/01", Value: "x"},
+			{Op: "add", Path: "/missing/key", Value: "x"},
+			{Op: "move", From: "/tags", Path: "/tags/0"},
+			{Op: "add", Path: "title", Value: "x"},
+			{Op: "test", Path: "title", Value: "Dash"},
+			{Op: "merge", Path: "/title"},
+		} {
+			_, err := ApplyPatch(decode(t, `{"title": "Dash", "tags": ["a", "b"]}`), []PatchOperation{op})
+			require.True(t, errors.Is(err, ErrInvalidPatch), "%s %s", op.Op, op.Path)
+		}
+	})
+}
+
+func TestPatchOperationJSON(t *testing.T) {
+	data, err := json.Marshal([]PatchOperation{
+		{Op: "add", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/b"},
+		{Op: "move", From: "/c", Path: "/d"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "add", "path": "/a", "value": null},
+		{"op": "remove", "path": "/b"},
+		{"op": "move", "from": "/c", "path": "/d"}
+	]`, string(data))
+}
+
+func TestApplyDashboardPatch(t *testing.T) {
+	dash := simplejson.NewFromAny(map[string]interface{}{"title": "Dash", "version": 2})
+
+	patched, err := ApplyDashboardPatch(dash, []PatchOperation{{Op: "replace", Path: "/title", Value: "Dash 2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Dash 2", patched.Get("title").MustString())
+	assert.Equal(t, 2, patched.Get("version").MustInt())
+	assert.Equal(t, "Dash", dash.Get("title").MustString())
+
+	_, err = ApplyDashboardPatch(dash, []PatchOperation{{Op: "replace", Path: "", Value: "Dash"}})
+	require.True(t, errors.Is(err, ErrInvalidPatch))
+}
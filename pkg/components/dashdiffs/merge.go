@@ -0,0 +1,193 @@
+package dashdiffs
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// mergeIgnoredKeys are the dashboard properties set when saving a dashboard rather than by its changes.
+var mergeIgnoredKeys = map[string]bool{"id": true, "uid": true, "version": true}
+
+// missing stands for a property or a panel that does not exist in a dashboard.
+var missing = &struct{ missing bool }{true}
+
+// MergeDashboards merges the changes of two dashboards made from the same base version: the local
+// dashboard being saved and the remote dashboard saved by someone else in the meantime. Panels are merged
+// one by one, matched by their id, and the other properties of the dashboards one by one. Panels and
+// properties changed differently on both sides are returned as conflicts, in which case no dashboard is
+// returned.
+func MergeDashboards(base, local, remote *simplejson.Json) (*simplejson.Json, []models.DashboardEditConflict, error) {
+	objects := make([]map[string]interface{}, 0, 3)
+	for _, data := range []*simplejson.Json{base, local, remote} {
+		value, err := decodeJSON(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil, errors.New("dashdiff: dashboards to merge must be objects")
+		}
+		objects = append(objects, object)
+	}
+
+	merged, conflicts := mergeObjects(objects[0], objects[1], objects[2])
+	if len(conflicts) > 0 {
+		return nil, conflicts, nil
+	}
+	return simplejson.NewFromAny(merged), nil, nil
+}
+
+func mergeObjects(base, local, remote map[string]interface{}) (map[string]interface{}, []models.DashboardEditConflict) {
+	keys := map[string]bool{}
+	for _, object := range []map[string]interface{}{base, local, remote} {
+		for key := range object {
+			keys[key] = true
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	merged := map[string]interface{}{}
+	conflicts := []models.DashboardEditConflict{}
+	for _, key := range sortedKeys {
+		if mergeIgnoredKeys[key] {
+			if value, ok := local[key]; ok {
+				merged[key] = value
+			}
+			continue
+		}
+
+		if key == "panels" {
+			basePanels, baseOk := panelsOf(base)
+			localPanels, localOk := panelsOf(local)
+			remotePanels, remoteOk := panelsOf(remote)
+			if baseOk && localOk && remoteOk {
+				panels, panelConflicts := mergePanels(basePanels, localPanels, remotePanels)
+				merged[key] = panels
+				conflicts = append(conflicts, panelConflicts...)
+				continue
+			}
+		}
+
+		value, ok := mergeValues(valueOf(base, key), valueOf(local, key), valueOf(remote, key))
+		if !ok {
+			conflicts = append(conflicts, models.DashboardEditConflict{Path: "/" + escapePointerToken(key)})
+			continue
+		}
+		if value != missing {
+			merged[key] = value
+		}
+	}
+	return merged, conflicts
+}
+
+// mergePanels merges panels matched by their id. The panels keep the order of the side that reordered
+// them, with the panels added on the other side at the end.
+func mergePanels(base, local, remote []interface{}) ([]interface{}, []models.DashboardEditConflict) {
+	first, second := remote, local
+	if reflect.DeepEqual(panelIds(base), panelIds(remote)) {
+		first, second = local, remote
+	}
+
+	merged := []interface{}{}
+	conflicts := []models.DashboardEditConflict{}
+	seen := map[float64]bool{}
+	for _, panels := range [][]interface{}{first, second} {
+		for _, panel := range panels {
+			id := panelId(panel)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			value, ok := mergeValues(findPanel(base, id), findPanel(local, id), findPanel(remote, id))
+			if !ok {
+				conflicts = append(conflicts, panelConflict(local, remote, id))
+				continue
+			}
+			if value != missing {
+				merged = append(merged, value)
+			}
+		}
+	}
+	return merged, conflicts
+}
+
+// mergeValues returns the value changed on one side, or the same value changed on both sides. It returns
+// false when both sides changed the value differently.
+func mergeValues(base, local, remote interface{}) (interface{}, bool) {
+	switch {
+	case reflect.DeepEqual(local, base), reflect.DeepEqual(local, remote):
+		return remote, true
+	case reflect.DeepEqual(remote, base):
+		return local, true
+	}
+	return nil, false
+}
+
+func valueOf(object map[string]interface{}, key string) interface{} {
+	if value, ok := object[key]; ok {
+		return value
+	}
+	return missing
+}
+
+// panelsOf returns the panels of a dashboard, if they can be matched by their id.
+func panelsOf(object map[string]interface{}) ([]interface{}, bool) {
+	value, ok := object["panels"]
+	if !ok {
+		return []interface{}{}, true
+	}
+	panels, ok := value.([]interface{})
+	if !ok || !hasUniquePanelIds(panels) {
+		return nil, false
+	}
+	return panels, true
+}
+
+func panelIds(panels []interface{}) []float64 {
+	ids := make([]float64, 0, len(panels))
+	for _, panel := range panels {
+		ids = append(ids, panelId(panel))
+	}
+	return ids
+}
+
+func findPanel(panels []interface{}, id float64) interface{} {
+	if index := panelIndex(panels, id); index >= 0 {
+		return panels[index]
+	}
+	return missing
+}
+
+func panelIndex(panels []interface{}, id float64) int {
+	for i, panel := range panels {
+		if panelId(panel) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func panelConflict(local, remote []interface{}, id float64) models.DashboardEditConflict {
+	panels := local
+	index := panelIndex(local, id)
+	if index < 0 {
+		panels = remote
+		index = panelIndex(remote, id)
+	}
+
+	title, _ := panels[index].(map[string]interface{})["title"].(string)
+	return models.DashboardEditConflict{
+		Path:    indexPath("/panels", index),
+		PanelId: int64(id),
+		Title:   title,
+	}
+}
@@ -0,0 +1,109 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func dashboardJSON(t *testing.T, doc string) *simplejson.Json {
+	t.Helper()
+	data, err := simplejson.NewJson([]byte(doc))
+	require.NoError(t, err)
+	return data
+}
+
+func TestMergeDashboards(t *testing.T) {
+	base := dashboardJSON(t, `{"id": 1, "uid": "abc", "version": 1, "title": "Dash", "tags": ["a"], "panels": [
+		{"id": 1, "title": "CPU"},
+		{"id": 2, "title": "Memory"},
+		{"id": 3, "title": "Disk"}
+	]}`)
+
+	t.Run("merges changes of different panels and properties", func(t *testing.T) {
+		local := dashboardJSON(t, `{"id": 1, "uid": "abc", "version": 1, "title": "Dash", "tags": ["a", "b"], "panels": [
+			{"id": 1, "title": "CPU usage"},
+			{"id": 2, "title": "Memory"},
+			{"id": 4, "title": "Network"}
+		]}`)
+		remote := dashboardJSON(t, `{"id": 1, "uid": "abc", "version": 3, "title": "Dashboard", "tags": ["a"], "panels": [
+			{"id": 2, "title": "Memory usage"},
+			{"id": 1, "title": "CPU"},
+			{"id": 3, "title": "Disk"},
+			{"id": 5, "title": "Load"}
+		]}`)
+
+		merged, conflicts, err := MergeDashboards(base, local, remote)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+
+		expected, err := decodeJSON(dashboardJSON(t, `{"id": 1, "uid": "abc", "version": 1, "title": "Dashboard", "tags": ["a", "b"], "panels": [
+			{"id": 2, "title": "Memory usage"},
+			{"id": 1, "title": "CPU usage"},
+			{"id": 5, "title": "Load"},
+			{"id": 4, "title": "Network"}
+		]}`))
+		require.NoError(t, err)
+		actual, err := decodeJSON(merged)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("keeps the panel order of the side that reordered the panels", func(t *testing.T) {
+		local := dashboardJSON(t, `{"title": "Dash", "tags": ["a"], "panels": [
+			{"id": 3, "title": "Disk"},
+			{"id": 1, "title": "CPU"},
+			{"id": 2, "title": "Memory"}
+		]}`)
+		remote := dashboardJSON(t, `{"title": "Dash", "tags": ["a"], "panels": [
+			{"id": 1, "title": "CPU"},
+			{"id": 2, "title": "Memory"},
+			{"id": 3, "title": "Disk usage"}
+		]}`)
+
+		merged, conflicts, err := MergeDashboards(base, local, remote)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+
+		ids := []int{}
+		for _, panel := range merged.Get("panels").MustArray() {
+			ids = append(ids, simplejson.NewFromAny(panel).Get("id").MustInt())
+		}
+		assert.Equal(t, []int{3, 1, 2}, ids)
+		assert.Equal(t, "Disk usage", merged.Get("panels").GetIndex(0).Get("title").MustString())
+	})
+
+	t.Run("returns conflicts for panels and properties changed on both sides", func(t *testing.T) {
+		local := dashboardJSON(t, `{"title": "Local", "tags": ["a"], "panels": [
+			{"id": 1, "title": "CPU usage"},
+			{"id": 3, "title": "Disk"}
+		]}`)
+		remote := dashboardJSON(t, `{"title": "Remote", "tags": ["a"], "panels": [
+			{"id": 1, "title": "CPU load"},
+			{"id": 2, "title": "Memory usage"},
+			{"id": 3, "title": "Disk"}
+		]}`)
+
+		merged, conflicts, err := MergeDashboards(base, local, remote)
+		require.NoError(t, err)
+		assert.Nil(t, merged)
+		assert.Equal(t, []models.DashboardEditConflict{
+			{Path: "/panels/0", PanelId: 1, Title: "CPU usage"},
+			{Path: "/panels/1", PanelId: 2, Title: "Memory usage"},
+			{Path: "/title"},
+		}, conflicts)
+	})
+
+	t.Run("merges panels without unique ids as a whole", func(t *testing.T) {
+		local := dashboardJSON(t, `{"title": "Dash", "tags": ["a"], "panels": [{"id": 1}, {"id": 1}]}`)
+		remote := dashboardJSON(t, `{"title": "Dash", "tags": ["a"], "panels": [{"id": 1}]}`)
+
+		_, conflicts, err := MergeDashboards(base, local, remote)
+		require.NoError(t, err)
+		assert.Equal(t, []models.DashboardEditConflict{{Path: "/panels"}}, conflicts)
+	})
+}
@@ -46,3 +46,21 @@ type UserUpdated struct {
 	Login     string    `json:"login"`
 	Email     string    `json:"email"`
 }
+
+// DashboardViewed is published when a user views a dashboard. UserId is 0 for anonymous users.
+type DashboardViewed struct {
+	Timestamp   time.Time `json:"timestamp"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+	UserId      int64     `json:"userId"`
+}
+
+// DashboardQueried is published when the panels of a dashboard query their data sources.
+type DashboardQueried struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	OrgId       int64         `json:"orgId"`
+	DashboardId int64         `json:"dashboardId"`
+	Queries     int           `json:"queries"`
+	Duration    time.Duration `json:"duration"`
+	Failed      bool          `json:"failed"`
+}
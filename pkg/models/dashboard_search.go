@@ -0,0 +1,207 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// DashboardSearchTermKind is the part of a dashboard a search term comes from.
+type DashboardSearchTermKind string
+
+const (
+	SearchTermPanelTitle       DashboardSearchTermKind = "panelTitle"
+	SearchTermPanelDescription DashboardSearchTermKind = "panelDescription"
+	SearchTermRow              DashboardSearchTermKind = "row"
+	SearchTermVariable         DashboardSearchTermKind = "variable"
+	SearchTermQuery            DashboardSearchTermKind = "query"
+)
+
+// maxSearchTermLocationLength is the length of the location column of the dashboard_search_term table.
+const maxSearchTermLocationLength = 255
+
+// searchQueryKeys are the properties of panel targets and template variables holding the query text of the
+// data sources.
+var searchQueryKeys = []string{"expr", "expression", "query", "queryText", "rawSql", "target"}
+
+// DashboardSearchTerm is a text of a dashboard indexed for the dashboard search, other than its title and tags.
+type DashboardSearchTerm struct {
+	Id          int64
+	DashboardId int64
+	Kind        DashboardSearchTermKind
+	// PanelId is the id of the panel of the term, 0 for template variables.
+	PanelId int64
+	// Location describes where the term is in the dashboard: the title of its panel or row, the name of its
+	// variable, or the refId of its query.
+	Location string
+	Term     string
+}
+
+// LibraryPanelRef is a library panel used by a dashboard, with the version the dashboard is pinned to, 0 when it
+// follows the latest version.
+type LibraryPanelRef struct {
+	UID     string
+	Version int64
+}
+
+// GetLibraryPanelModelsQuery gets the models of the library panels of a dashboard, which are not stored in the
+// dashboard, to index them with its search terms. The library panels that do not exist are not in the result.
+type GetLibraryPanelModelsQuery struct {
+	OrgId  int64
+	Panels []LibraryPanelRef
+	Result map[LibraryPanelRef]*simplejson.Json
+}
+
+// UpdateDashboardSearchTermsCommand indexes the search terms of dashboards again, when their library panels change.
+type UpdateDashboardSearchTermsCommand struct {
+	OrgId        int64
+	DashboardIds []int64
+}
+
+// GetLibraryPanelRefs returns the library panels used by the dashboard.
+func (d *Dashboard) GetLibraryPanelRefs() []LibraryPanelRef {
+	refs := make([]LibraryPanelRef, 0)
+	seen := map[LibraryPanelRef]bool{}
+	var addPanels func(panels []interface{})
+	addPanels = func(panels []interface{}) {
+		for _, item := range panels {
+			panel := simplejson.NewFromAny(item)
+			if ref, ok := libraryPanelRef(panel); ok && !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+			addPanels(panel.Get("panels").MustArray())
+		}
+	}
+	addPanels(d.Data.Get("panels").MustArray())
+	for _, item := range d.Data.Get("rows").MustArray() {
+		addPanels(simplejson.NewFromAny(item).Get("panels").MustArray())
+	}
+	return refs
+}
+
+// libraryPanelRef returns the library panel of a panel of a dashboard, which only stores its UID and the version
+// the dashboard is pinned to.
+func libraryPanelRef(panel *simplejson.Json) (LibraryPanelRef, bool) {
+	uid := panel.GetPath("libraryPanel", "uid").MustString()
+	if uid == "" {
+		return LibraryPanelRef{}, false
+	}
+	return LibraryPanelRef{UID: uid, Version: panel.GetPath("libraryPanel", "pinnedVersion").MustInt64()}, true
+}
+
+// GetSearchTerms returns the texts of the dashboard indexed for the dashboard search: the titles and descriptions
+// of its panels, the titles of its rows, the names of its template variables, and the queries of its panels and
+// variables. The texts of library panels are taken from their models, the library panels missing from
+// libraryPanels are not indexed.
+func (d *Dashboard) GetSearchTerms(libraryPanels map[LibraryPanelRef]*simplejson.Json) []*DashboardSearchTerm {
+	terms := make([]*DashboardSearchTerm, 0)
+	seen := map[DashboardSearchTerm]bool{}
+	add := func(kind DashboardSearchTermKind, panelId int64, location string, term string) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return
+		}
+		location = truncateSearchTermLocation(location)
+		key := DashboardSearchTerm{Kind: kind, PanelId: panelId, Location: location, Term: term}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		terms = append(terms, &DashboardSearchTerm{
+			DashboardId: d.Id,
+			Kind:        kind,
+			PanelId:     panelId,
+			Location:    location,
+			Term:        term,
+		})
+	}
+
+	var addPanels func(panels []interface{})
+	addPanels = func(panels []interface{}) {
+		for _, item := range panels {
+			panel := simplejson.NewFromAny(item)
+			id := panel.Get("id").MustInt64()
+			if ref, ok := libraryPanelRef(panel); ok {
+				model, ok := libraryPanels[ref]
+				if !ok {
+					continue
+				}
+				panel = model
+			}
+			title := panel.Get("title").MustString()
+			if panel.Get("type").MustString() == "row" {
+				add(SearchTermRow, id, title, title)
+				// collapsed rows contain their panels
+				addPanels(panel.Get("panels").MustArray())
+				continue
+			}
+
+			add(SearchTermPanelTitle, id, title, title)
+			add(SearchTermPanelDescription, id, title, panel.Get("description").MustString())
+			for _, target := range panel.Get("targets").MustArray() {
+				target := simplejson.NewFromAny(target)
+				location := title
+				if refId := target.Get("refId").MustString(); refId != "" {
+					location = strings.TrimSpace(title + " " + refId)
+				}
+				for _, query := range searchQueries(target) {
+					add(SearchTermQuery, id, location, query)
+				}
+			}
+		}
+	}
+
+	addPanels(d.Data.Get("panels").MustArray())
+	// dashboards of schema versions before 16 have rows instead of row panels
+	for _, item := range d.Data.Get("rows").MustArray() {
+		row := simplejson.NewFromAny(item)
+		title := row.Get("title").MustString()
+		add(SearchTermRow, 0, title, title)
+		addPanels(row.Get("panels").MustArray())
+	}
+
+	for _, item := range d.Data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		name := variable.Get("name").MustString()
+		add(SearchTermVariable, 0, name, name)
+		if label := variable.Get("label").MustString(); label != "" && label != name {
+			add(SearchTermVariable, 0, name, label)
+		}
+		if variable.Get("type").MustString() == "query" {
+			for _, query := range searchQueries(variable) {
+				add(SearchTermQuery, 0, "$"+name, query)
+			}
+		}
+	}
+
+	return terms
+}
+
+// truncateSearchTermLocation truncates a location to the length of the location column, which counts
+// characters, without splitting a multi-byte character.
+func truncateSearchTermLocation(location string) string {
+	if utf8.RuneCountInString(location) <= maxSearchTermLocationLength {
+		return location
+	}
+	return string([]rune(location)[:maxSearchTermLocationLength])
+}
+
+// searchQueries returns the query texts of a panel target or template variable. Queries of some data sources are
+// objects holding the query text.
+func searchQueries(json *simplejson.Json) []string {
+	queries := make([]string, 0)
+	for _, key := range searchQueryKeys {
+		value, ok := json.CheckGet(key)
+		if !ok {
+			continue
+		}
+		if query, err := value.String(); err == nil {
+			queries = append(queries, query)
+		} else if query, err := value.Get("query").String(); err == nil {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard_GetSearchTerms(t *testing.T) {
+	dash := NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"id":    1,
+		"title": "API",
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":          2,
+				"title":       "Requests",
+				"description": "Rate of HTTP requests",
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "expr": "rate(http_requests_total[5m])"},
+					map[string]interface{}{"refId": "B", "query": map[string]interface{}{"query": "SELECT 1"}},
+				},
+			},
+			map[string]interface{}{"id": 3, "type": "row", "title": "Errors", "panels": []interface{}{
+				map[string]interface{}{"id": 4, "title": "5xx", "targets": []interface{}{
+					map[string]interface{}{"rawSql": "SELECT count(*) FROM errors"},
+				}},
+			}},
+		},
+		"rows": []interface{}{
+			map[string]interface{}{"title": "Legacy", "panels": []interface{}{
+				map[string]interface{}{"id": 5, "title": "Graphite", "targets": []interface{}{
+					map[string]interface{}{"refId": "A", "target": "servers.*.cpu"},
+				}},
+			}},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "instance", "label": "Instance", "type": "query", "query": "label_values(up, instance)"},
+				map[string]interface{}{"name": "interval", "type": "interval", "query": "1m,5m"},
+			},
+		},
+	}))
+
+	terms := dash.GetSearchTerms(nil)
+
+	assert.Equal(t, []*DashboardSearchTerm{
+		{DashboardId: 1, Kind: SearchTermPanelTitle, PanelId: 2, Location: "Requests", Term: "Requests"},
+		{DashboardId: 1, Kind: SearchTermPanelDescription, PanelId: 2, Location: "Requests", Term: "Rate of HTTP requests"},
+		{DashboardId: 1, Kind: SearchTermQuery, PanelId: 2, Location: "Requests A", Term: "rate(http_requests_total[5m])"},
+		{DashboardId: 1, Kind: SearchTermQuery, PanelId: 2, Location: "Requests B", Term: "SELECT 1"},
+		{DashboardId: 1, Kind: SearchTermRow, PanelId: 3, Location: "Errors", Term: "Errors"},
+		{DashboardId: 1, Kind: SearchTermPanelTitle, PanelId: 4, Location: "5xx", Term: "5xx"},
+		{DashboardId: 1, Kind: SearchTermQuery, PanelId: 4, Location: "5xx", Term: "SELECT count(*) FROM errors"},
+		{DashboardId: 1, Kind: SearchTermRow, Location: "Legacy", Term: "Legacy"},
+		{DashboardId: 1, Kind: SearchTermPanelTitle, PanelId: 5, Location: "Graphite", Term: "Graphite"},
+		{DashboardId: 1, Kind: SearchTermQuery, PanelId: 5, Location: "Graphite A", Term: "servers.*.cpu"},
+		{DashboardId: 1, Kind: SearchTermVariable, Location: "instance", Term: "instance"},
+		{DashboardId: 1, Kind: SearchTermVariable, Location: "instance", Term: "Instance"},
+		{DashboardId: 1, Kind: SearchTermQuery, Location: "$instance", Term: "label_values(up, instance)"},
+		{DashboardId: 1, Kind: SearchTermVariable, Location: "interval", Term: "interval"},
+	}, terms)
+}
+
+func TestDashboard_GetSearchTermsTruncatesLocation(t *testing.T) {
+	title := strings.Repeat("é", maxSearchTermLocationLength+1)
+	dash := NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "title": title},
+		},
+	}))
+
+	terms := dash.GetSearchTerms(nil)
+
+	require.Len(t, terms, 1)
+	assert.True(t, utf8.ValidString(terms[0].Location))
+	assert.Equal(t, strings.Repeat("é", maxSearchTermLocationLength), terms[0].Location)
+	assert.Equal(t, title, terms[0].Term)
+}
+
+func TestDashboard_GetSearchTermsOfLibraryPanels(t *testing.T) {
+	dash := NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+		"id": 1,
+		"panels": []interface{}{
+			map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "latency", "name": "Latency"}},
+			map[string]interface{}{"id": 3, "libraryPanel": map[string]interface{}{"uid": "latency", "name": "Latency", "pinnedVersion": 2}},
+			map[string]interface{}{"id": 4, "libraryPanel": map[string]interface{}{"uid": "deleted", "name": "Deleted"}},
+		},
+	}))
+
+	refs := dash.GetLibraryPanelRefs()
+	require.Equal(t, []LibraryPanelRef{{UID: "latency"}, {UID: "latency", Version: 2}, {UID: "deleted"}}, refs)
+
+	terms := dash.GetSearchTerms(map[LibraryPanelRef]*simplejson.Json{
+		{UID: "latency"}: simplejson.NewFromAny(map[string]interface{}{"id": 10, "title": "Latency", "targets": []interface{}{
+			map[string]interface{}{"refId": "A", "expr": "histogram_quantile(0.99, latency)"},
+		}}),
+		{UID: "latency", Version: 2}: simplejson.NewFromAny(map[string]interface{}{"title": "Old latency"}),
+	})
+
+	assert.Equal(t, []*DashboardSearchTerm{
+		{DashboardId: 1, Kind: SearchTermPanelTitle, PanelId: 2, Location: "Latency", Term: "Latency"},
+		{DashboardId: 1, Kind: SearchTermQuery, PanelId: 2, Location: "Latency A", Term: "histogram_quantile(0.99, latency)"},
+		{DashboardId: 1, Kind: SearchTermPanelTitle, PanelId: 3, Location: "Old latency", Term: "Old latency"},
+	}, terms)
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDashboardTrashItemNotFound = errors.New("dashboard trash item not found")
+	// ErrDashboardTrashLibraryPanelExists is returned when a library panel of a trash item has been created again
+	// since the item was deleted.
+	ErrDashboardTrashLibraryPanelExists = errors.New("a library panel with the same uid already exists")
+)
+
+// A DashboardTrashItem is a deleted dashboard or folder, kept with the content needed to restore it
+// until it is purged.
+type DashboardTrashItem struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	Uid         string
+	Title       string
+	IsFolder    bool
+	FolderId    int64
+	Data        string
+	DeletedBy   int64
+	Deleted     time.Time
+}
+
+func (i DashboardTrashItem) TableName() string {
+	return "dashboard_trash"
+}
+
+// DashboardTrashItemDTO represents a dashboard trash item, without the deleted content.
+type DashboardTrashItemDTO struct {
+	Id          int64     `json:"id"`
+	DashboardId int64     `json:"dashboardId"`
+	Uid         string    `json:"uid"`
+	Title       string    `json:"title"`
+	IsFolder    bool      `json:"isFolder"`
+	FolderId    int64     `json:"folderId"`
+	DeletedBy   string    `json:"deletedBy"`
+	Deleted     time.Time `json:"deleted"`
+}
+
+//
+// Queries
+//
+
+type GetDashboardTrashQuery struct {
+	OrgId int64
+
+	Result []*DashboardTrashItemDTO
+}
+
+//
+// Commands
+//
+
+// RestoreDashboardFromTrashCommand restores a dashboard or folder with its content. A dashboard whose
+// folder no longer exists is restored to the General folder.
+type RestoreDashboardFromTrashCommand struct {
+	Id    int64
+	OrgId int64
+
+	Result []*Dashboard
+}
+
+type PurgeDashboardTrashItemCommand struct {
+	Id    int64
+	OrgId int64
+}
+
+type DeleteExpiredDashboardTrashCommand struct {
+	DeletedRows int64
+}
+
+// MoveLibraryPanelsToTrashCommand deletes the library panels of folders moved to the trash. The result is the
+// content to restore them with RestoreLibraryPanelsFromTrashCommand, or nil if the folders have no library panels.
+// Both commands are handled by the library panels service, in the transaction of the context.
+type MoveLibraryPanelsToTrashCommand struct {
+	OrgId     int64
+	FolderIds []int64
+
+	Result json.RawMessage
+}
+
+type RestoreLibraryPanelsFromTrashCommand struct {
+	Data json.RawMessage
+}
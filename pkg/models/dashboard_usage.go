@@ -0,0 +1,27 @@
+package models
+
+// DashboardUsage is the usage of a dashboard: how often it is viewed and the query load it generates.
+type DashboardUsage struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	Views       int64
+	// LastViewed is the unix time of the last view of the dashboard, 0 if it has never been viewed.
+	LastViewed int64
+	// Queries is the number of data source queries of the panels of the dashboard, QueryErrors the number of failed
+	// query requests, and QueryDurationMs the total duration of the query requests.
+	Queries         int64
+	QueryErrors     int64
+	QueryDurationMs int64
+}
+
+// DashboardUserView is how often a user has viewed a dashboard.
+type DashboardUserView struct {
+	Id          int64
+	OrgId       int64
+	DashboardId int64
+	UserId      int64
+	Views       int64
+	// LastViewed is the unix time of the last view of the dashboard by the user.
+	LastViewed int64
+}
@@ -47,10 +47,6 @@ var (
 		Reason:     "Dashboard title cannot be empty",
 		StatusCode: 400,
 	}
-	ErrDashboardFolderCannotHaveParent = DashboardErr{
-		Reason:     "A Dashboard Folder cannot be added to another folder",
-		StatusCode: 400,
-	}
 	ErrDashboardsWithSameSlugExists = DashboardErr{
 		Reason:     "Multiple dashboards with the same slug exists",
 		StatusCode: 412,
@@ -134,6 +130,38 @@ func (e DashboardErr) Body() util.DynMap {
 	return util.DynMap{"status": e.Status, "message": e.Error()}
 }
 
+// DashboardEditConflict is a part of a dashboard that has been changed both by the saved dashboard and by
+// someone else in the meantime.
+type DashboardEditConflict struct {
+	Path    string `json:"path"`
+	PanelId int64  `json:"panelId,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+// DashboardEditConflictError occurs when the changes of a dashboard cannot be merged with the changes saved
+// by someone else in the meantime.
+type DashboardEditConflictError struct {
+	Version   int
+	Conflicts []DashboardEditConflict
+}
+
+func (e DashboardEditConflictError) Error() string {
+	return ErrDashboardVersionMismatch.Error()
+}
+
+// Unwrap returns ErrDashboardVersionMismatch, the error of the dashboard save without merge.
+func (e DashboardEditConflictError) Unwrap() error {
+	return ErrDashboardVersionMismatch
+}
+
+// Body returns the response body of the error, with the conflicts.
+func (e DashboardEditConflictError) Body() util.DynMap {
+	body := ErrDashboardVersionMismatch.Body()
+	body["version"] = e.Version
+	body["conflicts"] = e.Conflicts
+	return body
+}
+
 type UpdatePluginDashboardError struct {
 	PluginId string
 }
@@ -365,6 +393,9 @@ type SaveProvisionedDashboardCommand struct {
 type DeleteDashboardCommand struct {
 	Id    int64
 	OrgId int64
+	// MoveToTrash keeps the dashboard in the trash of the organization, from where it can be restored.
+	MoveToTrash bool
+	DeletedBy   int64
 }
 
 type ValidateDashboardBeforeSaveCommand struct {
@@ -2,19 +2,31 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
 
+// MaxFolderDepth is the maximum number of levels of nested folders, top-level folders included.
+const MaxFolderDepth = 8
+
 // Typed errors
 var (
 	ErrFolderNotFound                = errors.New("folder not found")
 	ErrFolderVersionMismatch         = errors.New("the folder has been changed by someone else")
 	ErrFolderTitleEmpty              = errors.New("folder title cannot be empty")
 	ErrFolderWithSameUIDExists       = errors.New("a folder/dashboard with the same uid already exists")
-	ErrFolderSameNameExists          = errors.New("a folder or dashboard in the general folder with the same name already exists")
+	ErrFolderSameNameExists          = errors.New("a folder or dashboard in the parent folder with the same name already exists")
 	ErrFolderFailedGenerateUniqueUid = errors.New("failed to generate unique folder ID")
 	ErrFolderAccessDenied            = errors.New("access denied to folder")
+	ErrFolderCannotBeOwnAncestor     = DashboardErr{
+		Reason:     "A folder cannot be moved into itself or one of its subfolders",
+		StatusCode: 400,
+	}
+	ErrFolderMaxDepthExceeded = DashboardErr{
+		Reason:     fmt.Sprintf("Folders cannot be nested more than %d levels deep", MaxFolderDepth),
+		StatusCode: 400,
+	}
 )
 
 type Folder struct {
@@ -24,6 +36,9 @@ type Folder struct {
 	Url     string
 	Version int
 
+	ParentId  int64
+	ParentUid string
+
 	Created time.Time
 	Updated time.Time
 
@@ -75,8 +90,9 @@ func (cmd *UpdateFolderCommand) UpdateDashboardModel(dashFolder *Dashboard, orgI
 //
 
 type CreateFolderCommand struct {
-	Uid   string `json:"uid"`
-	Title string `json:"title"`
+	Uid       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUid string `json:"parentUid"`
 
 	Result *Folder
 }
@@ -90,10 +106,26 @@ type UpdateFolderCommand struct {
 	Result *Folder
 }
 
+// MoveFolderCommand moves a folder into another folder, or to the top level if ParentUid is empty.
+type MoveFolderCommand struct {
+	ParentUid string `json:"parentUid"`
+
+	Result *Folder
+}
+
 //
 // QUERIES
 //
 
+// GetFolderByTitleQuery looks up a folder by title within its parent folder, 0 being the top level.
+type GetFolderByTitleQuery struct {
+	OrgId    int64
+	ParentId int64
+	Title    string
+
+	Result *Dashboard
+}
+
 type HasEditPermissionInFoldersQuery struct {
 	SignedInUser *SignedInUser
 	Result       bool
@@ -24,5 +24,6 @@ type ChannelHandlerFactory interface {
 // DashboardActivityChannel is a service to advertise dashboard activity
 type DashboardActivityChannel interface {
 	DashboardSaved(uid string, userID int64) error
+	DashboardMerged(uid string, userID int64, version int) error
 	DashboardDeleted(uid string, userID int64) error
 }
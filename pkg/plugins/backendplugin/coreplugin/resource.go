@@ -0,0 +1,104 @@
+package coreplugin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// RegisterResourceHandler registers a core data source plugin serving the resource calls of pluginID with mux.
+// The plugin only serves resources, the queries of the data source are still executed through the executor
+// registered in the tsdb service.
+func RegisterResourceHandler(manager backendplugin.Manager, pluginID string, mux *http.ServeMux, logger log.Logger) {
+	factory := New(backend.ServeOpts{
+		CallResourceHandler: httpadapter.New(mux),
+	})
+	if err := manager.Register(pluginID, factory); err != nil {
+		logger.Error("Failed to register plugin", "error", err)
+	}
+}
+
+// DataSourceFromContext returns the data source a resource call of a core data source plugin was made for.
+func DataSourceFromContext(req *http.Request) (*models.DataSource, error) {
+	pluginCtx := httpadapter.PluginConfigFromContext(req.Context())
+	if pluginCtx.DataSourceInstanceSettings == nil {
+		return nil, fmt.Errorf("resource call is missing data source settings")
+	}
+
+	query := &models.GetDataSourceQuery{
+		Id:    pluginCtx.DataSourceInstanceSettings.ID,
+		OrgId: pluginCtx.OrgID,
+	}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+// ProxyResourceHandler returns a handler forwarding GET resource calls, with their query parameters, to apiPath
+// of the URL of the data source the call was made for.
+func ProxyResourceHandler(apiPath string, logger log.Logger) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		dsInfo, err := DataSourceFromContext(req)
+		if err != nil {
+			logger.Error("Failed to get data source", "error", err)
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		httpClient, err := dsInfo.GetHttpClient()
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		u, err := url.Parse(dsInfo.Url)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		u.Path = path.Join(u.Path, apiPath)
+		u.RawQuery = req.URL.Query().Encode()
+
+		proxyReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, u.String(), nil)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if dsInfo.BasicAuth {
+			proxyReq.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+		}
+
+		res, err := httpClient.Do(proxyReq)
+		if err != nil {
+			logger.Error("Resource request failed", "path", apiPath, "error", err)
+			http.Error(rw, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer func() {
+			if err := res.Body.Close(); err != nil {
+				logger.Warn("Failed to close response body", "err", err)
+			}
+		}()
+
+		rw.Header().Set("Content-Type", res.Header.Get("Content-Type"))
+		rw.WriteHeader(res.StatusCode)
+		if _, err := io.Copy(rw, res.Body); err != nil {
+			logger.Error("Failed to write response", "error", err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package coreplugin_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type responseSenderFunc func(res *backend.CallResourceResponse) error
+
+func (fn responseSenderFunc) Send(res *backend.CallResourceResponse) error {
+	return fn(res)
+}
+
+func TestProxyResourceHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"path": "` + req.URL.Path + `", "query": "` + req.URL.Query().Get("query") + `"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.GetDataSourceQuery) error {
+		if query.Id != 1 || query.OrgId != 2 {
+			return models.ErrDataSourceNotFound
+		}
+		query.Result = &models.DataSource{Id: 1, OrgId: 2, Url: server.URL + "/graphite", JsonData: simplejson.New()}
+		return nil
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics/find", coreplugin.ProxyResourceHandler("metrics/find", log.New("test")))
+	handler := httpadapter.New(mux)
+
+	callResource := func(t *testing.T, dataSourceID int64, method string) *backend.CallResourceResponse {
+		t.Helper()
+		var res *backend.CallResourceResponse
+		err := handler.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      2,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: dataSourceID},
+			},
+			Path:   "metrics/find",
+			Method: method,
+			URL:    "metrics/find?query=servers.*",
+		}, responseSenderFunc(func(r *backend.CallResourceResponse) error {
+			res = r
+			return nil
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		return res
+	}
+
+	t.Run("requests are proxied to the API path of the data source", func(t *testing.T) {
+		res := callResource(t, 1, http.MethodGet)
+		require.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `{"path": "/graphite/metrics/find", "query": "servers.*"}`, string(res.Body))
+	})
+
+	t.Run("unknown data sources are rejected", func(t *testing.T) {
+		res := callResource(t, 3, http.MethodGet)
+		assert.Equal(t, http.StatusBadRequest, res.Status)
+	})
+
+	t.Run("methods other than GET are not allowed", func(t *testing.T) {
+		res := callResource(t, 1, http.MethodPost)
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	})
+}
+
+type fakePluginManager struct {
+	backendplugin.Manager
+	factories map[string]backendplugin.PluginFactoryFunc
+}
+
+func (m *fakePluginManager) Register(pluginID string, factory backendplugin.PluginFactoryFunc) error {
+	m.factories[pluginID] = factory
+	return nil
+}
+
+func TestRegisterResourceHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("pong"))
+	})
+	manager := &fakePluginManager{factories: map[string]backendplugin.PluginFactoryFunc{}}
+	coreplugin.RegisterResourceHandler(manager, "test-datasource", mux, log.New("test"))
+
+	factory, ok := manager.factories["test-datasource"]
+	require.True(t, ok)
+	plugin, err := factory("test-datasource", log.New("test"), nil)
+	require.NoError(t, err)
+	assert.False(t, plugin.CanHandleDataQueries())
+
+	var res *backend.CallResourceResponse
+	err = plugin.CallResource(context.Background(), &backend.CallResourceRequest{
+		Path:   "ping",
+		Method: http.MethodGet,
+		URL:    "ping",
+	}, responseSenderFunc(func(r *backend.CallResourceResponse) error {
+		res = r
+		return nil
+	}))
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, "pong", string(res.Body))
+}
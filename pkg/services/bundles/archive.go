@@ -0,0 +1,295 @@
+package bundles
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// maxBundleSize is the maximum size of the uncompressed content of an imported bundle.
+const maxBundleSize = 100 << 20
+
+const (
+	manifestFile               = "manifest.json"
+	dashboardsDir              = "dashboards"
+	libraryPanelsDir           = "library-panels"
+	dataSourcesDir             = "datasources"
+	alertNotificationsDir      = "alert-notifications"
+	generalFolderDir           = "general"
+	provisioningDashboardsFile = "provisioning/dashboards/bundle.yaml"
+	provisioningDataSourceFile = "provisioning/datasources/bundle.yaml"
+	provisioningNotifiersFile  = "provisioning/notifiers/bundle.yaml"
+)
+
+var safeFileName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// fileName returns the name of the file of a bundle item, its UID unless the UID is not safe to use in a path.
+func fileName(uid string, index int) string {
+	if safeFileName.MatchString(uid) {
+		return uid + ".json"
+	}
+	return fmt.Sprintf("item-%d.json", index)
+}
+
+// dashboardPath returns the path of a dashboard file, in the directory of its folder.
+func dashboardPath(uid string, folderUID string, index int) string {
+	dir := generalFolderDir
+	if folderUID != "" {
+		dir = strings.TrimSuffix(fileName(folderUID, index), ".json")
+	}
+	return path.Join(dashboardsDir, dir, fileName(uid, index))
+}
+
+// archiveFile is a file of a bundle archive.
+type archiveFile struct {
+	name    string
+	content []byte
+}
+
+// Write writes the bundle as an archive. The provisioning files of the bundle expect the archive to be extracted
+// in provisioningPath.
+func (b *Bundle) Write(w io.Writer, format Format, provisioningPath string) error {
+	files, err := b.files(provisioningPath)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatZip:
+		return writeZip(w, files, b.Manifest.Exported)
+	case FormatTar:
+		return writeTar(w, files, b.Manifest.Exported)
+	}
+	return ErrUnsupportedBundleFormat
+}
+
+func (b *Bundle) files(provisioningPath string) ([]archiveFile, error) {
+	files := make([]archiveFile, 0)
+	add := func(name string, value interface{}) error {
+		content, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		files = append(files, archiveFile{name: name, content: append(content, '\n')})
+		return nil
+	}
+
+	if err := add(manifestFile, b.Manifest); err != nil {
+		return nil, err
+	}
+	for _, dash := range b.Manifest.Dashboards {
+		if err := add(dash.Path, b.Dashboards[dash.UID]); err != nil {
+			return nil, err
+		}
+	}
+	for i, panel := range b.LibraryPanels {
+		if err := add(path.Join(libraryPanelsDir, fileName(panel.UID, i)), panel); err != nil {
+			return nil, err
+		}
+	}
+	for i, ds := range b.DataSources {
+		if err := add(path.Join(dataSourcesDir, fileName(ds.UID, i)), ds); err != nil {
+			return nil, err
+		}
+	}
+	for i, notification := range b.AlertNotifications {
+		if err := add(path.Join(alertNotificationsDir, fileName(notification.UID, i)), notification); err != nil {
+			return nil, err
+		}
+	}
+
+	provisioning, err := b.provisioningFiles(provisioningPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, provisioning...), nil
+}
+
+func writeZip(w io.Writer, files []archiveFile, modified time.Time) error {
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(file.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeTar(w io.Writer, files []archiveFile, modified time.Time) error {
+	compressed := gzip.NewWriter(w)
+	archive := tar.NewWriter(compressed)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(file.content)),
+			ModTime: modified,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write(file.content); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// ReadBundle reads a bundle archive, zip or tar, as written by Write.
+func ReadBundle(data []byte) (*Bundle, error) {
+	var files map[string][]byte
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		files, err = readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		files, err = readTar(data)
+	default:
+		return nil, fmt.Errorf("%w: the archive is neither a zip nor a gzipped tar", ErrInvalidBundle)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{Dashboards: map[string]*simplejson.Json{}}
+	manifest, ok := files[manifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrInvalidBundle, manifestFile)
+	}
+	if err := json.Unmarshal(manifest, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidBundle, manifestFile, err)
+	}
+	if bundle.Manifest.Version > bundleVersion {
+		return nil, fmt.Errorf("%w: unsupported bundle version %d", ErrInvalidBundle, bundle.Manifest.Version)
+	}
+
+	for _, dash := range bundle.Manifest.Dashboards {
+		content, ok := files[dash.Path]
+		if !ok {
+			return nil, fmt.Errorf("%w: dashboard file %s is missing", ErrInvalidBundle, dash.Path)
+		}
+		dashboard, err := simplejson.NewJson(content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidBundle, dash.Path, err)
+		}
+		bundle.Dashboards[dash.UID] = dashboard
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		content := files[name]
+		var err error
+		switch path.Dir(name) {
+		case libraryPanelsDir:
+			panel := &LibraryPanel{}
+			err = json.Unmarshal(content, panel)
+			bundle.LibraryPanels = append(bundle.LibraryPanels, panel)
+		case dataSourcesDir:
+			ds := &DataSource{}
+			err = json.Unmarshal(content, ds)
+			bundle.DataSources = append(bundle.DataSources, ds)
+		case alertNotificationsDir:
+			notification := &AlertNotification{}
+			err = json.Unmarshal(content, notification)
+			bundle.AlertNotifications = append(bundle.AlertNotifications, notification)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidBundle, name, err)
+		}
+	}
+
+	return bundle, nil
+}
+
+func readZip(data []byte) (map[string][]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+	}
+
+	files := map[string][]byte{}
+	size := int64(0)
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+		}
+		content, err := readLimited(reader, &size)
+		if closeErr := reader.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(file.Name)] = content
+	}
+	return files, nil
+}
+
+func readTar(data []byte) (map[string][]byte, error) {
+	compressed, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+	}
+	archive := tar.NewReader(compressed)
+
+	files := map[string][]byte{}
+	size := int64(0)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := readLimited(archive, &size)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(header.Name)] = content
+	}
+	return files, nil
+}
+
+// readLimited reads a file of an archive, failing when the files read so far exceed the maximum bundle size.
+func readLimited(reader io.Reader, size *int64) ([]byte, error) {
+	content, err := ioutil.ReadAll(io.LimitReader(reader, maxBundleSize-*size+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+	}
+	*size += int64(len(content))
+	if *size > maxBundleSize {
+		return nil, ErrBundleTooLarge
+	}
+	return content, nil
+}
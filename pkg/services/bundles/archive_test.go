@@ -0,0 +1,165 @@
+package bundles
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Manifest: Manifest{
+			Version:        bundleVersion,
+			GrafanaVersion: "7.5.0",
+			Exported:       time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+			Folders:        []*Folder{{UID: "ops", Title: "Ops"}},
+			Dashboards: []*DashboardManifest{
+				{UID: "home", Title: "Home", Path: dashboardPath("home", "", 0)},
+				{UID: "nodes", Title: "Nodes", FolderUID: "ops", Path: dashboardPath("nodes", "ops", 1)},
+			},
+		},
+		Dashboards: map[string]*simplejson.Json{
+			"home":  simplejson.NewFromAny(map[string]interface{}{"uid": "home", "title": "Home"}),
+			"nodes": simplejson.NewFromAny(map[string]interface{}{"uid": "nodes", "title": "Nodes"}),
+		},
+		LibraryPanels: []*LibraryPanel{
+			{UID: "cpu", Name: "CPU", FolderUID: "ops", Model: json.RawMessage(`{"type":"graph"}`)},
+		},
+		DataSources: []*DataSource{
+			{
+				UID:              "prom",
+				Name:             "Prometheus",
+				Type:             "prometheus",
+				Access:           "proxy",
+				URL:              "http://prometheus:9090",
+				JSONData:         simplejson.NewFromAny(map[string]interface{}{"timeInterval": "15s"}),
+				SecureJSONFields: map[string]bool{"basicAuthPassword": true},
+			},
+		},
+		AlertNotifications: []*AlertNotification{
+			{
+				UID:          "slack",
+				Name:         "Ops Slack",
+				Type:         "slack",
+				Settings:     simplejson.NewFromAny(map[string]interface{}{"recipient": "#ops"}),
+				SecureFields: map[string]bool{"url": true},
+			},
+		},
+	}
+}
+
+func TestBundleArchive(t *testing.T) {
+	for _, format := range []Format{FormatZip, FormatTar} {
+		t.Run("Should read a bundle written as "+string(format), func(t *testing.T) {
+			bundle := testBundle()
+			var archive bytes.Buffer
+			require.NoError(t, bundle.Write(&archive, format, "/var/lib/grafana/bundles"))
+
+			read, err := ReadBundle(archive.Bytes())
+			require.NoError(t, err)
+
+			assert.Equal(t, bundle.Manifest.Folders, read.Manifest.Folders)
+			assert.Equal(t, bundle.Manifest.Dashboards, read.Manifest.Dashboards)
+			assert.True(t, bundle.Manifest.Exported.Equal(read.Manifest.Exported))
+			require.Len(t, read.Dashboards, 2)
+			assert.Equal(t, "Nodes", read.Dashboards["nodes"].Get("title").MustString())
+			require.Len(t, read.LibraryPanels, 1)
+			assert.Equal(t, "cpu", read.LibraryPanels[0].UID)
+			assert.JSONEq(t, `{"type":"graph"}`, string(read.LibraryPanels[0].Model))
+			require.Len(t, read.DataSources, 1)
+			assert.Equal(t, "http://prometheus:9090", read.DataSources[0].URL)
+			assert.Equal(t, "15s", read.DataSources[0].JSONData.Get("timeInterval").MustString())
+			require.Len(t, read.AlertNotifications, 1)
+			assert.Equal(t, map[string]bool{"url": true}, read.AlertNotifications[0].SecureFields)
+		})
+	}
+
+	t.Run("Should store dashboards in the directory of their folder", func(t *testing.T) {
+		assert.Equal(t, "dashboards/general/home.json", dashboardPath("home", "", 0))
+		assert.Equal(t, "dashboards/ops/nodes.json", dashboardPath("nodes", "ops", 1))
+		assert.Equal(t, "dashboards/general/item-2.json", dashboardPath("../etc", "", 2))
+	})
+
+	t.Run("Should reject an archive which is neither a zip nor a tar", func(t *testing.T) {
+		_, err := ReadBundle([]byte(`{"dashboard": {}}`))
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+	})
+
+	t.Run("Should reject an archive without manifest", func(t *testing.T) {
+		var archive bytes.Buffer
+		writer := zip.NewWriter(&archive)
+		file, err := writer.Create("dashboards/general/home.json")
+		require.NoError(t, err)
+		_, err = file.Write([]byte(`{}`))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		_, err = ReadBundle(archive.Bytes())
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+	})
+
+	t.Run("Should reject a bundle with a missing dashboard file", func(t *testing.T) {
+		bundle := testBundle()
+		var archive bytes.Buffer
+		require.NoError(t, bundle.Write(&archive, FormatZip, ""))
+
+		read, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+		require.NoError(t, err)
+		var filtered bytes.Buffer
+		writer := zip.NewWriter(&filtered)
+		for _, file := range read.File {
+			if file.Name == "dashboards/ops/nodes.json" {
+				continue
+			}
+			reader, err := file.Open()
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(reader)
+			require.NoError(t, err)
+			out, err := writer.Create(file.Name)
+			require.NoError(t, err)
+			_, err = out.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		_, err = ReadBundle(filtered.Bytes())
+		assert.True(t, errors.Is(err, ErrInvalidBundle))
+	})
+}
+
+func TestBundleProvisioningFiles(t *testing.T) {
+	files, err := testBundle().provisioningFiles("/var/lib/grafana/bundles")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	content := map[string]string{}
+	for _, file := range files {
+		content[file.name] = string(file.content)
+	}
+
+	dashboards := content[provisioningDashboardsFile]
+	assert.Contains(t, dashboards, "name: bundle-general")
+	assert.Contains(t, dashboards, "path: /var/lib/grafana/bundles/dashboards/general")
+	assert.Contains(t, dashboards, "name: bundle-ops")
+	assert.Contains(t, dashboards, "folder: Ops")
+	assert.Contains(t, dashboards, "folderUid: ops")
+
+	dataSources := content[provisioningDataSourceFile]
+	assert.Contains(t, dataSources, "uid: prom")
+	assert.Contains(t, dataSources, "timeInterval: 15s")
+	assert.Contains(t, dataSources, "basicAuthPassword: $DATASOURCE_PROMETHEUS_BASICAUTHPASSWORD")
+
+	notifiers := content[provisioningNotifiersFile]
+	assert.Contains(t, notifiers, "uid: slack")
+	assert.Contains(t, notifiers, "recipient: '#ops'")
+	assert.Contains(t, notifiers, "url: $NOTIFIER_OPS_SLACK_URL")
+}
@@ -0,0 +1,35 @@
+package bundles
+
+import (
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+)
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+// libraryPanelService is the part of the library panel service used by the bundles.
+type libraryPanelService interface {
+	IsEnabled() bool
+	GetLibraryPanel(c *models.ReqContext, uid string) (librarypanels.LibraryPanelDTO, error)
+	ImportLibraryPanel(c *models.ReqContext, cmd librarypanels.ImportLibraryPanelCommand) error
+	ConnectLibraryPanelsForDashboard(c *models.ReqContext, dash *models.Dashboard) error
+}
+
+// Service exports dashboards with everything they depend on as portable bundles, and imports these bundles.
+type Service struct {
+	LibraryPanelService *librarypanels.LibraryPanelService `inject:""`
+
+	libraryPanels libraryPanelService
+	log           log.Logger
+}
+
+// Init initializes the bundle service.
+func (s *Service) Init() error {
+	s.log = log.New("bundles")
+	s.libraryPanels = s.LibraryPanelService
+	return nil
+}
@@ -0,0 +1,446 @@
+package bundles
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+)
+
+func TestExport(t *testing.T) {
+	scenario(t, "Should export a dashboard with its dependencies", func(t *testing.T, sc *scenarioContext) {
+		sc.folders.add(&models.Folder{Id: 2, Uid: "ops", Title: "Ops"})
+		sc.dashboards["nodes"] = &models.Dashboard{Id: 10, Uid: "nodes", Title: "Nodes", FolderId: 2, Data: simplejson.NewFromAny(map[string]interface{}{
+			"id":      10,
+			"uid":     "nodes",
+			"title":   "Nodes",
+			"version": 4,
+			"panels": []interface{}{
+				map[string]interface{}{"id": 1, "datasource": "Prometheus", "alert": map[string]interface{}{
+					"notifications": []interface{}{map[string]interface{}{"id": 3}},
+				}},
+				map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "cpu", "name": "CPU"}},
+				map[string]interface{}{"id": 3, "datasource": "-- Grafana --"},
+			},
+		})}
+		sc.dataSources = append(sc.dataSources,
+			&models.DataSource{Id: 1, Uid: "prom", Name: "Prometheus", Type: "prometheus", Password: "secret"},
+			&models.DataSource{Id: 2, Uid: "loki", Name: "Loki", Type: "loki", IsDefault: true,
+				SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{"token": "secret"})},
+		)
+		sc.notifications = append(sc.notifications, &models.AlertNotification{Id: 3, Uid: "slack", Name: "Ops Slack", Type: "slack",
+			Settings: simplejson.New()})
+		sc.libraryPanels.panels["cpu"] = librarypanels.LibraryPanelDTO{UID: "cpu", Name: "CPU", FolderID: 2,
+			Model: json.RawMessage(`{"type":"graph","datasource":null}`)}
+
+		bundle, err := sc.service.Export(sc.reqContext, []string{"nodes"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []*Folder{{UID: "ops", Title: "Ops"}}, bundle.Manifest.Folders)
+		require.Len(t, bundle.Manifest.Dashboards, 1)
+		assert.Equal(t, "ops", bundle.Manifest.Dashboards[0].FolderUID)
+
+		dash := bundle.Dashboards["nodes"]
+		_, hasID := dash.CheckGet("id")
+		_, hasVersion := dash.CheckGet("version")
+		assert.False(t, hasID)
+		assert.False(t, hasVersion)
+		notification := dash.Get("panels").GetIndex(0).GetPath("alert", "notifications").GetIndex(0)
+		assert.Equal(t, "slack", notification.Get("uid").MustString())
+
+		require.Len(t, bundle.LibraryPanels, 1)
+		assert.Equal(t, "ops", bundle.LibraryPanels[0].FolderUID)
+
+		require.Len(t, bundle.DataSources, 2)
+		assert.Equal(t, "Loki", bundle.DataSources[0].Name)
+		assert.Equal(t, map[string]bool{"token": true}, bundle.DataSources[0].SecureJSONFields)
+		assert.Equal(t, "Prometheus", bundle.DataSources[1].Name)
+		assert.Equal(t, map[string]bool{"password": true}, bundle.DataSources[1].SecureJSONFields)
+
+		require.Len(t, bundle.AlertNotifications, 1)
+		assert.Equal(t, "slack", bundle.AlertNotifications[0].UID)
+	})
+
+	scenario(t, "Should not export a dashboard the user cannot view", func(t *testing.T, sc *scenarioContext) {
+		sc.dashboards["nodes"] = &models.Dashboard{Id: 10, Uid: "nodes", Title: "Nodes", Data: simplejson.New()}
+		sc.guardian.CanViewValue = false
+
+		_, err := sc.service.Export(sc.reqContext, []string{"nodes"})
+		assert.True(t, errors.Is(err, ErrDashboardAccessDenied))
+	})
+
+	scenario(t, "Should not export a folder", func(t *testing.T, sc *scenarioContext) {
+		sc.dashboards["ops"] = &models.Dashboard{Id: 2, Uid: "ops", Title: "Ops", IsFolder: true, Data: simplejson.New()}
+
+		_, err := sc.service.Export(sc.reqContext, []string{"ops"})
+		assert.True(t, errors.Is(err, models.ErrDashboardNotFound))
+	})
+}
+
+func TestImport(t *testing.T) {
+	scenario(t, "Should create the content of a bundle", func(t *testing.T, sc *scenarioContext) {
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictFail})
+		require.NoError(t, err)
+
+		for _, item := range result.Items {
+			assert.Equal(t, ActionCreate, item.Action, item.UID)
+		}
+		assert.Equal(t, []string{"basicAuthPassword"}, findItem(result, kindDataSource, "prom").SecretsRequired)
+		assert.Equal(t, []string{"url"}, findItem(result, kindAlertNotification, "slack").SecretsRequired)
+
+		folder := sc.folders.byUID["ops"]
+		require.NotNil(t, folder)
+		require.Len(t, sc.addedDataSources, 1)
+		assert.Equal(t, "prom", sc.addedDataSources[0].Uid)
+		require.Len(t, sc.createdNotifications, 1)
+		assert.Equal(t, "slack", sc.createdNotifications[0].Uid)
+		require.Len(t, sc.libraryPanels.imported, 1)
+		assert.Equal(t, folder.Id, sc.libraryPanels.imported[0].FolderID)
+
+		require.Len(t, sc.dashboardService.SavedDashboards, 2)
+		saved := map[string]*dashboards.SaveDashboardDTO{}
+		for _, dto := range sc.dashboardService.SavedDashboards {
+			saved[dto.Dashboard.Uid] = dto
+		}
+		assert.Equal(t, int64(0), saved["home"].Dashboard.FolderId)
+		assert.Equal(t, folder.Id, saved["nodes"].Dashboard.FolderId)
+		assert.False(t, saved["nodes"].Overwrite)
+		assert.Equal(t, 2, sc.libraryPanels.connected)
+	})
+
+	scenario(t, "Should fail without changes when content exists", func(t *testing.T, sc *scenarioContext) {
+		sc.dashboards["nodes"] = &models.Dashboard{Id: 10, Uid: "nodes", Title: "Nodes", Data: simplejson.New()}
+
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictFail})
+		require.True(t, errors.Is(err, ErrBundleConflicts))
+
+		conflicts := result.Conflicts()
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "nodes", conflicts[0].UID)
+		assert.Empty(t, sc.folders.created)
+		assert.Empty(t, sc.addedDataSources)
+		assert.Empty(t, sc.dashboardService.SavedDashboards)
+	})
+
+	scenario(t, "Should skip existing content", func(t *testing.T, sc *scenarioContext) {
+		sc.folders.add(&models.Folder{Id: 2, Uid: "ops", Title: "Operations"})
+		sc.dashboards["nodes"] = &models.Dashboard{Id: 10, Uid: "nodes", Title: "Nodes", Data: simplejson.New()}
+		sc.dataSources = append(sc.dataSources, &models.DataSource{Id: 1, Uid: "prom", Name: "Prometheus"})
+
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictSkip})
+		require.NoError(t, err)
+
+		assert.Equal(t, ActionSkip, findItem(result, kindFolder, "ops").Action)
+		assert.Equal(t, ActionSkip, findItem(result, kindDataSource, "prom").Action)
+		assert.Equal(t, ActionSkip, findItem(result, kindDashboard, "nodes").Action)
+		assert.Empty(t, sc.folders.updated)
+		assert.Empty(t, sc.addedDataSources)
+		require.Len(t, sc.dashboardService.SavedDashboards, 1)
+		assert.Equal(t, "home", sc.dashboardService.SavedDashboards[0].Dashboard.Uid)
+		require.Len(t, sc.libraryPanels.imported, 1)
+		assert.Equal(t, int64(2), sc.libraryPanels.imported[0].FolderID)
+	})
+
+	scenario(t, "Should overwrite existing content and keep its secrets", func(t *testing.T, sc *scenarioContext) {
+		sc.folders.add(&models.Folder{Id: 2, Uid: "ops", Title: "Operations"})
+		sc.dashboards["nodes"] = &models.Dashboard{Id: 10, Uid: "nodes", Title: "Nodes", Data: simplejson.New()}
+		sc.dataSources = append(sc.dataSources, &models.DataSource{Id: 1, Uid: "prom", Name: "Prometheus", Version: 3,
+			SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{"basicAuthPassword": "secret"})})
+		sc.notifications = append(sc.notifications, &models.AlertNotification{Id: 3, Uid: "slack", Name: "Slack",
+			SecureSettings: securejsondata.GetEncryptedJsonData(map[string]string{"url": "https://hooks.slack.com"})})
+
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictOverwrite})
+		require.NoError(t, err)
+
+		assert.Equal(t, ActionUpdate, findItem(result, kindFolder, "ops").Action)
+		assert.Equal(t, []string{"ops"}, sc.folders.updated)
+		require.Len(t, sc.updatedDataSources, 1)
+		assert.Equal(t, int64(1), sc.updatedDataSources[0].Id)
+		assert.Equal(t, 3, sc.updatedDataSources[0].Version)
+		assert.Equal(t, "http://prometheus:9090", sc.updatedDataSources[0].Url)
+		assert.Equal(t, map[string]string{"basicAuthPassword": "secret"}, sc.updatedDataSources[0].SecureJsonData)
+		assert.Empty(t, findItem(result, kindDataSource, "prom").SecretsRequired)
+		require.Len(t, sc.updatedNotifications, 1)
+		assert.Equal(t, "Ops Slack", sc.updatedNotifications[0].Name)
+		assert.Equal(t, map[string]string{"url": "https://hooks.slack.com"}, sc.updatedNotifications[0].SecureSettings)
+
+		saved := map[string]*dashboards.SaveDashboardDTO{}
+		for _, dto := range sc.dashboardService.SavedDashboards {
+			saved[dto.Dashboard.Uid] = dto
+		}
+		assert.True(t, saved["nodes"].Overwrite)
+		assert.Equal(t, int64(2), saved["nodes"].Dashboard.FolderId)
+	})
+
+	scenario(t, "Should not overwrite provisioned data sources", func(t *testing.T, sc *scenarioContext) {
+		sc.dataSources = append(sc.dataSources, &models.DataSource{Id: 1, Uid: "prom", Name: "Prometheus", ReadOnly: true})
+
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictFail})
+		require.NoError(t, err)
+
+		assert.Equal(t, ActionSkip, findItem(result, kindDataSource, "prom").Action)
+		assert.Empty(t, sc.updatedDataSources)
+	})
+
+	scenario(t, "Should return the partial result of a failed import", func(t *testing.T, sc *scenarioContext) {
+		sc.libraryPanels.importErr = errors.New("import failed")
+
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictFail})
+		require.Equal(t, sc.libraryPanels.importErr, err)
+		require.NotNil(t, result)
+
+		for _, item := range result.Items {
+			switch item.Kind {
+			case kindLibraryPanel:
+				assert.Equal(t, ActionCreate, item.Action)
+				assert.Equal(t, "import failed", item.Error)
+			case kindDashboard:
+				assert.Equal(t, ActionNotImported, item.Action, item.UID)
+				assert.Empty(t, item.Error)
+			default:
+				assert.Equal(t, ActionCreate, item.Action, item.UID)
+				assert.Empty(t, item.Error)
+			}
+		}
+		assert.NotNil(t, sc.folders.byUID["ops"])
+		assert.Len(t, sc.addedDataSources, 1)
+		assert.Empty(t, sc.dashboardService.SavedDashboards)
+	})
+
+	scenario(t, "Should only plan a dry run", func(t *testing.T, sc *scenarioContext) {
+		sc.dashboards["nodes"] = &models.Dashboard{Id: 10, Uid: "nodes", Title: "Nodes", Data: simplejson.New()}
+
+		result, err := sc.service.Import(sc.reqContext, testBundle(), ImportOptions{Conflicts: ConflictOverwrite, DryRun: true})
+		require.NoError(t, err)
+
+		assert.True(t, result.DryRun)
+		assert.Equal(t, ActionUpdate, findItem(result, kindDashboard, "nodes").Action)
+		assert.Equal(t, ActionCreate, findItem(result, kindDashboard, "home").Action)
+		assert.Empty(t, sc.folders.created)
+		assert.Empty(t, sc.addedDataSources)
+		assert.Empty(t, sc.libraryPanels.imported)
+		assert.Empty(t, sc.dashboardService.SavedDashboards)
+	})
+}
+
+func findItem(result *ImportResult, kind string, uid string) *ImportItem {
+	for _, item := range result.Items {
+		if item.Kind == kind && item.UID == uid {
+			return item
+		}
+	}
+	return nil
+}
+
+type scenarioContext struct {
+	service          *Service
+	reqContext       *models.ReqContext
+	guardian         *guardian.FakeDashboardGuardian
+	dashboardService *dashboards.FakeDashboardService
+	folders          *fakeFolderService
+	libraryPanels    *fakeLibraryPanelService
+
+	dashboards           map[string]*models.Dashboard
+	dataSources          []*models.DataSource
+	notifications        []*models.AlertNotification
+	addedDataSources     []*models.AddDataSourceCommand
+	updatedDataSources   []*models.UpdateDataSourceCommand
+	createdNotifications []*models.CreateAlertNotificationCommand
+	updatedNotifications []*models.UpdateAlertNotificationWithUidCommand
+}
+
+func scenario(t *testing.T, desc string, fn func(t *testing.T, sc *scenarioContext)) {
+	t.Helper()
+
+	t.Run(desc, func(t *testing.T) {
+		origNewGuardian := guardian.New
+		origNewService := dashboards.NewService
+		origNewFolderService := dashboards.NewFolderService
+		t.Cleanup(func() {
+			guardian.New = origNewGuardian
+			dashboards.NewService = origNewService
+			dashboards.NewFolderService = origNewFolderService
+			bus.ClearBusHandlers()
+		})
+
+		sc := &scenarioContext{
+			reqContext: &models.ReqContext{
+				SignedInUser: &models.SignedInUser{UserId: 1, OrgId: 1, OrgRole: models.ROLE_ADMIN},
+			},
+			guardian:         &guardian.FakeDashboardGuardian{CanViewValue: true},
+			dashboardService: &dashboards.FakeDashboardService{},
+			folders:          &fakeFolderService{byUID: map[string]*models.Folder{}},
+			libraryPanels:    &fakeLibraryPanelService{panels: map[string]librarypanels.LibraryPanelDTO{}},
+			dashboards:       map[string]*models.Dashboard{},
+		}
+		sc.service = &Service{libraryPanels: sc.libraryPanels, log: log.New("bundles.test")}
+		guardian.MockDashboardGuardian(sc.guardian)
+		dashboards.MockDashboardService(sc.dashboardService)
+		dashboards.NewFolderService = func(orgId int64, user *models.SignedInUser) dashboards.FolderService {
+			return sc.folders
+		}
+		sc.addBusHandlers()
+
+		fn(t, sc)
+	})
+}
+
+func (sc *scenarioContext) addBusHandlers() {
+	bus.ClearBusHandlers()
+
+	bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+		dash, ok := sc.dashboards[query.Uid]
+		if !ok {
+			return models.ErrDashboardNotFound
+		}
+		query.Result = dash
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetDataSourceQuery) error {
+		for _, ds := range sc.dataSources {
+			if (query.Uid != "" && ds.Uid == query.Uid) || (query.Name != "" && ds.Name == query.Name) {
+				query.Result = ds
+				return nil
+			}
+		}
+		return models.ErrDataSourceNotFound
+	})
+
+	bus.AddHandler("test", func(query *models.GetDefaultDataSourceQuery) error {
+		for _, ds := range sc.dataSources {
+			if ds.IsDefault {
+				query.Result = ds
+				return nil
+			}
+		}
+		return models.ErrDataSourceNotFound
+	})
+
+	bus.AddHandler("test", func(cmd *models.AddDataSourceCommand) error {
+		sc.addedDataSources = append(sc.addedDataSources, cmd)
+		return nil
+	})
+
+	bus.AddHandler("test", func(cmd *models.UpdateDataSourceCommand) error {
+		sc.updatedDataSources = append(sc.updatedDataSources, cmd)
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetAlertNotificationsQuery) error {
+		for _, notification := range sc.notifications {
+			if notification.Id == query.Id {
+				query.Result = notification
+			}
+		}
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetAlertNotificationsWithUidQuery) error {
+		for _, notification := range sc.notifications {
+			if notification.Uid == query.Uid {
+				query.Result = notification
+			}
+		}
+		return nil
+	})
+
+	bus.AddHandler("test", func(cmd *models.CreateAlertNotificationCommand) error {
+		sc.createdNotifications = append(sc.createdNotifications, cmd)
+		return nil
+	})
+
+	bus.AddHandler("test", func(cmd *models.UpdateAlertNotificationWithUidCommand) error {
+		sc.updatedNotifications = append(sc.updatedNotifications, cmd)
+		return nil
+	})
+}
+
+type fakeFolderService struct {
+	dashboards.FolderService
+
+	byUID   map[string]*models.Folder
+	created []string
+	updated []string
+}
+
+func (s *fakeFolderService) add(folder *models.Folder) {
+	s.byUID[folder.Uid] = folder
+}
+
+func (s *fakeFolderService) GetFolderByID(id int64) (*models.Folder, error) {
+	for _, folder := range s.byUID {
+		if folder.Id == id {
+			return folder, nil
+		}
+	}
+	return nil, models.ErrFolderNotFound
+}
+
+func (s *fakeFolderService) GetFolderByUID(uid string) (*models.Folder, error) {
+	if folder, ok := s.byUID[uid]; ok {
+		return folder, nil
+	}
+	return nil, models.ErrFolderNotFound
+}
+
+func (s *fakeFolderService) CreateFolder(cmd *models.CreateFolderCommand) error {
+	cmd.Result = &models.Folder{Id: int64(100 + len(s.created)), Uid: cmd.Uid, Title: cmd.Title}
+	s.add(cmd.Result)
+	s.created = append(s.created, cmd.Uid)
+	return nil
+}
+
+func (s *fakeFolderService) UpdateFolder(uid string, cmd *models.UpdateFolderCommand) error {
+	s.byUID[uid].Title = cmd.Title
+	s.updated = append(s.updated, uid)
+	return nil
+}
+
+func (s *fakeFolderService) MoveFolder(uid string, cmd *models.MoveFolderCommand) error {
+	s.byUID[uid].ParentUid = cmd.ParentUid
+	return nil
+}
+
+type fakeLibraryPanelService struct {
+	panels    map[string]librarypanels.LibraryPanelDTO
+	imported  []librarypanels.ImportLibraryPanelCommand
+	importErr error
+	connected int
+}
+
+func (s *fakeLibraryPanelService) IsEnabled() bool {
+	return true
+}
+
+func (s *fakeLibraryPanelService) GetLibraryPanel(c *models.ReqContext, uid string) (librarypanels.LibraryPanelDTO, error) {
+	panel, ok := s.panels[uid]
+	if !ok {
+		return librarypanels.LibraryPanelDTO{}, librarypanels.ErrLibraryPanelNotFound
+	}
+	return panel, nil
+}
+
+func (s *fakeLibraryPanelService) ImportLibraryPanel(c *models.ReqContext, cmd librarypanels.ImportLibraryPanelCommand) error {
+	if s.importErr != nil {
+		return s.importErr
+	}
+	s.imported = append(s.imported, cmd)
+	return nil
+}
+
+func (s *fakeLibraryPanelService) ConnectLibraryPanelsForDashboard(c *models.ReqContext, dash *models.Dashboard) error {
+	s.connected++
+	return nil
+}
@@ -0,0 +1,346 @@
+package bundles
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// builtInDataSources are the data source names of dashboards which do not refer to a data source of the org.
+var builtInDataSources = map[string]bool{
+	"":                true,
+	"-- Grafana --":   true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+}
+
+// exporter builds the bundle of an export.
+type exporter struct {
+	service       *Service
+	c             *models.ReqContext
+	folderService dashboards.FolderService
+	bundle        *Bundle
+	folders       map[int64]*models.Folder
+	libraryPanels map[string]bool
+	dataSources   map[int64]bool
+	notifications map[int64]bool
+}
+
+// Export exports the dashboards with the given UIDs, with their folders, library panels, data sources and alert
+// notification channels. The secrets of the data sources and notification channels are not exported.
+func (s *Service) Export(c *models.ReqContext, dashboardUIDs []string) (*Bundle, error) {
+	e := &exporter{
+		service:       s,
+		c:             c,
+		folderService: dashboards.NewFolderService(c.OrgId, c.SignedInUser),
+		bundle: &Bundle{
+			Manifest: Manifest{
+				Version:        bundleVersion,
+				GrafanaVersion: setting.BuildVersion,
+				Exported:       time.Now().UTC(),
+				Folders:        []*Folder{},
+				Dashboards:     []*DashboardManifest{},
+			},
+			Dashboards:         map[string]*simplejson.Json{},
+			LibraryPanels:      []*LibraryPanel{},
+			DataSources:        []*DataSource{},
+			AlertNotifications: []*AlertNotification{},
+		},
+		folders:       map[int64]*models.Folder{},
+		libraryPanels: map[string]bool{},
+		dataSources:   map[int64]bool{},
+		notifications: map[int64]bool{},
+	}
+
+	for _, uid := range dashboardUIDs {
+		if _, ok := e.bundle.Dashboards[uid]; ok {
+			continue
+		}
+		if err := e.addDashboard(uid); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.bundle, nil
+}
+
+func (e *exporter) addDashboard(uid string) error {
+	query := models.GetDashboardQuery{Uid: uid, OrgId: e.c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+	dash := query.Result
+	if dash.IsFolder {
+		return models.ErrDashboardNotFound
+	}
+	if canView, err := guardian.New(dash.Id, e.c.OrgId, e.c.SignedInUser).CanView(); err != nil || !canView {
+		if err == nil {
+			err = ErrDashboardAccessDenied
+		}
+		return err
+	}
+
+	folderUID, err := e.addFolder(dash.FolderId)
+	if err != nil {
+		return err
+	}
+
+	// the dashboard is exported as stored, library panels included, without its instance specific properties
+	data := simplejson.NewFromAny(dash.Data.MustMap())
+	data.Del("id")
+	data.Del("version")
+
+	var panelErr error
+	forEachPanel(data, func(panel *simplejson.Json) {
+		if panelErr == nil {
+			panelErr = e.addPanelDependencies(panel)
+		}
+	})
+	if panelErr != nil {
+		return panelErr
+	}
+	if err := e.addDataSources(data.Interface()); err != nil {
+		return err
+	}
+
+	e.bundle.Dashboards[dash.Uid] = data
+	e.bundle.Manifest.Dashboards = append(e.bundle.Manifest.Dashboards, &DashboardManifest{
+		UID:       dash.Uid,
+		Title:     dash.Title,
+		FolderUID: folderUID,
+		Path:      dashboardPath(dash.Uid, folderUID, len(e.bundle.Manifest.Dashboards)),
+	})
+	return nil
+}
+
+// addFolder adds a folder and its parent folders to the bundle, and returns the UID of the folder.
+func (e *exporter) addFolder(folderID int64) (string, error) {
+	if folderID == 0 {
+		return "", nil
+	}
+	if folder, ok := e.folders[folderID]; ok {
+		return folder.Uid, nil
+	}
+
+	folder, err := e.folderService.GetFolderByID(folderID)
+	if err != nil {
+		return "", err
+	}
+	// the parent folders are listed first, so that they are imported first
+	parentUID, err := e.addFolder(folder.ParentId)
+	if err != nil {
+		return "", err
+	}
+
+	e.folders[folderID] = folder
+	e.bundle.Manifest.Folders = append(e.bundle.Manifest.Folders, &Folder{
+		UID:       folder.Uid,
+		Title:     folder.Title,
+		ParentUID: parentUID,
+	})
+	return folder.Uid, nil
+}
+
+// addPanelDependencies adds the library panel and the alert notification channels of a panel to the bundle.
+func (e *exporter) addPanelDependencies(panel *simplejson.Json) error {
+	if uid := panel.GetPath("libraryPanel", "uid").MustString(); uid != "" && !e.libraryPanels[uid] && e.service.libraryPanels.IsEnabled() {
+		e.libraryPanels[uid] = true
+		libraryPanel, err := e.service.libraryPanels.GetLibraryPanel(e.c, uid)
+		if errors.Is(err, librarypanels.ErrLibraryPanelNotFound) {
+			e.service.log.Warn("Library panel of exported dashboard not found", "uid", uid)
+		} else if err != nil {
+			return err
+		} else {
+			folderUID, err := e.addFolder(libraryPanel.FolderID)
+			if err != nil {
+				return err
+			}
+			model, err := simplejson.NewJson(libraryPanel.Model)
+			if err != nil {
+				return err
+			}
+			if err := e.addDataSources(model.Interface()); err != nil {
+				return err
+			}
+			e.bundle.LibraryPanels = append(e.bundle.LibraryPanels, &LibraryPanel{
+				UID:       libraryPanel.UID,
+				Name:      libraryPanel.Name,
+				FolderUID: folderUID,
+				Model:     libraryPanel.Model,
+			})
+		}
+	}
+
+	for _, item := range panel.GetPath("alert", "notifications").MustArray() {
+		notification := simplejson.NewFromAny(item)
+		query := models.GetAlertNotificationsWithUidQuery{Uid: notification.Get("uid").MustString(), OrgId: e.c.OrgId}
+		if query.Uid == "" {
+			// notification channels referenced by id are referenced by uid in the bundle
+			byID := models.GetAlertNotificationsQuery{Id: notification.Get("id").MustInt64(), OrgId: e.c.OrgId}
+			if byID.Id == 0 {
+				continue
+			}
+			if err := bus.Dispatch(&byID); err != nil {
+				return err
+			}
+			if byID.Result == nil {
+				continue
+			}
+			notification.Del("id")
+			notification.Set("uid", byID.Result.Uid)
+			query.Uid = byID.Result.Uid
+		}
+		if err := bus.Dispatch(&query); err != nil {
+			return err
+		}
+		if query.Result != nil {
+			e.addAlertNotification(query.Result)
+		}
+	}
+	return nil
+}
+
+// addDataSources adds the data sources referenced in JSON, by name or by uid, to the bundle.
+func (e *exporter) addDataSources(value interface{}) error {
+	var refs []models.GetDataSourceQuery
+	usesDefault := false
+	collectDataSources(value, &refs, &usesDefault)
+
+	for _, query := range refs {
+		query.OrgId = e.c.OrgId
+		if err := bus.Dispatch(&query); err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				e.service.log.Warn("Data source of exported dashboard not found", "name", query.Name, "uid", query.Uid)
+				continue
+			}
+			return err
+		}
+		e.addDataSource(query.Result)
+	}
+
+	if usesDefault {
+		query := models.GetDefaultDataSourceQuery{OrgId: e.c.OrgId, User: e.c.SignedInUser}
+		if err := bus.Dispatch(&query); err != nil {
+			if errors.Is(err, models.ErrDataSourceNotFound) {
+				return nil
+			}
+			return err
+		}
+		e.addDataSource(query.Result)
+	}
+	return nil
+}
+
+// collectDataSources collects the data source references of JSON. A null datasource refers to the default data
+// source of the org.
+func collectDataSources(value interface{}, refs *[]models.GetDataSourceQuery, usesDefault *bool) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			if key != "datasource" {
+				collectDataSources(item, refs, usesDefault)
+				continue
+			}
+			switch ref := item.(type) {
+			case nil:
+				*usesDefault = true
+			case string:
+				if !builtInDataSources[ref] && ref[0] != '$' {
+					*refs = append(*refs, models.GetDataSourceQuery{Name: ref})
+				}
+			case map[string]interface{}:
+				if uid, ok := ref["uid"].(string); ok && uid != "" && uid[0] != '$' {
+					*refs = append(*refs, models.GetDataSourceQuery{Uid: uid})
+				}
+			}
+		}
+	case []interface{}:
+		for _, item := range typed {
+			collectDataSources(item, refs, usesDefault)
+		}
+	}
+}
+
+func (e *exporter) addDataSource(ds *models.DataSource) {
+	if e.dataSources[ds.Id] {
+		return
+	}
+	e.dataSources[ds.Id] = true
+
+	secureFields := map[string]bool{}
+	for key := range ds.SecureJsonData {
+		secureFields[key] = true
+	}
+	if ds.Password != "" {
+		secureFields["password"] = true
+	}
+	if ds.BasicAuthPassword != "" {
+		secureFields["basicAuthPassword"] = true
+	}
+
+	e.bundle.DataSources = append(e.bundle.DataSources, &DataSource{
+		UID:              ds.Uid,
+		Name:             ds.Name,
+		Type:             ds.Type,
+		Access:           string(ds.Access),
+		URL:              ds.Url,
+		User:             ds.User,
+		Database:         ds.Database,
+		BasicAuth:        ds.BasicAuth,
+		BasicAuthUser:    ds.BasicAuthUser,
+		WithCredentials:  ds.WithCredentials,
+		IsDefault:        ds.IsDefault,
+		JSONData:         ds.JsonData,
+		SecureJSONFields: secureFields,
+	})
+	sort.Slice(e.bundle.DataSources, func(i, j int) bool {
+		return e.bundle.DataSources[i].Name < e.bundle.DataSources[j].Name
+	})
+}
+
+func (e *exporter) addAlertNotification(notification *models.AlertNotification) {
+	if e.notifications[notification.Id] {
+		return
+	}
+	e.notifications[notification.Id] = true
+
+	secureFields := map[string]bool{}
+	for key := range notification.SecureSettings {
+		secureFields[key] = true
+	}
+	frequency := ""
+	if notification.SendReminder {
+		frequency = notification.Frequency.String()
+	}
+
+	e.bundle.AlertNotifications = append(e.bundle.AlertNotifications, &AlertNotification{
+		UID:                   notification.Uid,
+		Name:                  notification.Name,
+		Type:                  notification.Type,
+		SendReminder:          notification.SendReminder,
+		DisableResolveMessage: notification.DisableResolveMessage,
+		Frequency:             frequency,
+		IsDefault:             notification.IsDefault,
+		Settings:              notification.Settings,
+		SecureFields:          secureFields,
+	})
+}
+
+// forEachPanel calls fn for every panel of a dashboard, including the panels of collapsed rows.
+func forEachPanel(data *simplejson.Json, fn func(panel *simplejson.Json)) {
+	for _, item := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(item)
+		fn(panel)
+		for _, rowItem := range panel.Get("panels").MustArray() {
+			fn(simplejson.NewFromAny(rowItem))
+		}
+	}
+}
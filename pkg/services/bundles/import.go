@@ -0,0 +1,356 @@
+package bundles
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/librarypanels"
+)
+
+const (
+	kindFolder            = "folder"
+	kindDataSource        = "datasource"
+	kindAlertNotification = "alert-notification"
+	kindLibraryPanel      = "library-panel"
+	kindDashboard         = "dashboard"
+)
+
+// importer imports the content of a bundle.
+type importer struct {
+	service       *Service
+	c             *models.ReqContext
+	options       ImportOptions
+	folderService dashboards.FolderService
+	bundle        *Bundle
+	result        *ImportResult
+	folderIDs     map[string]int64
+}
+
+// Import imports the content of a bundle. Content already existing with the same UID is a conflict, resolved as
+// requested by the options: the import either fails before any change, skips the existing content or overwrites it.
+// Existing folders are never a conflict, the bundle content is imported in them. The data sources and notification
+// channels created by the import have no secrets, the result lists the secrets to set. If an item fails to be
+// imported, the content imported before it is kept and the partial result is returned with the error: the failed
+// item has the error and the items after it are not imported.
+func (s *Service) Import(c *models.ReqContext, bundle *Bundle, options ImportOptions) (*ImportResult, error) {
+	i := &importer{
+		service:       s,
+		c:             c,
+		options:       options,
+		folderService: dashboards.NewFolderService(c.OrgId, c.SignedInUser),
+		bundle:        bundle,
+		result:        &ImportResult{DryRun: options.DryRun, Items: []*ImportItem{}},
+		folderIDs:     map[string]int64{"": 0},
+	}
+
+	plan, err := i.plan()
+	if err != nil {
+		return nil, err
+	}
+	if len(i.result.Conflicts()) > 0 && options.Conflicts == ConflictFail {
+		return i.result, ErrBundleConflicts
+	}
+	if options.DryRun {
+		return i.result, nil
+	}
+
+	for n, step := range plan {
+		if err := step.run(); err != nil {
+			step.item.Error = err.Error()
+			for _, next := range plan[n+1:] {
+				next.item.Action = ActionNotImported
+			}
+			return i.result, err
+		}
+	}
+	return i.result, nil
+}
+
+// importStep imports an item of a bundle.
+type importStep struct {
+	item *ImportItem
+	run  func() error
+}
+
+// action returns the action of an item of the bundle, depending on whether it already exists.
+func (i *importer) action(exists bool) ImportAction {
+	if !exists {
+		return ActionCreate
+	}
+	switch i.options.Conflicts {
+	case ConflictOverwrite:
+		return ActionUpdate
+	case ConflictSkip:
+		return ActionSkip
+	}
+	return ActionConflict
+}
+
+func (i *importer) addItem(kind string, uid string, name string, action ImportAction) *ImportItem {
+	item := &ImportItem{Kind: kind, UID: uid, Name: name, Action: action}
+	i.result.Items = append(i.result.Items, item)
+	return item
+}
+
+// plan finds the action of every item of the bundle, and returns the steps of the import. Folders are imported
+// first, then the data sources and notification channels, the library panels and finally the dashboards.
+func (i *importer) plan() ([]importStep, error) {
+	steps := make([]importStep, 0)
+
+	for _, folder := range i.bundle.Manifest.Folders {
+		existing, err := i.folderService.GetFolderByUID(folder.UID)
+		if err != nil && !errors.Is(err, models.ErrFolderNotFound) {
+			return nil, err
+		}
+		action := ActionCreate
+		if existing != nil {
+			action = ActionSkip
+			if i.options.Conflicts == ConflictOverwrite {
+				action = ActionUpdate
+			}
+		}
+		item := i.addItem(kindFolder, folder.UID, folder.Title, action)
+		steps = append(steps, importStep{item: item, run: i.importFolder(folder, existing, action)})
+	}
+
+	for _, ds := range i.bundle.DataSources {
+		query := models.GetDataSourceQuery{Uid: ds.UID, OrgId: i.c.OrgId}
+		if err := bus.Dispatch(&query); err != nil && !errors.Is(err, models.ErrDataSourceNotFound) {
+			return nil, err
+		}
+		action := i.action(query.Result != nil)
+		if query.Result != nil && query.Result.ReadOnly {
+			// provisioned data sources are managed by their provisioning files
+			action = ActionSkip
+		}
+		item := i.addItem(kindDataSource, ds.UID, ds.Name, action)
+		if action == ActionCreate {
+			item.SecretsRequired = secretNames(ds.SecureJSONFields)
+		}
+		steps = append(steps, importStep{item: item, run: i.importDataSource(ds, query.Result, action)})
+	}
+
+	for _, notification := range i.bundle.AlertNotifications {
+		query := models.GetAlertNotificationsWithUidQuery{Uid: notification.UID, OrgId: i.c.OrgId}
+		if err := bus.Dispatch(&query); err != nil {
+			return nil, err
+		}
+		action := i.action(query.Result != nil)
+		item := i.addItem(kindAlertNotification, notification.UID, notification.Name, action)
+		if action == ActionCreate {
+			item.SecretsRequired = secretNames(notification.SecureFields)
+		}
+		steps = append(steps, importStep{item: item, run: i.importAlertNotification(notification, query.Result, action)})
+	}
+
+	for _, panel := range i.bundle.LibraryPanels {
+		if !i.service.libraryPanels.IsEnabled() {
+			i.addItem(kindLibraryPanel, panel.UID, panel.Name, ActionSkip)
+			continue
+		}
+		_, err := i.service.libraryPanels.GetLibraryPanel(i.c, panel.UID)
+		if err != nil && !errors.Is(err, librarypanels.ErrLibraryPanelNotFound) {
+			return nil, err
+		}
+		action := i.action(err == nil)
+		item := i.addItem(kindLibraryPanel, panel.UID, panel.Name, action)
+		steps = append(steps, importStep{item: item, run: i.importLibraryPanel(panel, action)})
+	}
+
+	for _, dash := range i.bundle.Manifest.Dashboards {
+		query := models.GetDashboardQuery{Uid: dash.UID, OrgId: i.c.OrgId}
+		err := bus.Dispatch(&query)
+		if err != nil && !errors.Is(err, models.ErrDashboardNotFound) {
+			return nil, err
+		}
+		action := i.action(err == nil)
+		item := i.addItem(kindDashboard, dash.UID, dash.Title, action)
+		steps = append(steps, importStep{item: item, run: i.importDashboard(dash, action)})
+	}
+
+	return steps, nil
+}
+
+func (i *importer) importFolder(folder *Folder, existing *models.Folder, action ImportAction) func() error {
+	return func() error {
+		switch action {
+		case ActionCreate:
+			cmd := models.CreateFolderCommand{Uid: folder.UID, Title: folder.Title, ParentUid: folder.ParentUID}
+			if err := i.folderService.CreateFolder(&cmd); err != nil {
+				return err
+			}
+			existing = cmd.Result
+		case ActionUpdate:
+			cmd := models.UpdateFolderCommand{Title: folder.Title, Overwrite: true}
+			if err := i.folderService.UpdateFolder(folder.UID, &cmd); err != nil {
+				return err
+			}
+			if existing.ParentUid != folder.ParentUID {
+				if err := i.folderService.MoveFolder(folder.UID, &models.MoveFolderCommand{ParentUid: folder.ParentUID}); err != nil {
+					return err
+				}
+			}
+		}
+		i.folderIDs[folder.UID] = existing.Id
+		return nil
+	}
+}
+
+func (i *importer) importDataSource(ds *DataSource, existing *models.DataSource, action ImportAction) func() error {
+	return func() error {
+		switch action {
+		case ActionCreate:
+			return bus.Dispatch(&models.AddDataSourceCommand{
+				Name:            ds.Name,
+				Type:            ds.Type,
+				Access:          models.DsAccess(ds.Access),
+				Url:             ds.URL,
+				User:            ds.User,
+				Database:        ds.Database,
+				BasicAuth:       ds.BasicAuth,
+				BasicAuthUser:   ds.BasicAuthUser,
+				WithCredentials: ds.WithCredentials,
+				IsDefault:       ds.IsDefault,
+				JsonData:        ds.JSONData,
+				Uid:             ds.UID,
+				OrgId:           i.c.OrgId,
+			})
+		case ActionUpdate:
+			// the secrets are not part of the bundle, the existing secrets are kept
+			return bus.Dispatch(&models.UpdateDataSourceCommand{
+				Name:              ds.Name,
+				Type:              ds.Type,
+				Access:            models.DsAccess(ds.Access),
+				Url:               ds.URL,
+				Password:          existing.Password,
+				User:              ds.User,
+				Database:          ds.Database,
+				BasicAuth:         ds.BasicAuth,
+				BasicAuthUser:     ds.BasicAuthUser,
+				BasicAuthPassword: existing.BasicAuthPassword,
+				WithCredentials:   ds.WithCredentials,
+				IsDefault:         ds.IsDefault,
+				JsonData:          ds.JSONData,
+				SecureJsonData:    existing.SecureJsonData.Decrypt(),
+				Version:           existing.Version,
+				Uid:               ds.UID,
+				OrgId:             i.c.OrgId,
+				Id:                existing.Id,
+			})
+		}
+		return nil
+	}
+}
+
+func (i *importer) importAlertNotification(notification *AlertNotification, existing *models.AlertNotification, action ImportAction) func() error {
+	return func() error {
+		switch action {
+		case ActionCreate:
+			return bus.Dispatch(&models.CreateAlertNotificationCommand{
+				Uid:                   notification.UID,
+				Name:                  notification.Name,
+				Type:                  notification.Type,
+				SendReminder:          notification.SendReminder,
+				DisableResolveMessage: notification.DisableResolveMessage,
+				Frequency:             notification.Frequency,
+				IsDefault:             notification.IsDefault,
+				Settings:              notification.Settings,
+				OrgId:                 i.c.OrgId,
+			})
+		case ActionUpdate:
+			// the secrets are not part of the bundle, the existing secrets are kept
+			return bus.Dispatch(&models.UpdateAlertNotificationWithUidCommand{
+				Uid:                   notification.UID,
+				NewUid:                notification.UID,
+				Name:                  notification.Name,
+				Type:                  notification.Type,
+				SendReminder:          notification.SendReminder,
+				DisableResolveMessage: notification.DisableResolveMessage,
+				Frequency:             notification.Frequency,
+				IsDefault:             notification.IsDefault,
+				Settings:              notification.Settings,
+				SecureSettings:        existing.SecureSettings.Decrypt(),
+				OrgId:                 i.c.OrgId,
+			})
+		}
+		return nil
+	}
+}
+
+func (i *importer) importLibraryPanel(panel *LibraryPanel, action ImportAction) func() error {
+	return func() error {
+		if action != ActionCreate && action != ActionUpdate {
+			return nil
+		}
+		folderID, err := i.folderID(panel.FolderUID)
+		if err != nil {
+			return err
+		}
+		return i.service.libraryPanels.ImportLibraryPanel(i.c, librarypanels.ImportLibraryPanelCommand{
+			UID:      panel.UID,
+			FolderID: folderID,
+			Name:     panel.Name,
+			Model:    panel.Model,
+		})
+	}
+}
+
+func (i *importer) importDashboard(manifest *DashboardManifest, action ImportAction) func() error {
+	return func() error {
+		if action != ActionCreate && action != ActionUpdate {
+			return nil
+		}
+		folderID, err := i.folderID(manifest.FolderUID)
+		if err != nil {
+			return err
+		}
+
+		dash := models.NewDashboardFromJson(i.bundle.Dashboards[manifest.UID])
+		dash.SetUid(manifest.UID)
+		dash.FolderId = folderID
+		dash.OrgId = i.c.OrgId
+		dto := &dashboards.SaveDashboardDTO{
+			Dashboard: dash,
+			Message:   "Imported from bundle",
+			OrgId:     i.c.OrgId,
+			User:      i.c.SignedInUser,
+			Overwrite: action == ActionUpdate,
+		}
+		saved, err := dashboards.NewService().SaveDashboard(dto, false)
+		if err != nil {
+			return err
+		}
+
+		if i.service.libraryPanels.IsEnabled() {
+			return i.service.libraryPanels.ConnectLibraryPanelsForDashboard(i.c, saved)
+		}
+		return nil
+	}
+}
+
+// folderID returns the id of a folder of the bundle, imported or already existing.
+func (i *importer) folderID(uid string) (int64, error) {
+	if id, ok := i.folderIDs[uid]; ok {
+		return id, nil
+	}
+	folder, err := i.folderService.GetFolderByUID(uid)
+	if err != nil {
+		return 0, err
+	}
+	i.folderIDs[uid] = folder.Id
+	return folder.Id, nil
+}
+
+func secretNames(fields map[string]bool) []string {
+	names := make([]string, 0, len(fields))
+	for name, set := range fields {
+		if set {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
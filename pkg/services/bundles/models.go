@@ -0,0 +1,204 @@
+package bundles
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// bundleVersion is the version of the bundle format.
+const bundleVersion = 1
+
+var (
+	// ErrInvalidBundle is returned when an imported archive is not a valid bundle.
+	ErrInvalidBundle = errors.New("invalid bundle")
+	// ErrUnsupportedBundleFormat is returned when a bundle is exported in an unknown archive format.
+	ErrUnsupportedBundleFormat = errors.New("unsupported bundle format, expected zip or tar")
+	// ErrBundleTooLarge is returned when an imported archive exceeds the maximum bundle size.
+	ErrBundleTooLarge = errors.New("bundle is too large")
+	// ErrDashboardAccessDenied is returned when a dashboard the user cannot view is exported.
+	ErrDashboardAccessDenied = errors.New("access denied to dashboard")
+	// ErrBundleConflicts is returned when an import finds existing content and conflicts are not resolved.
+	ErrBundleConflicts = errors.New("bundle content conflicts with existing content")
+)
+
+// Format is the archive format of a bundle.
+type Format string
+
+const (
+	FormatZip Format = "zip"
+	FormatTar Format = "tar"
+)
+
+// ParseFormat returns the archive format with the given name, zip by default.
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "", "zip":
+		return FormatZip, nil
+	case "tar", "tar.gz", "tgz":
+		return FormatTar, nil
+	}
+	return "", ErrUnsupportedBundleFormat
+}
+
+// Extension returns the file extension of the archive format.
+func (f Format) Extension() string {
+	if f == FormatTar {
+		return ".tar.gz"
+	}
+	return ".zip"
+}
+
+// ContentType returns the content type of the archive format.
+func (f Format) ContentType() string {
+	if f == FormatTar {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// ConflictResolution is how an import handles content already existing with the same UID.
+type ConflictResolution string
+
+const (
+	// ConflictFail aborts the import when any content already exists.
+	ConflictFail ConflictResolution = "fail"
+	// ConflictSkip keeps the existing content.
+	ConflictSkip ConflictResolution = "skip"
+	// ConflictOverwrite replaces the existing content, keeping the secrets of data sources and notification channels.
+	ConflictOverwrite ConflictResolution = "overwrite"
+)
+
+// ParseConflictResolution returns the conflict resolution with the given name, fail by default.
+func ParseConflictResolution(name string) (ConflictResolution, error) {
+	switch ConflictResolution(name) {
+	case "", ConflictFail:
+		return ConflictFail, nil
+	case ConflictSkip, ConflictOverwrite:
+		return ConflictResolution(name), nil
+	}
+	return "", errors.New("invalid conflict resolution, expected fail, skip or overwrite")
+}
+
+// Bundle is a portable export of dashboards with everything they depend on.
+type Bundle struct {
+	Manifest           Manifest
+	Dashboards         map[string]*simplejson.Json
+	LibraryPanels      []*LibraryPanel
+	DataSources        []*DataSource
+	AlertNotifications []*AlertNotification
+}
+
+// Manifest describes the content of a bundle. It is stored as manifest.json at the root of the archive.
+type Manifest struct {
+	Version        int                  `json:"version"`
+	GrafanaVersion string               `json:"grafanaVersion"`
+	Exported       time.Time            `json:"exported"`
+	Folders        []*Folder            `json:"folders"`
+	Dashboards     []*DashboardManifest `json:"dashboards"`
+}
+
+// Folder is a folder of a bundle. Parent folders are listed before their subfolders.
+type Folder struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUID string `json:"parentUid,omitempty"`
+}
+
+// DashboardManifest is a dashboard of a bundle, stored in the file at Path.
+type DashboardManifest struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folderUid,omitempty"`
+	Path      string `json:"path"`
+}
+
+// LibraryPanel is a library panel used by the dashboards of a bundle.
+type LibraryPanel struct {
+	UID       string          `json:"uid"`
+	Name      string          `json:"name"`
+	FolderUID string          `json:"folderUid,omitempty"`
+	Model     json.RawMessage `json:"model"`
+}
+
+// DataSource is a data source used by the dashboards of a bundle. Its secrets are not exported, SecureJSONFields lists
+// the secrets to set after the import.
+type DataSource struct {
+	UID              string           `json:"uid"`
+	Name             string           `json:"name"`
+	Type             string           `json:"type"`
+	Access           string           `json:"access"`
+	URL              string           `json:"url"`
+	User             string           `json:"user"`
+	Database         string           `json:"database"`
+	BasicAuth        bool             `json:"basicAuth"`
+	BasicAuthUser    string           `json:"basicAuthUser"`
+	WithCredentials  bool             `json:"withCredentials"`
+	IsDefault        bool             `json:"isDefault"`
+	JSONData         *simplejson.Json `json:"jsonData"`
+	SecureJSONFields map[string]bool  `json:"secureJsonFields"`
+}
+
+// AlertNotification is a notification channel of the alerts of the dashboards of a bundle. Its secrets are not
+// exported, SecureFields lists the secrets to set after the import.
+type AlertNotification struct {
+	UID                   string           `json:"uid"`
+	Name                  string           `json:"name"`
+	Type                  string           `json:"type"`
+	SendReminder          bool             `json:"sendReminder"`
+	DisableResolveMessage bool             `json:"disableResolveMessage"`
+	Frequency             string           `json:"frequency,omitempty"`
+	IsDefault             bool             `json:"isDefault"`
+	Settings              *simplejson.Json `json:"settings"`
+	SecureFields          map[string]bool  `json:"secureFields"`
+}
+
+// ImportOptions are the options of a bundle import.
+type ImportOptions struct {
+	Conflicts ConflictResolution
+	DryRun    bool
+}
+
+// ImportAction is what an import does with an item of a bundle.
+type ImportAction string
+
+const (
+	ActionCreate   ImportAction = "create"
+	ActionUpdate   ImportAction = "update"
+	ActionSkip     ImportAction = "skip"
+	ActionConflict ImportAction = "conflict"
+	// ActionNotImported is the action of the items after an item that failed to be imported.
+	ActionNotImported ImportAction = "not-imported"
+)
+
+// ImportItem is the result of the import of an item of a bundle.
+type ImportItem struct {
+	Kind   string       `json:"kind"`
+	UID    string       `json:"uid"`
+	Name   string       `json:"name"`
+	Action ImportAction `json:"action"`
+	// SecretsRequired lists the secrets of a created data source or notification channel, which are not part of
+	// the bundle and have to be set after the import.
+	SecretsRequired []string `json:"secretsRequired,omitempty"`
+	// Error is the error of an item that failed to be imported.
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResult is the result of a bundle import. The items are in the order they are imported.
+type ImportResult struct {
+	DryRun bool          `json:"dryRun"`
+	Items  []*ImportItem `json:"items"`
+}
+
+// Conflicts returns the items conflicting with existing content.
+func (r *ImportResult) Conflicts() []*ImportItem {
+	conflicts := make([]*ImportItem, 0)
+	for _, item := range r.Items {
+		if item.Action == ActionConflict {
+			conflicts = append(conflicts, item)
+		}
+	}
+	return conflicts
+}
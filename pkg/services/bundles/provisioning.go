@@ -0,0 +1,196 @@
+package bundles
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// The provisioning files of a bundle use the formats of the dashboards, data sources and notifiers provisioning
+// configuration files.
+
+type provisioningDashboards struct {
+	APIVersion int64                           `yaml:"apiVersion"`
+	Providers  []provisioningDashboardProvider `yaml:"providers"`
+}
+
+type provisioningDashboardProvider struct {
+	Name      string                 `yaml:"name"`
+	Type      string                 `yaml:"type"`
+	Folder    string                 `yaml:"folder"`
+	FolderUID string                 `yaml:"folderUid,omitempty"`
+	Options   map[string]interface{} `yaml:"options"`
+}
+
+type provisioningDataSources struct {
+	APIVersion  int64                    `yaml:"apiVersion"`
+	DataSources []provisioningDataSource `yaml:"datasources"`
+}
+
+type provisioningDataSource struct {
+	Name            string            `yaml:"name"`
+	Type            string            `yaml:"type"`
+	UID             string            `yaml:"uid"`
+	Access          string            `yaml:"access"`
+	URL             string            `yaml:"url,omitempty"`
+	User            string            `yaml:"user,omitempty"`
+	Database        string            `yaml:"database,omitempty"`
+	BasicAuth       bool              `yaml:"basicAuth"`
+	BasicAuthUser   string            `yaml:"basicAuthUser,omitempty"`
+	WithCredentials bool              `yaml:"withCredentials"`
+	IsDefault       bool              `yaml:"isDefault"`
+	JSONData        interface{}       `yaml:"jsonData,omitempty"`
+	SecureJSONData  map[string]string `yaml:"secureJsonData,omitempty"`
+	Editable        bool              `yaml:"editable"`
+}
+
+type provisioningNotifiers struct {
+	APIVersion int64                  `yaml:"apiVersion"`
+	Notifiers  []provisioningNotifier `yaml:"notifiers"`
+}
+
+type provisioningNotifier struct {
+	Name                  string            `yaml:"name"`
+	Type                  string            `yaml:"type"`
+	UID                   string            `yaml:"uid"`
+	IsDefault             bool              `yaml:"is_default"`
+	SendReminder          bool              `yaml:"send_reminder"`
+	Frequency             string            `yaml:"frequency,omitempty"`
+	DisableResolveMessage bool              `yaml:"disable_resolve_message"`
+	Settings              interface{}       `yaml:"settings,omitempty"`
+	SecureSettings        map[string]string `yaml:"secure_settings,omitempty"`
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// secretVariable returns the environment variable expected by the provisioning files for a secret.
+func secretVariable(kind string, name string, key string) string {
+	variable := strings.ToUpper(kind + "_" + name + "_" + key)
+	return "$" + strings.Trim(nonAlphanumeric.ReplaceAllString(variable, "_"), "_")
+}
+
+// secretVariables returns the environment variables of the secrets of a data source or notification channel.
+func secretVariables(kind string, name string, fields map[string]bool) map[string]string {
+	variables := map[string]string{}
+	for key, set := range fields {
+		if set {
+			variables[key] = secretVariable(kind, name, key)
+		}
+	}
+	return variables
+}
+
+// provisioningFiles returns the provisioning configuration files of the bundle. The dashboards are provisioned from
+// the bundle extracted in provisioningPath, one provider per folder. The secrets are read from environment variables.
+func (b *Bundle) provisioningFiles(provisioningPath string) ([]archiveFile, error) {
+	folders := map[string]*Folder{}
+	for _, folder := range b.Manifest.Folders {
+		folders[folder.UID] = folder
+	}
+
+	dashboards := provisioningDashboards{APIVersion: 1, Providers: []provisioningDashboardProvider{}}
+	dirs := map[string]bool{}
+	for _, dash := range b.Manifest.Dashboards {
+		dir := path.Dir(dash.Path)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+
+		provider := provisioningDashboardProvider{
+			Name:    "bundle-" + path.Base(dir),
+			Type:    "file",
+			Options: map[string]interface{}{"path": path.Join(provisioningPath, dir)},
+		}
+		if folder, ok := folders[dash.FolderUID]; ok {
+			provider.Folder = folder.Title
+			provider.FolderUID = folder.UID
+		}
+		dashboards.Providers = append(dashboards.Providers, provider)
+	}
+	sort.Slice(dashboards.Providers, func(i, j int) bool {
+		return dashboards.Providers[i].Name < dashboards.Providers[j].Name
+	})
+
+	dataSources := provisioningDataSources{APIVersion: 1, DataSources: []provisioningDataSource{}}
+	for _, ds := range b.DataSources {
+		jsonData, err := plainJSON(ds.JSONData)
+		if err != nil {
+			return nil, err
+		}
+		dataSources.DataSources = append(dataSources.DataSources, provisioningDataSource{
+			Name:            ds.Name,
+			Type:            ds.Type,
+			UID:             ds.UID,
+			Access:          ds.Access,
+			URL:             ds.URL,
+			User:            ds.User,
+			Database:        ds.Database,
+			BasicAuth:       ds.BasicAuth,
+			BasicAuthUser:   ds.BasicAuthUser,
+			WithCredentials: ds.WithCredentials,
+			IsDefault:       ds.IsDefault,
+			JSONData:        jsonData,
+			SecureJSONData:  secretVariables("datasource", ds.Name, ds.SecureJSONFields),
+		})
+	}
+
+	notifiers := provisioningNotifiers{APIVersion: 1, Notifiers: []provisioningNotifier{}}
+	for _, notification := range b.AlertNotifications {
+		settings, err := plainJSON(notification.Settings)
+		if err != nil {
+			return nil, err
+		}
+		notifiers.Notifiers = append(notifiers.Notifiers, provisioningNotifier{
+			Name:                  notification.Name,
+			Type:                  notification.Type,
+			UID:                   notification.UID,
+			IsDefault:             notification.IsDefault,
+			SendReminder:          notification.SendReminder,
+			Frequency:             notification.Frequency,
+			DisableResolveMessage: notification.DisableResolveMessage,
+			Settings:              settings,
+			SecureSettings:        secretVariables("notifier", notification.Name, notification.SecureFields),
+		})
+	}
+
+	files := make([]archiveFile, 0, 3)
+	for name, config := range map[string]interface{}{
+		provisioningDashboardsFile: dashboards,
+		provisioningDataSourceFile: dataSources,
+		provisioningNotifiersFile:  notifiers,
+	} {
+		content, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{name: name, content: content})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+
+	return files, nil
+}
+
+// plainJSON converts JSON to plain values, so that numbers are not written as strings in YAML.
+func plainJSON(data *simplejson.Json) (interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	content, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(content, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
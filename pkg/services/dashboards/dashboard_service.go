@@ -1,9 +1,11 @@
 package dashboards
 
 import (
+	"errors"
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/setting"
 
@@ -20,6 +22,8 @@ type DashboardService interface {
 	SaveDashboard(dto *SaveDashboardDTO, allowUiUpdate bool) (*models.Dashboard, error)
 	ImportDashboard(dto *SaveDashboardDTO) (*models.Dashboard, error)
 	DeleteDashboard(dashboardId int64, orgId int64) error
+	MoveDashboardToTrash(dashboardId int64, orgId int64, userId int64) error
+	RestoreDashboardFromTrash(trashItemId int64, orgId int64, user *models.SignedInUser) ([]*models.Dashboard, error)
 }
 
 // DashboardProvisioningService is a service for operating on provisioned dashboards.
@@ -51,6 +55,8 @@ type SaveDashboardDTO struct {
 	Message   string
 	Overwrite bool
 	Dashboard *models.Dashboard
+	// Merged is set when the dashboard has been merged with changes saved by someone else in the meantime.
+	Merged bool
 }
 
 type dashboardServiceImpl struct {
@@ -91,10 +97,6 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 		return nil, models.ErrDashboardTitleEmpty
 	}
 
-	if dash.IsFolder && dash.FolderId > 0 {
-		return nil, models.ErrDashboardFolderCannotHaveParent
-	}
-
 	if dash.IsFolder && strings.EqualFold(dash.Title, models.RootFolderName) {
 		return nil, models.ErrDashboardFolderNameExists
 	}
@@ -128,7 +130,17 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 	}
 
 	if err := bus.Dispatch(&validateBeforeSaveCmd); err != nil {
-		return nil, err
+		if !errors.Is(err, models.ErrDashboardVersionMismatch) {
+			return nil, err
+		}
+
+		// someone else has saved the dashboard in the meantime, try to merge the changes
+		if err := dr.mergeDashboardChanges(dto); err != nil {
+			return nil, err
+		}
+		if err := bus.Dispatch(&validateBeforeSaveCmd); err != nil {
+			return nil, err
+		}
 	}
 
 	if validateBeforeSaveCmd.Result.IsParentFolderChanged {
@@ -178,6 +190,50 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 	return cmd, nil
 }
 
+// mergeDashboardChanges merges the changes of a dashboard with the changes saved by someone else since the
+// version the dashboard was edited from. It returns a DashboardEditConflictError when the same panels or
+// properties have been changed differently.
+func (dr *dashboardServiceImpl) mergeDashboardChanges(dto *SaveDashboardDTO) error {
+	dash := dto.Dashboard
+
+	query := models.GetDashboardQuery{Id: dash.Id, Uid: dash.Uid, OrgId: dto.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+	existing := query.Result
+
+	if dash.Version <= 0 || dash.Version > existing.Version {
+		return models.ErrDashboardVersionMismatch
+	}
+
+	versionQuery := models.GetDashboardVersionQuery{DashboardId: existing.Id, OrgId: dto.OrgId, Version: dash.Version}
+	if err := bus.Dispatch(&versionQuery); err != nil {
+		if errors.Is(err, models.ErrDashboardVersionNotFound) {
+			return models.ErrDashboardVersionMismatch
+		}
+		return err
+	}
+
+	merged, conflicts, err := dashdiffs.MergeDashboards(versionQuery.Result.Data, dash.Data, existing.Data)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return models.DashboardEditConflictError{Version: existing.Version, Conflicts: conflicts}
+	}
+
+	dr.log.Debug("Merged dashboard changes", "dashboardUid", existing.Uid, "baseVersion", dash.Version,
+		"version", existing.Version)
+
+	dash.Data = merged
+	dash.Title = strings.TrimSpace(merged.Get("title").MustString())
+	dash.Data.Set("title", dash.Title)
+	dash.UpdateSlug()
+	dash.SetVersion(existing.Version)
+	dto.Merged = true
+	return nil
+}
+
 func validateDashboardRefreshInterval(dash *models.Dashboard) error {
 	if setting.MinRefreshInterval == "" {
 		return nil
@@ -304,17 +360,55 @@ func (dr *dashboardServiceImpl) SaveDashboard(dto *SaveDashboardDTO, allowUiUpda
 // DeleteDashboard removes dashboard from the DB. Errors out if the dashboard was provisioned. Should be used for
 // operations by the user where we want to make sure user does not delete provisioned dashboard.
 func (dr *dashboardServiceImpl) DeleteDashboard(dashboardId int64, orgId int64) error {
-	return dr.deleteDashboard(dashboardId, orgId, true)
+	return dr.deleteDashboard(&models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId}, true)
+}
+
+// MoveDashboardToTrash moves a dashboard to the trash of its organization, from where it can be restored
+// until the trash retention expires. Without trash retention the dashboard is deleted permanently.
+func (dr *dashboardServiceImpl) MoveDashboardToTrash(dashboardId int64, orgId int64, userId int64) error {
+	cmd := &models.DeleteDashboardCommand{
+		OrgId:       orgId,
+		Id:          dashboardId,
+		MoveToTrash: setting.DashboardTrashRetention > 0,
+		DeletedBy:   userId,
+	}
+	return dr.deleteDashboard(cmd, true)
+}
+
+// RestoreDashboardFromTrash restores a dashboard or folder from the trash and returns the restored
+// dashboards and folders. The alerts of the dashboards are extracted again.
+func (dr *dashboardServiceImpl) RestoreDashboardFromTrash(trashItemId int64, orgId int64, user *models.SignedInUser) ([]*models.Dashboard, error) {
+	cmd := models.RestoreDashboardFromTrashCommand{Id: trashItemId, OrgId: orgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return nil, err
+	}
+
+	for _, dash := range cmd.Result {
+		if dash.IsFolder {
+			continue
+		}
+
+		alertCmd := models.UpdateDashboardAlertsCommand{
+			OrgId:     orgId,
+			Dashboard: dash,
+			User:      user,
+		}
+		if err := bus.Dispatch(&alertCmd); err != nil {
+			dr.log.Warn("Failed to restore alerts of dashboard", "dashboardUid", dash.Uid, "error", err)
+		}
+	}
+
+	return cmd.Result, nil
 }
 
 // DeleteProvisionedDashboard removes dashboard from the DB even if it is provisioned.
 func (dr *dashboardServiceImpl) DeleteProvisionedDashboard(dashboardId int64, orgId int64) error {
-	return dr.deleteDashboard(dashboardId, orgId, false)
+	return dr.deleteDashboard(&models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId}, false)
 }
 
-func (dr *dashboardServiceImpl) deleteDashboard(dashboardId int64, orgId int64, validateProvisionedDashboard bool) error {
+func (dr *dashboardServiceImpl) deleteDashboard(cmd *models.DeleteDashboardCommand, validateProvisionedDashboard bool) error {
 	if validateProvisionedDashboard {
-		provisionedData, err := dr.GetProvisionedDashboardDataByDashboardID(dashboardId)
+		provisionedData, err := dr.GetProvisionedDashboardDataByDashboardID(cmd.Id)
 		if err != nil {
 			return errutil.Wrap("failed to check if dashboard is provisioned", err)
 		}
@@ -323,7 +417,6 @@ func (dr *dashboardServiceImpl) deleteDashboard(dashboardId int64, orgId int64,
 			return models.ErrDashboardCannotDeleteProvisionedDashboard
 		}
 	}
-	cmd := &models.DeleteDashboardCommand{OrgId: orgId, Id: dashboardId}
 	return bus.Dispatch(cmd)
 }
 
@@ -385,6 +478,14 @@ func (s *FakeDashboardService) DeleteDashboard(dashboardId int64, orgId int64) e
 	return nil
 }
 
+func (s *FakeDashboardService) MoveDashboardToTrash(dashboardId int64, orgId int64, userId int64) error {
+	return s.DeleteDashboard(dashboardId, orgId)
+}
+
+func (s *FakeDashboardService) RestoreDashboardFromTrash(trashItemId int64, orgId int64, user *models.SignedInUser) ([]*models.Dashboard, error) {
+	return nil, nil
+}
+
 func MockDashboardService(mock *FakeDashboardService) {
 	NewService = func() DashboardService {
 		return mock
@@ -3,6 +3,7 @@ package dashboards
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
@@ -37,13 +38,6 @@ func TestDashboardService(t *testing.T) {
 				}
 			})
 
-			Convey("Should return validation error if it's a folder and have a folder id", func() {
-				dto.Dashboard = models.NewDashboardFolder("Folder")
-				dto.Dashboard.FolderId = 1
-				_, err := service.SaveDashboard(dto, false)
-				So(err, ShouldEqual, models.ErrDashboardFolderCannotHaveParent)
-			})
-
 			Convey("Should return validation error if folder is named General", func() {
 				dto.Dashboard = models.NewDashboardFolder("General")
 				_, err := service.SaveDashboard(dto, false)
@@ -152,6 +146,79 @@ func TestDashboardService(t *testing.T) {
 				_, err := service.SaveDashboard(dto, false)
 				So(err.Error(), ShouldEqual, "alert validation error")
 			})
+
+			Convey("Given a dashboard saved by someone else in the meantime", func() {
+				newDashboard := func(title string, version int, panelTitles ...string) *models.Dashboard {
+					dash := models.NewDashboard(title)
+					dash.SetId(3)
+					dash.SetUid("abc")
+					dash.SetVersion(version)
+					panels := []interface{}{}
+					for i, panelTitle := range panelTitles {
+						panels = append(panels, map[string]interface{}{"id": i + 1, "title": panelTitle})
+					}
+					dash.Data.Set("panels", panels)
+					return dash
+				}
+
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardAlertsCommand) error {
+					return nil
+				})
+
+				bus.AddHandler("test", func(cmd *models.ValidateDashboardBeforeSaveCommand) error {
+					cmd.Result = &models.ValidateDashboardBeforeSaveResult{}
+					if cmd.Dashboard.Version != 3 {
+						return models.ErrDashboardVersionMismatch
+					}
+					return nil
+				})
+
+				bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+					query.Result = newDashboard("Dash", 3, "CPU", "Memory usage")
+					return nil
+				})
+
+				bus.AddHandler("test", func(query *models.GetDashboardVersionQuery) error {
+					if query.Version != 2 {
+						return models.ErrDashboardVersionNotFound
+					}
+					query.Result = &models.DashboardVersion{Data: newDashboard("Dash", 2, "CPU", "Memory").Data}
+					return nil
+				})
+
+				bus.AddHandler("test", func(cmd *models.GetProvisionedDashboardDataByIdQuery) error {
+					cmd.Result = nil
+					return nil
+				})
+
+				dto.User = &models.SignedInUser{UserId: 1}
+
+				Convey("Should merge changes of other panels", func() {
+					dto.Dashboard = newDashboard("Dash", 2, "CPU usage", "Memory")
+					cmd, err := service.buildSaveDashboardCommand(dto, true, false)
+					So(err, ShouldBeNil)
+					So(dto.Merged, ShouldBeTrue)
+					So(cmd.Dashboard.Get("version").MustInt(), ShouldEqual, 3)
+					So(cmd.Dashboard.Get("panels").GetIndex(0).Get("title").MustString(), ShouldEqual, "CPU usage")
+					So(cmd.Dashboard.Get("panels").GetIndex(1).Get("title").MustString(), ShouldEqual, "Memory usage")
+				})
+
+				Convey("Should return conflicts when the same panel has been changed", func() {
+					dto.Dashboard = newDashboard("Dash", 2, "CPU", "Memory used")
+					_, err := service.buildSaveDashboardCommand(dto, true, false)
+					So(err, ShouldResemble, models.DashboardEditConflictError{
+						Version:   3,
+						Conflicts: []models.DashboardEditConflict{{Path: "/panels/1", PanelId: 2, Title: "Memory used"}},
+					})
+					So(dto.Merged, ShouldBeFalse)
+				})
+
+				Convey("Should return version mismatch when the base version does not exist", func() {
+					dto.Dashboard = newDashboard("Dash", 1, "CPU usage", "Memory")
+					_, err := service.buildSaveDashboardCommand(dto, true, false)
+					So(err, ShouldEqual, models.ErrDashboardVersionMismatch)
+				})
+			})
 		})
 
 		Convey("Save provisioned dashboard validation", func() {
@@ -278,6 +345,12 @@ func TestDashboardService(t *testing.T) {
 				So(err, ShouldEqual, models.ErrDashboardCannotDeleteProvisionedDashboard)
 				So(result.deleteWasCalled, ShouldBeFalse)
 			})
+
+			Convey("MoveDashboardToTrash should fail to delete it", func() {
+				err := service.MoveDashboardToTrash(1, 1, 2)
+				So(err, ShouldEqual, models.ErrDashboardCannotDeleteProvisionedDashboard)
+				So(result.deleteWasCalled, ShouldBeFalse)
+			})
 		})
 
 		Convey("Given non provisioned dashboard", func() {
@@ -293,6 +366,30 @@ func TestDashboardService(t *testing.T) {
 				err := service.DeleteDashboard(1, 1)
 				So(err, ShouldBeNil)
 				So(result.deleteWasCalled, ShouldBeTrue)
+				So(result.movedToTrash, ShouldBeFalse)
+			})
+
+			Convey("MoveDashboardToTrash should move it to the trash", func() {
+				origRetention := setting.DashboardTrashRetention
+				setting.DashboardTrashRetention = time.Hour
+				defer func() { setting.DashboardTrashRetention = origRetention }()
+
+				err := service.MoveDashboardToTrash(1, 1, 2)
+				So(err, ShouldBeNil)
+				So(result.deleteWasCalled, ShouldBeTrue)
+				So(result.movedToTrash, ShouldBeTrue)
+				So(result.deletedBy, ShouldEqual, 2)
+			})
+
+			Convey("MoveDashboardToTrash should delete it without trash retention", func() {
+				origRetention := setting.DashboardTrashRetention
+				setting.DashboardTrashRetention = 0
+				defer func() { setting.DashboardTrashRetention = origRetention }()
+
+				err := service.MoveDashboardToTrash(1, 1, 2)
+				So(err, ShouldBeNil)
+				So(result.deleteWasCalled, ShouldBeTrue)
+				So(result.movedToTrash, ShouldBeFalse)
 			})
 		})
 
@@ -304,6 +401,8 @@ func TestDashboardService(t *testing.T) {
 
 type Result struct {
 	deleteWasCalled bool
+	movedToTrash    bool
+	deletedBy       int64
 }
 
 func setupDeleteHandlers(provisioned bool) *Result {
@@ -321,6 +420,8 @@ func setupDeleteHandlers(provisioned bool) *Result {
 		So(cmd.Id, ShouldEqual, 1)
 		So(cmd.OrgId, ShouldEqual, 1)
 		result.deleteWasCalled = true
+		result.movedToTrash = cmd.MoveToTrash
+		result.deletedBy = cmd.DeletedBy
 		return nil
 	})
 
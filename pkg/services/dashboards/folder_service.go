@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // FolderService service for operating on folders
@@ -16,6 +17,7 @@ type FolderService interface {
 	GetFolderByUID(uid string) (*models.Folder, error)
 	CreateFolder(cmd *models.CreateFolderCommand) error
 	UpdateFolder(uid string, cmd *models.UpdateFolderCommand) error
+	MoveFolder(uid string, cmd *models.MoveFolderCommand) error
 	DeleteFolder(uid string) (*models.Folder, error)
 }
 
@@ -46,9 +48,11 @@ func (dr *dashboardServiceImpl) GetFolders(limit int64) ([]*models.Folder, error
 
 	for _, hit := range searchQuery.Result {
 		folders = append(folders, &models.Folder{
-			Id:    hit.ID,
-			Uid:   hit.UID,
-			Title: hit.Title,
+			Id:        hit.ID,
+			Uid:       hit.UID,
+			Title:     hit.Title,
+			ParentId:  hit.FolderID,
+			ParentUid: hit.FolderUID,
 		})
 	}
 
@@ -71,7 +75,7 @@ func (dr *dashboardServiceImpl) GetFolderByID(id int64) (*models.Folder, error)
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return dashToFolderWithParent(dashFolder)
 }
 
 func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, error) {
@@ -90,12 +94,18 @@ func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, erro
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return dashToFolderWithParent(dashFolder)
 }
 
 func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) error {
 	dashFolder := cmd.GetDashboardModel(dr.orgId, dr.user.UserId)
 
+	parentID, err := dr.getParentFolderID(cmd.ParentUid)
+	if err != nil {
+		return err
+	}
+	dashFolder.FolderId = parentID
+
 	dto := &SaveDashboardDTO{
 		Dashboard: dashFolder,
 		OrgId:     dr.orgId,
@@ -118,9 +128,8 @@ func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) er
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
-
-	return nil
+	cmd.Result, err = dashToFolderWithParent(dashFolder)
+	return err
 }
 
 func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.UpdateFolderCommand) error {
@@ -155,9 +164,63 @@ func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.Upd
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
+	cmd.Result, err = dashToFolderWithParent(dashFolder)
+	return err
+}
+
+// MoveFolder moves a folder with its content into another folder. Moving a folder changes the
+// permissions inherited by its content, so it requires admin permission on the folder and permission
+// to save in the new parent folder.
+func (dr *dashboardServiceImpl) MoveFolder(uid string, cmd *models.MoveFolderCommand) error {
+	query := models.GetDashboardQuery{OrgId: dr.orgId, Uid: uid}
+	dashFolder, err := getFolder(query)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	g := guardian.New(dashFolder.Id, dr.orgId, dr.user)
+	if canAdmin, err := g.CanAdmin(); err != nil || !canAdmin {
+		if err != nil {
+			return toFolderError(err)
+		}
+		return models.ErrFolderAccessDenied
+	}
+
+	parentID, err := dr.getParentFolderID(cmd.ParentUid)
+	if err != nil {
+		return err
+	}
+	dashFolder.FolderId = parentID
+	if dr.user.UserId != 0 {
+		dashFolder.UpdatedBy = dr.user.UserId
+	} else {
+		dashFolder.UpdatedBy = -1
+	}
+
+	dto := &SaveDashboardDTO{
+		Dashboard: dashFolder,
+		OrgId:     dr.orgId,
+		User:      dr.user,
+	}
+
+	saveDashboardCmd, err := dr.buildSaveDashboardCommand(dto, false, false)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	err = bus.Dispatch(saveDashboardCmd)
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	query = models.GetDashboardQuery{OrgId: dr.orgId, Id: saveDashboardCmd.Result.Id}
+	dashFolder, err = getFolder(query)
+	if err != nil {
+		return toFolderError(err)
+	}
 
-	return nil
+	cmd.Result, err = dashToFolderWithParent(dashFolder)
+	return err
 }
 
 func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error) {
@@ -175,7 +238,12 @@ func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error)
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	deleteCmd := models.DeleteDashboardCommand{OrgId: dr.orgId, Id: dashFolder.Id}
+	deleteCmd := models.DeleteDashboardCommand{
+		OrgId:       dr.orgId,
+		Id:          dashFolder.Id,
+		MoveToTrash: setting.DashboardTrashRetention > 0,
+		DeletedBy:   dr.user.UserId,
+	}
 	if err := bus.Dispatch(&deleteCmd); err != nil {
 		return nil, toFolderError(err)
 	}
@@ -183,6 +251,20 @@ func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error)
 	return dashToFolder(dashFolder), nil
 }
 
+// getParentFolderID returns the ID of the parent folder with the given UID, or 0 for the top level.
+func (dr *dashboardServiceImpl) getParentFolderID(parentUID string) (int64, error) {
+	if parentUID == "" {
+		return 0, nil
+	}
+
+	parent, err := getFolder(models.GetDashboardQuery{OrgId: dr.orgId, Uid: parentUID})
+	if err != nil {
+		return 0, toFolderError(err)
+	}
+
+	return parent.Id, nil
+}
+
 func getFolder(query models.GetDashboardQuery) (*models.Dashboard, error) {
 	if err := bus.Dispatch(&query); err != nil {
 		return nil, toFolderError(err)
@@ -203,6 +285,7 @@ func dashToFolder(dash *models.Dashboard) *models.Folder {
 		HasAcl:    dash.HasAcl,
 		Url:       dash.GetUrl(),
 		Version:   dash.Version,
+		ParentId:  dash.FolderId,
 		Created:   dash.Created,
 		CreatedBy: dash.CreatedBy,
 		Updated:   dash.Updated,
@@ -210,6 +293,22 @@ func dashToFolder(dash *models.Dashboard) *models.Folder {
 	}
 }
 
+// dashToFolderWithParent returns the folder with the UID of its parent folder.
+func dashToFolderWithParent(dash *models.Dashboard) (*models.Folder, error) {
+	folder := dashToFolder(dash)
+	if folder.ParentId == 0 {
+		return folder, nil
+	}
+
+	query := models.GetDashboardRefByIdQuery{Id: folder.ParentId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, toFolderError(err)
+	}
+	folder.ParentUid = query.Result.Uid
+
+	return folder, nil
+}
+
 func toFolderError(err error) error {
 	if errors.Is(err, models.ErrDashboardTitleEmpty) {
 		return models.ErrFolderTitleEmpty
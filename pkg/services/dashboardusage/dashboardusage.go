@@ -0,0 +1,233 @@
+package dashboardusage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// flushInterval is how often the recorded usage is written to the database.
+const flushInterval = time.Minute
+
+func init() {
+	// the search service has to be initialized first to register the sort options
+	registry.RegisterServiceWithPriority(&DashboardUsageService{}, registry.Low)
+}
+
+// DashboardUsageService records how often dashboards are viewed, by whom, and the query load they generate.
+// The usage is recorded in memory and written to the database every minute, so that views and queries do not
+// write to the database.
+type DashboardUsageService struct {
+	Bus           bus.Bus               `inject:""`
+	SQLStore      *sqlstore.SQLStore    `inject:""`
+	SearchService *search.SearchService `inject:""`
+
+	log     log.Logger
+	mutex   sync.Mutex
+	pending map[int64]*pendingUsage
+}
+
+// pendingUsage is the usage of a dashboard recorded since the last flush.
+type pendingUsage struct {
+	usage models.DashboardUsage
+	users map[int64]*models.DashboardUserView
+}
+
+func (s *DashboardUsageService) Init() error {
+	s.log = log.New("dashboardusage")
+	s.pending = map[int64]*pendingUsage{}
+
+	s.Bus.AddEventListener(s.dashboardViewedHandler)
+	s.Bus.AddEventListener(s.dashboardQueriedHandler)
+
+	s.SearchService.RegisterSortOption(sortMostViewed)
+	s.SearchService.RegisterSortOption(sortRecentlyViewed)
+	return nil
+}
+
+func (s *DashboardUsageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flush(ctx); err != nil {
+				s.log.Error("Failed to save dashboard usage", "error", err)
+			}
+		case <-ctx.Done():
+			// the context is done, the last usage is saved without it
+			if err := s.flush(context.Background()); err != nil {
+				s.log.Error("Failed to save dashboard usage", "error", err)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// getPending returns the pending usage of a dashboard. The mutex must be held.
+func (s *DashboardUsageService) getPending(orgID int64, dashboardID int64) *pendingUsage {
+	pending, ok := s.pending[dashboardID]
+	if !ok {
+		pending = &pendingUsage{
+			usage: models.DashboardUsage{OrgId: orgID, DashboardId: dashboardID},
+			users: map[int64]*models.DashboardUserView{},
+		}
+		s.pending[dashboardID] = pending
+	}
+	return pending
+}
+
+func (s *DashboardUsageService) dashboardViewedHandler(event *events.DashboardViewed) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	viewed := event.Timestamp.Unix()
+	pending := s.getPending(event.OrgId, event.DashboardId)
+	pending.usage.Views++
+	pending.usage.LastViewed = viewed
+
+	if event.UserId > 0 {
+		user, ok := pending.users[event.UserId]
+		if !ok {
+			user = &models.DashboardUserView{OrgId: event.OrgId, DashboardId: event.DashboardId, UserId: event.UserId}
+			pending.users[event.UserId] = user
+		}
+		user.Views++
+		user.LastViewed = viewed
+	}
+	return nil
+}
+
+func (s *DashboardUsageService) dashboardQueriedHandler(event *events.DashboardQueried) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pending := s.getPending(event.OrgId, event.DashboardId)
+	pending.usage.Queries += int64(event.Queries)
+	pending.usage.QueryDurationMs += event.Duration.Milliseconds()
+	if event.Failed {
+		pending.usage.QueryErrors++
+	}
+	return nil
+}
+
+// flush writes the usage recorded since the last flush to the database.
+func (s *DashboardUsageService) flush(ctx context.Context) error {
+	s.mutex.Lock()
+	pending := s.pending
+	s.pending = map[int64]*pendingUsage{}
+	s.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// the usage of a dashboard that fails to be saved is kept for the next flush, without stopping the others
+	failed := make([]*pendingUsage, 0)
+	for _, usage := range pending {
+		err := s.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+			return saveUsage(session, usage)
+		})
+		if err != nil {
+			s.log.Warn("Failed to save the usage of a dashboard", "dashboard", usage.usage.DashboardId, "error", err)
+			failed = append(failed, usage)
+		}
+	}
+	if len(failed) > 0 {
+		s.requeue(failed)
+		return fmt.Errorf("failed to save the usage of %d dashboards", len(failed))
+	}
+	return nil
+}
+
+// requeue merges usage that failed to be saved into the usage recorded since, to save it at the next flush.
+func (s *DashboardUsageService) requeue(failed []*pendingUsage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, usage := range failed {
+		pending := s.getPending(usage.usage.OrgId, usage.usage.DashboardId)
+		pending.usage.Views += usage.usage.Views
+		pending.usage.LastViewed = maxInt64(pending.usage.LastViewed, usage.usage.LastViewed)
+		pending.usage.Queries += usage.usage.Queries
+		pending.usage.QueryErrors += usage.usage.QueryErrors
+		pending.usage.QueryDurationMs += usage.usage.QueryDurationMs
+
+		for userID, failedUser := range usage.users {
+			user, ok := pending.users[userID]
+			if !ok {
+				pending.users[userID] = failedUser
+				continue
+			}
+			user.Views += failedUser.Views
+			user.LastViewed = maxInt64(user.LastViewed, failedUser.LastViewed)
+		}
+	}
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func saveUsage(session *sqlstore.DBSession, pending *pendingUsage) error {
+	usage := pending.usage
+	// the dashboard id of query requests is sent by the client, and the dashboard may have been deleted since
+	exists, err := session.Where("id=? AND org_id=?", usage.DashboardId, usage.OrgId).Exist(&models.Dashboard{})
+	if err != nil || !exists {
+		return err
+	}
+
+	result, err := session.Exec(`UPDATE dashboard_usage SET
+			views = views + ?,
+			last_viewed = CASE WHEN last_viewed < ? THEN ? ELSE last_viewed END,
+			queries = queries + ?,
+			query_errors = query_errors + ?,
+			query_duration_ms = query_duration_ms + ?
+		WHERE dashboard_id = ?`,
+		usage.Views, usage.LastViewed, usage.LastViewed, usage.Queries, usage.QueryErrors, usage.QueryDurationMs,
+		usage.DashboardId)
+	if err != nil {
+		return err
+	}
+	if err := insertIfNotUpdated(session, result, &usage); err != nil {
+		return err
+	}
+
+	for _, user := range pending.users {
+		result, err := session.Exec(`UPDATE dashboard_user_view SET
+				views = views + ?,
+				last_viewed = CASE WHEN last_viewed < ? THEN ? ELSE last_viewed END
+			WHERE dashboard_id = ? AND user_id = ?`,
+			user.Views, user.LastViewed, user.LastViewed, user.DashboardId, user.UserId)
+		if err != nil {
+			return err
+		}
+		if err := insertIfNotUpdated(session, result, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertIfNotUpdated inserts a row when the update of its counters did not find it.
+func insertIfNotUpdated(session *sqlstore.DBSession, result sql.Result, row interface{}) error {
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected > 0 {
+		return err
+	}
+	_, err := session.Insert(row)
+	return err
+}
@@ -0,0 +1,199 @@
+package dashboardusage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardUsage(t *testing.T) {
+	service, searchBus := setupTestService(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &models.CreateUserCommand{Login: "viewer", Name: "Viewer", Email: "viewer@example.com"}
+	require.NoError(t, sqlstore.CreateUser(ctx, user))
+	viewed := insertTestDashboard(t, "Viewed")
+	unused := insertTestDashboard(t, "Unused")
+	queried := insertTestDashboard(t, "Queried")
+
+	publish := func(event interface{}) {
+		require.NoError(t, service.Bus.Publish(event))
+	}
+	publish(&events.DashboardViewed{Timestamp: now.Add(-time.Hour), OrgId: 1, DashboardId: viewed.Id, UserId: user.Result.Id})
+	publish(&events.DashboardViewed{Timestamp: now, OrgId: 1, DashboardId: viewed.Id})
+	publish(&events.DashboardViewed{Timestamp: now.Add(time.Hour), OrgId: 1, DashboardId: queried.Id, UserId: user.Result.Id})
+	publish(&events.DashboardQueried{Timestamp: now, OrgId: 1, DashboardId: queried.Id, Queries: 3, Duration: 200 * time.Millisecond})
+	publish(&events.DashboardQueried{Timestamp: now, OrgId: 1, DashboardId: queried.Id, Queries: 1, Duration: 50 * time.Millisecond, Failed: true})
+	// queries of dashboards that do not exist are ignored
+	publish(&events.DashboardQueried{Timestamp: now, OrgId: 1, DashboardId: 1000, Queries: 1})
+	require.NoError(t, service.flush(ctx))
+
+	t.Run("The usage of a dashboard should be returned with the views of its users", func(t *testing.T) {
+		usage, err := service.GetDashboardUsage(ctx, 1, viewed.Id, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), usage.Views)
+		require.Equal(t, now.Unix(), usage.LastViewed.Unix())
+		require.Len(t, usage.Users, 1)
+		require.Equal(t, "viewer", usage.Users[0].Login)
+		require.Equal(t, int64(1), usage.Users[0].Views)
+		require.Equal(t, now.Add(-time.Hour).Unix(), usage.Users[0].LastViewed.Unix())
+	})
+
+	t.Run("The query load of a dashboard should be returned", func(t *testing.T) {
+		usage, err := service.GetDashboardUsage(ctx, 1, queried.Id, false)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), usage.Queries)
+		require.Equal(t, int64(1), usage.QueryErrors)
+		require.Equal(t, int64(250), usage.QueryDurationMs)
+		require.Nil(t, usage.Users)
+	})
+
+	t.Run("A dashboard that has never been viewed should have no usage", func(t *testing.T) {
+		usage, err := service.GetDashboardUsage(ctx, 1, unused.Id, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), usage.Views)
+		require.Nil(t, usage.LastViewed)
+		require.Empty(t, usage.Users)
+	})
+
+	t.Run("The usage of following flushes should be added to the saved usage", func(t *testing.T) {
+		publish(&events.DashboardViewed{Timestamp: now.Add(-2 * time.Hour), OrgId: 1, DashboardId: viewed.Id, UserId: user.Result.Id})
+		require.NoError(t, service.flush(ctx))
+
+		usage, err := service.GetDashboardUsage(ctx, 1, viewed.Id, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), usage.Views)
+		require.Equal(t, now.Unix(), usage.LastViewed.Unix())
+		require.Equal(t, int64(2), usage.Users[0].Views)
+		require.Equal(t, now.Add(-time.Hour).Unix(), usage.Users[0].LastViewed.Unix())
+	})
+
+	t.Run("The dashboards not viewed since a time should be reported as unused", func(t *testing.T) {
+		result, err := service.GetUnusedDashboards(ctx, UnusedDashboardsQuery{OrgID: 1, Since: now.Add(30 * time.Minute)})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.Dashboards, 2)
+		require.Equal(t, unused.Uid, result.Dashboards[0].UID)
+		require.Nil(t, result.Dashboards[0].LastViewed)
+		require.Equal(t, viewed.Uid, result.Dashboards[1].UID)
+		require.Equal(t, int64(3), result.Dashboards[1].Views)
+
+		result, err = service.GetUnusedDashboards(ctx, UnusedDashboardsQuery{OrgID: 1, Since: now.Add(30 * time.Minute), Limit: 1, Page: 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.Dashboards, 1)
+		require.Equal(t, viewed.Uid, result.Dashboards[0].UID)
+	})
+
+	t.Run("The number of unused dashboards per page should be limited", func(t *testing.T) {
+		result, err := service.GetUnusedDashboards(ctx, UnusedDashboardsQuery{OrgID: 1, Since: now.Add(30 * time.Minute)})
+		require.NoError(t, err)
+		require.Equal(t, int64(defaultUnusedDashboardsLimit), result.PerPage)
+
+		result, err = service.GetUnusedDashboards(ctx, UnusedDashboardsQuery{OrgID: 1, Since: now.Add(30 * time.Minute), Limit: maxUnusedDashboardsLimit + 1})
+		require.NoError(t, err)
+		require.Equal(t, int64(maxUnusedDashboardsLimit), result.PerPage)
+		require.Len(t, result.Dashboards, 2)
+	})
+
+	t.Run("Dashboards created after the time should not be reported as unused", func(t *testing.T) {
+		result, err := service.GetUnusedDashboards(ctx, UnusedDashboardsQuery{OrgID: 1, Since: now.AddDate(0, 0, -1)})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), result.TotalCount)
+		require.Empty(t, result.Dashboards)
+	})
+
+	t.Run("Search results should be sorted by views", func(t *testing.T) {
+		hits := searchDashboards(t, searchBus, sortMostViewed.Name)
+		require.Equal(t, []string{"Viewed", "Queried", "Unused"}, hitTitles(hits))
+		require.Equal(t, int64(3), hits[0].SortMeta)
+		require.Equal(t, "views", hits[0].SortMetaName)
+	})
+
+	t.Run("Search results should be sorted by the time they were last viewed", func(t *testing.T) {
+		hits := searchDashboards(t, searchBus, sortRecentlyViewed.Name)
+		require.Equal(t, []string{"Queried", "Viewed", "Unused"}, hitTitles(hits))
+	})
+}
+
+func TestDashboardUsageRequeue(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.Background()
+	now := time.Now()
+	dash := insertTestDashboard(t, "Viewed")
+
+	// the usage of a flush that failed to be saved is added to the usage recorded since
+	failed := &pendingUsage{
+		usage: models.DashboardUsage{OrgId: 1, DashboardId: dash.Id, Views: 2, LastViewed: now.Unix(), Queries: 3},
+		users: map[int64]*models.DashboardUserView{
+			2: {OrgId: 1, DashboardId: dash.Id, UserId: 2, Views: 2, LastViewed: now.Unix()},
+		},
+	}
+	require.NoError(t, service.Bus.Publish(&events.DashboardViewed{Timestamp: now.Add(-time.Hour), OrgId: 1, DashboardId: dash.Id, UserId: 2}))
+	service.requeue([]*pendingUsage{failed})
+	require.NoError(t, service.flush(ctx))
+
+	usage, err := service.GetDashboardUsage(ctx, 1, dash.Id, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), usage.Views)
+	require.Equal(t, int64(3), usage.Queries)
+	require.Equal(t, now.Unix(), usage.LastViewed.Unix())
+}
+
+func setupTestService(t *testing.T) (*DashboardUsageService, bus.Bus) {
+	t.Helper()
+
+	sqlStore := sqlstore.InitTestDB(t)
+	searchService := &search.SearchService{Bus: bus.New()}
+	require.NoError(t, searchService.Init())
+
+	service := &DashboardUsageService{
+		Bus:           bus.New(),
+		SQLStore:      sqlStore,
+		SearchService: searchService,
+	}
+	require.NoError(t, service.Init())
+	return service, searchService.Bus
+}
+
+func insertTestDashboard(t *testing.T, title string) *models.Dashboard {
+	t.Helper()
+
+	cmd := models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"title": title}),
+	}
+	require.NoError(t, bus.Dispatch(&cmd))
+	return cmd.Result
+}
+
+func searchDashboards(t *testing.T, searchBus bus.Bus, sort string) search.HitList {
+	t.Helper()
+
+	query := search.Query{
+		OrgId:        1,
+		SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN},
+		Sort:         sort,
+		Limit:        100,
+		Permission:   models.PERMISSION_VIEW,
+	}
+	require.NoError(t, searchBus.Dispatch(&query))
+	return query.Result
+}
+
+func hitTitles(hits search.HitList) []string {
+	titles := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		titles = append(titles, hit.Title)
+	}
+	return titles
+}
@@ -0,0 +1,148 @@
+package dashboardusage
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// GetDashboardUsage returns the usage of a dashboard, with the views of each user if withUsers is true. The usage
+// recorded since the last flush is not included.
+func (s *DashboardUsageService) GetDashboardUsage(ctx context.Context, orgID int64, dashboardID int64, withUsers bool) (*DashboardUsageDTO, error) {
+	result := &DashboardUsageDTO{DashboardID: dashboardID}
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		usage := models.DashboardUsage{}
+		exists, err := session.Where("dashboard_id=? AND org_id=?", dashboardID, orgID).Get(&usage)
+		if err != nil {
+			return err
+		}
+		if exists {
+			result.Views = usage.Views
+			result.LastViewed = unixTime(usage.LastViewed)
+			result.Queries = usage.Queries
+			result.QueryErrors = usage.QueryErrors
+			result.QueryDurationMs = usage.QueryDurationMs
+		}
+		if !withUsers {
+			return nil
+		}
+
+		views := make([]*dashboardUserView, 0)
+		err = session.SQL(`SELECT
+				dashboard_user_view.user_id,
+				u.login,
+				u.name,
+				dashboard_user_view.views,
+				dashboard_user_view.last_viewed
+			FROM dashboard_user_view
+			INNER JOIN `+s.SQLStore.Dialect.Quote("user")+` AS u ON u.id = dashboard_user_view.user_id
+			WHERE dashboard_user_view.dashboard_id = ? AND dashboard_user_view.org_id = ?
+			ORDER BY dashboard_user_view.last_viewed DESC, u.login ASC`, dashboardID, orgID).Find(&views)
+		if err != nil {
+			return err
+		}
+		result.Users = make([]*DashboardUserViewDTO, 0, len(views))
+		for _, view := range views {
+			result.Users = append(result.Users, &DashboardUserViewDTO{
+				UserID:     view.UserId,
+				Login:      view.Login,
+				Name:       view.Name,
+				Views:      view.Views,
+				LastViewed: time.Unix(view.LastViewed, 0),
+			})
+		}
+		return nil
+	})
+	return result, err
+}
+
+const (
+	// defaultUnusedDashboardsLimit is the number of unused dashboards returned when no limit is requested.
+	defaultUnusedDashboardsLimit = 100
+	// maxUnusedDashboardsLimit is the maximum number of unused dashboards returned at once.
+	maxUnusedDashboardsLimit = 1000
+)
+
+// GetUnusedDashboards returns the dashboards of an organization that have not been viewed since query.Since, the
+// longest unused first. Dashboards created after query.Since are not returned.
+func (s *DashboardUsageService) GetUnusedDashboards(ctx context.Context, query UnusedDashboardsQuery) (*UnusedDashboardsResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultUnusedDashboardsLimit
+	} else if query.Limit > maxUnusedDashboardsLimit {
+		query.Limit = maxUnusedDashboardsLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	result := &UnusedDashboardsResult{
+		Dashboards: make([]*UnusedDashboardDTO, 0),
+		Page:       query.Page,
+		PerPage:    query.Limit,
+	}
+
+	since := query.Since.Unix()
+	where := `dashboard.org_id = ? AND dashboard.is_folder = ` + s.SQLStore.Dialect.BooleanStr(false) + `
+		AND COALESCE(dashboard_usage.last_viewed, 0) < ? AND dashboard.created < ?`
+	params := []interface{}{query.OrgID, since, query.Since}
+
+	err := s.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		count := struct{ Count int64 }{}
+		_, err := session.SQL(`SELECT COUNT(*) AS count FROM dashboard
+			LEFT OUTER JOIN dashboard_usage ON dashboard_usage.dashboard_id = dashboard.id
+			WHERE `+where, params...).Get(&count)
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count.Count
+
+		dashboards := make([]*unusedDashboard, 0)
+		err = session.SQL(`SELECT
+				dashboard.id,
+				dashboard.uid,
+				dashboard.title,
+				dashboard.slug,
+				dashboard.folder_id,
+				folder.title AS folder_title,
+				COALESCE(dashboard_usage.views, 0) AS views,
+				COALESCE(dashboard_usage.last_viewed, 0) AS last_viewed,
+				dashboard.created,
+				dashboard.updated
+			FROM dashboard
+			LEFT OUTER JOIN dashboard_usage ON dashboard_usage.dashboard_id = dashboard.id
+			LEFT OUTER JOIN dashboard AS folder ON folder.id = dashboard.folder_id
+			WHERE `+where+`
+			ORDER BY COALESCE(dashboard_usage.last_viewed, 0) ASC, dashboard.title ASC`+
+			s.SQLStore.Dialect.LimitOffset(query.Limit, (query.Page-1)*query.Limit), params...).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+
+		for _, dash := range dashboards {
+			result.Dashboards = append(result.Dashboards, &UnusedDashboardDTO{
+				ID:          dash.Id,
+				UID:         dash.Uid,
+				Title:       dash.Title,
+				URL:         models.GetDashboardUrl(dash.Uid, dash.Slug),
+				FolderID:    dash.FolderId,
+				FolderTitle: dash.FolderTitle,
+				Views:       dash.Views,
+				LastViewed:  unixTime(dash.LastViewed),
+				Created:     dash.Created,
+				Updated:     dash.Updated,
+			})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// unixTime returns the time of a unix timestamp, nil for 0.
+func unixTime(timestamp int64) *time.Time {
+	if timestamp == 0 {
+		return nil
+	}
+	t := time.Unix(timestamp, 0)
+	return &t
+}
@@ -0,0 +1,82 @@
+package dashboardusage
+
+import (
+	"time"
+)
+
+// DashboardUsageDTO is the usage of a dashboard.
+type DashboardUsageDTO struct {
+	DashboardID int64 `json:"dashboardId"`
+	Views       int64 `json:"views"`
+	// LastViewed is nil if the dashboard has never been viewed.
+	LastViewed      *time.Time `json:"lastViewed"`
+	Queries         int64      `json:"queries"`
+	QueryErrors     int64      `json:"queryErrors"`
+	QueryDurationMs int64      `json:"queryDurationMs"`
+	// Users is how often each user has viewed the dashboard, the most recent first. It is only returned to org
+	// admins.
+	Users []*DashboardUserViewDTO `json:"users,omitempty"`
+}
+
+// DashboardUserViewDTO is how often a user has viewed a dashboard.
+type DashboardUserViewDTO struct {
+	UserID     int64     `json:"userId"`
+	Login      string    `json:"login"`
+	Name       string    `json:"name"`
+	Views      int64     `json:"views"`
+	LastViewed time.Time `json:"lastViewed"`
+}
+
+// UnusedDashboardDTO is a dashboard that has not been viewed for some time.
+type UnusedDashboardDTO struct {
+	ID          int64      `json:"id"`
+	UID         string     `json:"uid"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	FolderID    int64      `json:"folderId"`
+	FolderTitle string     `json:"folderTitle"`
+	Views       int64      `json:"views"`
+	LastViewed  *time.Time `json:"lastViewed"`
+	Created     time.Time  `json:"created"`
+	Updated     time.Time  `json:"updated"`
+}
+
+// UnusedDashboardsQuery selects the dashboards of an organization that have not been viewed since a time and
+// were created before it.
+type UnusedDashboardsQuery struct {
+	OrgID int64
+	Since time.Time
+	Limit int64
+	Page  int64
+}
+
+// UnusedDashboardsResult is a page of unused dashboards, the longest unused first.
+type UnusedDashboardsResult struct {
+	TotalCount int64                 `json:"totalCount"`
+	Dashboards []*UnusedDashboardDTO `json:"dashboards"`
+	Page       int64                 `json:"page"`
+	PerPage    int64                 `json:"perPage"`
+}
+
+// unusedDashboard is a row of the unused dashboards query.
+type unusedDashboard struct {
+	Id          int64
+	Uid         string
+	Title       string
+	Slug        string
+	FolderId    int64
+	FolderTitle string
+	Views       int64
+	LastViewed  int64
+	Created     time.Time
+	Updated     time.Time
+}
+
+// dashboardUserView is a row of the dashboard user views query.
+type dashboardUserView struct {
+	UserId     int64
+	Login      string
+	Name       string
+	Views      int64
+	LastViewed int64
+}
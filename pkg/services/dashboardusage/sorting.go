@@ -0,0 +1,47 @@
+package dashboardusage
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+var (
+	sortMostViewed = search.SortOption{
+		Name:        "views-desc",
+		DisplayName: "Most viewed",
+		Description: "Sort results by the number of views of the dashboards, the most viewed first",
+		Index:       1,
+		MetaName:    "views",
+		Filter: []search.SortOptionFilter{
+			usageSorter{column: "views"},
+		},
+	}
+	sortRecentlyViewed = search.SortOption{
+		Name:        "viewed-desc",
+		DisplayName: "Recently viewed",
+		Description: "Sort results by the time the dashboards were last viewed, the most recent first",
+		Index:       2,
+		Filter: []search.SortOptionFilter{
+			usageSorter{column: "last_viewed"},
+		},
+	}
+)
+
+// usageSorter sorts the search results by a column of the dashboard_usage table. The column is read with a
+// subquery rather than a join so that the results can still be grouped by dashboard when filtering by tags.
+type usageSorter struct {
+	column string
+}
+
+func (s usageSorter) value() string {
+	return fmt.Sprintf("COALESCE((SELECT dashboard_usage.%s FROM dashboard_usage WHERE dashboard_usage.dashboard_id = dashboard.id), 0)", s.column)
+}
+
+func (s usageSorter) OrderBy() string {
+	return fmt.Sprintf("%s DESC, dashboard.title ASC", s.value())
+}
+
+func (s usageSorter) Select() string {
+	return fmt.Sprintf("%s AS sort_meta", s.value())
+}
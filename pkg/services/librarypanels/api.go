@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util"
@@ -25,6 +26,10 @@ func (lps *LibraryPanelService) registerAPIEndpoints() {
 		libraryPanels.Get("/", middleware.ReqSignedIn, routing.Wrap(lps.getAllHandler))
 		libraryPanels.Get("/:uid", middleware.ReqSignedIn, routing.Wrap(lps.getHandler))
 		libraryPanels.Get("/:uid/dashboards/", middleware.ReqSignedIn, routing.Wrap(lps.getConnectedDashboardsHandler))
+		libraryPanels.Get("/:uid/impact", middleware.ReqSignedIn, routing.Wrap(lps.getImpactHandler))
+		libraryPanels.Get("/:uid/versions", middleware.ReqSignedIn, routing.Wrap(lps.getVersionsHandler))
+		libraryPanels.Get("/:uid/versions/:version", middleware.ReqSignedIn, routing.Wrap(lps.getVersionHandler))
+		libraryPanels.Get("/:uid/diff", middleware.ReqSignedIn, routing.Wrap(lps.getDiffHandler))
 		libraryPanels.Patch("/:uid", middleware.ReqSignedIn, binding.Bind(patchLibraryPanelCommand{}), routing.Wrap(lps.patchHandler))
 	})
 }
@@ -99,6 +104,51 @@ func (lps *LibraryPanelService) getConnectedDashboardsHandler(c *models.ReqConte
 	return response.JSON(200, util.DynMap{"result": dashboardIDs})
 }
 
+// getImpactHandler handles GET /api/library-panels/:uid/impact.
+func (lps *LibraryPanelService) getImpactHandler(c *models.ReqContext) response.Response {
+	impact, err := lps.getLibraryPanelImpact(c, c.Params(":uid"))
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel impact")
+	}
+
+	return response.JSON(200, util.DynMap{"result": impact})
+}
+
+// getVersionsHandler handles GET /api/library-panels/:uid/versions.
+func (lps *LibraryPanelService) getVersionsHandler(c *models.ReqContext) response.Response {
+	versions, err := lps.getLibraryPanelVersions(c, c.Params(":uid"), c.QueryInt64("limit"))
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel versions")
+	}
+
+	return response.JSON(200, util.DynMap{"result": versions})
+}
+
+// getVersionHandler handles GET /api/library-panels/:uid/versions/:version.
+func (lps *LibraryPanelService) getVersionHandler(c *models.ReqContext) response.Response {
+	version, err := lps.getLibraryPanelVersion(c, c.Params(":uid"), c.ParamsInt64(":version"))
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel version")
+	}
+
+	return response.JSON(200, util.DynMap{"result": version})
+}
+
+// getDiffHandler handles GET /api/library-panels/:uid/diff.
+func (lps *LibraryPanelService) getDiffHandler(c *models.ReqContext) response.Response {
+	diffType := dashdiffs.ParseDiffType(c.Query("diffType"))
+	result, err := lps.getLibraryPanelDiff(c, c.Params(":uid"), c.QueryInt64("base"), c.QueryInt64("new"), diffType)
+	if err != nil {
+		return toLibraryPanelError(err, "Failed to compute library panel diff")
+	}
+
+	if diffType == dashdiffs.DiffDelta || diffType == dashdiffs.DiffJSONPatch {
+		return response.Respond(200, result.Delta).Header("Content-Type", "application/json")
+	}
+
+	return response.Respond(200, result.Delta).Header("Content-Type", "text/html")
+}
+
 // patchHandler handles PATCH /api/library-panels/:uid
 func (lps *LibraryPanelService) patchHandler(c *models.ReqContext, cmd patchLibraryPanelCommand) response.Response {
 	libraryPanel, err := lps.patchLibraryPanel(c, cmd, c.Params(":uid"))
@@ -113,12 +163,18 @@ func toLibraryPanelError(err error, message string) response.Response {
 	if errors.Is(err, errLibraryPanelAlreadyExists) {
 		return response.Error(400, errLibraryPanelAlreadyExists.Error(), err)
 	}
-	if errors.Is(err, errLibraryPanelNotFound) {
-		return response.Error(404, errLibraryPanelNotFound.Error(), err)
+	if errors.Is(err, ErrLibraryPanelNotFound) {
+		return response.Error(404, ErrLibraryPanelNotFound.Error(), err)
 	}
 	if errors.Is(err, errLibraryPanelDashboardNotFound) {
 		return response.Error(404, errLibraryPanelDashboardNotFound.Error(), err)
 	}
+	if errors.Is(err, errLibraryPanelVersionNotFound) {
+		return response.Error(404, errLibraryPanelVersionNotFound.Error(), err)
+	}
+	if errors.Is(err, errLibraryPanelHeaderPinnedVersionInvalid) {
+		return response.Error(400, errLibraryPanelHeaderPinnedVersionInvalid.Error(), err)
+	}
 	if errors.Is(err, errLibraryPanelVersionMismatch) {
 		return response.Error(412, errLibraryPanelVersionMismatch.Error(), err)
 	}
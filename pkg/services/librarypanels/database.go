@@ -1,11 +1,17 @@
 package librarypanels
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
@@ -45,6 +51,21 @@ func syncTitleWithName(libraryPanel *LibraryPanel) error {
 	return nil
 }
 
+// saveLibraryPanelVersion stores the current version of a Library Panel.
+func saveLibraryPanelVersion(session *sqlstore.DBSession, libraryPanel LibraryPanel, message string) error {
+	version := libraryPanelVersion{
+		LibraryPanelID: libraryPanel.ID,
+		Version:        libraryPanel.Version,
+		Name:           libraryPanel.Name,
+		Model:          libraryPanel.Model,
+		Message:        message,
+		Created:        libraryPanel.Updated,
+		CreatedBy:      libraryPanel.UpdatedBy,
+	}
+	_, err := session.Insert(&version)
+	return err
+}
+
 // createLibraryPanel adds a Library Panel.
 func (lps *LibraryPanelService) createLibraryPanel(c *models.ReqContext, cmd createLibraryPanelCommand) (LibraryPanelDTO, error) {
 	libraryPanel := LibraryPanel{
@@ -76,7 +97,7 @@ func (lps *LibraryPanelService) createLibraryPanel(c *models.ReqContext, cmd cre
 			}
 			return err
 		}
-		return nil
+		return saveLibraryPanelVersion(session, libraryPanel, "")
 	})
 
 	dto := LibraryPanelDTO{
@@ -108,7 +129,7 @@ func (lps *LibraryPanelService) createLibraryPanel(c *models.ReqContext, cmd cre
 	return dto, err
 }
 
-func connectDashboard(session *sqlstore.DBSession, dialect migrator.Dialect, user *models.SignedInUser, uid string, dashboardID int64) error {
+func connectDashboard(session *sqlstore.DBSession, dialect migrator.Dialect, user *models.SignedInUser, uid string, dashboardID int64, pinnedVersion int64) error {
 	panel, err := getLibraryPanel(session, uid, user.OrgId)
 	if err != nil {
 		return err
@@ -116,12 +137,18 @@ func connectDashboard(session *sqlstore.DBSession, dialect migrator.Dialect, use
 	if err := requirePermissionsOnFolder(user, panel.FolderID); err != nil {
 		return err
 	}
+	if pinnedVersion != 0 {
+		if _, err := getLibraryPanelVersion(session, panel.ID, pinnedVersion); err != nil {
+			return err
+		}
+	}
 
 	// TODO add check that dashboard exists
 
 	libraryPanelDashboard := libraryPanelDashboard{
 		DashboardID:    dashboardID,
 		LibraryPanelID: panel.ID,
+		PinnedVersion:  pinnedVersion,
 		Created:        time.Now(),
 		CreatedBy:      user.UserId,
 	}
@@ -137,21 +164,22 @@ func connectDashboard(session *sqlstore.DBSession, dialect migrator.Dialect, use
 // connectDashboard adds a connection between a Library Panel and a Dashboard.
 func (lps *LibraryPanelService) connectDashboard(c *models.ReqContext, uid string, dashboardID int64) error {
 	err := lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
-		return connectDashboard(session, lps.SQLStore.Dialect, c.SignedInUser, uid, dashboardID)
+		return connectDashboard(session, lps.SQLStore.Dialect, c.SignedInUser, uid, dashboardID, 0)
 	})
 
 	return err
 }
 
-// connectLibraryPanelsForDashboard adds connections for all Library Panels in a Dashboard.
-func (lps *LibraryPanelService) connectLibraryPanelsForDashboard(c *models.ReqContext, uids []string, dashboardID int64) error {
+// connectLibraryPanelsForDashboard adds connections for all Library Panels in a Dashboard. The pinned versions are
+// the versions the dashboard is pinned to by Library Panel uid, 0 for the Library Panels following the latest version.
+func (lps *LibraryPanelService) connectLibraryPanelsForDashboard(c *models.ReqContext, uids []string, pinnedVersions map[string]int64, dashboardID int64) error {
 	err := lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
 		_, err := session.Exec("DELETE FROM library_panel_dashboard WHERE dashboard_id=?", dashboardID)
 		if err != nil {
 			return err
 		}
 		for _, uid := range uids {
-			err := connectDashboard(session, lps.SQLStore.Dialect, c.SignedInUser, uid, dashboardID)
+			err := connectDashboard(session, lps.SQLStore.Dialect, c.SignedInUser, uid, dashboardID, pinnedVersions[uid])
 			if err != nil {
 				return err
 			}
@@ -175,6 +203,9 @@ func (lps *LibraryPanelService) deleteLibraryPanel(c *models.ReqContext, uid str
 		if _, err := session.Exec("DELETE FROM library_panel_dashboard WHERE librarypanel_id=?", panel.ID); err != nil {
 			return err
 		}
+		if _, err := session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panel.ID); err != nil {
+			return err
+		}
 
 		result, err := session.Exec("DELETE FROM library_panel WHERE id=?", panel.ID)
 		if err != nil {
@@ -183,7 +214,7 @@ func (lps *LibraryPanelService) deleteLibraryPanel(c *models.ReqContext, uid str
 		if rowsAffected, err := result.RowsAffected(); err != nil {
 			return err
 		} else if rowsAffected != 1 {
-			return errLibraryPanelNotFound
+			return ErrLibraryPanelNotFound
 		}
 
 		return nil
@@ -236,56 +267,224 @@ func (lps *LibraryPanelService) disconnectLibraryPanelsForDashboard(c *models.Re
 // deleteLibraryPanelsInFolder deletes all Library Panels for a folder.
 func (lps *LibraryPanelService) deleteLibraryPanelsInFolder(c *models.ReqContext, folderUID string) error {
 	return lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
-		var folderUIDs []struct {
-			ID int64 `xorm:"id"`
-		}
-		err := session.SQL("SELECT id from dashboard WHERE uid=? AND org_id=? AND is_folder=1", folderUID, c.SignedInUser.OrgId).Find(&folderUIDs)
+		panelIDs, err := getLibraryPanelsInFolderToDelete(session, c.SignedInUser, folderUID)
 		if err != nil {
 			return err
 		}
-		if len(folderUIDs) != 1 {
-			return fmt.Errorf("found %d folders, while expecting at most one", len(folderUIDs))
+		for _, panelID := range panelIDs {
+			_, err := session.Exec("DELETE FROM library_panel_dashboard WHERE librarypanel_id=?", panelID)
+			if err != nil {
+				return err
+			}
+			if _, err := session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panelID); err != nil {
+				return err
+			}
+			if _, err := session.Exec("DELETE FROM library_panel WHERE id=?", panelID); err != nil {
+				return err
+			}
 		}
-		folderID := folderUIDs[0].ID
 
-		if err := requirePermissionsOnFolder(c.SignedInUser, folderID); err != nil {
+		return nil
+	})
+}
+
+// moveLibraryPanelsToTrash deletes the Library Panels of folders moved to the trash, returning them with their
+// versions to be restored with the folders.
+func (lps *LibraryPanelService) moveLibraryPanelsToTrash(ctx context.Context, cmd *models.MoveLibraryPanelsToTrashCommand) error {
+	if !lps.IsEnabled() || len(cmd.FolderIds) == 0 {
+		return nil
+	}
+
+	return lps.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		var trash libraryPanelsInTrash
+		if err := session.Where("org_id=?", cmd.OrgId).In("folder_id", cmd.FolderIds).OrderBy("id").Find(&trash.LibraryPanels); err != nil {
 			return err
 		}
-		var dashIDs []struct {
-			DashboardID int64 `xorm:"dashboard_id"`
+		if len(trash.LibraryPanels) == 0 {
+			return nil
 		}
-		sql := "SELECT lpd.dashboard_id FROM library_panel AS lp"
-		sql += " INNER JOIN library_panel_dashboard lpd on lp.id = lpd.librarypanel_id"
-		sql += " WHERE lp.folder_id=? AND lp.org_id=?"
-		err = session.SQL(sql, folderID, c.SignedInUser.OrgId).Find(&dashIDs)
-		if err != nil {
-			return err
+
+		panelIDs := make([]int64, 0, len(trash.LibraryPanels))
+		for _, panel := range trash.LibraryPanels {
+			panelIDs = append(panelIDs, panel.ID)
 		}
-		if len(dashIDs) > 0 {
-			return ErrFolderHasConnectedLibraryPanels
+		if err := session.In("librarypanel_id", panelIDs).OrderBy("id").Find(&trash.Versions); err != nil {
+			return err
 		}
 
-		var panelIDs []struct {
-			ID int64 `xorm:"id"`
+		for _, panelID := range panelIDs {
+			if _, err := session.Exec("DELETE FROM library_panel_version WHERE librarypanel_id=?", panelID); err != nil {
+				return err
+			}
+			if _, err := session.Exec("DELETE FROM library_panel WHERE id=?", panelID); err != nil {
+				return err
+			}
 		}
-		err = session.SQL("SELECT id from library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId).Find(&panelIDs)
+
+		data, err := json.Marshal(trash)
 		if err != nil {
 			return err
 		}
-		for _, panelID := range panelIDs {
-			_, err := session.Exec("DELETE FROM library_panel_dashboard WHERE librarypanel_id=?", panelID.ID)
+		cmd.Result = data
+		return nil
+	})
+}
+
+// restoreLibraryPanelsFromTrash restores the Library Panels of folders restored from the trash. It fails if a
+// Library Panel with the same id or uid has been created since.
+func (lps *LibraryPanelService) restoreLibraryPanelsFromTrash(ctx context.Context, cmd *models.RestoreLibraryPanelsFromTrashCommand) error {
+	var trash libraryPanelsInTrash
+	if err := json.Unmarshal(cmd.Data, &trash); err != nil {
+		return err
+	}
+
+	return lps.SQLStore.WithTransactionalDbSession(ctx, func(session *sqlstore.DBSession) error {
+		for i := range trash.LibraryPanels {
+			panel := &trash.LibraryPanels[i]
+			exists, err := session.Where("id=? OR (org_id=? AND uid=?)", panel.ID, panel.OrgID, panel.UID).Exist(&LibraryPanel{})
 			if err != nil {
 				return err
 			}
+			if exists {
+				return models.ErrDashboardTrashLibraryPanelExists
+			}
+			if _, err := session.Insert(panel); err != nil {
+				return err
+			}
 		}
-		if _, err := session.Exec("DELETE FROM library_panel WHERE folder_id=? AND org_id=?", folderID, c.SignedInUser.OrgId); err != nil {
-			return err
+		for i := range trash.Versions {
+			version := &trash.Versions[i]
+			version.ID = 0
+			if _, err := session.Insert(version); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
 
+// getLibraryPanelModels gets the models of the Library Panels of a dashboard, to index them with the search terms
+// of the dashboard.
+func (lps *LibraryPanelService) getLibraryPanelModels(ctx context.Context, query *models.GetLibraryPanelModelsQuery) error {
+	query.Result = make(map[models.LibraryPanelRef]*simplejson.Json)
+	if !lps.IsEnabled() {
 		return nil
+	}
+
+	return lps.SQLStore.WithDbSession(ctx, func(session *sqlstore.DBSession) error {
+		for _, ref := range query.Panels {
+			var panel LibraryPanel
+			exists, err := session.Where("org_id=? AND uid=?", query.OrgId, ref.UID).Get(&panel)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			model := panel.Model
+			if ref.Version != 0 {
+				var version libraryPanelVersion
+				exists, err := session.Where("librarypanel_id=? AND version=?", panel.ID, ref.Version).Get(&version)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					continue
+				}
+				model = version.Model
+			}
+			modelJSON, err := simplejson.NewJson(model)
+			if err != nil {
+				return fmt.Errorf("could not convert library panel to simplejson model: %w", err)
+			}
+			query.Result[ref] = modelJSON
+		}
+		return nil
+	})
+}
+
+// updateConnectedDashboardsSearchTerms indexes the search terms of the dashboards connected to a Library Panel
+// again, after a change of the Library Panel.
+func updateConnectedDashboardsSearchTerms(session *sqlstore.DBSession, orgID int64, libraryPanelID int64) error {
+	var dashboardIDs []int64
+	if err := session.Table("library_panel_dashboard").Where("librarypanel_id=?", libraryPanelID).Cols("dashboard_id").Find(&dashboardIDs); err != nil {
+		return err
+	}
+	if len(dashboardIDs) == 0 {
+		return nil
+	}
+
+	ctx := context.WithValue(context.Background(), sqlstore.ContextSessionKey{}, session)
+	return bus.DispatchCtx(ctx, &models.UpdateDashboardSearchTermsCommand{OrgId: orgID, DashboardIds: dashboardIDs})
+}
+
+// validateDeleteLibraryPanelsInFolder checks that the Library Panels of a folder can be deleted, without deleting
+// them.
+func (lps *LibraryPanelService) validateDeleteLibraryPanelsInFolder(c *models.ReqContext, folderUID string) error {
+	return lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		_, err := getLibraryPanelsInFolderToDelete(session, c.SignedInUser, folderUID)
+		return err
 	})
 }
 
+// getLibraryPanelsInFolderToDelete returns the ids of the Library Panels of a folder and its subfolders. It fails
+// if the user cannot delete them, or if any of them is connected to a dashboard.
+func getLibraryPanelsInFolderToDelete(session *sqlstore.DBSession, user *models.SignedInUser, folderUID string) ([]int64, error) {
+	var folderUIDs []struct {
+		ID int64 `xorm:"id"`
+	}
+	err := session.SQL("SELECT id from dashboard WHERE uid=? AND org_id=? AND is_folder=1", folderUID, user.OrgId).Find(&folderUIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(folderUIDs) != 1 {
+		return nil, fmt.Errorf("found %d folders, while expecting at most one", len(folderUIDs))
+	}
+	folderID := folderUIDs[0].ID
+
+	if err := requirePermissionsOnFolder(user, folderID); err != nil {
+		return nil, err
+	}
+
+	// the library panels of the subfolders are deleted with the folder too
+	folderIDs, err := sqlstore.GetFolderSubtreeIds(session, user.OrgId, folderID)
+	if err != nil {
+		return nil, err
+	}
+	folderParams := []interface{}{user.OrgId}
+	for _, id := range folderIDs {
+		folderParams = append(folderParams, id)
+	}
+	inFolders := "lp.org_id=? AND lp.folder_id IN (?" + strings.Repeat(",?", len(folderIDs)-1) + ")"
+
+	var dashIDs []struct {
+		DashboardID int64 `xorm:"dashboard_id"`
+	}
+	sql := "SELECT lpd.dashboard_id FROM library_panel AS lp"
+	sql += " INNER JOIN library_panel_dashboard lpd on lp.id = lpd.librarypanel_id"
+	sql += " WHERE " + inFolders
+	err = session.SQL(sql, folderParams...).Find(&dashIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(dashIDs) > 0 {
+		return nil, ErrFolderHasConnectedLibraryPanels
+	}
+
+	var panelIDs []struct {
+		ID int64 `xorm:"id"`
+	}
+	err = session.SQL("SELECT lp.id from library_panel AS lp WHERE "+inFolders, folderParams...).Find(&panelIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(panelIDs))
+	for _, panelID := range panelIDs {
+		ids = append(ids, panelID.ID)
+	}
+	return ids, nil
+}
+
 func getLibraryPanel(session *sqlstore.DBSession, uid string, orgID int64) (LibraryPanelWithMeta, error) {
 	libraryPanels := make([]LibraryPanelWithMeta, 0)
 	sql := sqlStatmentLibrayPanelDTOWithMeta + "WHERE lp.uid=? AND lp.org_id=?"
@@ -295,7 +494,7 @@ func getLibraryPanel(session *sqlstore.DBSession, uid string, orgID int64) (Libr
 		return LibraryPanelWithMeta{}, err
 	}
 	if len(libraryPanels) == 0 {
-		return LibraryPanelWithMeta{}, errLibraryPanelNotFound
+		return LibraryPanelWithMeta{}, ErrLibraryPanelNotFound
 	}
 	if len(libraryPanels) > 1 {
 		return LibraryPanelWithMeta{}, fmt.Errorf("found %d panels, while expecting at most one", len(libraryPanels))
@@ -324,7 +523,7 @@ func (lps *LibraryPanelService) getLibraryPanel(c *models.ReqContext, uid string
 			return err
 		}
 		if len(libraryPanels) == 0 {
-			return errLibraryPanelNotFound
+			return ErrLibraryPanelNotFound
 		}
 		if len(libraryPanels) > 1 {
 			return fmt.Errorf("found %d panels, while expecting at most one", len(libraryPanels))
@@ -563,7 +762,13 @@ func (lps *LibraryPanelService) patchLibraryPanel(c *models.ReqContext, cmd patc
 			}
 			return err
 		} else if rowsAffected != 1 {
-			return errLibraryPanelNotFound
+			return ErrLibraryPanelNotFound
+		}
+		if err := saveLibraryPanelVersion(session, libraryPanel, cmd.Message); err != nil {
+			return err
+		}
+		if err := updateConnectedDashboardsSearchTerms(session, libraryPanel.OrgID, libraryPanel.ID); err != nil {
+			return err
 		}
 
 		dto = LibraryPanelDTO{
@@ -597,3 +802,258 @@ func (lps *LibraryPanelService) patchLibraryPanel(c *models.ReqContext, cmd patc
 
 	return dto, err
 }
+
+const (
+	// defaultLibraryPanelVersionsLimit is the number of versions returned when no limit is requested.
+	defaultLibraryPanelVersionsLimit = 100
+	// maxLibraryPanelVersionsLimit is the maximum number of versions returned at once.
+	maxLibraryPanelVersionsLimit = 1000
+)
+
+var sqlStatmentLibraryPanelVersionWithMeta = `
+SELECT
+	lpv.id, lpv.librarypanel_id, lpv.version, lpv.name, lpv.model, lpv.message, lpv.created, lpv.created_by
+	, u.login AS created_by_name
+	, u.email AS created_by_email
+FROM library_panel_version AS lpv
+	LEFT JOIN user AS u ON lpv.created_by = u.id
+`
+
+func getLibraryPanelVersion(session *sqlstore.DBSession, libraryPanelID int64, version int64) (libraryPanelVersionWithMeta, error) {
+	versions := make([]libraryPanelVersionWithMeta, 0)
+	sql := sqlStatmentLibraryPanelVersionWithMeta + "WHERE lpv.librarypanel_id=? AND lpv.version=?"
+	if err := session.SQL(sql, libraryPanelID, version).Find(&versions); err != nil {
+		return libraryPanelVersionWithMeta{}, err
+	}
+	if len(versions) == 0 {
+		return libraryPanelVersionWithMeta{}, errLibraryPanelVersionNotFound
+	}
+
+	return versions[0], nil
+}
+
+func toLibraryPanelVersionDTO(version libraryPanelVersionWithMeta, withModel bool) LibraryPanelVersionDTO {
+	dto := LibraryPanelVersionDTO{
+		Version: version.Version,
+		Name:    version.Name,
+		Message: version.Message,
+		Created: version.Created,
+		CreatedBy: LibraryPanelDTOMetaUser{
+			ID:        version.CreatedBy,
+			Name:      version.CreatedByName,
+			AvatarUrl: dtos.GetGravatarUrl(version.CreatedByEmail),
+		},
+	}
+	if withModel {
+		dto.Model = version.Model
+	}
+
+	return dto
+}
+
+// getLibraryPanelVersions gets the versions of a Library Panel, latest first.
+func (lps *LibraryPanelService) getLibraryPanelVersions(c *models.ReqContext, uid string, limit int64) ([]LibraryPanelVersionDTO, error) {
+	panel, err := lps.getLibraryPanel(c, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultLibraryPanelVersionsLimit
+	} else if limit > maxLibraryPanelVersionsLimit {
+		limit = maxLibraryPanelVersionsLimit
+	}
+
+	versions := make([]libraryPanelVersionWithMeta, 0)
+	err = lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		sql := sqlStatmentLibraryPanelVersionWithMeta + "WHERE lpv.librarypanel_id=? ORDER BY lpv.version DESC" + lps.SQLStore.Dialect.Limit(limit)
+		return session.SQL(sql, panel.ID).Find(&versions)
+	})
+
+	result := make([]LibraryPanelVersionDTO, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, toLibraryPanelVersionDTO(version, false))
+	}
+
+	return result, err
+}
+
+// getLibraryPanelVersion gets a version of a Library Panel.
+func (lps *LibraryPanelService) getLibraryPanelVersion(c *models.ReqContext, uid string, version int64) (LibraryPanelVersionDTO, error) {
+	panel, err := lps.getLibraryPanel(c, uid)
+	if err != nil {
+		return LibraryPanelVersionDTO{}, err
+	}
+
+	var libraryPanelVersion libraryPanelVersionWithMeta
+	err = lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		libraryPanelVersion, err = getLibraryPanelVersion(session, panel.ID, version)
+		return err
+	})
+
+	return toLibraryPanelVersionDTO(libraryPanelVersion, true), err
+}
+
+// getPinnedLibraryPanelModel gets the model of the version a dashboard is pinned to.
+func (lps *LibraryPanelService) getPinnedLibraryPanelModel(c *models.ReqContext, libraryPanelID int64, version int64) (json.RawMessage, error) {
+	var model json.RawMessage
+	err := lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		libraryPanelVersion, err := getLibraryPanelVersion(session, libraryPanelID, version)
+		if err != nil {
+			return err
+		}
+		model = libraryPanelVersion.Model
+		return nil
+	})
+
+	return model, err
+}
+
+// getLibraryPanelImpact gets the dashboards connected to a Library Panel, and whether they would change with the next
+// change of the Library Panel.
+func (lps *LibraryPanelService) getLibraryPanelImpact(c *models.ReqContext, uid string) ([]LibraryPanelImpactDTO, error) {
+	impact := make([]LibraryPanelImpactDTO, 0)
+	err := lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		panel, err := getLibraryPanel(session, uid, c.SignedInUser.OrgId)
+		if err != nil {
+			return err
+		}
+		var connections []struct {
+			DashboardID   int64  `xorm:"dashboard_id"`
+			DashboardUID  string `xorm:"dashboard_uid"`
+			Title         string
+			FolderID      int64 `xorm:"folder_id"`
+			PinnedVersion int64 `xorm:"pinned_version"`
+		}
+		builder := sqlstore.SQLBuilder{}
+		builder.Write("SELECT lpd.dashboard_id, dashboard.uid AS dashboard_uid, dashboard.title, dashboard.folder_id, lpd.pinned_version")
+		builder.Write(" FROM library_panel_dashboard lpd")
+		builder.Write(" INNER JOIN dashboard AS dashboard on lpd.dashboard_id = dashboard.id")
+		builder.Write(` WHERE lpd.librarypanel_id=?`, panel.ID)
+		if c.SignedInUser.OrgRole != models.ROLE_ADMIN {
+			builder.WriteDashboardPermissionFilter(c.SignedInUser, models.PERMISSION_VIEW)
+		}
+		builder.Write(" ORDER BY dashboard.title ASC")
+		if err := session.SQL(builder.GetSQLString(), builder.GetParams()...).Find(&connections); err != nil {
+			return err
+		}
+
+		for _, connection := range connections {
+			impact = append(impact, LibraryPanelImpactDTO{
+				DashboardID:   connection.DashboardID,
+				DashboardUID:  connection.DashboardUID,
+				Title:         connection.Title,
+				FolderID:      connection.FolderID,
+				PinnedVersion: connection.PinnedVersion,
+				Affected:      connection.PinnedVersion == 0,
+			})
+		}
+
+		return nil
+	})
+
+	return impact, err
+}
+
+// getLibraryPanelDiff computes the diff of two versions of a Library Panel. The latest version is used when
+// newVersion is 0.
+func (lps *LibraryPanelService) getLibraryPanelDiff(c *models.ReqContext, uid string, baseVersion int64, newVersion int64, diffType dashdiffs.DiffType) (*dashdiffs.Result, error) {
+	panel, err := lps.getLibraryPanel(c, uid)
+	if err != nil {
+		return nil, err
+	}
+	if newVersion == 0 {
+		newVersion = panel.Version
+	}
+
+	var base, target libraryPanelVersionWithMeta
+	err = lps.SQLStore.WithDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		if base, err = getLibraryPanelVersion(session, panel.ID, baseVersion); err != nil {
+			return err
+		}
+		target, err = getLibraryPanelVersion(session, panel.ID, newVersion)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseModel, err := simplejson.NewJson(base.Model)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert library panel to simplejson model: %w", err)
+	}
+	newModel, err := simplejson.NewJson(target.Model)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert library panel to simplejson model: %w", err)
+	}
+
+	result, err := dashdiffs.CalculateDiff(&dashdiffs.Options{
+		OrgId:    c.SignedInUser.OrgId,
+		DiffType: diffType,
+		Base:     dashdiffs.DiffTarget{UnsavedDashboard: baseModel},
+		New:      dashdiffs.DiffTarget{UnsavedDashboard: newModel},
+	})
+	if errors.Is(err, dashdiffs.ErrNilDiff) {
+		// identical versions have an empty diff
+		return &dashdiffs.Result{Delta: []byte{}}, nil
+	}
+
+	return result, err
+}
+
+// importLibraryPanel creates a Library Panel with the UID of the command, or updates the Library Panel having this
+// UID with a new version.
+func (lps *LibraryPanelService) importLibraryPanel(c *models.ReqContext, cmd ImportLibraryPanelCommand) error {
+	return lps.SQLStore.WithTransactionalDbSession(c.Context.Req.Context(), func(session *sqlstore.DBSession) error {
+		libraryPanel := LibraryPanel{
+			OrgID:     c.SignedInUser.OrgId,
+			FolderID:  cmd.FolderID,
+			UID:       cmd.UID,
+			Name:      cmd.Name,
+			Model:     cmd.Model,
+			Version:   1,
+			Created:   time.Now(),
+			Updated:   time.Now(),
+			CreatedBy: c.SignedInUser.UserId,
+			UpdatedBy: c.SignedInUser.UserId,
+		}
+		if err := syncTitleWithName(&libraryPanel); err != nil {
+			return err
+		}
+		if err := requirePermissionsOnFolder(c.SignedInUser, cmd.FolderID); err != nil {
+			return err
+		}
+
+		panelInDB, err := getLibraryPanel(session, cmd.UID, c.SignedInUser.OrgId)
+		if errors.Is(err, ErrLibraryPanelNotFound) {
+			if _, err := session.Insert(&libraryPanel); err != nil {
+				if lps.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+					return errLibraryPanelAlreadyExists
+				}
+				return err
+			}
+			return saveLibraryPanelVersion(session, libraryPanel, "Imported")
+		}
+		if err != nil {
+			return err
+		}
+		if err := requirePermissionsOnFolder(c.SignedInUser, panelInDB.FolderID); err != nil {
+			return err
+		}
+
+		libraryPanel.ID = panelInDB.ID
+		libraryPanel.Version = panelInDB.Version + 1
+		libraryPanel.Created = panelInDB.Created
+		libraryPanel.CreatedBy = panelInDB.CreatedBy
+		if _, err := session.ID(panelInDB.ID).AllCols().Update(&libraryPanel); err != nil {
+			if lps.SQLStore.Dialect.IsUniqueConstraintViolation(err) {
+				return errLibraryPanelAlreadyExists
+			}
+			return err
+		}
+		if err := saveLibraryPanelVersion(session, libraryPanel, "Imported"); err != nil {
+			return err
+		}
+		return updateConnectedDashboardsSearchTerms(session, libraryPanel.OrgID, libraryPanel.ID)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
@@ -30,6 +31,9 @@ func (lps *LibraryPanelService) Init() error {
 	lps.log = log.New("librarypanels")
 
 	lps.registerAPIEndpoints()
+	bus.AddHandlerCtx("librarypanels", lps.moveLibraryPanelsToTrash)
+	bus.AddHandlerCtx("librarypanels", lps.restoreLibraryPanelsFromTrash)
+	bus.AddHandlerCtx("librarypanels", lps.getLibraryPanelModels)
 
 	return nil
 }
@@ -83,8 +87,21 @@ func (lps *LibraryPanelService) LoadLibraryPanelsForDashboard(c *models.ReqConte
 			continue
 		}
 
+		// dashboards pinned to a version of the library panel get the model of that version
+		pinnedVersion, err := getPinnedVersion(libraryPanel)
+		if err != nil {
+			return err
+		}
+		model, version := libraryPanelInDB.Model, libraryPanelInDB.Version
+		if pinnedVersion != 0 {
+			if model, err = lps.getPinnedLibraryPanelModel(c, libraryPanelInDB.ID, pinnedVersion); err != nil {
+				return err
+			}
+			version = pinnedVersion
+		}
+
 		// we have a match between what is stored in db and in dashboard json
-		libraryPanelModel, err := libraryPanelInDB.Model.MarshalJSON()
+		libraryPanelModel, err := model.MarshalJSON()
 		if err != nil {
 			return fmt.Errorf("could not marshal library panel JSON: %w", err)
 		}
@@ -101,10 +118,10 @@ func (lps *LibraryPanelService) LoadLibraryPanelsForDashboard(c *models.ReqConte
 		elem := dash.Data.Get("panels").GetIndex(i)
 		elem.Set("gridPos", panelAsJSON.Get("gridPos").MustMap())
 		elem.Set("id", panelAsJSON.Get("id").MustInt64())
-		elem.Set("libraryPanel", map[string]interface{}{
+		header := map[string]interface{}{
 			"uid":     libraryPanelInDB.UID,
 			"name":    libraryPanelInDB.Name,
-			"version": libraryPanelInDB.Version,
+			"version": version,
 			"meta": map[string]interface{}{
 				"canEdit":             libraryPanelInDB.Meta.CanEdit,
 				"connectedDashboards": libraryPanelInDB.Meta.ConnectedDashboards,
@@ -121,7 +138,11 @@ func (lps *LibraryPanelService) LoadLibraryPanelsForDashboard(c *models.ReqConte
 					"avatarUrl": libraryPanelInDB.Meta.UpdatedBy.AvatarUrl,
 				},
 			},
-		})
+		}
+		if pinnedVersion != 0 {
+			header["pinnedVersion"] = pinnedVersion
+		}
+		elem.Set("libraryPanel", header)
 	}
 
 	return nil
@@ -152,16 +173,25 @@ func (lps *LibraryPanelService) CleanLibraryPanelsForDashboard(dash *models.Dash
 			return errLibraryPanelHeaderNameMissing
 		}
 
+		pinnedVersion, err := getPinnedVersion(libraryPanel)
+		if err != nil {
+			return err
+		}
+
 		// keep only the necessary JSON properties, the rest of the properties should be safely stored in library_panels table
 		gridPos := panelAsJSON.Get("gridPos").MustMap()
 		id := panelAsJSON.Get("id").MustInt64(int64(i))
+		header := map[string]interface{}{
+			"uid":  uid,
+			"name": name,
+		}
+		if pinnedVersion != 0 {
+			header["pinnedVersion"] = pinnedVersion
+		}
 		dash.Data.Get("panels").SetIndex(i, map[string]interface{}{
-			"id":      id,
-			"gridPos": gridPos,
-			"libraryPanel": map[string]interface{}{
-				"uid":  uid,
-				"name": name,
-			},
+			"id":           id,
+			"gridPos":      gridPos,
+			"libraryPanel": header,
 		})
 	}
 
@@ -176,6 +206,7 @@ func (lps *LibraryPanelService) ConnectLibraryPanelsForDashboard(c *models.ReqCo
 
 	panels := dash.Data.Get("panels").MustArray()
 	var libraryPanels []string
+	pinnedVersions := make(map[string]int64)
 	for _, panel := range panels {
 		panelAsJSON := simplejson.NewFromAny(panel)
 		libraryPanel := panelAsJSON.Get("libraryPanel")
@@ -188,10 +219,40 @@ func (lps *LibraryPanelService) ConnectLibraryPanelsForDashboard(c *models.ReqCo
 		if len(uid) == 0 {
 			return errLibraryPanelHeaderUIDMissing
 		}
+		pinnedVersion, err := getPinnedVersion(libraryPanel)
+		if err != nil {
+			return err
+		}
+
+		// a dashboard using a library panel more than once is only pinned when all the panels are pinned to the
+		// same version, so that it shows up in the impact of the library panel changes otherwise
+		if previous, ok := pinnedVersions[uid]; ok {
+			if previous != 0 && previous != pinnedVersion {
+				pinnedVersions[uid] = 0
+			}
+			continue
+		}
 		libraryPanels = append(libraryPanels, uid)
+		pinnedVersions[uid] = pinnedVersion
 	}
 
-	return lps.connectLibraryPanelsForDashboard(c, libraryPanels, dash.Id)
+	return lps.connectLibraryPanelsForDashboard(c, libraryPanels, pinnedVersions, dash.Id)
+}
+
+// getPinnedVersion returns the version of the library panel a dashboard is pinned to, or 0 when the dashboard
+// follows the latest version of the library panel.
+func getPinnedVersion(libraryPanel *simplejson.Json) (int64, error) {
+	value, ok := libraryPanel.CheckGet("pinnedVersion")
+	if !ok || value.Interface() == nil {
+		return 0, nil
+	}
+
+	version, err := value.Int64()
+	if err != nil || version < 0 {
+		return 0, errLibraryPanelHeaderPinnedVersionInvalid
+	}
+
+	return version, nil
 }
 
 // DisconnectLibraryPanelsForDashboard loops through all panels in dashboard JSON and disconnects any library panels from the dashboard.
@@ -220,6 +281,22 @@ func (lps *LibraryPanelService) DisconnectLibraryPanelsForDashboard(c *models.Re
 	return lps.disconnectLibraryPanelsForDashboard(c, dash.Id, panelCount)
 }
 
+// GetLibraryPanel gets the library panel with the given uid.
+func (lps *LibraryPanelService) GetLibraryPanel(c *models.ReqContext, uid string) (LibraryPanelDTO, error) {
+	if !lps.IsEnabled() {
+		return LibraryPanelDTO{}, ErrLibraryPanelNotFound
+	}
+	return lps.getLibraryPanel(c, uid)
+}
+
+// ImportLibraryPanel creates or updates a library panel exported from another instance.
+func (lps *LibraryPanelService) ImportLibraryPanel(c *models.ReqContext, cmd ImportLibraryPanelCommand) error {
+	if !lps.IsEnabled() {
+		return errLibraryPanelsDisabled
+	}
+	return lps.importLibraryPanel(c, cmd)
+}
+
 func (lps *LibraryPanelService) DeleteLibraryPanelsInFolder(c *models.ReqContext, folderUID string) error {
 	if !lps.IsEnabled() {
 		return nil
@@ -227,6 +304,15 @@ func (lps *LibraryPanelService) DeleteLibraryPanelsInFolder(c *models.ReqContext
 	return lps.deleteLibraryPanelsInFolder(c, folderUID)
 }
 
+// ValidateDeleteLibraryPanelsInFolder returns the error DeleteLibraryPanelsInFolder would return, without deleting
+// the library panels. It is used when the library panels are moved to the trash with their folder.
+func (lps *LibraryPanelService) ValidateDeleteLibraryPanelsInFolder(c *models.ReqContext, folderUID string) error {
+	if !lps.IsEnabled() {
+		return nil
+	}
+	return lps.validateDeleteLibraryPanelsInFolder(c, folderUID)
+}
+
 // AddMigration defines database migrations.
 // If Panel Library is not enabled does nothing.
 func (lps *LibraryPanelService) AddMigration(mg *migrator.Migrator) {
@@ -273,4 +359,30 @@ func (lps *LibraryPanelService) AddMigration(mg *migrator.Migrator) {
 
 	mg.AddMigration("create library_panel_dashboard table v1", migrator.NewAddTableMigration(libraryPanelDashboardV1))
 	mg.AddMigration("add index library_panel_dashboard librarypanel_id & dashboard_id", migrator.NewAddIndexMigration(libraryPanelDashboardV1, libraryPanelDashboardV1.Indices[0]))
+	mg.AddMigration("add column pinned_version to library_panel_dashboard", migrator.NewAddColumnMigration(libraryPanelDashboardV1, &migrator.Column{
+		Name: "pinned_version", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	libraryPanelVersionV1 := migrator.Table{
+		Name: "library_panel_version",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "librarypanel_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "model", Type: migrator.DB_Text, Nullable: false},
+			{Name: "message", Type: migrator.DB_Text, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"librarypanel_id", "version"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create library_panel_version table v1", migrator.NewAddTableMigration(libraryPanelVersionV1))
+	mg.AddMigration("add index library_panel_version librarypanel_id & version", migrator.NewAddIndexMigration(libraryPanelVersionV1, libraryPanelVersionV1.Indices[0]))
+	mg.AddMigration("save existing library panels in library_panel_version table", migrator.NewRawSQLMigration(`
+		INSERT INTO library_panel_version (librarypanel_id, version, name, model, message, created, created_by)
+		SELECT id, version, name, model, '', updated, updated_by FROM library_panel`))
 }
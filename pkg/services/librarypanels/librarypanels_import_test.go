@@ -0,0 +1,59 @@
+package librarypanels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportLibraryPanel(t *testing.T) {
+	testScenario(t, "When an admin imports a library panel that does not exist, it should be created with its UID",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.ImportLibraryPanel(sc.reqContext, ImportLibraryPanelCommand{
+				UID:      "imported",
+				FolderID: sc.folder.Id,
+				Name:     "Imported",
+				Model:    []byte(`{"id": 1, "title": "Other title", "type": "text"}`),
+			})
+			require.NoError(t, err)
+
+			panel, err := sc.service.GetLibraryPanel(sc.reqContext, "imported")
+			require.NoError(t, err)
+			require.Equal(t, "Imported", panel.Name)
+			require.Equal(t, sc.folder.Id, panel.FolderID)
+			require.Equal(t, int64(1), panel.Version)
+			require.JSONEq(t, `{"id": 1, "title": "Imported", "type": "text"}`, string(panel.Model))
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports an existing library panel, it should be updated with a new version",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.ImportLibraryPanel(sc.reqContext, ImportLibraryPanelCommand{
+				UID:      sc.initialResult.Result.UID,
+				FolderID: 0,
+				Name:     "Imported",
+				Model:    []byte(`{"id": 1, "title": "Imported", "type": "graph"}`),
+			})
+			require.NoError(t, err)
+
+			panel, err := sc.service.GetLibraryPanel(sc.reqContext, sc.initialResult.Result.UID)
+			require.NoError(t, err)
+			require.Equal(t, int64(0), panel.FolderID)
+			require.Equal(t, int64(2), panel.Version)
+			require.JSONEq(t, `{"id": 1, "title": "Imported", "type": "graph"}`, string(panel.Model))
+
+			version, err := sc.service.getLibraryPanelVersion(sc.reqContext, sc.initialResult.Result.UID, 2)
+			require.NoError(t, err)
+			require.Equal(t, "Imported", version.Message)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin imports a library panel with the name of another library panel, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.ImportLibraryPanel(sc.reqContext, ImportLibraryPanelCommand{
+				UID:      "imported",
+				FolderID: sc.folder.Id,
+				Name:     sc.initialResult.Result.Name,
+				Model:    []byte(`{"id": 1, "type": "text"}`),
+			})
+			require.ErrorIs(t, err, errLibraryPanelAlreadyExists)
+		})
+}
@@ -0,0 +1,58 @@
+package librarypanels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestLibraryPanelsInDashboardSearch(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When a library panel of a dashboard is patched, the dashboard should be found by its new content",
+		func(t *testing.T, sc scenarioContext) {
+			bus.AddHandlerCtx("librarypanels", sc.service.getLibraryPanelModels)
+
+			dash := getDashboardWithLibraryPanel(0, sc.initialResult.Result.UID, sc.initialResult.Result.Name, nil)
+			dash.Data.Set("title", "Dashboard with library panel")
+			cmd := models.SaveDashboardCommand{OrgId: sc.user.OrgId, Dashboard: dash.Data}
+			require.NoError(t, bus.Dispatch(&cmd))
+			require.NoError(t, sc.service.ConnectLibraryPanelsForDashboard(sc.reqContext, cmd.Result))
+
+			searchContent := func(text string) []int64 {
+				query := &search.FindPersistedDashboardsQuery{Title: text, SignedInUser: &sc.user, SearchContent: true}
+				require.NoError(t, sqlstore.SearchDashboards(query))
+				ids := []int64{}
+				for _, hit := range query.Result {
+					ids = append(ids, hit.ID)
+				}
+				return ids
+			}
+			require.Equal(t, []int64{cmd.Result.Id}, searchContent(sc.initialResult.Result.Name))
+
+			patch := patchLibraryPanelCommand{
+				FolderID: -1,
+				Name:     "Renamed Library Panel",
+				Model:    []byte(`{"id": 1, "type": "graph", "targets": [{"refId": "A", "expr": "up"}]}`),
+				Version:  1,
+			}
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.patchHandler(sc.reqContext, patch)
+			require.Equal(t, 200, resp.Status())
+
+			require.Equal(t, []int64{cmd.Result.Id}, searchContent("Renamed Library Panel"))
+			require.Empty(t, searchContent(sc.initialResult.Result.Name))
+
+			query := &models.GetLibraryPanelModelsQuery{
+				OrgId:  sc.user.OrgId,
+				Panels: []models.LibraryPanelRef{{UID: sc.initialResult.Result.UID, Version: 1}},
+			}
+			require.NoError(t, bus.Dispatch(query))
+			model := query.Result[query.Panels[0]]
+			require.NotNil(t, model)
+			require.Equal(t, sc.initialResult.Result.Name, model.Get("title").MustString())
+		})
+}
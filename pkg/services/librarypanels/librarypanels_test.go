@@ -693,6 +693,10 @@ func overrideLibraryPanelServiceInRegistry(cfg *setting.Cfg) LibraryPanelService
 	}
 
 	overrideServiceFunc := func(d registry.Descriptor) (*registry.Descriptor, bool) {
+		if d.Name != "LibraryPanelService" {
+			return nil, false
+		}
+
 		descriptor := registry.Descriptor{
 			Name:         "LibraryPanelService",
 			Instance:     &lps,
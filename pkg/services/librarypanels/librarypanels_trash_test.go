@@ -0,0 +1,94 @@
+package librarypanels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryPanelsInTrash(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin moves a folder with library panels to the trash and restores it, its library panels should be restored",
+		func(t *testing.T, sc scenarioContext) {
+			setTrashRetention(t, time.Hour)
+			bus.AddHandlerCtx("librarypanels", sc.service.moveLibraryPanelsToTrash)
+			bus.AddHandlerCtx("librarypanels", sc.service.restoreLibraryPanelsFromTrash)
+			uid := sc.initialResult.Result.UID
+			subfolder := createFolderWithACL(t, "Subfolder", sc.user, []folderACLItem{})
+			folderService := dashboards.NewFolderService(sc.user.OrgId, &sc.user)
+			err := folderService.MoveFolder(subfolder.Uid, &models.MoveFolderCommand{ParentUid: sc.folder.Uid})
+			require.NoError(t, err)
+			resp := sc.service.createHandler(sc.reqContext, getCreateCommand(subfolder.Id, "Subfolder - Library Panel"))
+			subfolderPanel := validateAndUnMarshalResponse(t, resp)
+
+			require.NoError(t, sc.service.ValidateDeleteLibraryPanelsInFolder(sc.reqContext, sc.folder.Uid))
+			_, err = folderService.DeleteFolder(sc.folder.Uid)
+			require.NoError(t, err)
+
+			_, err = sc.service.GetLibraryPanel(sc.reqContext, uid)
+			require.ErrorIs(t, err, ErrLibraryPanelNotFound)
+			_, err = sc.service.GetLibraryPanel(sc.reqContext, subfolderPanel.Result.UID)
+			require.ErrorIs(t, err, ErrLibraryPanelNotFound)
+			require.Equal(t, int64(0), countRows(t, sc, &LibraryPanel{}))
+			require.Equal(t, int64(0), countRows(t, sc, &libraryPanelVersion{}))
+
+			trashQuery := models.GetDashboardTrashQuery{OrgId: sc.user.OrgId}
+			require.NoError(t, bus.Dispatch(&trashQuery))
+			require.Len(t, trashQuery.Result, 1)
+			_, err = dashboards.NewService().RestoreDashboardFromTrash(trashQuery.Result[0].Id, sc.user.OrgId, &sc.user)
+			require.NoError(t, err)
+
+			panel, err := sc.service.GetLibraryPanel(sc.reqContext, uid)
+			require.NoError(t, err)
+			require.Equal(t, sc.initialResult.Result.Name, panel.Name)
+			require.Equal(t, sc.folder.Id, panel.FolderID)
+			version, err := sc.service.getLibraryPanelVersion(sc.reqContext, uid, 1)
+			require.NoError(t, err)
+			require.Equal(t, sc.initialResult.Result.Name, version.Name)
+
+			panel, err = sc.service.GetLibraryPanel(sc.reqContext, subfolderPanel.Result.UID)
+			require.NoError(t, err)
+			require.Equal(t, subfolder.Id, panel.FolderID)
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin moves a folder with connected library panels to the trash, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID, ":dashboardId": "1"})
+			resp := sc.service.connectHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+
+			err := sc.service.ValidateDeleteLibraryPanelsInFolder(sc.reqContext, sc.folder.Uid)
+			require.ErrorIs(t, err, ErrFolderHasConnectedLibraryPanels)
+			_, err = sc.service.GetLibraryPanel(sc.reqContext, sc.initialResult.Result.UID)
+			require.NoError(t, err)
+		})
+}
+
+func countRows(t *testing.T, sc scenarioContext, bean interface{}) int64 {
+	t.Helper()
+
+	var count int64
+	err := sc.service.SQLStore.WithDbSession(context.Background(), func(session *sqlstore.DBSession) error {
+		var err error
+		count, err = session.Count(bean)
+		return err
+	})
+	require.NoError(t, err)
+	return count
+}
+
+func setTrashRetention(t *testing.T, retention time.Duration) {
+	t.Helper()
+
+	previous := setting.DashboardTrashRetention
+	setting.DashboardTrashRetention = retention
+	t.Cleanup(func() {
+		setting.DashboardTrashRetention = previous
+	})
+}
@@ -0,0 +1,200 @@
+package librarypanels
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type libraryPanelVersionsResult struct {
+	Result []LibraryPanelVersionDTO `json:"result"`
+}
+
+type libraryPanelVersionResult struct {
+	Result LibraryPanelVersionDTO `json:"result"`
+}
+
+type libraryPanelImpactResult struct {
+	Result []LibraryPanelImpactDTO `json:"result"`
+}
+
+func patchLibraryPanelModel(t *testing.T, sc scenarioContext, version int64, datasource string, message string) {
+	cmd := patchLibraryPanelCommand{
+		FolderID: -1,
+		Model:    []byte(`{"datasource": "` + datasource + `", "id": 1, "title": "Text - Library Panel", "type": "text"}`),
+		Version:  version,
+		Message:  message,
+	}
+	sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+	resp := sc.service.patchHandler(sc.reqContext, cmd)
+	require.Equal(t, 200, resp.Status())
+}
+
+func getDashboardWithLibraryPanel(id int64, uid string, name string, pinnedVersion interface{}) *models.Dashboard {
+	header := map[string]interface{}{
+		"uid":  uid,
+		"name": name,
+	}
+	if pinnedVersion != nil {
+		header["pinnedVersion"] = pinnedVersion
+	}
+	dashJSON := map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id": int64(1),
+				"gridPos": map[string]interface{}{
+					"h": 6,
+					"w": 6,
+					"x": 0,
+					"y": 0,
+				},
+				"libraryPanel": header,
+			},
+		},
+	}
+
+	return &models.Dashboard{
+		Id:   id,
+		Data: simplejson.NewFromAny(dashJSON),
+	}
+}
+
+func TestLibraryPanelVersions(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin patches a library panel, it should store a new version",
+		func(t *testing.T, sc scenarioContext) {
+			patchLibraryPanelModel(t, sc, 1, "prometheus", "Use prometheus")
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.getVersionsHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var result libraryPanelVersionsResult
+			require.NoError(t, json.Unmarshal(resp.Body(), &result))
+			require.Len(t, result.Result, 2)
+			require.Equal(t, int64(2), result.Result[0].Version)
+			require.Equal(t, "Use prometheus", result.Result[0].Message)
+			require.Equal(t, UserInDbName, result.Result[0].CreatedBy.Name)
+			require.Nil(t, result.Result[0].Model)
+			require.Equal(t, int64(1), result.Result[1].Version)
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID, ":version": "1"})
+			resp = sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var version libraryPanelVersionResult
+			require.NoError(t, json.Unmarshal(resp.Body(), &version))
+			model, err := simplejson.NewJson(version.Result.Model)
+			require.NoError(t, err)
+			require.Equal(t, "${DS_GDEV-TESTDATA}", model.Get("datasource").MustString())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin gets a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID, ":version": "5"})
+			resp := sc.service.getVersionHandler(sc.reqContext)
+			require.Equal(t, 404, resp.Status())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin gets library panel versions with a limit, it should be clamped",
+		func(t *testing.T, sc scenarioContext) {
+			patchLibraryPanelModel(t, sc, 1, "prometheus", "")
+			patchLibraryPanelModel(t, sc, 2, "loki", "")
+
+			for limit, expected := range map[string]int{"1": 1, "-1": 3, "100000": 3, "": 3} {
+				sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+				sc.ctx.Req.Form = map[string][]string{"limit": {limit}}
+				resp := sc.service.getVersionsHandler(sc.reqContext)
+				require.Equal(t, 200, resp.Status())
+				var result libraryPanelVersionsResult
+				require.NoError(t, json.Unmarshal(resp.Body(), &result))
+				require.Len(t, result.Result, expected, "limit %q", limit)
+			}
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin diffs two library panel versions, it should return the changes",
+		func(t *testing.T, sc scenarioContext) {
+			patchLibraryPanelModel(t, sc, 1, "prometheus", "")
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			sc.ctx.Req.Form = map[string][]string{"base": {"1"}, "diffType": {"jsonpatch"}}
+			resp := sc.service.getDiffHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			require.JSONEq(t, `[{"op": "replace", "path": "/datasource", "value": "prometheus"}]`, string(resp.Body()))
+		})
+}
+
+func TestLibraryPanelPinnedVersions(t *testing.T) {
+	scenarioWithLibraryPanel(t, "When an admin loads a dashboard pinned to a library panel version, it should use the model of that version",
+		func(t *testing.T, sc scenarioContext) {
+			patchLibraryPanelModel(t, sc, 1, "prometheus", "")
+			dash := getDashboardWithLibraryPanel(1, sc.initialResult.Result.UID, sc.initialResult.Result.Name, json.Number("1"))
+			require.NoError(t, sc.service.ConnectLibraryPanelsForDashboard(sc.reqContext, dash))
+
+			require.NoError(t, sc.service.LoadLibraryPanelsForDashboard(sc.reqContext, dash))
+			panel := dash.Data.Get("panels").GetIndex(0)
+			require.Equal(t, "${DS_GDEV-TESTDATA}", panel.Get("datasource").MustString())
+			require.Equal(t, int64(1), panel.Get("libraryPanel").Get("version").MustInt64())
+			require.Equal(t, int64(1), panel.Get("libraryPanel").Get("pinnedVersion").MustInt64())
+
+			latest := getDashboardWithLibraryPanel(1, sc.initialResult.Result.UID, sc.initialResult.Result.Name, nil)
+			require.NoError(t, sc.service.LoadLibraryPanelsForDashboard(sc.reqContext, latest))
+			panel = latest.Data.Get("panels").GetIndex(0)
+			require.Equal(t, "prometheus", panel.Get("datasource").MustString())
+			require.Equal(t, int64(2), panel.Get("libraryPanel").Get("version").MustInt64())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin stores a dashboard pinned to a library panel version, it should keep the pinned version",
+		func(t *testing.T, sc scenarioContext) {
+			dash := getDashboardWithLibraryPanel(1, sc.initialResult.Result.UID, sc.initialResult.Result.Name, json.Number("1"))
+			require.NoError(t, sc.service.CleanLibraryPanelsForDashboard(dash))
+			require.Equal(t, map[string]interface{}{
+				"uid":           sc.initialResult.Result.UID,
+				"name":          sc.initialResult.Result.Name,
+				"pinnedVersion": int64(1),
+			}, dash.Data.Get("panels").GetIndex(0).Get("libraryPanel").MustMap())
+
+			invalid := getDashboardWithLibraryPanel(1, sc.initialResult.Result.UID, sc.initialResult.Result.Name, "latest")
+			require.EqualError(t, sc.service.CleanLibraryPanelsForDashboard(invalid), errLibraryPanelHeaderPinnedVersionInvalid.Error())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin connects a dashboard pinned to a library panel version that does not exist, it should fail",
+		func(t *testing.T, sc scenarioContext) {
+			dash := getDashboardWithLibraryPanel(1, sc.initialResult.Result.UID, sc.initialResult.Result.Name, json.Number("5"))
+			err := sc.service.ConnectLibraryPanelsForDashboard(sc.reqContext, dash)
+			require.EqualError(t, err, errLibraryPanelVersionNotFound.Error())
+		})
+
+	scenarioWithLibraryPanel(t, "When an admin gets the impact of a library panel, it should list the dashboards following the latest version as affected",
+		func(t *testing.T, sc scenarioContext) {
+			following := createDashboard(t, sc.user, "Following", sc.folder.Id)
+			pinned := createDashboard(t, sc.user, "Pinned", sc.folder.Id)
+			dash := getDashboardWithLibraryPanel(following.Id, sc.initialResult.Result.UID, sc.initialResult.Result.Name, nil)
+			require.NoError(t, sc.service.ConnectLibraryPanelsForDashboard(sc.reqContext, dash))
+			dash = getDashboardWithLibraryPanel(pinned.Id, sc.initialResult.Result.UID, sc.initialResult.Result.Name, json.Number("1"))
+			require.NoError(t, sc.service.ConnectLibraryPanelsForDashboard(sc.reqContext, dash))
+
+			sc.reqContext.ReplaceAllParams(map[string]string{":uid": sc.initialResult.Result.UID})
+			resp := sc.service.getImpactHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var result libraryPanelImpactResult
+			require.NoError(t, json.Unmarshal(resp.Body(), &result))
+			require.Equal(t, []LibraryPanelImpactDTO{
+				{
+					DashboardID:  following.Id,
+					DashboardUID: following.Uid,
+					Title:        "Following",
+					FolderID:     sc.folder.Id,
+					Affected:     true,
+				},
+				{
+					DashboardID:   pinned.Id,
+					DashboardUID:  pinned.Uid,
+					Title:         "Pinned",
+					FolderID:      sc.folder.Id,
+					PinnedVersion: 1,
+				},
+			}, result.Result)
+		})
+}
@@ -77,22 +77,84 @@ type LibraryPanelDTOMetaUser struct {
 	AvatarUrl string `json:"avatarUrl"`
 }
 
-// libraryPanelDashboard is the model for library panel connections.
+// libraryPanelDashboard is the model for library panel connections. A dashboard either follows the latest version
+// of a library panel, when PinnedVersion is 0, or is pinned to a specific version.
 type libraryPanelDashboard struct {
 	ID             int64 `xorm:"pk autoincr 'id'"`
 	LibraryPanelID int64 `xorm:"librarypanel_id"`
 	DashboardID    int64 `xorm:"dashboard_id"`
+	PinnedVersion  int64 `xorm:"pinned_version"`
 
 	Created time.Time
 
 	CreatedBy int64
 }
 
+// libraryPanelVersion is the model for the versions of library panels. A version is stored every time a library
+// panel is created or changed.
+type libraryPanelVersion struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	Version        int64
+	Name           string
+	Model          json.RawMessage
+	Message        string
+
+	Created time.Time
+
+	CreatedBy int64
+}
+
+// libraryPanelsInTrash is the content of the Library Panels of folders in the trash. Library Panels connected to
+// dashboards cannot be deleted, so there are no connections to keep.
+type libraryPanelsInTrash struct {
+	LibraryPanels []LibraryPanel        `json:"libraryPanels"`
+	Versions      []libraryPanelVersion `json:"versions"`
+}
+
+// libraryPanelVersionWithMeta is the model used to retrieve library panel versions with additional meta information.
+type libraryPanelVersionWithMeta struct {
+	ID             int64 `xorm:"pk autoincr 'id'"`
+	LibraryPanelID int64 `xorm:"librarypanel_id"`
+	Version        int64
+	Name           string
+	Model          json.RawMessage
+	Message        string
+
+	Created time.Time
+
+	CreatedBy      int64
+	CreatedByName  string
+	CreatedByEmail string
+}
+
+// LibraryPanelVersionDTO is the frontend DTO for library panel versions. The model is only returned when a
+// single version is requested.
+type LibraryPanelVersionDTO struct {
+	Version   int64                   `json:"version"`
+	Name      string                  `json:"name"`
+	Model     json.RawMessage         `json:"model,omitempty"`
+	Message   string                  `json:"message"`
+	Created   time.Time               `json:"created"`
+	CreatedBy LibraryPanelDTOMetaUser `json:"createdBy"`
+}
+
+// LibraryPanelImpactDTO is a dashboard connected to a library panel. Affected is true when the dashboard follows the
+// latest version of the library panel, and would therefore change with the next change of the library panel.
+type LibraryPanelImpactDTO struct {
+	DashboardID   int64  `json:"dashboardId"`
+	DashboardUID  string `json:"dashboardUid"`
+	Title         string `json:"title"`
+	FolderID      int64  `json:"folderId"`
+	PinnedVersion int64  `json:"pinnedVersion"`
+	Affected      bool   `json:"affected"`
+}
+
 var (
 	// errLibraryPanelAlreadyExists is an error for when the user tries to add a library panel that already exists.
 	errLibraryPanelAlreadyExists = errors.New("library panel with that name already exists")
-	// errLibraryPanelNotFound is an error for when a library panel can't be found.
-	errLibraryPanelNotFound = errors.New("library panel could not be found")
+	// ErrLibraryPanelNotFound is an error for when a library panel can't be found.
+	ErrLibraryPanelNotFound = errors.New("library panel could not be found")
 	// errLibraryPanelDashboardNotFound is an error for when a library panel connection can't be found.
 	errLibraryPanelDashboardNotFound = errors.New("library panel connection could not be found")
 	// errLibraryPanelHeaderUIDMissing is an error for when a library panel header is missing the uid property.
@@ -103,6 +165,12 @@ var (
 	ErrFolderHasConnectedLibraryPanels = errors.New("folder contains library panels that are linked to dashboards")
 	// errLibraryPanelVersionMismatch is an error for when a library panel has been changed by someone else.
 	errLibraryPanelVersionMismatch = errors.New("the library panel has been changed by someone else")
+	// errLibraryPanelVersionNotFound is an error for when a library panel version can't be found.
+	errLibraryPanelVersionNotFound = errors.New("library panel version could not be found")
+	// errLibraryPanelsDisabled is an error for when library panels are imported while the Panel Library is disabled.
+	errLibraryPanelsDisabled = errors.New("the panel library is not enabled")
+	// errLibraryPanelHeaderPinnedVersionInvalid is an error for when a library panel header has an invalid pinned version.
+	errLibraryPanelHeaderPinnedVersionInvalid = errors.New("library panel header has an invalid pinnedVersion property")
 )
 
 // Commands
@@ -120,4 +188,14 @@ type patchLibraryPanelCommand struct {
 	Name     string          `json:"name"`
 	Model    json.RawMessage `json:"model"`
 	Version  int64           `json:"version" binding:"Required"`
+	Message  string          `json:"message"`
+}
+
+// ImportLibraryPanelCommand is the command for importing a LibraryPanel exported from another instance. The
+// LibraryPanel keeps its UID, so it is created when no LibraryPanel has this UID and updated otherwise.
+type ImportLibraryPanelCommand struct {
+	UID      string
+	FolderID int64
+	Name     string
+	Model    json.RawMessage
 }
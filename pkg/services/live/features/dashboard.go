@@ -10,8 +10,9 @@ import (
 // DashboardEvent events related to dashboards
 type dashboardEvent struct {
 	UID       string `json:"uid"`
-	Action    string `json:"action"` // saved, editing
+	Action    string `json:"action"` // saved, merged, editing, deleted
 	UserID    int64  `json:"userId,omitempty"`
+	Version   int    `json:"version,omitempty"`
 	SessionID string `json:"sessionId,omitempty"`
 }
 
@@ -64,6 +65,17 @@ func (h *DashboardHandler) DashboardSaved(uid string, userID int64) error {
 	})
 }
 
+// DashboardMerged will broadcast to all connected dashboards that changes have been merged with the changes
+// saved by someone else. Editors of the dashboard need to reload the merged version.
+func (h *DashboardHandler) DashboardMerged(uid string, userID int64, version int) error {
+	return h.publish(dashboardEvent{
+		UID:     uid,
+		Action:  "merged",
+		UserID:  userID,
+		Version: version,
+	})
+}
+
 // DashboardDeleted will broadcast to all connected dashboards
 func (h *DashboardHandler) DashboardDeleted(uid string, userID int64) error {
 	return h.publish(dashboardEvent{
@@ -0,0 +1,49 @@
+package dashboards
+
+import "sync"
+
+// backgroundRunner runs a function in the background, at most once at a time. The runs requested while it is
+// running are coalesced into a single run after it.
+type backgroundRunner struct {
+	run func()
+
+	mutex   sync.Mutex
+	running bool
+	pending bool
+	done    sync.WaitGroup
+}
+
+// request runs the function in the background, or after the current run if it is running.
+func (b *backgroundRunner) request() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.running {
+		b.pending = true
+		return
+	}
+	b.running = true
+	b.done.Add(1)
+	go b.loop()
+}
+
+func (b *backgroundRunner) loop() {
+	defer b.done.Done()
+	for {
+		b.run()
+
+		b.mutex.Lock()
+		if !b.pending {
+			b.running = false
+			b.mutex.Unlock()
+			return
+		}
+		b.pending = false
+		b.mutex.Unlock()
+	}
+}
+
+// wait waits until the requested runs are done.
+func (b *backgroundRunner) wait() {
+	b.done.Wait()
+}
@@ -19,10 +19,13 @@ type DashboardProvisioner interface {
 	GetProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 	CleanUpOrphanedDashboards()
+	HandleRepositoryWebhook(name string, body []byte, signature string, token string) error
+	CommitDashboardToRepository(name string, externalID string, dash *models.Dashboard, message string,
+		user *models.SignedInUser) error
 }
 
-// DashboardProvisionerFactory creates DashboardProvisioners based on input
-type DashboardProvisionerFactory func(string) (DashboardProvisioner, error)
+// DashboardProvisionerFactory creates DashboardProvisioners based on the config directory and the data path
+type DashboardProvisionerFactory func(configDirectory string, dataPath string) (DashboardProvisioner, error)
 
 // Provisioner is responsible for syncing dashboard from disk to Grafana's database.
 type Provisioner struct {
@@ -31,8 +34,8 @@ type Provisioner struct {
 	configs     []*config
 }
 
-// New returns a new DashboardProvisioner
-func New(configDirectory string) (*Provisioner, error) {
+// New returns a new DashboardProvisioner. Git repositories are cloned in the data path.
+func New(configDirectory string, dataPath string) (*Provisioner, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger}
 	configs, err := cfgReader.readConfig()
@@ -41,7 +44,7 @@ func New(configDirectory string) (*Provisioner, error) {
 		return nil, errutil.Wrap("Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger)
+	fileReaders, err := getFileReaders(configs, dataPath, logger)
 	if err != nil {
 		return nil, errutil.Wrap("Failed to initialize file readers", err)
 	}
@@ -115,7 +118,43 @@ func (provider *Provisioner) GetAllowUIUpdatesFromConfig(name string) bool {
 	return false
 }
 
-func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error) {
+// HandleRepositoryWebhook verifies a webhook of the git repository of a provisioner and syncs the
+// dashboards of the repository in the background. Webhooks received during a sync result in a single sync after it.
+func (provider *Provisioner) HandleRepositoryWebhook(name string, body []byte, signature string, token string) error {
+	reader := provider.getFileReader(name)
+	if reader == nil || reader.repository == nil {
+		return ErrRepositoryNotFound
+	}
+
+	if err := reader.repository.verifyWebhook(body, signature, token); err != nil {
+		return err
+	}
+
+	reader.webhookSyncs.request()
+	return nil
+}
+
+// CommitDashboardToRepository commits a dashboard saved from the UI to the git repository it was provisioned
+// from, when the provisioner has a commit branch. The commit is pushed in the background.
+func (provider *Provisioner) CommitDashboardToRepository(name string, externalID string, dash *models.Dashboard,
+	message string, user *models.SignedInUser) error {
+	reader := provider.getFileReader(name)
+	if reader == nil {
+		return nil
+	}
+	return reader.commitDashboard(externalID, dash, message, user)
+}
+
+func (provider *Provisioner) getFileReader(name string) *FileReader {
+	for _, reader := range provider.fileReaders {
+		if reader.Cfg.Name == name {
+			return reader
+		}
+	}
+	return nil
+}
+
+func getFileReaders(configs []*config, dataPath string, logger log.Logger) ([]*FileReader, error) {
 	var readers []*FileReader
 
 	for _, config := range configs {
@@ -126,6 +165,12 @@ func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error)
 				return nil, errutil.Wrapf(err, "Failed to create file reader for config %v", config.Name)
 			}
 			readers = append(readers, fileReader)
+		case "git":
+			gitReader, err := NewDashboardGitReader(config, dataPath, logger.New("type", config.Type, "name", config.Name))
+			if err != nil {
+				return nil, errutil.Wrapf(err, "Failed to create git reader for config %v", config.Name)
+			}
+			readers = append(readers, gitReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
 		}
@@ -1,6 +1,10 @@
 package dashboards
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+)
 
 // Calls is a mock implementation of the provisioner interface
 type calls struct {
@@ -8,6 +12,8 @@ type calls struct {
 	PollChanges                 []interface{}
 	GetProvisionerResolvedPath  []interface{}
 	GetAllowUIUpdatesFromConfig []interface{}
+	HandleRepositoryWebhook     []interface{}
+	CommitDashboardToRepository []interface{}
 }
 
 // ProvisionerMock is a mock implementation of `Provisioner`
@@ -17,6 +23,9 @@ type ProvisionerMock struct {
 	PollChangesFunc                 func(ctx context.Context)
 	GetProvisionerResolvedPathFunc  func(name string) string
 	GetAllowUIUpdatesFromConfigFunc func(name string) bool
+	HandleRepositoryWebhookFunc     func(name string, body []byte, signature string, token string) error
+	CommitDashboardToRepositoryFunc func(name string, externalID string, dash *models.Dashboard, message string,
+		user *models.SignedInUser) error
 }
 
 // NewDashboardProvisionerMock returns a new dashboardprovisionermock
@@ -63,3 +72,22 @@ func (dpm *ProvisionerMock) GetAllowUIUpdatesFromConfig(name string) bool {
 
 // CleanUpOrphanedDashboards not implemented for mocks
 func (dpm *ProvisionerMock) CleanUpOrphanedDashboards() {}
+
+// HandleRepositoryWebhook is a mock implementation of `Provisioner.HandleRepositoryWebhook`
+func (dpm *ProvisionerMock) HandleRepositoryWebhook(name string, body []byte, signature string, token string) error {
+	dpm.Calls.HandleRepositoryWebhook = append(dpm.Calls.HandleRepositoryWebhook, name)
+	if dpm.HandleRepositoryWebhookFunc != nil {
+		return dpm.HandleRepositoryWebhookFunc(name, body, signature, token)
+	}
+	return nil
+}
+
+// CommitDashboardToRepository is a mock implementation of `Provisioner.CommitDashboardToRepository`
+func (dpm *ProvisionerMock) CommitDashboardToRepository(name string, externalID string, dash *models.Dashboard,
+	message string, user *models.SignedInUser) error {
+	dpm.Calls.CommitDashboardToRepository = append(dpm.Calls.CommitDashboardToRepository, name)
+	if dpm.CommitDashboardToRepositoryFunc != nil {
+		return dpm.CommitDashboardToRepositoryFunc(name, externalID, dash, message, user)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package dashboards
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// dashboardFileExtensions are the extensions of the dashboard files read by the file reader. Jsonnet libraries,
+// `.libsonnet` files, are not dashboards but can be imported by the jsonnet dashboards.
+var dashboardFileExtensions = map[string]bool{
+	".json":    true,
+	".jsonnet": true,
+	".yaml":    true,
+	".yml":     true,
+}
+
+func isDashboardFile(name string) bool {
+	return dashboardFileExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// isEvaluatedDashboardFile returns whether a dashboard file is evaluated rather than read as is. The output of
+// jsonnet files depends on their imports and external variables, so it can change without the file changing.
+func isEvaluatedDashboardFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".jsonnet"
+}
+
+// jsonnetOptions are the options of the jsonnet dashboards of a file reader.
+type jsonnetOptions struct {
+	libraryPaths []string
+	extVars      map[string]string
+}
+
+// parseJsonnetOptions reads the jsonnetLibraryPaths and jsonnetExtVars options of a provisioner. Relative library
+// paths are relative to baseDir.
+func parseJsonnetOptions(cfg *config, baseDir string) (jsonnetOptions, error) {
+	options := jsonnetOptions{extVars: map[string]string{}}
+
+	if value, ok := cfg.Options["jsonnetLibraryPaths"]; ok {
+		paths, ok := value.([]interface{})
+		if !ok {
+			return options, fmt.Errorf("jsonnetLibraryPaths param is not a list")
+		}
+		for _, item := range paths {
+			path, ok := item.(string)
+			if !ok {
+				return options, fmt.Errorf("jsonnetLibraryPaths param is not a list of strings")
+			}
+			if !filepath.IsAbs(path) && baseDir != "" {
+				path = filepath.Join(baseDir, path)
+			}
+			options.libraryPaths = append(options.libraryPaths, path)
+		}
+	}
+
+	if value, ok := cfg.Options["jsonnetExtVars"]; ok {
+		vars, ok := value.(map[string]interface{})
+		if !ok {
+			return options, fmt.Errorf("jsonnetExtVars param is not a map")
+		}
+		for name, item := range vars {
+			options.extVars[name] = fmt.Sprint(item)
+		}
+	}
+
+	return options, nil
+}
+
+// evaluateDashboardFile returns the JSON of a dashboard file. Jsonnet files are evaluated and YAML files are
+// converted to JSON. Errors report the file and line.
+func (fr *FileReader) evaluateDashboardFile(path string, content []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonnet":
+		vm := jsonnet.MakeVM()
+		vm.Importer(newConfinedImporter(path, fr.jsonnet.libraryPaths, append([]string{fr.Path}, fr.jsonnet.libraryPaths...)))
+		for name, value := range fr.jsonnet.extVars {
+			vm.ExtVar(name, value)
+		}
+		output, err := vm.EvaluateAnonymousSnippet(path, string(content))
+		if err != nil {
+			// jsonnet errors start with the file and the position of the error
+			return nil, fmt.Errorf("failed to evaluate jsonnet: %s", strings.TrimSpace(err.Error()))
+		}
+		return []byte(output), nil
+	case ".yaml", ".yml":
+		var value interface{}
+		if err := yaml.Unmarshal(content, &value); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return json.Marshal(convertYAMLValue(value))
+	default:
+		return content, nil
+	}
+}
+
+// confinedImporter is a jsonnet file importer whose import and importstr are confined to allowed directories:
+// the path of the provisioner and the library paths. Symbolic links are resolved before checking the imported
+// files, so that a link cannot escape the directories.
+type confinedImporter struct {
+	importer    *jsonnet.FileImporter
+	file        string
+	allowedDirs []string
+}
+
+// newConfinedImporter returns the importer of a jsonnet file. The imports of the file itself are relative to it.
+func newConfinedImporter(file string, libraryPaths []string, allowedDirs []string) *confinedImporter {
+	dirs := make([]string, 0, len(allowedDirs))
+	for _, dir := range allowedDirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dirs = append(dirs, abs)
+		}
+	}
+	return &confinedImporter{
+		importer:    &jsonnet.FileImporter{JPaths: libraryPaths},
+		file:        file,
+		allowedDirs: dirs,
+	}
+}
+
+// Import imports a file with the jsonnet file importer, failing if the file is not in the allowed directories.
+func (i *confinedImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	// the evaluated snippet has no file name
+	if importedFrom == "" {
+		importedFrom = i.file
+	}
+	contents, foundAt, err := i.importer.Import(importedFrom, importedPath)
+	if err != nil {
+		return contents, foundAt, err
+	}
+
+	abs, err := filepath.Abs(foundAt)
+	if err != nil {
+		return jsonnet.Contents{}, "", err
+	}
+	if _, err := util.ResolvePathWithin(abs, i.allowedDirs); err != nil {
+		return jsonnet.Contents{}, "", fmt.Errorf("import %q is not allowed: %w", importedPath, err)
+	}
+	return contents, foundAt, nil
+}
+
+// jsonFileError adds the file and, for syntax errors, the line to an error decoding a JSON file.
+func jsonFileError(path string, content []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Offset <= int64(len(content)) {
+		line := bytes.Count(content[:syntaxErr.Offset], []byte("\n")) + 1
+		return fmt.Errorf("%s:%d: %w", path, line, err)
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// convertYAMLValue converts the maps decoded from YAML, having keys of any type, to JSON objects.
+func convertYAMLValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			object[fmt.Sprint(key)] = convertYAMLValue(item)
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			array = append(array, convertYAMLValue(item))
+		}
+		return array
+	default:
+		return value
+	}
+}
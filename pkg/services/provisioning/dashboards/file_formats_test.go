@@ -0,0 +1,139 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	jsonnetAndYAMLDashboards = "testdata/test-dashboards/jsonnet-and-yaml"
+	brokenFormatDashboards   = "testdata/test-dashboards/broken-formats"
+)
+
+func TestDashboardFileFormats(t *testing.T) {
+	bus.ClearBusHandlers()
+	fakeService = mockDashboardProvisioningService()
+
+	cfg := &config{
+		Name:  "Default",
+		Type:  "file",
+		OrgID: 1,
+		Options: map[string]interface{}{
+			"path":                jsonnetAndYAMLDashboards,
+			"jsonnetLibraryPaths": []interface{}{"testdata/jsonnet-libs"},
+			"jsonnetExtVars":      map[string]interface{}{"env": "prod"},
+		},
+	}
+	reader, err := NewDashboardFileReader(cfg, log.New("test.logger"))
+	require.NoError(t, err)
+
+	t.Run("jsonnet and YAML dashboards are provisioned", func(t *testing.T) {
+		require.NoError(t, reader.walkDisk())
+
+		titles := map[string]string{}
+		for _, dash := range fakeService.inserted {
+			titles[dash.Dashboard.Uid] = dash.Dashboard.Title
+		}
+		assert.Equal(t, map[string]string{"cpu": "CPU prod", "memory": "Memory"}, titles)
+	})
+
+	t.Run("the checksum of jsonnet dashboards is the checksum of their output", func(t *testing.T) {
+		path := filepath.Join(jsonnetAndYAMLDashboards, "cpu.jsonnet")
+		prod, err := reader.readDashboardFromFile(path, time.Now(), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Usage", prod.dashboard.Dashboard.Data.Get("panels").GetIndex(0).Get("title").MustString())
+
+		reader.jsonnet.extVars["env"] = "dev"
+		t.Cleanup(func() { reader.jsonnet.extVars["env"] = "prod" })
+		dev, err := reader.readDashboardFromFile(path, time.Now(), 0)
+		require.NoError(t, err)
+		assert.Equal(t, "CPU dev", dev.dashboard.Dashboard.Title)
+		assert.NotEqual(t, prod.checkSum, dev.checkSum)
+	})
+
+	t.Run("errors report the file and line", func(t *testing.T) {
+		for file, expected := range map[string]string{
+			"broken.jsonnet": "broken.jsonnet:3:",
+			"broken.yaml":    "broken.yaml: yaml: line 3",
+			"broken.json":    "broken.json:3:",
+		} {
+			_, err := reader.readDashboardFromFile(filepath.Join(brokenFormatDashboards, file), time.Now(), 0)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), expected)
+		}
+	})
+}
+
+func TestJsonnetImportsAreConfined(t *testing.T) {
+	root := t.TempDir()
+	dashboardsDir := filepath.Join(root, "dashboards")
+	libDir := filepath.Join(root, "lib")
+	require.NoError(t, os.Mkdir(dashboardsDir, 0750))
+	require.NoError(t, os.Mkdir(libDir, 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(libDir, "library.txt"), []byte("Library"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dashboardsDir, "title.txt"), []byte("Dashboards"), 0600))
+	require.NoError(t, os.Symlink(filepath.Join(root, "secret.txt"), filepath.Join(dashboardsDir, "link.txt")))
+
+	cfg := &config{
+		Name:  "Default",
+		Type:  "file",
+		OrgID: 1,
+		Options: map[string]interface{}{
+			"path":                dashboardsDir,
+			"jsonnetLibraryPaths": []interface{}{libDir},
+		},
+	}
+	reader, err := NewDashboardFileReader(cfg, log.New("test.logger"))
+	require.NoError(t, err)
+	path := filepath.Join(dashboardsDir, "dashboard.jsonnet")
+
+	t.Run("files of the library paths can be imported", func(t *testing.T) {
+		output, err := reader.evaluateDashboardFile(path, []byte(`{title: importstr "library.txt"}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title": "Library"}`, string(output))
+	})
+
+	t.Run("files of the provisioner path are imported relative to the dashboard", func(t *testing.T) {
+		output, err := reader.evaluateDashboardFile(path, []byte(`{title: importstr "title.txt"}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title": "Dashboards"}`, string(output))
+	})
+
+	t.Run("files outside the allowed paths cannot be imported", func(t *testing.T) {
+		_, err := reader.evaluateDashboardFile(path, []byte(`{title: importstr "../secret.txt"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not allowed")
+	})
+
+	t.Run("symbolic links cannot escape the allowed paths", func(t *testing.T) {
+		_, err := reader.evaluateDashboardFile(path, []byte(`{title: importstr "link.txt"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not allowed")
+	})
+}
+
+func TestParseJsonnetOptions(t *testing.T) {
+	cfg := &config{Options: map[string]interface{}{
+		"jsonnetLibraryPaths": []interface{}{"vendor", "/usr/share/grafonnet"},
+		"jsonnetExtVars":      map[string]interface{}{"replicas": 3},
+	}}
+
+	options, err := parseJsonnetOptions(cfg, "/repo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("/repo", "vendor"), "/usr/share/grafonnet"}, options.libraryPaths)
+	assert.Equal(t, map[string]string{"replicas": "3"}, options.extVars)
+
+	cfg.Options["jsonnetLibraryPaths"] = "vendor"
+	_, err = parseJsonnetOptions(cfg, "")
+	require.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -32,6 +33,13 @@ type FileReader struct {
 	log                          log.Logger
 	dashboardProvisioningService dashboards.DashboardProvisioningService
 	FoldersFromFilesStructure    bool
+	repository                   *gitRepository
+	jsonnet                      jsonnetOptions
+	// webhookSyncs syncs the repository after its webhooks, at most once at a time
+	webhookSyncs backgroundRunner
+	// walkMutex makes the walks wait for each other, so that a sync of the repository does not change the
+	// files while another walk reads them
+	walkMutex sync.Mutex
 }
 
 // NewDashboardFileReader returns a new filereader based on `config`
@@ -52,15 +60,62 @@ func NewDashboardFileReader(cfg *config, log log.Logger) (*FileReader, error) {
 		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
 	}
 
+	jsonnet, err := parseJsonnetOptions(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+
 	return &FileReader{
 		Cfg:                          cfg,
 		Path:                         path,
 		log:                          log,
 		dashboardProvisioningService: dashboards.NewProvisioningService(),
 		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		jsonnet:                      jsonnet,
 	}, nil
 }
 
+// NewDashboardGitReader returns a new filereader reading the dashboards of a git repository, synced before
+// reading the dashboards. The path option is the directory of the dashboards in the repository.
+func NewDashboardGitReader(cfg *config, dataPath string, log log.Logger) (*FileReader, error) {
+	repository, err := newGitRepository(cfg, dataPath, log)
+	if err != nil {
+		return nil, err
+	}
+
+	subPath, _ := cfg.Options["path"].(string)
+	if filepath.IsAbs(subPath) || strings.HasPrefix(filepath.ToSlash(filepath.Clean(subPath)), "../") {
+		return nil, fmt.Errorf("path %q must be a directory of the repository", subPath)
+	}
+
+	foldersFromFilesStructure, _ := cfg.Options["foldersFromFilesStructure"].(bool)
+	if foldersFromFilesStructure && cfg.Folder != "" && cfg.FolderUID != "" {
+		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
+	}
+
+	// library paths are directories of the repository
+	jsonnet, err := parseJsonnetOptions(cfg, repository.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &FileReader{
+		Cfg:                          cfg,
+		Path:                         filepath.Join(repository.dir, subPath),
+		log:                          log,
+		dashboardProvisioningService: dashboards.NewProvisioningService(),
+		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		repository:                   repository,
+		jsonnet:                      jsonnet,
+	}
+	reader.webhookSyncs.run = func() {
+		if err := reader.walkDisk(); err != nil {
+			reader.log.Error("failed to sync dashboards after webhook", "error", err)
+		}
+	}
+	return reader, nil
+}
+
 // pollChanges periodically runs walkDisk based on interval specified in the config.
 func (fr *FileReader) pollChanges(ctx context.Context) {
 	ticker := time.NewTicker(time.Duration(int64(time.Second) * fr.Cfg.UpdateIntervalSeconds))
@@ -79,6 +134,15 @@ func (fr *FileReader) pollChanges(ctx context.Context) {
 // walkDisk traverses the file system for the defined path, reading dashboard definition files,
 // and applies any change to the database.
 func (fr *FileReader) walkDisk() error {
+	fr.walkMutex.Lock()
+	defer fr.walkMutex.Unlock()
+
+	if fr.repository != nil {
+		if err := fr.repository.sync(); err != nil {
+			return err
+		}
+	}
+
 	fr.log.Debug("Start walking disk", "path", fr.Path)
 	resolvedPath := fr.resolvedPath()
 	if _, err := os.Stat(resolvedPath); err != nil {
@@ -136,20 +200,23 @@ func (fr *FileReader) storeDashboardsInFolder(filesFoundOnDisk map[string]os.Fil
 }
 
 // storeDashboardsInFoldersFromFilesystemStructure saves dashboards from the filesystem on disk to the same folder
-// in Grafana as they are in on the filesystem.
+// in Grafana as they are in on the filesystem. Subdirectories become nested folders.
 func (fr *FileReader) storeDashboardsInFoldersFromFileStructure(filesFoundOnDisk map[string]os.FileInfo,
 	dashboardRefs map[string]*models.DashboardProvisioning, resolvedPath string, sanityChecker *provisioningSanityChecker) error {
+	folderIDs := map[string]int64{}
 	for path, fileInfo := range filesFoundOnDisk {
-		folderName := ""
-
-		dashboardsFolder := filepath.Dir(path)
-		if dashboardsFolder != resolvedPath {
-			folderName = filepath.Base(dashboardsFolder)
+		dashboardsFolder, err := filepath.Rel(resolvedPath, filepath.Dir(path))
+		if err != nil {
+			return err
 		}
 
-		folderID, err := getOrCreateFolderID(fr.Cfg, fr.dashboardProvisioningService, folderName)
-		if err != nil && !errors.Is(err, ErrFolderNameMissing) {
-			return fmt.Errorf("can't provision folder %q from file system structure: %w", folderName, err)
+		folderID, err := fr.getOrCreateFolderPath(dashboardsFolder, folderIDs)
+		if err != nil {
+			if errors.Is(err, models.ErrFolderMaxDepthExceeded) {
+				fr.log.Error("failed to save dashboard", "file", path, "error", err)
+				continue
+			}
+			return fmt.Errorf("can't provision folder %q from file system structure: %w", dashboardsFolder, err)
 		}
 
 		provisioningMetadata, err := fr.saveDashboard(path, folderID, fileInfo, dashboardRefs)
@@ -161,6 +228,35 @@ func (fr *FileReader) storeDashboardsInFoldersFromFileStructure(filesFoundOnDisk
 	return nil
 }
 
+// getOrCreateFolderPath returns the ID of the nested folder for a directory relative to the provisioning path,
+// creating the missing folders. The IDs of the folders found so far are kept in folderIDs by directory.
+func (fr *FileReader) getOrCreateFolderPath(dir string, folderIDs map[string]int64) (int64, error) {
+	if dir == "." {
+		return 0, nil
+	}
+	if folderID, ok := folderIDs[dir]; ok {
+		return folderID, nil
+	}
+
+	names := strings.Split(filepath.ToSlash(dir), "/")
+	if len(names) > models.MaxFolderDepth {
+		return 0, models.ErrFolderMaxDepthExceeded
+	}
+
+	parentID, err := fr.getOrCreateFolderPath(filepath.Dir(dir), folderIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	folderID, err := getOrCreateFolderInParent(fr.Cfg, fr.dashboardProvisioningService, names[len(names)-1], parentID)
+	if err != nil {
+		return 0, err
+	}
+
+	folderIDs[dir] = folderID
+	return folderID, nil
+}
+
 // handleMissingDashboardFiles will unprovision or delete dashboards which are missing on disk.
 func (fr *FileReader) handleMissingDashboardFiles(provisionedDashboardRefs map[string]*models.DashboardProvisioning,
 	filesFoundOnDisk map[string]os.FileInfo) {
@@ -205,7 +301,8 @@ func (fr *FileReader) saveDashboard(path string, folderID int64, fileInfo os.Fil
 	}
 
 	provisionedData, alreadyProvisioned := provisionedDashboardRefs[path]
-	upToDate := alreadyProvisioned && provisionedData.Updated >= resolvedFileInfo.ModTime().Unix()
+	upToDate := alreadyProvisioned && provisionedData.Updated >= resolvedFileInfo.ModTime().Unix() &&
+		!isEvaluatedDashboardFile(path)
 
 	jsonFile, err := fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), folderID)
 	if err != nil {
@@ -247,6 +344,35 @@ func (fr *FileReader) saveDashboard(path string, folderID int64, fileInfo os.Fil
 	return provisioningMetadata, err
 }
 
+// commitDashboard queues the commit of a dashboard saved from the UI to the file it was provisioned from, on the
+// commit branch of the repository. Nothing is committed without repository or commit branch.
+func (fr *FileReader) commitDashboard(path string, dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	if fr.repository == nil || fr.repository.commitBranch == "" {
+		return nil
+	}
+	if strings.ToLower(filepath.Ext(path)) != ".json" {
+		return fmt.Errorf("dashboards provisioned from %s files cannot be committed", filepath.Ext(path))
+	}
+
+	// the id and version of the dashboard are specific to this instance
+	data := simplejson.New()
+	for key, value := range dash.Data.MustMap() {
+		if key != "id" && key != "version" {
+			data.Set(key, value)
+		}
+	}
+	content, err := data.EncodePretty()
+	if err != nil {
+		return err
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("Update dashboard %s", dash.Title)
+	}
+	fr.repository.queueCommit(&commitJob{path: path, content: append(content, '\n'), message: message, user: user})
+	return nil
+}
+
 func getProvisionedDashboardsByPath(service dashboards.DashboardProvisioningService, name string) (
 	map[string]*models.DashboardProvisioning, error) {
 	arr, err := service.GetProvisionedDashboardData(name)
@@ -298,6 +424,33 @@ func getOrCreateFolderID(cfg *config, service dashboards.DashboardProvisioningSe
 	return cmd.Result.Id, nil
 }
 
+// getOrCreateFolderInParent returns the ID of the folder with the given name within the parent folder, creating
+// it when it does not exist.
+func getOrCreateFolderInParent(cfg *config, service dashboards.DashboardProvisioningService, folderName string,
+	parentID int64) (int64, error) {
+	query := &models.GetFolderByTitleQuery{OrgId: cfg.OrgID, ParentId: parentID, Title: folderName}
+	err := bus.Dispatch(query)
+	if err == nil {
+		return query.Result.Id, nil
+	}
+	if !errors.Is(err, models.ErrFolderNotFound) {
+		return 0, err
+	}
+
+	dash := &dashboards.SaveDashboardDTO{}
+	dash.Dashboard = models.NewDashboardFolder(folderName)
+	dash.Dashboard.IsFolder = true
+	dash.Dashboard.FolderId = parentID
+	dash.Overwrite = true
+	dash.OrgId = cfg.OrgID
+	dbDash, err := service.SaveFolderForProvisionedDashboards(dash)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbDash.Id, nil
+}
+
 func resolveSymlink(fileinfo os.FileInfo, path string) (os.FileInfo, error) {
 	checkFilepath, err := filepath.EvalSymlinks(path)
 	if path != checkFilepath {
@@ -336,7 +489,7 @@ func validateWalkablePath(fileInfo os.FileInfo) (bool, error) {
 		return false, nil
 	}
 
-	if !strings.HasSuffix(fileInfo.Name(), ".json") {
+	if !isDashboardFile(fileInfo.Name()) {
 		return false, nil
 	}
 
@@ -367,16 +520,22 @@ func (fr *FileReader) readDashboardFromFile(path string, lastModified time.Time,
 		return nil, err
 	}
 
-	checkSum, err := util.Md5SumString(string(all))
+	// the checksum of evaluated files is the checksum of their output, which changes with their imports
+	output, err := fr.evaluateDashboardFile(path, all)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := simplejson.NewJson(all)
+	checkSum, err := util.Md5SumString(string(output))
 	if err != nil {
 		return nil, err
 	}
 
+	data, err := simplejson.NewJson(output)
+	if err != nil {
+		return nil, jsonFileError(path, output, err)
+	}
+
 	dash, err := createDashboardJSON(data, lastModified, fr.Cfg, folderID)
 	if err != nil {
 		return nil, err
@@ -25,6 +25,7 @@ const (
 	containingID              = "testdata/test-dashboards/containing-id"
 	unprovision               = "testdata/test-dashboards/unprovision"
 	foldersFromFilesStructure = "testdata/test-dashboards/folders-from-files-structure"
+	nestedFoldersFromFiles    = "testdata/test-dashboards/nested-folders-from-files-structure"
 )
 
 var fakeService *fakeDashboardProvisioningService
@@ -96,6 +97,7 @@ func TestDashboardFileReader(t *testing.T) {
 		fakeService = mockDashboardProvisioningService()
 
 		bus.AddHandler("test", mockGetDashboardQuery)
+		bus.AddHandler("test", mockGetFolderByTitleQuery)
 		logger := log.New("test.logger")
 
 		Convey("Reading dashboards from disk", func() {
@@ -203,6 +205,31 @@ func TestDashboardFileReader(t *testing.T) {
 				}
 			})
 
+			Convey("Get nested folders from files structure", func() {
+				cfg.Options["path"] = nestedFoldersFromFiles
+				cfg.Options["foldersFromFilesStructure"] = true
+
+				reader, err := NewDashboardFileReader(cfg, logger)
+				So(err, ShouldBeNil)
+
+				err = reader.walkDisk()
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 4)
+
+				byTitle := map[string]*models.Dashboard{}
+				for _, d := range fakeService.inserted {
+					byTitle[d.Dashboard.Title] = d.Dashboard
+				}
+
+				So(byTitle["team"].IsFolder, ShouldBeTrue)
+				So(byTitle["team"].FolderId, ShouldEqual, 0)
+				So(byTitle["services"].IsFolder, ShouldBeTrue)
+				So(byTitle["services"].FolderId, ShouldEqual, byTitle["team"].Id)
+				So(byTitle["TeamOverview"].FolderId, ShouldEqual, byTitle["team"].Id)
+				So(byTitle["ServiceLatency"].FolderId, ShouldEqual, byTitle["services"].Id)
+			})
+
 			Convey("Invalid configuration should return error", func() {
 				cfg := &config{
 					Name:   "Default",
@@ -456,6 +483,9 @@ func (s *fakeDashboardProvisioningService) SaveProvisionedDashboard(dto *dashboa
 }
 
 func (s *fakeDashboardProvisioningService) SaveFolderForProvisionedDashboards(dto *dashboards.SaveDashboardDTO) (*models.Dashboard, error) {
+	if dto.Dashboard.Id == 0 {
+		dto.Dashboard.Id = rand.Int63n(1000000) + 1
+	}
 	s.inserted = append(s.inserted, dto)
 	return dto.Dashboard, nil
 }
@@ -499,3 +529,14 @@ func mockGetDashboardQuery(cmd *models.GetDashboardQuery) error {
 
 	return models.ErrDashboardNotFound
 }
+
+func mockGetFolderByTitleQuery(cmd *models.GetFolderByTitleQuery) error {
+	for _, d := range fakeService.inserted {
+		if d.Dashboard.IsFolder && d.Dashboard.FolderId == cmd.ParentId && d.Dashboard.Title == cmd.Title {
+			cmd.Result = d.Dashboard
+			return nil
+		}
+	}
+
+	return models.ErrFolderNotFound
+}
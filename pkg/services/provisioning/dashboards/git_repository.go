@@ -0,0 +1,415 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const gitCommandTimeout = 2 * time.Minute
+
+// credentialsGitVersion is the first version of git reading its configuration from the environment, which is
+// required to pass the credentials of a repository.
+var credentialsGitVersion = [2]int{2, 31}
+
+// gitVersion returns the output of git --version.
+var gitVersion = func() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("git --version failed: %w", err)
+	}
+	return string(out), nil
+}
+
+var (
+	// ErrRepositoryNotFound is returned when no git provisioner has the requested name.
+	ErrRepositoryNotFound = errors.New("dashboard repository not found")
+	// ErrRepositoryWebhookDisabled is returned when a webhook is received for a repository without webhook secret.
+	ErrRepositoryWebhookDisabled = errors.New("webhook is not enabled for the dashboard repository")
+	// ErrRepositoryWebhookInvalidSignature is returned when the signature of a webhook does not match its secret.
+	ErrRepositoryWebhookInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// gitRepository is a local clone of the git repository of a git provisioner. The dashboards are read from
+// the working tree, which is reset to the provisioned branch on every sync.
+type gitRepository struct {
+	url           string
+	branch        string
+	dir           string
+	username      string
+	password      string
+	sshKeyFile    string
+	knownHosts    string
+	commitBranch  string
+	webhookSecret string
+	log           log.Logger
+	mutex         sync.Mutex
+
+	// the commits of dashboards saved from the UI are queued and pushed in the background
+	queueMutex sync.Mutex
+	queue      []*commitJob
+	committing bool
+	commits    sync.WaitGroup
+}
+
+// commitJob is a file to commit to the commit branch.
+type commitJob struct {
+	path    string
+	content []byte
+	message string
+	user    *models.SignedInUser
+}
+
+// newGitRepository returns the repository of a git provisioner, cloned in cloneDir or by default in the data
+// directory of Grafana.
+func newGitRepository(cfg *config, dataPath string, log log.Logger) (*gitRepository, error) {
+	url, _ := cfg.Options["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("failed to load dashboards, url param is not a string")
+	}
+
+	repo := &gitRepository{
+		url:    url,
+		branch: "master",
+		dir:    filepath.Join(dataPath, "provisioning", "git", models.SlugifyTitle(cfg.Name)),
+		log:    log,
+	}
+	if branch, ok := cfg.Options["branch"].(string); ok && branch != "" {
+		repo.branch = branch
+	}
+	if dir, ok := cfg.Options["cloneDir"].(string); ok && dir != "" {
+		repo.dir = dir
+	}
+	repo.username, _ = cfg.Options["username"].(string)
+	repo.password, _ = cfg.Options["password"].(string)
+	repo.sshKeyFile, _ = cfg.Options["sshKeyFile"].(string)
+	repo.knownHosts, _ = cfg.Options["sshKnownHostsFile"].(string)
+	repo.commitBranch, _ = cfg.Options["commitBranch"].(string)
+	repo.webhookSecret, _ = cfg.Options["webhookSecret"].(string)
+
+	for _, value := range []string{repo.url, repo.branch, repo.commitBranch} {
+		if strings.HasPrefix(value, "-") {
+			return nil, fmt.Errorf("invalid git repository option %q", value)
+		}
+	}
+
+	if repo.username != "" || repo.password != "" {
+		if err := checkCredentialsGitVersion(); err != nil {
+			return nil, err
+		}
+	}
+
+	return repo, nil
+}
+
+// checkCredentialsGitVersion checks that the installed git passes the credentials of a repository, as older
+// versions silently ignore them.
+func checkCredentialsGitVersion() error {
+	out, err := gitVersion()
+	if err != nil {
+		return err
+	}
+	major, minor, err := parseGitVersion(out)
+	if err != nil {
+		return err
+	}
+	if major < credentialsGitVersion[0] || (major == credentialsGitVersion[0] && minor < credentialsGitVersion[1]) {
+		return fmt.Errorf("git %d.%d or later is required for the username and password of a repository, found %d.%d",
+			credentialsGitVersion[0], credentialsGitVersion[1], major, minor)
+	}
+	return nil
+}
+
+// parseGitVersion returns the major and minor version of the output of git --version, such as
+// "git version 2.39.5" or "git version 2.24.3 (Apple Git-128)".
+func parseGitVersion(out string) (int, int, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 3 || fields[0] != "git" || fields[1] != "version" {
+		return 0, 0, fmt.Errorf("unexpected git version %q", strings.TrimSpace(out))
+	}
+	parts := strings.SplitN(fields[2], ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("unexpected git version %q", fields[2])
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected git version %q", fields[2])
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected git version %q", fields[2])
+	}
+	return major, minor, nil
+}
+
+// sync fetches the provisioned branch and resets the working tree to it.
+func (r *gitRepository) sync() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); os.IsNotExist(err) {
+		r.log.Info("Cloning dashboard repository", "url", r.url, "dir", r.dir)
+		if err := os.MkdirAll(r.dir, 0750); err != nil {
+			return err
+		}
+		if _, err := r.git(nil, nil, "init", "-q"); err != nil {
+			return err
+		}
+		if _, err := r.git(nil, nil, "remote", "add", "origin", r.url); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// keep the remote up to date with the configuration
+	if _, err := r.git(nil, nil, "remote", "set-url", "origin", r.url); err != nil {
+		return err
+	}
+	if err := r.fetch(r.branch); err != nil {
+		return err
+	}
+	if _, err := r.git(nil, nil, "checkout", "-q", "-f", "-B", r.branch, "refs/remotes/origin/"+r.branch); err != nil {
+		return err
+	}
+	_, err := r.git(nil, nil, "clean", "-q", "-f", "-d")
+	return err
+}
+
+// queueCommit commits a file in the background, so that saving a dashboard does not wait for the remote. A
+// pending commit of the same file is replaced, its content being outdated.
+func (r *gitRepository) queueCommit(job *commitJob) {
+	r.queueMutex.Lock()
+	defer r.queueMutex.Unlock()
+
+	for i, pending := range r.queue {
+		if pending.path == job.path {
+			r.queue[i] = job
+			return
+		}
+	}
+	r.queue = append(r.queue, job)
+	r.commits.Add(1)
+	if !r.committing {
+		r.committing = true
+		go r.runCommits()
+	}
+}
+
+// runCommits commits the queued files one at a time, until the queue is empty.
+func (r *gitRepository) runCommits() {
+	for {
+		r.queueMutex.Lock()
+		if len(r.queue) == 0 {
+			r.committing = false
+			r.queueMutex.Unlock()
+			return
+		}
+		job := r.queue[0]
+		r.queue = r.queue[1:]
+		r.queueMutex.Unlock()
+
+		if err := r.commitFile(job.path, job.content, job.message, job.user); err != nil {
+			r.log.Error("Failed to commit dashboard to repository", "file", job.path, "error", err)
+		}
+		r.commits.Done()
+	}
+}
+
+// waitForCommits waits until the queued commits are pushed.
+func (r *gitRepository) waitForCommits() {
+	r.commits.Wait()
+}
+
+// commitFile commits a file of the working tree with new content to the commit branch and pushes it. The
+// commit is made on top of the commit branch when it exists, or else of the provisioned branch. The working
+// tree is not changed, so the provisioned dashboards keep following the provisioned branch.
+func (r *gitRepository) commitFile(path string, content []byte, message string, user *models.SignedInUser) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	relPath, err := filepath.Rel(r.dir, path)
+	if err != nil {
+		return err
+	}
+	relPath = filepath.ToSlash(relPath)
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return fmt.Errorf("file %q is not in the dashboard repository", path)
+	}
+
+	parent := "refs/remotes/origin/" + r.branch
+	remoteBranch, err := r.git(nil, nil, "ls-remote", "--heads", "origin", "refs/heads/"+r.commitBranch)
+	if err != nil {
+		return err
+	}
+	if remoteBranch != "" {
+		if err := r.fetch(r.commitBranch); err != nil {
+			return err
+		}
+		parent = "refs/remotes/origin/" + r.commitBranch
+	}
+
+	index, err := ioutil.TempFile("", "grafana-git-index")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(index.Name()); err != nil {
+			r.log.Warn("Failed to remove temporary git index", "path", index.Name(), "error", err)
+		}
+	}()
+	if err := index.Close(); err != nil {
+		return err
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + index.Name()}
+	if _, err := r.git(env, nil, "read-tree", parent); err != nil {
+		return err
+	}
+	blob, err := r.git(env, content, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+	if _, err := r.git(env, nil, "update-index", "--add", "--cacheinfo", "100644,"+blob+","+relPath); err != nil {
+		return err
+	}
+	tree, err := r.git(env, nil, "write-tree")
+	if err != nil {
+		return err
+	}
+	parentTree, err := r.git(nil, nil, "rev-parse", parent+"^{tree}")
+	if err != nil {
+		return err
+	}
+	if tree == parentTree {
+		r.log.Debug("Dashboard is unchanged in the repository", "file", relPath, "branch", r.commitBranch)
+		return nil
+	}
+
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+	author := []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + user.Email,
+		"GIT_COMMITTER_NAME=" + name,
+		"GIT_COMMITTER_EMAIL=" + user.Email,
+	}
+	commit, err := r.git(author, nil, "commit-tree", tree, "-p", parent, "-m", message)
+	if err != nil {
+		return err
+	}
+	if _, err := r.git(nil, nil, "push", "-q", "origin", commit+":refs/heads/"+r.commitBranch); err != nil {
+		return err
+	}
+
+	r.log.Info("Committed dashboard to repository", "file", relPath, "branch", r.commitBranch, "commit", commit)
+	return nil
+}
+
+// verifyWebhook checks the signature of a webhook, either a GitHub HMAC signature or a GitLab token.
+func (r *gitRepository) verifyWebhook(body []byte, signature string, token string) error {
+	if r.webhookSecret == "" {
+		return ErrRepositoryWebhookDisabled
+	}
+
+	if signature != "" {
+		mac := hmac.New(sha256.New, []byte(r.webhookSecret))
+		_, _ = mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+		return ErrRepositoryWebhookInvalidSignature
+	}
+
+	if token != "" && hmac.Equal([]byte(token), []byte(r.webhookSecret)) {
+		return nil
+	}
+	return ErrRepositoryWebhookInvalidSignature
+}
+
+func (r *gitRepository) fetch(branch string) error {
+	refspec := "+refs/heads/" + branch + ":refs/remotes/origin/" + branch
+	_, err := r.git(nil, nil, "fetch", "-q", "--depth", "1", "origin", refspec)
+	return err
+}
+
+// git runs a git command in the repository and returns its trimmed output.
+func (r *gitRepository) git(env []string, stdin []byte, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	cmd := r.command(ctx, env, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// command returns a git command run in the repository. The credentials of the repository are passed in the
+// environment, which unlike the arguments cannot be read by other users, as configuration of git 2.31 or later.
+// The version of git is checked by newGitRepository.
+func (r *gitRepository) command(ctx context.Context, env []string, args ...string) *exec.Cmd {
+	// nolint:gosec
+	// We can ignore the gosec G204 warning on this one because the arguments come from the provisioning
+	// configuration file.
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if r.username != "" || r.password != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(r.username + ":" + r.password))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+	if r.sshKeyFile != "" || r.knownHosts != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+r.sshCommand())
+	}
+	cmd.Env = append(cmd.Env, env...)
+	return cmd
+}
+
+func (r *gitRepository) sshCommand() string {
+	command := []string{"ssh"}
+	if r.sshKeyFile != "" {
+		command = append(command, "-i", shellQuote(r.sshKeyFile), "-o", "IdentitiesOnly=yes")
+	}
+	if r.knownHosts != "" {
+		command = append(command, "-o", "UserKnownHostsFile="+shellQuote(r.knownHosts), "-o", "StrictHostKeyChecking=yes")
+	}
+	return strings.Join(command, " ")
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
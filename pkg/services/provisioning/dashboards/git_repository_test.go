@@ -0,0 +1,243 @@
+package dashboards
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Env, "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com", "HOME="+dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// pushDashboard commits a dashboard file to the main branch of the bare repository.
+func pushDashboard(t *testing.T, workDir string, path string, title string) {
+	t.Helper()
+	dash := simplejson.NewFromAny(map[string]interface{}{"title": title, "uid": models.SlugifyTitle(title)})
+	content, err := dash.EncodePretty()
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, path), content, 0600))
+	runGit(t, workDir, "add", ".")
+	runGit(t, workDir, "commit", "-q", "-m", "Update "+title)
+	runGit(t, workDir, "push", "-q", "origin", "HEAD:refs/heads/main")
+}
+
+func TestDashboardGitReader(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	bus.ClearBusHandlers()
+	fakeService = mockDashboardProvisioningService()
+	bus.AddHandler("test", mockGetDashboardQuery)
+	bus.AddHandler("test", mockGetFolderByTitleQuery)
+
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+
+	workDir := t.TempDir()
+	runGit(t, workDir, "init", "-q")
+	runGit(t, workDir, "remote", "add", "origin", remote)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "README.md"), []byte("dashboards"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(workDir, "dashboards"), 0750))
+	pushDashboard(t, workDir, "dashboards/cpu.json", "CPU")
+
+	cfg := &config{
+		Name:  "Git",
+		Type:  "git",
+		OrgID: 1,
+		Options: map[string]interface{}{
+			"url":           remote,
+			"branch":        "main",
+			"path":          "dashboards",
+			"commitBranch":  "grafana",
+			"webhookSecret": "secret",
+		},
+	}
+	reader, err := NewDashboardGitReader(cfg, t.TempDir(), log.New("test.logger"))
+	require.NoError(t, err)
+
+	t.Run("dashboards are provisioned from the branch", func(t *testing.T) {
+		require.NoError(t, reader.walkDisk())
+		require.Len(t, fakeService.inserted, 1)
+		assert.Equal(t, "CPU", fakeService.inserted[0].Dashboard.Title)
+	})
+
+	t.Run("changes of the branch are synced", func(t *testing.T) {
+		pushDashboard(t, workDir, "dashboards/memory.json", "Memory")
+		pushDashboard(t, workDir, "README.md", "Not a dashboard")
+
+		require.NoError(t, reader.walkDisk())
+		titles := []string{}
+		for _, dash := range fakeService.inserted {
+			titles = append(titles, dash.Dashboard.Title)
+		}
+		assert.ElementsMatch(t, []string{"CPU", "Memory"}, titles)
+	})
+
+	t.Run("dashboards saved from the UI are committed to the commit branch", func(t *testing.T) {
+		dash := models.NewDashboard("CPU usage")
+		dash.SetId(10)
+		dash.SetUid("cpu")
+		dash.SetVersion(3)
+		user := &models.SignedInUser{Login: "editor", Email: "editor@example.com"}
+		path := filepath.Join(reader.Path, "cpu.json")
+
+		require.NoError(t, reader.commitDashboard(path, dash, "", user))
+		require.NoError(t, reader.commitDashboard(path, dash, "", user))
+		reader.repository.waitForCommits()
+
+		assert.Equal(t, "editor <editor@example.com> Update dashboard CPU usage",
+			runGit(t, remote, "log", "--format=%an <%ae> %s", "-n", "1", "grafana"))
+		// the unchanged dashboard is committed only once
+		assert.Equal(t, "1", runGit(t, remote, "rev-list", "--count", "main..grafana"))
+
+		saved, err := simplejson.NewJson([]byte(runGit(t, remote, "show", "grafana:dashboards/cpu.json")))
+		require.NoError(t, err)
+		assert.Equal(t, "CPU usage", saved.Get("title").MustString())
+		_, hasID := saved.CheckGet("id")
+		assert.False(t, hasID)
+
+		// the provisioned dashboards keep following the provisioned branch
+		require.NoError(t, reader.walkDisk())
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"CPU"`)
+	})
+
+	t.Run("webhook syncs wait for the running walk", func(t *testing.T) {
+		reader.walkMutex.Lock()
+		pushDashboard(t, workDir, "dashboards/disk.json", "Disk")
+		inserted := len(fakeService.inserted)
+		reader.webhookSyncs.request()
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Len(t, fakeService.inserted, inserted)
+		reader.walkMutex.Unlock()
+		reader.webhookSyncs.wait()
+		require.Len(t, fakeService.inserted, inserted+1)
+		assert.Equal(t, "Disk", fakeService.inserted[inserted].Dashboard.Title)
+	})
+
+	t.Run("webhooks are verified with the webhook secret", func(t *testing.T) {
+		body := []byte(`{"ref": "refs/heads/main"}`)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write(body)
+
+		assert.NoError(t, reader.repository.verifyWebhook(body, "sha256="+hex.EncodeToString(mac.Sum(nil)), ""))
+		assert.NoError(t, reader.repository.verifyWebhook(body, "", "secret"))
+		assert.Equal(t, ErrRepositoryWebhookInvalidSignature, reader.repository.verifyWebhook(body, "sha256=00", ""))
+		assert.Equal(t, ErrRepositoryWebhookInvalidSignature, reader.repository.verifyWebhook(body, "", ""))
+	})
+}
+
+func TestNewDashboardGitReader(t *testing.T) {
+	cfg := &config{Name: "Git", Type: "git", Options: map[string]interface{}{}}
+	_, err := NewDashboardGitReader(cfg, "/var/lib/grafana", log.New("test.logger"))
+	require.Error(t, err)
+
+	cfg.Options["url"] = "https://example.com/dashboards.git"
+	reader, err := NewDashboardGitReader(cfg, "/var/lib/grafana", log.New("test.logger"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/var/lib/grafana", "provisioning", "git", "git"), reader.Path)
+	assert.Equal(t, "master", reader.repository.branch)
+
+	cfg.Options["path"] = "../dashboards"
+	_, err = NewDashboardGitReader(cfg, "/var/lib/grafana", log.New("test.logger"))
+	require.Error(t, err)
+}
+
+func TestGitRepositoryCommand(t *testing.T) {
+	cfg := &config{Name: "Git", Type: "git", Options: map[string]interface{}{
+		"url":      "https://example.com/dashboards.git",
+		"username": "grafana",
+		"password": "secret",
+	}}
+	repository, err := newGitRepository(cfg, t.TempDir(), log.New("test.logger"))
+	require.NoError(t, err)
+
+	cmd := repository.command(context.Background(), nil, "fetch", "origin")
+	credentials := base64.StdEncoding.EncodeToString([]byte("grafana:secret"))
+	assert.NotContains(t, strings.Join(cmd.Args, " "), credentials)
+	assert.NotContains(t, strings.Join(cmd.Args, " "), "secret")
+	assert.Contains(t, cmd.Env, "GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+}
+
+func TestCredentialsGitVersion(t *testing.T) {
+	origGitVersion := gitVersion
+	t.Cleanup(func() { gitVersion = origGitVersion })
+
+	cfg := &config{Name: "Git", Type: "git", Options: map[string]interface{}{
+		"url":      "https://example.com/dashboards.git",
+		"username": "grafana",
+		"password": "secret",
+	}}
+	newRepository := func(version string) error {
+		gitVersion = func() (string, error) { return version, nil }
+		_, err := newGitRepository(cfg, t.TempDir(), log.New("test.logger"))
+		return err
+	}
+
+	assert.NoError(t, newRepository("git version 2.31.0\n"))
+	assert.NoError(t, newRepository("git version 2.39.5 (Apple Git-143)\n"))
+	assert.NoError(t, newRepository("git version 3.0.0.windows.1\n"))
+
+	err := newRepository("git version 2.30.2\n")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git 2.31 or later is required")
+
+	assert.Error(t, newRepository("not git\n"))
+
+	// the version does not matter without credentials
+	gitVersion = func() (string, error) { return "git version 2.20.1", nil }
+	_, err = newGitRepository(&config{Name: "Git", Type: "git", Options: map[string]interface{}{
+		"url": "https://example.com/dashboards.git",
+	}}, t.TempDir(), log.New("test.logger"))
+	assert.NoError(t, err)
+}
+
+func TestBackgroundRunner(t *testing.T) {
+	var runs int32
+	release := make(chan struct{})
+	runner := backgroundRunner{run: func() {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			<-release
+		}
+	}}
+
+	runner.request()
+	for i := 0; i < 10; i++ {
+		runner.request()
+	}
+	close(release)
+	runner.wait()
+
+	// the requests received during the first run are coalesced into a single run
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+}
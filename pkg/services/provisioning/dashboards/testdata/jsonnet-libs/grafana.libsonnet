@@ -0,0 +1,12 @@
+{
+  dashboard(title):: {
+    title: title,
+    schemaVersion: 27,
+    panels: [],
+  },
+  panel(id, title):: {
+    id: id,
+    title: title,
+    type: 'graph',
+  },
+}
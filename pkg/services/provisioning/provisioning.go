@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
@@ -22,14 +23,17 @@ type ProvisioningService interface {
 	ProvisionDashboards() error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
+	HandleDashboardRepositoryWebhook(name string, body []byte, signature string, token string) error
+	CommitDashboardToRepository(name string, externalID string, dash *models.Dashboard, message string,
+		user *models.SignedInUser) error
 }
 
 func init() {
 	registry.Register(&registry.Descriptor{
 		Name: "ProvisioningService",
 		Instance: NewProvisioningServiceImpl(
-			func(path string) (dashboards.DashboardProvisioner, error) {
-				return dashboards.New(path)
+			func(path string, dataPath string) (dashboards.DashboardProvisioner, error) {
+				return dashboards.New(path, dataPath)
 			},
 			notifiers.Provision,
 			datasources.Provision,
@@ -134,7 +138,7 @@ func (ps *provisioningServiceImpl) ProvisionNotifications() error {
 
 func (ps *provisioningServiceImpl) ProvisionDashboards() error {
 	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
-	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath)
+	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath, ps.Cfg.DataPath)
 	if err != nil {
 		return errutil.Wrap("Failed to create provisioner", err)
 	}
@@ -163,6 +167,18 @@ func (ps *provisioningServiceImpl) GetAllowUIUpdatesFromConfig(name string) bool
 	return ps.dashboardProvisioner.GetAllowUIUpdatesFromConfig(name)
 }
 
+// HandleDashboardRepositoryWebhook syncs the dashboards of a git repository after a verified webhook.
+func (ps *provisioningServiceImpl) HandleDashboardRepositoryWebhook(name string, body []byte, signature string,
+	token string) error {
+	return ps.dashboardProvisioner.HandleRepositoryWebhook(name, body, signature, token)
+}
+
+// CommitDashboardToRepository commits a dashboard saved from the UI to the git repository it was provisioned from.
+func (ps *provisioningServiceImpl) CommitDashboardToRepository(name string, externalID string, dash *models.Dashboard,
+	message string, user *models.SignedInUser) error {
+	return ps.dashboardProvisioner.CommitDashboardToRepository(name, externalID, dash, message, user)
+}
+
 func (ps *provisioningServiceImpl) cancelPolling() {
 	if ps.pollingCtxCancel != nil {
 		ps.log.Debug("Stop polling for dashboard changes")
@@ -1,5 +1,7 @@
 package provisioning
 
+import "github.com/grafana/grafana/pkg/models"
+
 type Calls struct {
 	ProvisionDatasources                []interface{}
 	ProvisionPlugins                    []interface{}
@@ -7,6 +9,8 @@ type Calls struct {
 	ProvisionDashboards                 []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
+	HandleDashboardRepositoryWebhook    []interface{}
+	CommitDashboardToRepository         []interface{}
 }
 
 type ProvisioningServiceMock struct {
@@ -17,6 +21,9 @@ type ProvisioningServiceMock struct {
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
+	HandleDashboardRepositoryWebhookFunc    func(name string, body []byte, signature string, token string) error
+	CommitDashboardToRepositoryFunc         func(name string, externalID string, dash *models.Dashboard, message string,
+		user *models.SignedInUser) error
 }
 
 func NewProvisioningServiceMock() *ProvisioningServiceMock {
@@ -72,3 +79,21 @@ func (mock *ProvisioningServiceMock) GetAllowUIUpdatesFromConfig(name string) bo
 	}
 	return false
 }
+
+func (mock *ProvisioningServiceMock) HandleDashboardRepositoryWebhook(name string, body []byte, signature string,
+	token string) error {
+	mock.Calls.HandleDashboardRepositoryWebhook = append(mock.Calls.HandleDashboardRepositoryWebhook, name)
+	if mock.HandleDashboardRepositoryWebhookFunc != nil {
+		return mock.HandleDashboardRepositoryWebhookFunc(name, body, signature, token)
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) CommitDashboardToRepository(name string, externalID string,
+	dash *models.Dashboard, message string, user *models.SignedInUser) error {
+	mock.Calls.CommitDashboardToRepository = append(mock.Calls.CommitDashboardToRepository, name)
+	if mock.CommitDashboardToRepositoryFunc != nil {
+		return mock.CommitDashboardToRepositoryFunc(name, externalID, dash, message, user)
+	}
+	return nil
+}
@@ -26,6 +26,21 @@ type Hit struct {
 	FolderURL    string   `json:"folderUrl,omitempty"`
 	SortMeta     int64    `json:"sortMeta"`
 	SortMetaName string   `json:"sortMetaName,omitempty"`
+	// Matches are the parts of the dashboard matching a content search.
+	Matches []*Match `json:"matches,omitempty"`
+}
+
+// MatchTitle is the kind of the match of the title of a dashboard, the other kinds are the kinds of the search terms
+// of the dashboards.
+const MatchTitle = "title"
+
+// Match is a part of a dashboard matching a content search.
+type Match struct {
+	Kind string `json:"kind"`
+	// PanelID is the id of the matching panel or row, 0 for the title and the template variables.
+	PanelID  int64  `json:"panelId,omitempty"`
+	Location string `json:"location,omitempty"`
+	Text     string `json:"text"`
 }
 
 type HitList []*Hit
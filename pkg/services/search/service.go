@@ -28,6 +28,12 @@ type Query struct {
 	Permission   models.PermissionType
 	Sort         string
 
+	// IncludeSubfolders searches the subfolders of the folders of FolderIds too.
+	IncludeSubfolders bool
+	// SearchContent matches Title against the panel titles and descriptions, row titles, template variables and
+	// queries of the dashboards too, and returns the matches of the hits.
+	SearchContent bool
+
 	Result HitList
 }
 
@@ -45,6 +51,9 @@ type FindPersistedDashboardsQuery struct {
 	Permission   models.PermissionType
 	Sort         SortOption
 
+	IncludeSubfolders bool
+	SearchContent     bool
+
 	Filters []interface{}
 
 	Result HitList
@@ -79,6 +88,9 @@ func (s *SearchService) searchHandler(query *Query) error {
 		Limit:        query.Limit,
 		Page:         query.Page,
 		Permission:   query.Permission,
+
+		IncludeSubfolders: query.IncludeSubfolders,
+		SearchContent:     query.SearchContent,
 	}
 
 	if sortOpt, exists := s.sortOptions[query.Sort]; exists {
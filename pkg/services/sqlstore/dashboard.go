@@ -26,6 +26,7 @@ var shadowSearchCounter = prometheus.NewCounterVec(
 func init() {
 	bus.AddHandler("sql", SaveDashboard)
 	bus.AddHandler("sql", GetDashboard)
+	bus.AddHandler("sql", GetFolderByTitle)
 	bus.AddHandler("sql", GetDashboards)
 	bus.AddHandler("sql", DeleteDashboard)
 	bus.AddHandler("sql", SearchDashboards)
@@ -160,6 +161,10 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 	}
 
+	if err := updateDashboardSearchTerms(sess, dash); err != nil {
+		return err
+	}
+
 	cmd.Result = dash
 
 	return err
@@ -202,6 +207,23 @@ func GetDashboard(query *models.GetDashboardQuery) error {
 	return nil
 }
 
+// GetFolderByTitle returns the folder with the given title, compared by slug, within a parent folder.
+func GetFolderByTitle(query *models.GetFolderByTitleQuery) error {
+	var folder models.Dashboard
+	has, err := x.Where("org_id=? AND folder_id=? AND slug=? AND is_folder=?", query.OrgId, query.ParentId,
+		models.SlugifyTitle(query.Title), dialect.BooleanStr(true)).Get(&folder)
+	if err != nil {
+		return err
+	} else if !has {
+		return models.ErrFolderNotFound
+	}
+
+	folder.SetId(folder.Id)
+	folder.SetUid(folder.Uid)
+	query.Result = &folder
+	return nil
+}
+
 type DashboardSearchProjection struct {
 	ID          int64  `xorm:"id"`
 	UID         string `xorm:"uid"`
@@ -217,6 +239,12 @@ type DashboardSearchProjection struct {
 }
 
 func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSearchProjection, error) {
+	orgId := query.OrgId
+	if orgId == 0 {
+		orgId = query.SignedInUser.OrgId
+	}
+	depth := folderDepth(orgId)
+
 	filters := []interface{}{
 		permissions.DashboardPermissionFilter{
 			OrgRole:         query.SignedInUser.OrgRole,
@@ -224,6 +252,7 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 			Dialect:         dialect,
 			UserId:          query.SignedInUser.UserId,
 			PermissionLevel: query.Permission,
+			FolderDepth:     depth,
 		},
 	}
 
@@ -250,7 +279,11 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 	}
 
 	if len(query.Title) > 0 {
-		filters = append(filters, searchstore.TitleFilter{Dialect: dialect, Title: query.Title})
+		if query.SearchContent {
+			filters = append(filters, searchstore.TitleOrContentFilter{Dialect: dialect, Title: query.Title})
+		} else {
+			filters = append(filters, searchstore.TitleFilter{Dialect: dialect, Title: query.Title})
+		}
 	}
 
 	if len(query.Type) > 0 {
@@ -258,7 +291,11 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 	}
 
 	if len(query.FolderIds) > 0 {
-		filters = append(filters, searchstore.FolderFilter{IDs: query.FolderIds})
+		filters = append(filters, searchstore.FolderFilter{
+			IDs:               query.FolderIds,
+			IncludeSubfolders: query.IncludeSubfolders,
+			FolderDepth:       depth,
+		})
 	}
 
 	var res []DashboardSearchProjection
@@ -291,6 +328,10 @@ func SearchDashboards(query *search.FindPersistedDashboardsQuery) error {
 
 	makeQueryResult(query, res)
 
+	if query.SearchContent && len(query.Title) > 0 {
+		return addSearchMatches(query.Title, query.Result)
+	}
+
 	return nil
 }
 
@@ -376,48 +417,37 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 
 	deletes := []string{
 		"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
+		"DELETE FROM dashboard_search_term WHERE dashboard_id = ? ",
+		"DELETE FROM dashboard_usage WHERE dashboard_id = ? ",
+		"DELETE FROM dashboard_user_view WHERE dashboard_id = ? ",
 		"DELETE FROM star WHERE dashboard_id = ? ",
 		"DELETE FROM dashboard WHERE id = ?",
 		"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
 		"DELETE FROM dashboard_version WHERE dashboard_id = ?",
-		"DELETE FROM annotation WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
 		"DELETE FROM dashboard_acl WHERE dashboard_id = ?",
 	}
 
-	if dashboard.IsFolder {
-		deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
+	// annotations of dashboards in the trash are deleted when they are purged
+	if cmd.MoveToTrash {
+		if err := moveDashboardToTrash(sess, &dashboard, cmd.DeletedBy); err != nil {
+			return err
+		}
+	} else {
+		deletes = append(deletes, "DELETE FROM annotation WHERE dashboard_id = ?")
+	}
 
-		dashIds := []struct {
-			Id int64
-		}{}
-		err := sess.SQL("SELECT id FROM dashboard WHERE folder_id = ?", dashboard.Id).Find(&dashIds)
+	if dashboard.IsFolder {
+		// delete the content of the subfolders before the content of their parents
+		folderIds, err := GetFolderSubtreeIds(sess, dashboard.OrgId, dashboard.Id)
 		if err != nil {
 			return err
 		}
-
-		for _, id := range dashIds {
-			if err := deleteAlertDefinition(id.Id, sess); err != nil {
+		for i := len(folderIds) - 1; i >= 0; i-- {
+			if err := deleteFolderContent(sess, dashboard.OrgId, folderIds[i], !cmd.MoveToTrash); err != nil {
 				return err
 			}
 		}
-
-		if len(dashIds) > 0 {
-			childrenDeletes := []string{
-				"DELETE FROM dashboard_tag WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM star WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM dashboard_version WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM annotation WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM dashboard_provisioning WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-				"DELETE FROM dashboard_acl WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
-			}
-			for _, sql := range childrenDeletes {
-				_, err := sess.Exec(sql, dashboard.OrgId, dashboard.Id)
-				if err != nil {
-					return err
-				}
-			}
-		}
 	}
 
 	if err := deleteAlertDefinition(dashboard.Id, sess); err != nil {
@@ -434,6 +464,69 @@ func deleteDashboard(cmd *models.DeleteDashboardCommand, sess *DBSession) error
 	return nil
 }
 
+// deleteFolderContent deletes the dashboards and folders directly within a folder.
+func deleteFolderContent(sess *DBSession, orgId int64, folderId int64, deleteAnnotations bool) error {
+	dashIds := []struct {
+		Id int64
+	}{}
+	err := sess.SQL("SELECT id FROM dashboard WHERE folder_id = ?", folderId).Find(&dashIds)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range dashIds {
+		if err := deleteAlertDefinition(id.Id, sess); err != nil {
+			return err
+		}
+	}
+
+	if len(dashIds) > 0 {
+		childrenDeletes := []string{
+			"DELETE FROM dashboard_tag WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM dashboard_search_term WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM dashboard_usage WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM dashboard_user_view WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM star WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM dashboard_version WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM dashboard_provisioning WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+			"DELETE FROM dashboard_acl WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)",
+		}
+		if deleteAnnotations {
+			childrenDeletes = append(childrenDeletes,
+				"DELETE FROM annotation WHERE dashboard_id IN (SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ?)")
+		}
+		for _, sql := range childrenDeletes {
+			_, err := sess.Exec(sql, orgId, folderId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = sess.Exec("DELETE FROM dashboard WHERE folder_id = ?", folderId)
+	return err
+}
+
+// getFolderSubtreeIds returns the ID of a folder followed by the IDs of all folders below it, parents
+// before their subfolders.
+func GetFolderSubtreeIds(sess *DBSession, orgId int64, folderId int64) ([]int64, error) {
+	ids := []int64{folderId}
+	for i := 0; i < len(ids); i++ {
+		children := []struct {
+			Id int64
+		}{}
+		err := sess.SQL("SELECT id FROM dashboard WHERE org_id = ? AND folder_id = ? AND is_folder = ?",
+			orgId, ids[i], dialect.BooleanStr(true)).Find(&children)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			ids = append(ids, child.Id)
+		}
+	}
+	return ids, nil
+}
+
 func GetDashboards(query *models.GetDashboardsQuery) error {
 	if len(query.DashboardIds) == 0 {
 		return models.ErrCommandValidationFailed
@@ -468,11 +561,12 @@ func GetDashboardPermissionsForUser(query *models.GetDashboardPermissionsForUser
 	}
 
 	params := make([]interface{}, 0)
+	depth := folderDepth(query.OrgId)
 
 	// check dashboards that have ACLs via user id, team id or role
 	sql := `SELECT d.id AS dashboard_id, MAX(COALESCE(da.permission, pt.permission)) AS permission
-	FROM dashboard AS d
-		LEFT JOIN dashboard_acl as da on d.folder_id = da.dashboard_id or d.id = da.dashboard_id
+	FROM dashboard AS d` + permissions.AncestorJoins("d", depth) + `
+		LEFT JOIN dashboard_acl as da on da.dashboard_id IN (` + permissions.AncestorIDs("d", depth) + `)
 		LEFT JOIN team_member as ugm on ugm.team_id =  da.team_id
 		LEFT JOIN org_user ou ON ou.role = da.role AND ou.user_id = ?
 	`
@@ -641,7 +735,7 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, cmd *models.Validat
 		return models.ErrDashboardTypeMismatch
 	}
 
-	if !dash.IsFolder && dash.FolderId != existing.FolderId {
+	if dash.FolderId != existing.FolderId {
 		cmd.Result.IsParentFolderChanged = true
 	}
 
@@ -666,7 +760,14 @@ func getExistingDashboardByTitleAndFolder(sess *DBSession, cmd *models.ValidateD
 	dash := cmd.Dashboard
 	var existing models.Dashboard
 
-	exists, err := sess.Where("org_id=? AND slug=? AND (is_folder=? OR folder_id=?)", dash.OrgId, dash.Slug, dialect.BooleanStr(true), dash.FolderId).Get(&existing)
+	var exists bool
+	var err error
+	if dash.IsFolder {
+		// folders only need a unique name within their parent folder
+		exists, err = sess.Where("org_id=? AND slug=? AND folder_id=?", dash.OrgId, dash.Slug, dash.FolderId).Get(&existing)
+	} else {
+		exists, err = sess.Where("org_id=? AND slug=? AND (is_folder=? OR folder_id=?)", dash.OrgId, dash.Slug, dialect.BooleanStr(true), dash.FolderId).Get(&existing)
+	}
 	if err != nil {
 		return err
 	}
@@ -708,10 +809,78 @@ func ValidateDashboardBeforeSave(cmd *models.ValidateDashboardBeforeSaveCommand)
 			return err
 		}
 
+		if cmd.Dashboard.IsFolder {
+			if err = validateFolderParent(sess, cmd.Dashboard); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 }
 
+// folderDepth returns the number of folder levels above the dashboards of the org, so that the permission
+// queries only join the folders of orgs with nested folders. All the levels are joined if the folders cannot
+// be queried.
+func folderDepth(orgId int64) int {
+	nested, err := x.Where("org_id=? AND is_folder=? AND folder_id<>0", orgId, dialect.BooleanStr(true)).Exist(&models.Dashboard{})
+	if err != nil {
+		sqlog.Warn("Failed to find nested folders", "orgId", orgId, "err", err)
+		return permissions.FolderDepth(true)
+	}
+	return permissions.FolderDepth(nested)
+}
+
+// validateFolderParent checks that a folder is not moved into itself or one of its subfolders, and that
+// the folders would not be nested more than models.MaxFolderDepth levels deep.
+func validateFolderParent(sess *DBSession, folder *models.Dashboard) error {
+	// levels of the parent folders
+	levels := 0
+	for parentId := folder.FolderId; parentId > 0; {
+		if parentId == folder.Id {
+			return models.ErrFolderCannotBeOwnAncestor
+		}
+		levels++
+		if levels >= models.MaxFolderDepth {
+			return models.ErrFolderMaxDepthExceeded
+		}
+
+		var parent models.Dashboard
+		exists, err := sess.Where("org_id=? AND id=?", folder.OrgId, parentId).Cols("folder_id").Get(&parent)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return models.ErrDashboardFolderNotFound
+		}
+		parentId = parent.FolderId
+	}
+
+	// levels of the folder and its subfolders
+	height := 1
+	if folder.Id > 0 {
+		current := []int64{folder.Id}
+		for {
+			var children []int64
+			err := sess.Table("dashboard").Cols("id").Where("org_id=? AND is_folder=?", folder.OrgId, dialect.BooleanStr(true)).
+				In("folder_id", current).Find(&children)
+			if err != nil {
+				return err
+			}
+			if len(children) == 0 {
+				break
+			}
+			height++
+			current = children
+		}
+	}
+
+	if levels+height > models.MaxFolderDepth {
+		return models.ErrFolderMaxDepthExceeded
+	}
+	return nil
+}
+
 func HasEditPermissionInFolders(query *models.HasEditPermissionInFoldersQuery) error {
 	if query.SignedInUser.HasRole(models.ROLE_EDITOR) {
 		query.Result = true
@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
 func init() {
@@ -43,8 +44,9 @@ func UpdateDashboardAcl(cmd *models.UpdateDashboardAclCommand) error {
 // GetDashboardAclInfoList returns a list of permissions for a dashboard. They can be fetched from three
 // different places.
 // 1) Permissions for the dashboard
-// 2) permissions for its parent folder
-// 3) if no specific permissions have been set for the dashboard or its parent folder then get the default permissions
+// 2) permissions for its parent folders, up to the top-level folder
+// 3) if no specific permissions have been set for any of its parent folders, or for the dashboard if it is a
+// folder or is not in a folder, then get the default permissions
 func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 	var err error
 
@@ -74,8 +76,9 @@ func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 		query.Result = make([]*models.DashboardAclInfoDTO, 0)
 		err = x.SQL(sql).Find(&query.Result)
 	} else {
+		depth := folderDepth(query.OrgID)
 		rawSQL := `
-			-- get permissions for the dashboard and its parent folder
+			-- get permissions for the dashboard and its parent folders
 			SELECT
 				da.id,
 				da.org_id,
@@ -94,18 +97,13 @@ func GetDashboardAclInfoList(query *models.GetDashboardAclInfoListQuery) error {
 				d.slug,
 				d.uid,
 				d.is_folder,
-				CASE WHEN (da.dashboard_id = -1 AND d.folder_id > 0) OR da.dashboard_id = d.folder_id THEN ` + dialect.BooleanStr(true) + ` ELSE ` + falseStr + ` END AS inherited
-			FROM dashboard as d
-				LEFT JOIN dashboard folder on folder.id = d.folder_id
+				CASE WHEN da.dashboard_id = d.id OR (da.dashboard_id = -1 AND d.folder_id = 0) THEN ` + falseStr + ` ELSE ` + dialect.BooleanStr(true) + ` END AS inherited
+			FROM dashboard as d` + permissions.AncestorJoins("d", depth) + `
 				LEFT JOIN dashboard_acl AS da ON
-				da.dashboard_id = d.id OR
-				da.dashboard_id = d.folder_id OR
+				da.dashboard_id IN (` + permissions.AncestorIDs("d", depth) + `) OR
 				(
 					-- include default permissions -->
-					da.org_id = -1 AND (
-					  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-					  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-					)
+					da.org_id = -1 AND ` + permissions.HasNoACL("d", depth, dialect) + `
 				)
 				LEFT JOIN ` + dialect.Quote("user") + ` AS u ON u.id = da.user_id
 				LEFT JOIN team ug on ug.id = da.team_id
@@ -0,0 +1,203 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNestedFolders(t *testing.T) {
+	InitTestDB(t)
+
+	root := insertTestDashboard(t, "root folder", 1, 0, true)
+	child := insertTestDashboard(t, "child folder", 1, root.Id, true)
+	grandchild := insertTestDashboard(t, "grandchild folder", 1, child.Id, true)
+	dashInChild := insertTestDashboard(t, "dash in child", 1, child.Id, false)
+	dashInGrandchild := insertTestDashboard(t, "dash in grandchild", 1, grandchild.Id, false)
+	dashInRoot := insertTestDashboard(t, "dash in root", 1, 0, false)
+
+	viewer := createUser(t, "viewer", "Viewer", false)
+	signedInViewer := &models.SignedInUser{UserId: viewer.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+
+	t.Run("folders with the same name are allowed in different parents", func(t *testing.T) {
+		other := insertTestDashboard(t, "child folder", 1, grandchild.Id, true)
+		t.Cleanup(func() {
+			err := DeleteDashboard(&models.DeleteDashboardCommand{Id: other.Id, OrgId: 1})
+			require.NoError(t, err)
+		})
+
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			FolderId:  root.Id,
+			IsFolder:  true,
+			Dashboard: models.NewDashboardFolder("child folder").Data,
+		}
+		validate := &models.ValidateDashboardBeforeSaveCommand{OrgId: 1, Dashboard: cmd.GetDashboardModel()}
+		err := ValidateDashboardBeforeSave(validate)
+		require.Equal(t, models.ErrDashboardWithSameNameInFolderExists, err)
+	})
+
+	t.Run("permissions are inherited from all ancestors", func(t *testing.T) {
+		err := testHelperUpdateDashboardAcl(root.Id, models.DashboardAcl{
+			DashboardID: root.Id, OrgID: 1, UserID: viewer.Id, Permission: models.PERMISSION_EDIT,
+		})
+		require.NoError(t, err)
+
+		query := &models.GetDashboardAclInfoListQuery{DashboardID: dashInGrandchild.Id, OrgID: 1}
+		err = GetDashboardAclInfoList(query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, viewer.Id, query.Result[0].UserId)
+		assert.True(t, query.Result[0].Inherited)
+
+		permissions := &models.GetDashboardPermissionsForUserQuery{
+			DashboardIds: []int64{dashInGrandchild.Id},
+			OrgId:        1,
+			UserId:       viewer.Id,
+			OrgRole:      models.ROLE_VIEWER,
+		}
+		err = GetDashboardPermissionsForUser(permissions)
+		require.NoError(t, err)
+		require.Len(t, permissions.Result, 1)
+		assert.Equal(t, models.PERMISSION_EDIT, permissions.Result[0].Permission)
+	})
+
+	t.Run("permissions of the top-level folder hide its subfolders", func(t *testing.T) {
+		other := createUser(t, "other", "Viewer", false)
+		query := &search.FindPersistedDashboardsQuery{
+			SignedInUser: &models.SignedInUser{UserId: other.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER},
+			OrgId:        1,
+			DashboardIds: []int64{dashInGrandchild.Id, dashInRoot.Id},
+		}
+		err := SearchDashboards(query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, dashInRoot.Id, query.Result[0].ID)
+	})
+
+	t.Run("search includes dashboards of subfolders", func(t *testing.T) {
+		query := &search.FindPersistedDashboardsQuery{
+			SignedInUser:      signedInViewer,
+			OrgId:             1,
+			FolderIds:         []int64{child.Id},
+			Type:              "dash-db",
+			IncludeSubfolders: true,
+		}
+		err := SearchDashboards(query)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, hit := range query.Result {
+			ids = append(ids, hit.ID)
+		}
+		assert.ElementsMatch(t, []int64{dashInChild.Id, dashInGrandchild.Id}, ids)
+
+		query.IncludeSubfolders = false
+		query.Result = nil
+		err = SearchDashboards(query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, dashInChild.Id, query.Result[0].ID)
+	})
+
+	t.Run("folders cannot be moved into their subfolders", func(t *testing.T) {
+		root.FolderId = grandchild.Id
+		cmd := &models.ValidateDashboardBeforeSaveCommand{OrgId: 1, Dashboard: root, Overwrite: true}
+		err := ValidateDashboardBeforeSave(cmd)
+		require.Equal(t, models.ErrFolderCannotBeOwnAncestor, err)
+		root.FolderId = 0
+	})
+
+	t.Run("folders cannot be nested too deep", func(t *testing.T) {
+		parentId := grandchild.Id
+		for i := 3; i < models.MaxFolderDepth; i++ {
+			parentId = insertTestDashboard(t, fmt.Sprintf("folder %d", i), 1, parentId, true).Id
+		}
+
+		cmd := models.SaveDashboardCommand{
+			OrgId:     1,
+			FolderId:  parentId,
+			IsFolder:  true,
+			Dashboard: models.NewDashboardFolder("too deep").Data,
+		}
+		validate := &models.ValidateDashboardBeforeSaveCommand{OrgId: 1, Dashboard: cmd.GetDashboardModel()}
+		err := ValidateDashboardBeforeSave(validate)
+		require.Equal(t, models.ErrFolderMaxDepthExceeded, err)
+
+		// dashboards can still be saved in the deepest folder
+		insertTestDashboard(t, "deepest dash", 1, parentId, false)
+	})
+
+	t.Run("deleting a folder deletes its subfolders and dashboards", func(t *testing.T) {
+		err := DeleteDashboard(&models.DeleteDashboardCommand{Id: child.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		for _, id := range []int64{child.Id, grandchild.Id, dashInChild.Id, dashInGrandchild.Id} {
+			query := &models.GetDashboardQuery{Id: id, OrgId: 1}
+			err := GetDashboard(query)
+			require.Equal(t, models.ErrDashboardNotFound, err)
+		}
+
+		query := &models.GetDashboardQuery{Id: root.Id, OrgId: 1}
+		require.NoError(t, GetDashboard(query))
+	})
+}
+
+func TestNestedFolderWithPermissionsUnderFolderWithout(t *testing.T) {
+	InitTestDB(t)
+
+	root := insertTestDashboard(t, "open folder", 1, 0, true)
+	dashInRoot := insertTestDashboard(t, "dash in open folder", 1, root.Id, false)
+	assert.Equal(t, 1, folderDepth(1))
+
+	restricted := insertTestDashboard(t, "restricted folder", 1, root.Id, true)
+	dashInRestricted := insertTestDashboard(t, "dash in restricted folder", 1, restricted.Id, false)
+	assert.Equal(t, models.MaxFolderDepth, folderDepth(1))
+
+	viewer := createUser(t, "viewer", "Viewer", false)
+	other := createUser(t, "other", "Viewer", false)
+	err := testHelperUpdateDashboardAcl(restricted.Id, models.DashboardAcl{
+		DashboardID: restricted.Id, OrgID: 1, UserID: viewer.Id, Permission: models.PERMISSION_VIEW,
+	})
+	require.NoError(t, err)
+
+	searchIDs := func(t *testing.T, userId int64) []int64 {
+		query := &search.FindPersistedDashboardsQuery{
+			SignedInUser: &models.SignedInUser{UserId: userId, OrgId: 1, OrgRole: models.ROLE_VIEWER},
+			OrgId:        1,
+		}
+		err := SearchDashboards(query)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, hit := range query.Result {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("the permissions of the subfolder restrict its content", func(t *testing.T) {
+		assert.ElementsMatch(t, []int64{root.Id, dashInRoot.Id}, searchIDs(t, other.Id))
+		assert.ElementsMatch(t, []int64{root.Id, dashInRoot.Id, restricted.Id, dashInRestricted.Id}, searchIDs(t, viewer.Id))
+	})
+
+	t.Run("the default permissions do not apply to the content of the subfolder", func(t *testing.T) {
+		query := &models.GetDashboardAclInfoListQuery{DashboardID: dashInRestricted.Id, OrgID: 1}
+		err := GetDashboardAclInfoList(query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		assert.Equal(t, viewer.Id, query.Result[0].UserId)
+
+		query = &models.GetDashboardAclInfoListQuery{DashboardID: dashInRoot.Id, OrgID: 1}
+		err = GetDashboardAclInfoList(query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 2)
+		for _, item := range query.Result {
+			assert.Equal(t, int64(-1), item.DashboardId)
+		}
+	})
+}
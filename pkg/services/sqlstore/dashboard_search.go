@@ -0,0 +1,114 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+)
+
+// maxSearchMatchesPerHit is the maximum number of matches returned for a dashboard by a content search.
+const maxSearchMatchesPerHit = 20
+
+func init() {
+	bus.AddHandlerCtx("sql", UpdateDashboardSearchTerms)
+}
+
+// UpdateDashboardSearchTerms indexes the search terms of dashboards again, in the transaction of the context if
+// there is one.
+func UpdateDashboardSearchTerms(ctx context.Context, cmd *models.UpdateDashboardSearchTermsCommand) error {
+	return inTransactionCtx(ctx, func(sess *DBSession) error {
+		for _, id := range cmd.DashboardIds {
+			var dash models.Dashboard
+			exists, err := sess.Where("org_id=? AND id=?", cmd.OrgId, id).Get(&dash)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			if err := updateDashboardSearchTerms(sess, &dash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// updateDashboardSearchTerms replaces the search terms of a dashboard with the terms of its current data. The
+// models of its library panels are indexed when the library panels service is registered.
+func updateDashboardSearchTerms(sess *DBSession, dash *models.Dashboard) error {
+	if _, err := sess.Exec("DELETE FROM dashboard_search_term WHERE dashboard_id=?", dash.Id); err != nil {
+		return err
+	}
+	if dash.IsFolder {
+		return nil
+	}
+
+	var libraryPanels map[models.LibraryPanelRef]*simplejson.Json
+	if refs := dash.GetLibraryPanelRefs(); len(refs) > 0 {
+		query := &models.GetLibraryPanelModelsQuery{OrgId: dash.OrgId, Panels: refs}
+		ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+		if err := bus.DispatchCtx(ctx, query); err != nil && !errors.Is(err, bus.ErrHandlerNotFound) {
+			return err
+		}
+		libraryPanels = query.Result
+	}
+
+	for _, term := range dash.GetSearchTerms(libraryPanels) {
+		term.DashboardId = dash.Id
+		if _, err := sess.Insert(term); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSearchMatches adds the matches of a content search to the dashboard hits.
+func addSearchMatches(text string, hits search.HitList) error {
+	ids := make([]interface{}, 0, len(hits))
+	hitsById := map[int64]*search.Hit{}
+	lowerText := strings.ToLower(text)
+	for _, hit := range hits {
+		if hit.Type != search.DashHitDB {
+			continue
+		}
+		hit.Matches = make([]*search.Match, 0)
+		if strings.Contains(strings.ToLower(hit.Title), lowerText) {
+			hit.Matches = append(hit.Matches, &search.Match{Kind: search.MatchTitle, Text: hit.Title})
+		}
+		ids = append(ids, hit.ID)
+		hitsById[hit.ID] = hit
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	terms := make([]*models.DashboardSearchTerm, 0)
+	sql := fmt.Sprintf(`SELECT * FROM dashboard_search_term
+		WHERE dashboard_id IN (?%s) AND term %s ?
+		ORDER BY dashboard_id, id`, strings.Repeat(",?", len(ids)-1), dialect.LikeStr())
+	params := append(ids, "%"+text+"%")
+	if err := x.SQL(sql, params...).Find(&terms); err != nil {
+		return err
+	}
+
+	for _, term := range terms {
+		hit := hitsById[term.DashboardId]
+		if len(hit.Matches) >= maxSearchMatchesPerHit {
+			continue
+		}
+		hit.Matches = append(hit.Matches, &search.Match{
+			Kind:     string(term.Kind),
+			PanelID:  term.PanelId,
+			Location: term.Location,
+			Text:     term.Term,
+		})
+	}
+	return nil
+}
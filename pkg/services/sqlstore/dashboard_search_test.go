@@ -0,0 +1,121 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardContentSearch(t *testing.T) {
+	InitTestDB(t)
+
+	saveDashboard := func(data map[string]interface{}) *models.Dashboard {
+		cmd := models.SaveDashboardCommand{OrgId: 1, Overwrite: true, Dashboard: simplejson.NewFromAny(data)}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	api := saveDashboard(map[string]interface{}{
+		"title": "API",
+		"panels": []interface{}{
+			map[string]interface{}{
+				"id":          1,
+				"title":       "Requests",
+				"description": "Rate of HTTP requests",
+				"targets": []interface{}{
+					map[string]interface{}{"refId": "A", "expr": "sum(rate(http_requests_total[5m]))"},
+				},
+			},
+		},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "instance", "type": "query", "query": "label_values(http_requests_total, instance)"},
+			},
+		},
+	})
+	nodes := saveDashboard(map[string]interface{}{
+		"title": "Nodes",
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "type": "row", "title": "Memory", "collapsed": true, "panels": []interface{}{
+				map[string]interface{}{"id": 2, "title": "Used", "targets": []interface{}{
+					map[string]interface{}{"refId": "A", "expr": "node_memory_used_bytes"},
+				}},
+			}},
+		},
+	})
+	saveDashboard(map[string]interface{}{"title": "Requests overview"})
+
+	user := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_EDITOR}
+	searchContent := func(text string) search.HitList {
+		query := &search.FindPersistedDashboardsQuery{
+			Title:         text,
+			SignedInUser:  user,
+			SearchContent: true,
+		}
+		require.NoError(t, SearchDashboards(query))
+		return query.Result
+	}
+
+	t.Run("Should find the dashboards whose queries match", func(t *testing.T) {
+		hits := searchContent("HTTP_REQUESTS_TOTAL")
+		require.Len(t, hits, 1)
+		assert.Equal(t, api.Id, hits[0].ID)
+		require.Len(t, hits[0].Matches, 2)
+		assert.Equal(t, &search.Match{
+			Kind: string(models.SearchTermQuery), PanelID: 1, Location: "Requests A", Text: "sum(rate(http_requests_total[5m]))",
+		}, hits[0].Matches[0])
+		assert.Equal(t, &search.Match{
+			Kind: string(models.SearchTermQuery), Location: "$instance", Text: "label_values(http_requests_total, instance)",
+		}, hits[0].Matches[1])
+	})
+
+	t.Run("Should find the dashboards whose title or panels match", func(t *testing.T) {
+		hits := searchContent("requests")
+		require.Len(t, hits, 2)
+		assert.Equal(t, "API", hits[0].Title)
+		kinds := []string{}
+		for _, match := range hits[0].Matches {
+			kinds = append(kinds, match.Kind)
+		}
+		assert.Contains(t, kinds, string(models.SearchTermPanelTitle))
+		assert.Contains(t, kinds, string(models.SearchTermPanelDescription))
+		assert.Equal(t, "Requests overview", hits[1].Title)
+		assert.Equal(t, []*search.Match{{Kind: search.MatchTitle, Text: "Requests overview"}}, hits[1].Matches)
+	})
+
+	t.Run("Should find the panels of collapsed rows", func(t *testing.T) {
+		hits := searchContent("node_memory")
+		require.Len(t, hits, 1)
+		assert.Equal(t, nodes.Id, hits[0].ID)
+		assert.Equal(t, int64(2), hits[0].Matches[0].PanelID)
+	})
+
+	t.Run("Should only search titles without content search", func(t *testing.T) {
+		query := &search.FindPersistedDashboardsQuery{Title: "http_requests_total", SignedInUser: user}
+		require.NoError(t, SearchDashboards(query))
+		assert.Empty(t, query.Result)
+	})
+
+	t.Run("Should index the terms of the saved version", func(t *testing.T) {
+		data := nodes.Data
+		data.Set("id", nodes.Id)
+		data.Set("panels", []interface{}{})
+		saveDashboard(data.MustMap())
+
+		assert.Empty(t, searchContent("node_memory"))
+	})
+
+	t.Run("Should delete the terms of deleted dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: api.Id, OrgId: 1}))
+
+		count, err := x.Where("dashboard_id = ?", api.Id).Count(&models.DashboardSearchTerm{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
@@ -0,0 +1,277 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	bus.AddHandler("sql", GetDashboardTrash)
+	bus.AddHandler("sql", RestoreDashboardFromTrash)
+	bus.AddHandler("sql", PurgeDashboardTrashItem)
+	bus.AddHandler("sql", DeleteExpiredDashboardTrash)
+}
+
+// dashboardTrashContent is the content of a deleted dashboard or folder. The dashboards are ordered with
+// folders before their content. The library panels of a deleted folder and its subfolders are kept in the content
+// returned by the library panels service.
+type dashboardTrashContent struct {
+	Dashboards    []*models.Dashboard        `json:"dashboards"`
+	Versions      []*models.DashboardVersion `json:"versions"`
+	Acl           []*models.DashboardAcl     `json:"acl"`
+	Tags          []*DashboardTag            `json:"tags"`
+	LibraryPanels json.RawMessage            `json:"libraryPanels,omitempty"`
+}
+
+// moveDashboardToTrash keeps a dashboard, or a folder with all its content, in the trash before it is
+// deleted. Annotations are not part of the trash item, they are kept until the item is purged.
+func moveDashboardToTrash(sess *DBSession, dashboard *models.Dashboard, deletedBy int64) error {
+	content := dashboardTrashContent{Dashboards: []*models.Dashboard{dashboard}}
+	if dashboard.IsFolder {
+		folderIds, err := GetFolderSubtreeIds(sess, dashboard.OrgId, dashboard.Id)
+		if err != nil {
+			return err
+		}
+		for _, folderId := range folderIds {
+			var children []*models.Dashboard
+			err := sess.Where("org_id=? AND folder_id=?", dashboard.OrgId, folderId).
+				OrderBy("is_folder DESC, id").Find(&children)
+			if err != nil {
+				return err
+			}
+			content.Dashboards = append(content.Dashboards, children...)
+		}
+		if err := moveLibraryPanelsToTrash(sess, dashboard.OrgId, folderIds, &content); err != nil {
+			return err
+		}
+	}
+
+	dashboardIds := make([]int64, 0, len(content.Dashboards))
+	for _, dash := range content.Dashboards {
+		dashboardIds = append(dashboardIds, dash.Id)
+	}
+	if err := sess.In("dashboard_id", dashboardIds).Find(&content.Versions); err != nil {
+		return err
+	}
+	if err := sess.In("dashboard_id", dashboardIds).Find(&content.Acl); err != nil {
+		return err
+	}
+	if err := sess.In("dashboard_id", dashboardIds).Find(&content.Tags); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	_, err = sess.Insert(&models.DashboardTrashItem{
+		OrgId:       dashboard.OrgId,
+		DashboardId: dashboard.Id,
+		Uid:         dashboard.Uid,
+		Title:       dashboard.Title,
+		IsFolder:    dashboard.IsFolder,
+		FolderId:    dashboard.FolderId,
+		Data:        string(data),
+		DeletedBy:   deletedBy,
+		Deleted:     time.Now(),
+	})
+	return err
+}
+
+// moveLibraryPanelsToTrash adds the library panels of the folders to the trash content, and deletes them. The
+// library panels service handles them in the transaction of the session, when it is registered.
+func moveLibraryPanelsToTrash(sess *DBSession, orgId int64, folderIds []int64, content *dashboardTrashContent) error {
+	cmd := &models.MoveLibraryPanelsToTrashCommand{OrgId: orgId, FolderIds: folderIds}
+	ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+	if err := bus.DispatchCtx(ctx, cmd); err != nil && !errors.Is(err, bus.ErrHandlerNotFound) {
+		return err
+	}
+
+	content.LibraryPanels = cmd.Result
+	return nil
+}
+
+// GetDashboardTrash returns the deleted dashboards and folders of an organization, latest first.
+func GetDashboardTrash(query *models.GetDashboardTrashQuery) error {
+	query.Result = make([]*models.DashboardTrashItemDTO, 0)
+	return x.Table("dashboard_trash").
+		Select(`dashboard_trash.id,
+				dashboard_trash.dashboard_id,
+				dashboard_trash.uid,
+				dashboard_trash.title,
+				dashboard_trash.is_folder,
+				dashboard_trash.folder_id,
+				dashboard_trash.deleted,`+
+			dialect.Quote("user")+`.login as deleted_by`).
+		Join("LEFT", dialect.Quote("user"), `dashboard_trash.deleted_by = `+dialect.Quote("user")+`.id`).
+		Where("dashboard_trash.org_id=?", query.OrgId).
+		OrderBy("dashboard_trash.deleted DESC").
+		Find(&query.Result)
+}
+
+// RestoreDashboardFromTrash restores a deleted dashboard or folder with its versions, permissions, tags and
+// library panels, and removes it from the trash. The search terms of the dashboards are indexed again.
+func RestoreDashboardFromTrash(cmd *models.RestoreDashboardFromTrashCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		item, content, err := getDashboardTrashItem(sess, cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		dashboard := content.Dashboards[0]
+		if dashboard.FolderId > 0 {
+			exists, err := sess.Where("org_id=? AND id=? AND is_folder=?", cmd.OrgId, dashboard.FolderId,
+				dialect.BooleanStr(true)).Exist(&models.Dashboard{})
+			if err != nil {
+				return err
+			}
+			if !exists {
+				dashboard.FolderId = 0
+			}
+		}
+
+		validateCmd := &models.ValidateDashboardBeforeSaveCommand{
+			OrgId:     cmd.OrgId,
+			Dashboard: dashboard,
+			Result:    &models.ValidateDashboardBeforeSaveResult{},
+		}
+		if err := getExistingDashboardByTitleAndFolder(sess, validateCmd); err != nil {
+			return err
+		}
+
+		// the library panels are restored first, to index them with the search terms of the dashboards
+		if len(content.LibraryPanels) > 0 {
+			ctx := context.WithValue(context.Background(), ContextSessionKey{}, sess)
+			err := bus.DispatchCtx(ctx, &models.RestoreLibraryPanelsFromTrashCommand{Data: content.LibraryPanels})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, dash := range content.Dashboards {
+			exists, err := sess.Where("id=? OR (org_id=? AND uid=?)", dash.Id, dash.OrgId, dash.Uid).Exist(&models.Dashboard{})
+			if err != nil {
+				return err
+			}
+			if exists {
+				return models.ErrDashboardWithSameUIDExists
+			}
+			if _, err := sess.Insert(dash); err != nil {
+				return err
+			}
+			if err := updateDashboardSearchTerms(sess, dash); err != nil {
+				return err
+			}
+		}
+
+		for _, version := range content.Versions {
+			version.Id = 0
+			if _, err := sess.Insert(version); err != nil {
+				return err
+			}
+		}
+		for _, acl := range content.Acl {
+			acl.Id = 0
+			if _, err := sess.Insert(acl); err != nil {
+				return err
+			}
+		}
+		for _, tag := range content.Tags {
+			tag.Id = 0
+			if _, err := sess.Insert(tag); err != nil {
+				return err
+			}
+		}
+
+		if _, err := sess.Exec("DELETE FROM dashboard_trash WHERE id = ?", item.Id); err != nil {
+			return err
+		}
+
+		cmd.Result = content.Dashboards
+		return nil
+	})
+}
+
+// PurgeDashboardTrashItem permanently deletes a dashboard or folder from the trash.
+func PurgeDashboardTrashItem(cmd *models.PurgeDashboardTrashItemCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		item, content, err := getDashboardTrashItem(sess, cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		return purgeDashboardTrashItem(sess, item, content)
+	})
+}
+
+// DeleteExpiredDashboardTrash permanently deletes the dashboards and folders that have been in the trash
+// longer than the trash retention.
+func DeleteExpiredDashboardTrash(cmd *models.DeleteExpiredDashboardTrashCommand) error {
+	if setting.DashboardTrashRetention <= 0 {
+		return nil
+	}
+
+	return inTransaction(func(sess *DBSession) error {
+		var items []*models.DashboardTrashItem
+		err := sess.Where("deleted < ?", time.Now().Add(-setting.DashboardTrashRetention)).Find(&items)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			var content dashboardTrashContent
+			if err := json.Unmarshal([]byte(item.Data), &content); err != nil {
+				return err
+			}
+			if err := purgeDashboardTrashItem(sess, item, &content); err != nil {
+				return err
+			}
+		}
+
+		cmd.DeletedRows = int64(len(items))
+		return nil
+	})
+}
+
+func getDashboardTrashItem(sess *DBSession, orgId int64, id int64) (*models.DashboardTrashItem, *dashboardTrashContent, error) {
+	item := models.DashboardTrashItem{}
+	has, err := sess.Where("org_id=? AND id=?", orgId, id).Get(&item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !has {
+		return nil, nil, models.ErrDashboardTrashItemNotFound
+	}
+
+	var content dashboardTrashContent
+	if err := json.Unmarshal([]byte(item.Data), &content); err != nil {
+		return nil, nil, err
+	}
+
+	return &item, &content, nil
+}
+
+func purgeDashboardTrashItem(sess *DBSession, item *models.DashboardTrashItem, content *dashboardTrashContent) error {
+	if len(content.Dashboards) > 0 {
+		dashboardIds := make([]interface{}, 0, len(content.Dashboards))
+		for _, dash := range content.Dashboards {
+			dashboardIds = append(dashboardIds, dash.Id)
+		}
+
+		sql := "DELETE FROM annotation WHERE dashboard_id IN (?" + strings.Repeat(",?", len(dashboardIds)-1) + ")"
+		if _, err := sess.Exec(append([]interface{}{sql}, dashboardIds...)...); err != nil {
+			return err
+		}
+	}
+
+	_, err := sess.Exec("DELETE FROM dashboard_trash WHERE id = ?", item.Id)
+	return err
+}
@@ -0,0 +1,143 @@
+// +build integration
+
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardTrash(t *testing.T) {
+	InitTestDB(t)
+
+	user := createUser(t, "admin", "Admin", false)
+	folder := insertTestDashboard(t, "folder", 1, 0, true)
+	subfolder := insertTestDashboard(t, "subfolder", 1, folder.Id, true)
+	dash := insertTestDashboard(t, "dash", 1, subfolder.Id, false, "prod")
+	other := insertTestDashboard(t, "other", 1, 0, false)
+
+	err := testHelperUpdateDashboardAcl(subfolder.Id, models.DashboardAcl{
+		DashboardID: subfolder.Id, OrgID: 1, UserID: user.Id, Permission: models.PERMISSION_EDIT,
+	})
+	require.NoError(t, err)
+
+	repo := SQLAnnotationRepo{}
+	require.NoError(t, repo.Save(&annotations.Item{OrgId: 1, DashboardId: dash.Id, Text: "deploy", Epoch: 10}))
+
+	annotationCount := func(dashboardId int64) int64 {
+		count, err := x.Where("dashboard_id = ?", dashboardId).Count(&annotations.Item{})
+		require.NoError(t, err)
+		return count
+	}
+
+	trash := func() []*models.DashboardTrashItemDTO {
+		query := &models.GetDashboardTrashQuery{OrgId: 1}
+		require.NoError(t, GetDashboardTrash(query))
+		return query.Result
+	}
+
+	t.Run("deleted folders are moved to the trash with their content", func(t *testing.T) {
+		err := DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1, MoveToTrash: true, DeletedBy: user.Id})
+		require.NoError(t, err)
+
+		err = GetDashboard(&models.GetDashboardQuery{Id: dash.Id, OrgId: 1})
+		require.Equal(t, models.ErrDashboardNotFound, err)
+		assert.Equal(t, int64(1), annotationCount(dash.Id))
+
+		items := trash()
+		require.Len(t, items, 1)
+		assert.Equal(t, folder.Uid, items[0].Uid)
+		assert.True(t, items[0].IsFolder)
+		assert.Equal(t, "admin", items[0].DeletedBy)
+	})
+
+	t.Run("restored folders get back their content, versions, permissions and tags", func(t *testing.T) {
+		cmd := &models.RestoreDashboardFromTrashCommand{Id: trash()[0].Id, OrgId: 1}
+		require.NoError(t, RestoreDashboardFromTrash(cmd))
+		require.Len(t, cmd.Result, 3)
+		assert.Empty(t, trash())
+
+		query := &models.GetDashboardQuery{Uid: dash.Uid, OrgId: 1}
+		require.NoError(t, GetDashboard(query))
+		assert.Equal(t, dash.Id, query.Result.Id)
+		assert.Equal(t, subfolder.Id, query.Result.FolderId)
+
+		versions := &models.GetDashboardVersionsQuery{DashboardId: dash.Id, OrgId: 1}
+		require.NoError(t, GetDashboardVersions(versions))
+		assert.Len(t, versions.Result, 1)
+
+		acl := &models.GetDashboardAclInfoListQuery{DashboardID: dash.Id, OrgID: 1}
+		require.NoError(t, GetDashboardAclInfoList(acl))
+		userIds := []int64{}
+		for _, item := range acl.Result {
+			userIds = append(userIds, item.UserId)
+		}
+		assert.Contains(t, userIds, user.Id)
+
+		tags := &models.GetDashboardTagsQuery{OrgId: 1}
+		require.NoError(t, GetDashboardTags(tags))
+		require.Len(t, tags.Result, 1)
+		assert.Equal(t, "prod", tags.Result[0].Term)
+	})
+
+	t.Run("dashboards are restored to the General folder when their folder is gone", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1, MoveToTrash: true}))
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1}))
+
+		items := trash()
+		require.Len(t, items, 1)
+		cmd := &models.RestoreDashboardFromTrashCommand{Id: items[0].Id, OrgId: 1}
+		require.NoError(t, RestoreDashboardFromTrash(cmd))
+		assert.Equal(t, int64(0), cmd.Result[0].FolderId)
+	})
+
+	t.Run("dashboards are not restored over existing dashboards", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: other.Id, OrgId: 1, MoveToTrash: true}))
+		insertTestDashboard(t, "other", 1, 0, false)
+
+		items := trash()
+		require.Len(t, items, 1)
+		err := RestoreDashboardFromTrash(&models.RestoreDashboardFromTrashCommand{Id: items[0].Id, OrgId: 1})
+		require.Equal(t, models.ErrDashboardWithSameNameInFolderExists, err)
+		assert.Len(t, trash(), 1)
+	})
+
+	t.Run("purged dashboards are deleted with their annotations", func(t *testing.T) {
+		require.NoError(t, DeleteDashboard(&models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1, MoveToTrash: true}))
+		var itemId int64
+		for _, item := range trash() {
+			if item.Uid == dash.Uid {
+				itemId = item.Id
+			}
+		}
+
+		err := PurgeDashboardTrashItem(&models.PurgeDashboardTrashItemCommand{Id: itemId, OrgId: 2})
+		require.Equal(t, models.ErrDashboardTrashItemNotFound, err)
+
+		require.NoError(t, PurgeDashboardTrashItem(&models.PurgeDashboardTrashItemCommand{Id: itemId, OrgId: 1}))
+		assert.Len(t, trash(), 1)
+		assert.Equal(t, int64(0), annotationCount(dash.Id))
+	})
+
+	t.Run("expired dashboards are deleted from the trash", func(t *testing.T) {
+		origRetention := setting.DashboardTrashRetention
+		t.Cleanup(func() { setting.DashboardTrashRetention = origRetention })
+
+		setting.DashboardTrashRetention = time.Hour
+		cmd := &models.DeleteExpiredDashboardTrashCommand{}
+		require.NoError(t, DeleteExpiredDashboardTrash(cmd))
+		assert.Equal(t, int64(0), cmd.DeletedRows)
+
+		_, err := x.Exec("UPDATE dashboard_trash SET deleted = ?", time.Now().Add(-2*time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, DeleteExpiredDashboardTrash(cmd))
+		assert.Equal(t, int64(1), cmd.DeletedRows)
+		assert.Empty(t, trash())
+	})
+}
@@ -0,0 +1,182 @@
+package migrations
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"xorm.io/xorm"
+)
+
+func addDashboardSearchMigrations(mg *Migrator) {
+	dashboardSearchTermV1 := Table{
+		Name: "dashboard_search_term",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "panel_id", Type: DB_BigInt, Nullable: false},
+			{Name: "location", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "term", Type: DB_Text, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"dashboard_id"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_search_term table v1", NewAddTableMigration(dashboardSearchTermV1))
+	mg.AddMigration("add index dashboard_search_term.dashboard_id", NewAddIndexMigration(dashboardSearchTermV1, dashboardSearchTermV1.Indices[0]))
+	mg.AddMigration("index existing dashboards for search", &IndexDashboardSearchTermsMigration{})
+}
+
+// IndexDashboardSearchTermsMigration indexes the search terms of the dashboards saved before the
+// dashboard_search_term table existed. The extraction of the terms is frozen in the migration, so that later
+// changes of the search terms of models.Dashboard do not change what the migration does.
+type IndexDashboardSearchTermsMigration struct {
+	MigrationBase
+}
+
+func (m *IndexDashboardSearchTermsMigration) SQL(dialect Dialect) string {
+	return "code migration"
+}
+
+func (m *IndexDashboardSearchTermsMigration) Exec(sess *xorm.Session, mg *Migrator) error {
+	const batchSize = 100
+
+	lastId := int64(0)
+	for {
+		dashboards := make([]*struct {
+			Id   int64
+			Data []byte
+		}, 0)
+		err := sess.SQL("SELECT id, data FROM dashboard WHERE id > ? AND is_folder = ? ORDER BY id "+mg.Dialect.Limit(batchSize),
+			lastId, mg.Dialect.BooleanStr(false)).Find(&dashboards)
+		if err != nil {
+			return err
+		}
+		if len(dashboards) == 0 {
+			return nil
+		}
+
+		for _, dashboard := range dashboards {
+			lastId = dashboard.Id
+			data, err := simplejson.NewJson(dashboard.Data)
+			if err != nil {
+				mg.Logger.Warn("Failed to index dashboard for search", "id", dashboard.Id, "error", err)
+				continue
+			}
+			for _, term := range dashboardSearchTermsV1(dashboard.Id, data) {
+				if _, err := sess.Table("dashboard_search_term").Insert(term); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// dashboardSearchTermV1 is a row of the dashboard_search_term table v1.
+type dashboardSearchTermV1 struct {
+	Id          int64
+	DashboardId int64
+	Kind        string
+	PanelId     int64
+	Location    string
+	Term        string
+}
+
+// dashboardSearchTermsV1 returns the search terms of a dashboard: the titles and descriptions of its panels, the
+// titles of its rows, the names of its template variables, and the queries of its panels and variables.
+func dashboardSearchTermsV1(dashboardId int64, data *simplejson.Json) []*dashboardSearchTermV1 {
+	terms := make([]*dashboardSearchTermV1, 0)
+	seen := map[dashboardSearchTermV1]bool{}
+	add := func(kind string, panelId int64, location string, term string) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return
+		}
+		if utf8.RuneCountInString(location) > 255 {
+			location = string([]rune(location)[:255])
+		}
+		key := dashboardSearchTermV1{Kind: kind, PanelId: panelId, Location: location, Term: term}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		terms = append(terms, &dashboardSearchTermV1{
+			DashboardId: dashboardId,
+			Kind:        kind,
+			PanelId:     panelId,
+			Location:    location,
+			Term:        term,
+		})
+	}
+
+	var addPanels func(panels []interface{})
+	addPanels = func(panels []interface{}) {
+		for _, item := range panels {
+			panel := simplejson.NewFromAny(item)
+			id := panel.Get("id").MustInt64()
+			title := panel.Get("title").MustString()
+			if panel.Get("type").MustString() == "row" {
+				add("row", id, title, title)
+				addPanels(panel.Get("panels").MustArray())
+				continue
+			}
+
+			add("panelTitle", id, title, title)
+			add("panelDescription", id, title, panel.Get("description").MustString())
+			for _, target := range panel.Get("targets").MustArray() {
+				target := simplejson.NewFromAny(target)
+				location := title
+				if refId := target.Get("refId").MustString(); refId != "" {
+					location = strings.TrimSpace(title + " " + refId)
+				}
+				for _, query := range dashboardSearchQueriesV1(target) {
+					add("query", id, location, query)
+				}
+			}
+		}
+	}
+
+	addPanels(data.Get("panels").MustArray())
+	for _, item := range data.Get("rows").MustArray() {
+		row := simplejson.NewFromAny(item)
+		title := row.Get("title").MustString()
+		add("row", 0, title, title)
+		addPanels(row.Get("panels").MustArray())
+	}
+
+	for _, item := range data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		name := variable.Get("name").MustString()
+		add("variable", 0, name, name)
+		if label := variable.Get("label").MustString(); label != "" && label != name {
+			add("variable", 0, name, label)
+		}
+		if variable.Get("type").MustString() == "query" {
+			for _, query := range dashboardSearchQueriesV1(variable) {
+				add("query", 0, "$"+name, query)
+			}
+		}
+	}
+
+	return terms
+}
+
+// dashboardSearchQueriesV1 returns the query texts of a panel target or template variable.
+func dashboardSearchQueriesV1(json *simplejson.Json) []string {
+	queries := make([]string, 0)
+	for _, key := range []string{"expr", "expression", "query", "queryText", "rawSql", "target"} {
+		value, ok := json.CheckGet(key)
+		if !ok {
+			continue
+		}
+		if query, err := value.String(); err == nil {
+			queries = append(queries, query)
+		} else if query, err := value.Get("query").String(); err == nil {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
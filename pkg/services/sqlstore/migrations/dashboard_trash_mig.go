@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addDashboardTrashMigrations(mg *Migrator) {
+	dashboardTrashV1 := Table{
+		Name: "dashboard_trash",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 189, Nullable: false},
+			{Name: "is_folder", Type: DB_Bool, Nullable: false},
+			{Name: "folder_id", Type: DB_BigInt, Nullable: false},
+			{Name: "data", Type: DB_MediumText, Nullable: false},
+			{Name: "deleted_by", Type: DB_BigInt, Nullable: false},
+			{Name: "deleted", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id"}},
+			{Cols: []string{"deleted"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard_trash table v1", NewAddTableMigration(dashboardTrashV1))
+	mg.AddMigration("add index dashboard_trash.org_id", NewAddIndexMigration(dashboardTrashV1, dashboardTrashV1.Indices[0]))
+	mg.AddMigration("add index dashboard_trash.deleted", NewAddIndexMigration(dashboardTrashV1, dashboardTrashV1.Indices[1]))
+}
@@ -35,6 +35,9 @@ func AddMigrations(mg *Migrator) {
 	addUserAuthTokenMigrations(mg)
 	addCacheMigration(mg)
 	addShortURLMigrations(mg)
+	addDashboardTrashMigrations(mg)
+	addDashboardSearchMigrations(mg)
+	addDashboardUsageMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
@@ -235,6 +235,9 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 		deletes := []string{
 			"DELETE FROM star WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND star.dashboard_id = dashboard.id)",
 			"DELETE FROM dashboard_tag WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_tag.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_search_term WHERE EXISTS (SELECT 1 FROM dashboard WHERE org_id = ? AND dashboard_search_term.dashboard_id = dashboard.id)",
+			"DELETE FROM dashboard_usage WHERE org_id = ?",
+			"DELETE FROM dashboard_user_view WHERE org_id = ?",
 			"DELETE FROM dashboard WHERE org_id = ?",
 			"DELETE FROM api_key WHERE org_id = ?",
 			"DELETE FROM data_source WHERE org_id = ?",
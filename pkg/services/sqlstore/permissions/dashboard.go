@@ -13,6 +13,9 @@ type DashboardPermissionFilter struct {
 	UserId          int64
 	OrgId           int64
 	PermissionLevel models.PermissionType
+	// FolderDepth is the number of folder levels above the dashboards of the org, as returned by
+	// the FolderDepth function.
+	FolderDepth int
 }
 
 func (d DashboardPermissionFilter) Where() (string, []interface{}) {
@@ -25,17 +28,13 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 		okRoles = append(okRoles, models.ROLE_VIEWER)
 	}
 
-	falseStr := d.Dialect.BooleanStr(false)
-
 	sql := `(
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					FROM dashboard AS d` + AncestorJoins("d", d.FolderDepth) + `
 					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
+						da.dashboard_id IN (` + AncestorIDs("d", d.FolderDepth) + `)
 					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
 					WHERE
 						d.org_id = ? AND
@@ -47,15 +46,11 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 						)
 				UNION
 				SELECT d.id AS DashboardId
-					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					FROM dashboard AS d` + AncestorJoins("d", d.FolderDepth) + `
 					LEFT JOIN dashboard_acl AS da ON
 						(
 							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
+							da.org_id = -1 AND ` + HasNoACL("d", d.FolderDepth, d.Dialect) + `
 						)
 					WHERE
 						d.org_id = ? AND
@@ -0,0 +1,83 @@
+package permissions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+// Folders can be nested up to models.MaxFolderDepth levels, so the folders above a dashboard are found
+// with a fixed number of joins rather than with recursive queries, which not all supported databases have.
+// The depth of the functions below is the number of folder levels to join, which is only greater than one
+// when the org has nested folders. A depth of 0 joins all the levels.
+
+// FolderDepth returns the number of folder levels above the dashboards of an org.
+func FolderDepth(hasNestedFolders bool) int {
+	if hasNestedFolders {
+		return models.MaxFolderDepth
+	}
+	return 1
+}
+
+// AncestorJoins returns the joins of the folders above the dashboard with the given alias. The folders
+// are aliased <alias>_ancestor1 for the parent, <alias>_ancestor2 for the grandparent and so on.
+func AncestorJoins(alias string, depth int) string {
+	var sb strings.Builder
+	parent := alias
+	for i := 1; i <= levels(depth); i++ {
+		ancestor := ancestorAlias(alias, i)
+		sb.WriteString(fmt.Sprintf("\n\t\t\t\t\tLEFT JOIN dashboard AS %s ON %s.id = %s.folder_id", ancestor, ancestor, parent))
+		parent = ancestor
+	}
+	return sb.String()
+}
+
+// AncestorIDs returns the ID columns of the dashboard with the given alias and of its folders, to be used
+// with AncestorJoins.
+func AncestorIDs(alias string, depth int) string {
+	ids := []string{alias + ".id"}
+	for i := 1; i <= levels(depth); i++ {
+		ids = append(ids, ancestorAlias(alias, i)+".id")
+	}
+	return strings.Join(ids, ", ")
+}
+
+// AncestorFolderIDs returns the parent folder ID columns of the dashboard with the given alias and of its
+// folders, to be used with AncestorJoins.
+func AncestorFolderIDs(alias string, depth int) []string {
+	ids := []string{alias + ".folder_id"}
+	for i := 1; i < levels(depth); i++ {
+		ids = append(ids, ancestorAlias(alias, i)+".folder_id")
+	}
+	return ids
+}
+
+// HasNoACL returns a condition on the dashboard with the given alias, to be used with AncestorJoins, that is
+// true when the default permissions apply to it: when none of the folders above it has permissions of its
+// own, and neither has the dashboard itself if it is a folder or is not in a folder. The nearest folder with
+// permissions decides, so a subfolder with permissions restricts its content under a folder without.
+func HasNoACL(alias string, depth int, dialect migrator.Dialect) string {
+	trueStr := dialect.BooleanStr(true)
+	falseStr := dialect.BooleanStr(false)
+
+	conditions := make([]string, 0, levels(depth)+1)
+	conditions = append(conditions, fmt.Sprintf("(%s.has_acl = %s AND (%s.is_folder = %s OR %s.folder_id = 0))",
+		alias, trueStr, alias, trueStr, alias))
+	for i := 1; i <= levels(depth); i++ {
+		conditions = append(conditions, fmt.Sprintf("COALESCE(%s.has_acl, %s) = %s", ancestorAlias(alias, i), falseStr, trueStr))
+	}
+	return "NOT (" + strings.Join(conditions, " OR ") + ")"
+}
+
+func levels(depth int) int {
+	if depth < 1 || depth > models.MaxFolderDepth {
+		return models.MaxFolderDepth
+	}
+	return depth
+}
+
+func ancestorAlias(alias string, level int) string {
+	return fmt.Sprintf("%s_ancestor%d", alias, level)
+}
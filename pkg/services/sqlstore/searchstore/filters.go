@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
 // FilterWhere limits the set of dashboard IDs to the dashboards for
@@ -86,12 +87,45 @@ func (f TitleFilter) Where() (string, []interface{}) {
 	return fmt.Sprintf("dashboard.title %s ?", f.Dialect.LikeStr()), []interface{}{"%" + f.Title + "%"}
 }
 
+// TitleOrContentFilter matches the dashboards whose title or search terms contain Title. The terms are matched
+// with a leading wildcard, which cannot use an index and scans the dashboard_search_term table.
+type TitleOrContentFilter struct {
+	Dialect migrator.Dialect
+	Title   string
+}
+
+func (f TitleOrContentFilter) Where() (string, []interface{}) {
+	like := f.Dialect.LikeStr()
+	return fmt.Sprintf(`(dashboard.title %s ? OR dashboard.id IN (
+		SELECT dashboard_search_term.dashboard_id FROM dashboard_search_term WHERE dashboard_search_term.term %s ?
+	))`, like, like), []interface{}{"%" + f.Title + "%", "%" + f.Title + "%"}
+}
+
 type FolderFilter struct {
 	IDs []int64
+	// IncludeSubfolders matches the content of the subfolders of the folders too.
+	IncludeSubfolders bool
+	// FolderDepth is the number of folder levels above the dashboards of the org, as returned by
+	// permissions.FolderDepth.
+	FolderDepth int
 }
 
 func (f FolderFilter) Where() (string, []interface{}) {
-	return sqlIDin("dashboard.folder_id", f.IDs)
+	if !f.IncludeSubfolders || len(f.IDs) == 0 {
+		return sqlIDin("dashboard.folder_id", f.IDs)
+	}
+
+	wheres := []string{}
+	params := []interface{}{}
+	for _, column := range permissions.AncestorFolderIDs("d", f.FolderDepth) {
+		where, p := sqlIDin(column, f.IDs)
+		wheres = append(wheres, where)
+		params = append(params, p...)
+	}
+	return `dashboard.id IN (
+		SELECT d.id FROM dashboard AS d` + permissions.AncestorJoins("d", f.FolderDepth) + `
+		WHERE ` + strings.Join(wheres, " OR ") + `
+	)`, params
 }
 
 type DashboardFilter struct {
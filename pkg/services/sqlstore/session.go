@@ -27,38 +27,42 @@ func newSession() *DBSession {
 	return &DBSession{Session: x.NewSession()}
 }
 
-func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DBSession, error) {
+// startSession returns the session of the context, or a new session. The session of the context belongs to the
+// caller, which commits and closes it, and is only returned as new when it was created.
+func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DBSession, bool, error) {
 	value := ctx.Value(ContextSessionKey{})
 	var sess *DBSession
 	sess, ok := value.(*DBSession)
 
 	if ok {
-		return sess, nil
+		return sess, false, nil
 	}
 
 	newSess := &DBSession{Session: engine.NewSession()}
 	if beginTran {
 		err := newSess.Begin()
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 	}
-	return newSess, nil
+	return newSess, true, nil
 }
 
 // WithDbSession calls the callback with an session attached to the context.
 func (ss *SQLStore) WithDbSession(ctx context.Context, callback dbTransactionFunc) error {
-	sess, err := startSession(ctx, ss.engine, false)
+	sess, isNew, err := startSession(ctx, ss.engine, false)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
+	if isNew {
+		defer sess.Close()
+	}
 
 	return callback(sess)
 }
 
 func withDbSession(ctx context.Context, callback dbTransactionFunc) error {
-	sess, err := startSession(ctx, x, false)
+	sess, _, err := startSession(ctx, x, false)
 	if err != nil {
 		return err
 	}
@@ -33,11 +33,16 @@ func inTransactionWithRetry(callback dbTransactionFunc, retry int) error {
 }
 
 func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc, retry int) error {
-	sess, err := startSession(ctx, engine, true)
+	sess, isNew, err := startSession(ctx, engine, true)
 	if err != nil {
 		return err
 	}
 
+	// the transaction of the context is committed or rolled back by its caller
+	if !isNew {
+		return callback(sess)
+	}
+
 	defer sess.Close()
 
 	err = callback(sess)
@@ -98,6 +98,7 @@ var (
 
 	// Dashboard history
 	DashboardVersionsToKeep int
+	DashboardTrashRetention time.Duration
 	MinRefreshInterval      string
 
 	// User settings
@@ -330,6 +331,8 @@ type Cfg struct {
 
 	// Data sources
 	DataSourceLimit int
+	// Local directories the files data source may read from
+	FileDataSourceAllowedPaths []string
 
 	// Snapshots
 	SnapshotPublicMode bool
@@ -814,6 +817,11 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	dashboards := iniFile.Section("dashboards")
 	DashboardVersionsToKeep = dashboards.Key("versions_to_keep").MustInt(20)
 	MinRefreshInterval = valueAsString(dashboards, "min_refresh_interval", "5s")
+	trashRetention, err := gtime.ParseDuration(valueAsString(dashboards, "trash_retention", "30d"))
+	if err != nil {
+		return err
+	}
+	DashboardTrashRetention = trashRetention
 
 	cfg.DefaultHomeDashboardPath = dashboards.Key("default_home_dashboard_path").MustString("")
 
@@ -1379,4 +1387,7 @@ func (cfg *Cfg) GetContentDeliveryURL(prefix string) string {
 func (cfg *Cfg) readDataSourcesSettings() {
 	datasources := cfg.Raw.Section("datasources")
 	cfg.DataSourceLimit = datasources.Key("datasource_limit").MustInt(5000)
+
+	fileDataSource := cfg.Raw.Section("file_datasource")
+	cfg.FileDataSourceAllowedPaths = util.SplitString(fileDataSource.Key("allowed_paths").MustString(""))
 }
@@ -0,0 +1,400 @@
+package azuremonitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/pluginproxy"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// AzureResourceGraphDatasource calls the Azure Resource Graph API
+type AzureResourceGraphDatasource struct {
+	httpClient *http.Client
+	dsInfo     *models.DataSource
+}
+
+// AzureResourceGraphQuery is the query request that is built from the saved values for
+// from the UI
+type AzureResourceGraphQuery struct {
+	RefID             string
+	Model             *simplejson.Json
+	InterpolatedQuery string
+	Subscriptions     []string
+	Top               int
+	SkipToken         string
+}
+
+const (
+	argAPIVersion = "2021-03-01"
+	argURL        = "providers/Microsoft.ResourceGraph/resources"
+	// argPageSize is the maximum number of rows of a page of the API
+	argPageSize = 1000
+	// argDefaultTop is the default number of rows of a query, which are fetched page by page
+	argDefaultTop = 1000
+	argMaxTop     = 50000
+)
+
+// executeTimeSeriesQuery does the following:
+// 1. builds the Azure Resource Graph request for each query
+// 2. executes each query by calling the Azure Resource Graph API, following the skip tokens of the
+// responses until the number of rows of the query is reached
+// 3. parses the responses for each query into a table frame
+func (e *AzureResourceGraphDatasource) executeTimeSeriesQuery(ctx context.Context, originalQueries []plugins.DataSubQuery,
+	timeRange plugins.DataTimeRange) (plugins.DataResponse, error) {
+	result := plugins.DataResponse{
+		Results: map[string]plugins.DataQueryResult{},
+	}
+
+	queries, err := e.buildQueries(originalQueries, timeRange)
+	if err != nil {
+		return plugins.DataResponse{}, err
+	}
+
+	for _, query := range queries {
+		result.Results[query.RefID] = e.executeQuery(ctx, query, timeRange)
+	}
+
+	return result, nil
+}
+
+func (e *AzureResourceGraphDatasource) buildQueries(queries []plugins.DataSubQuery,
+	timeRange plugins.DataTimeRange) ([]*AzureResourceGraphQuery, error) {
+	azureResourceGraphQueries := []*AzureResourceGraphQuery{}
+
+	for _, query := range queries {
+		queryBytes, err := query.Model.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-encode the Azure Resource Graph query into JSON: %w", err)
+		}
+
+		queryJSONModel := argJSONQuery{}
+		err = json.Unmarshal(queryBytes, &queryJSONModel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the Azure Resource Graph query object from JSON: %w", err)
+		}
+
+		azureResourceGraphTarget := queryJSONModel.AzureResourceGraph
+		azlog.Debug("AzureResourceGraph", "target", azureResourceGraphTarget)
+
+		subscriptions := queryJSONModel.Subscriptions
+		if len(subscriptions) == 0 {
+			ub := urlBuilder{
+				DefaultSubscription: query.DataSource.JsonData.Get("subscriptionId").MustString(),
+				Subscription:        queryJSONModel.Subscription,
+			}
+			if subscription := ub.subscription(); subscription != "" {
+				subscriptions = []string{subscription}
+			}
+		}
+		if len(subscriptions) == 0 {
+			return nil, fmt.Errorf("query %q has no subscription", query.RefID)
+		}
+
+		top := azureResourceGraphTarget.Top
+		if top <= 0 {
+			top = argDefaultTop
+		}
+		if top > argMaxTop {
+			return nil, fmt.Errorf("the number of rows of query %q must not exceed %d", query.RefID, argMaxTop)
+		}
+
+		interpolatedQuery, err := KqlInterpolate(query, timeRange, azureResourceGraphTarget.Query)
+		if err != nil {
+			return nil, err
+		}
+
+		azureResourceGraphQueries = append(azureResourceGraphQueries, &AzureResourceGraphQuery{
+			RefID:             query.RefID,
+			Model:             query.Model,
+			InterpolatedQuery: interpolatedQuery,
+			Subscriptions:     subscriptions,
+			Top:               top,
+			SkipToken:         azureResourceGraphTarget.SkipToken,
+		})
+	}
+
+	return azureResourceGraphQueries, nil
+}
+
+func (e *AzureResourceGraphDatasource) executeQuery(ctx context.Context, query *AzureResourceGraphQuery,
+	timeRange plugins.DataTimeRange) plugins.DataQueryResult {
+	queryResult := plugins.DataQueryResult{RefID: query.RefID}
+
+	queryResultErrorWithExecuted := func(err error) plugins.DataQueryResult {
+		queryResult.Error = err
+		frames := data.Frames{
+			&data.Frame{
+				RefID: query.RefID,
+				Meta: &data.FrameMeta{
+					ExecutedQueryString: query.InterpolatedQuery,
+				},
+			},
+		}
+		queryResult.Dataframes = plugins.NewDecodedDataFrames(frames)
+		return queryResult
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "azure resource graph query")
+	span.SetTag("interpolated_query", query.InterpolatedQuery)
+	span.SetTag("from", timeRange.From)
+	span.SetTag("until", timeRange.To)
+	span.SetTag("datasource_id", e.dsInfo.Id)
+	span.SetTag("org_id", e.dsInfo.OrgId)
+
+	defer span.Finish()
+
+	var table *AzureResourceGraphTable
+	var totalRecords int64
+	skipToken := query.SkipToken
+	for {
+		top := query.Top
+		if table != nil {
+			top -= len(table.Rows)
+		}
+		if top > argPageSize {
+			top = argPageSize
+		}
+
+		req, err := e.createRequest(ctx, e.dsInfo, query, top, skipToken)
+		if err != nil {
+			return queryResultErrorWithExecuted(err)
+		}
+
+		if err := opentracing.GlobalTracer().Inject(
+			span.Context(),
+			opentracing.HTTPHeaders,
+			opentracing.HTTPHeadersCarrier(req.Header)); err != nil {
+			return queryResultErrorWithExecuted(err)
+		}
+
+		azlog.Debug("AzureResourceGraph", "Request ApiURL", req.URL.String())
+		res, err := ctxhttp.Do(ctx, e.httpClient, req)
+		if err != nil {
+			return queryResultErrorWithExecuted(err)
+		}
+
+		argResponse, err := e.unmarshalResponse(res)
+		if err != nil {
+			return queryResultErrorWithExecuted(err)
+		}
+
+		if table == nil {
+			table = &argResponse.Data
+		} else {
+			table.Rows = append(table.Rows, argResponse.Data.Rows...)
+		}
+		totalRecords = argResponse.TotalRecords
+		skipToken = argResponse.SkipToken
+
+		if skipToken == "" || len(table.Rows) >= query.Top || len(argResponse.Data.Rows) == 0 {
+			break
+		}
+	}
+
+	frame, err := ResourceGraphTableToFrame(table)
+	if err != nil {
+		return queryResultErrorWithExecuted(err)
+	}
+	frame.RefID = query.RefID
+	frame.Meta.ExecutedQueryString = query.InterpolatedQuery
+	frame.Meta.PreferredVisualization = data.VisTypeTable
+	meta := frame.Meta.Custom.(*ResourceGraphMeta)
+	meta.Subscriptions = query.Subscriptions
+	meta.TotalRecords = totalRecords
+	meta.SkipToken = skipToken
+	if skipToken != "" {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text: fmt.Sprintf("showing the first %d of %d rows, use the skip token of the query to get the next rows",
+				len(table.Rows), totalRecords),
+		})
+	}
+
+	queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{frame})
+	return queryResult
+}
+
+func (e *AzureResourceGraphDatasource) createRequest(ctx context.Context, dsInfo *models.DataSource,
+	query *AzureResourceGraphQuery, top int, skipToken string) (*http.Request, error) {
+	options := map[string]interface{}{
+		"resultFormat": "table",
+		"$top":         top,
+	}
+	if skipToken != "" {
+		options["$skipToken"] = skipToken
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"subscriptions": query.Subscriptions,
+		"query":         query.InterpolatedQuery,
+		"options":       options,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "render")
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		azlog.Debug("Failed to create request", "error", err)
+		return nil, errutil.Wrap("failed to create request", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("Grafana/%s", setting.BuildVersion))
+
+	// find plugin
+	plugin, ok := manager.DataSources[dsInfo.Type]
+	if !ok {
+		return nil, errors.New("unable to find datasource plugin Azure Monitor")
+	}
+
+	// Resource Graph is part of the Azure Resource Manager API, like Azure Monitor metrics
+	cloudName := dsInfo.JsonData.Get("cloudName").MustString("azuremonitor")
+	var azureMonitorRoute *plugins.AppPluginRoute
+	for _, route := range plugin.Routes {
+		if route.Path == cloudName {
+			azureMonitorRoute = route
+			break
+		}
+	}
+	if azureMonitorRoute == nil {
+		return nil, fmt.Errorf("unable to find the route of cloud %q", cloudName)
+	}
+
+	pluginproxy.ApplyRoute(ctx, req, fmt.Sprintf("%s/%s", cloudName, argURL), azureMonitorRoute, dsInfo)
+
+	params := req.URL.Query()
+	params.Set("api-version", argAPIVersion)
+	req.URL.RawQuery = params.Encode()
+
+	return req, nil
+}
+
+func (e *AzureResourceGraphDatasource) unmarshalResponse(res *http.Response) (AzureResourceGraphResponse, error) {
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return AzureResourceGraphResponse{}, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			azlog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		azlog.Debug("Request failed", "status", res.Status, "body", string(body))
+		return AzureResourceGraphResponse{}, fmt.Errorf("request failed, status: %s, body: %s", res.Status, string(body))
+	}
+
+	var data AzureResourceGraphResponse
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	err = d.Decode(&data)
+	if err != nil {
+		azlog.Debug("Failed to unmarshal Azure Resource Graph response", "error", err, "status", res.Status, "body", string(body))
+		return AzureResourceGraphResponse{}, err
+	}
+
+	return data, nil
+}
+
+// ResourceGraphMeta is a type for the a Frame's Meta's Custom property.
+type ResourceGraphMeta struct {
+	ColumnTypes   []string `json:"azureColumnTypes"`
+	Subscriptions []string `json:"subscriptions"`
+	TotalRecords  int64    `json:"totalRecords"`
+	// SkipToken is the token of the rows following the rows of the frame, if any.
+	SkipToken string `json:"skipToken,omitempty"`
+}
+
+// ResourceGraphTableToFrame converts an AzureResourceGraphTable to a data.Frame.
+func ResourceGraphTableToFrame(table *AzureResourceGraphTable) (*data.Frame, error) {
+	converters := []data.FieldConverter{}
+	colNames := make([]string, len(table.Columns))
+	colTypes := make([]string, len(table.Columns)) // for metadata
+
+	for i, col := range table.Columns {
+		colNames[i] = col.Name
+		colTypes[i] = col.Type
+		converter, ok := argConverterMap[col.Type]
+		if !ok {
+			return nil, fmt.Errorf("unsupported resource graph column type %v", col.Type)
+		}
+		converters = append(converters, converter)
+	}
+
+	fic, err := data.NewFrameInputConverter(converters, len(table.Rows))
+	if err != nil {
+		return nil, err
+	}
+
+	err = fic.Frame.SetFieldNames(colNames...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rowIdx, row := range table.Rows {
+		for fieldIdx, field := range row {
+			err = fic.Set(fieldIdx, rowIdx, field)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fic.Frame.Meta = &data.FrameMeta{
+		Custom: &ResourceGraphMeta{ColumnTypes: colTypes},
+	}
+
+	return fic.Frame, nil
+}
+
+var argConverterMap = map[string]data.FieldConverter{
+	"string":   stringConverter,
+	"datetime": timeConverter,
+	"integer":  longConverter,
+	"number":   realConverter,
+	"boolean":  boolConverter,
+	"object":   objectConverter,
+}
+
+// objectConverter converts the objects and arrays of resource properties, such as tags, to JSON.
+var objectConverter = data.FieldConverter{
+	OutputFieldType: data.FieldTypeNullableString,
+	Converter: func(v interface{}) (interface{}, error) {
+		var as *string
+		if v == nil {
+			return as, nil
+		}
+		if s, ok := v.(string); ok {
+			return &s, nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		return &s, nil
+	},
+}
@@ -0,0 +1,223 @@
+package azuremonitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildingAzureResourceGraphQueries(t *testing.T) {
+	datasource := &AzureResourceGraphDatasource{}
+	fromStart := time.Date(2018, 3, 15, 13, 0, 0, 0, time.UTC).In(time.Local)
+	timeRange := plugins.DataTimeRange{
+		From: fmt.Sprintf("%v", fromStart.Unix()*1000),
+		To:   fmt.Sprintf("%v", fromStart.Add(34*time.Minute).Unix()*1000),
+	}
+	dsInfo := &models.DataSource{
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"subscriptionId": "default-sub"}),
+	}
+
+	tests := []struct {
+		name                      string
+		queryModel                map[string]interface{}
+		azureResourceGraphQueries []*AzureResourceGraphQuery
+		Err                       require.ErrorAssertionFunc
+	}{
+		{
+			name: "Query across subscriptions",
+			queryModel: map[string]interface{}{
+				"queryType":     "Azure Resource Graph",
+				"subscriptions": []interface{}{"sub1", "sub2"},
+				"azureResourceGraph": map[string]interface{}{
+					"query": "Resources | where type == 'microsoft.compute/virtualmachines'",
+					"top":   500,
+				},
+			},
+			azureResourceGraphQueries: []*AzureResourceGraphQuery{
+				{
+					RefID:             "A",
+					InterpolatedQuery: "Resources | where type == 'microsoft.compute/virtualmachines'",
+					Subscriptions:     []string{"sub1", "sub2"},
+					Top:               500,
+				},
+			},
+			Err: require.NoError,
+		},
+		{
+			name: "Query without subscriptions uses the default subscription",
+			queryModel: map[string]interface{}{
+				"queryType": "Azure Resource Graph",
+				"azureResourceGraph": map[string]interface{}{
+					"query":     "Resources | summarize count() by location",
+					"skipToken": "token",
+				},
+			},
+			azureResourceGraphQueries: []*AzureResourceGraphQuery{
+				{
+					RefID:             "A",
+					InterpolatedQuery: "Resources | summarize count() by location",
+					Subscriptions:     []string{"default-sub"},
+					Top:               argDefaultTop,
+					SkipToken:         "token",
+				},
+			},
+			Err: require.NoError,
+		},
+		{
+			name: "Query with too many rows",
+			queryModel: map[string]interface{}{
+				"queryType":     "Azure Resource Graph",
+				"subscriptions": []interface{}{"sub1"},
+				"azureResourceGraph": map[string]interface{}{
+					"query": "Resources",
+					"top":   argMaxTop + 1,
+				},
+			},
+			Err: require.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries, err := datasource.buildQueries([]plugins.DataSubQuery{{
+				DataSource: dsInfo,
+				Model:      simplejson.NewFromAny(tt.queryModel),
+				RefID:      "A",
+			}}, timeRange)
+			tt.Err(t, err)
+			if diff := cmp.Diff(tt.azureResourceGraphQueries, queries,
+				cmpopts.IgnoreFields(AzureResourceGraphQuery{}, "Model")); diff != "" {
+				t.Errorf("Result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAzureResourceGraphPaging(t *testing.T) {
+	var requests []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/providers/Microsoft.ResourceGraph/resources", r.URL.Path)
+		assert.Equal(t, argAPIVersion, r.URL.Query().Get("api-version"))
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+
+		response := `{
+			"totalRecords": 3,
+			"count": 2,
+			"data": {
+				"columns": [
+					{"name": "name", "type": "string"},
+					{"name": "cores", "type": "integer"},
+					{"name": "tags", "type": "object"}
+				],
+				"rows": [["vm1", 2, {"env": "prod"}], ["vm2", 4, null]]
+			},
+			"$skipToken": "page2"
+		}`
+		if options := body["options"].(map[string]interface{}); options["$skipToken"] == "page2" {
+			response = `{
+				"totalRecords": 3,
+				"count": 1,
+				"data": {
+					"columns": [
+						{"name": "name", "type": "string"},
+						{"name": "cores", "type": "integer"},
+						{"name": "tags", "type": "object"}
+					],
+					"rows": [["vm3", 8, {}]]
+				}
+			}`
+		}
+		_, err := w.Write([]byte(response))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	origDataSources := manager.DataSources
+	t.Cleanup(func() {
+		manager.DataSources = origDataSources
+	})
+	manager.DataSources = map[string]*plugins.DataSourcePlugin{
+		"grafana-azure-monitor-datasource": {
+			Routes: []*plugins.AppPluginRoute{{Path: "azuremonitor", Method: "GET", URL: srv.URL}},
+		},
+	}
+
+	datasource := &AzureResourceGraphDatasource{
+		httpClient: srv.Client(),
+		dsInfo: &models.DataSource{
+			Type:     "grafana-azure-monitor-datasource",
+			Url:      srv.URL,
+			JsonData: simplejson.New(),
+		},
+	}
+
+	query := &AzureResourceGraphQuery{
+		RefID:             "A",
+		InterpolatedQuery: "Resources | project name, cores, tags",
+		Subscriptions:     []string{"sub1", "sub2"},
+		Top:               1500,
+	}
+
+	t.Run("Skip tokens are followed until all rows are fetched", func(t *testing.T) {
+		requests = nil
+		result := datasource.executeQuery(context.Background(), query, plugins.DataTimeRange{})
+		require.NoError(t, result.Error)
+
+		require.Len(t, requests, 2)
+		assert.Equal(t, []interface{}{"sub1", "sub2"}, requests[0]["subscriptions"])
+		assert.Equal(t, "Resources | project name, cores, tags", requests[0]["query"])
+		assert.Equal(t, map[string]interface{}{"resultFormat": "table", "$top": float64(1000)}, requests[0]["options"])
+		assert.Equal(t, map[string]interface{}{"resultFormat": "table", "$top": float64(1000), "$skipToken": "page2"},
+			requests[1]["options"])
+
+		frames, err := result.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "vm3", *frame.Fields[0].At(2).(*string))
+		assert.Equal(t, int64(4), *frame.Fields[1].At(1).(*int64))
+		assert.Equal(t, `{"env":"prod"}`, *frame.Fields[2].At(0).(*string))
+		assert.Nil(t, frame.Fields[2].At(1))
+		assert.Equal(t, data.VisTypeTable, string(frame.Meta.PreferredVisualization))
+
+		meta := frame.Meta.Custom.(*ResourceGraphMeta)
+		assert.Equal(t, []string{"string", "integer", "object"}, meta.ColumnTypes)
+		assert.Equal(t, int64(3), meta.TotalRecords)
+		assert.Empty(t, meta.SkipToken)
+	})
+
+	t.Run("Skip token of the next rows is returned when the rows of the query are reached", func(t *testing.T) {
+		requests = nil
+		query := *query
+		query.Top = 2
+		result := datasource.executeQuery(context.Background(), &query, plugins.DataTimeRange{})
+		require.NoError(t, result.Error)
+
+		require.Len(t, requests, 1)
+		frames, err := result.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Equal(t, 2, frames[0].Rows())
+		assert.Equal(t, "page2", frames[0].Meta.Custom.(*ResourceGraphMeta).SkipToken)
+		require.Len(t, frames[0].Meta.Notices, 1)
+	})
+}
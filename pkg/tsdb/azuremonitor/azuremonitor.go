@@ -32,7 +32,7 @@ func (s *Service) Init() error {
 	return nil
 }
 
-// AzureMonitorExecutor executes queries for the Azure Monitor datasource - all four services
+// AzureMonitorExecutor executes queries for the Azure Monitor datasource - all five services
 type AzureMonitorExecutor struct {
 	httpClient *http.Client
 	dsInfo     *models.DataSource
@@ -63,6 +63,7 @@ func (e *AzureMonitorExecutor) DataQuery(ctx context.Context, dsInfo *models.Dat
 	var applicationInsightsQueries []plugins.DataSubQuery
 	var azureLogAnalyticsQueries []plugins.DataSubQuery
 	var insightsAnalyticsQueries []plugins.DataSubQuery
+	var azureResourceGraphQueries []plugins.DataSubQuery
 
 	for _, query := range tsdbQuery.Queries {
 		queryType := query.Model.Get("queryType").MustString("")
@@ -76,6 +77,8 @@ func (e *AzureMonitorExecutor) DataQuery(ctx context.Context, dsInfo *models.Dat
 			azureLogAnalyticsQueries = append(azureLogAnalyticsQueries, query)
 		case "Insights Analytics":
 			insightsAnalyticsQueries = append(insightsAnalyticsQueries, query)
+		case "Azure Resource Graph":
+			azureResourceGraphQueries = append(azureResourceGraphQueries, query)
 		default:
 			return plugins.DataResponse{}, fmt.Errorf("alerting not supported for %q", queryType)
 		}
@@ -101,6 +104,11 @@ func (e *AzureMonitorExecutor) DataQuery(ctx context.Context, dsInfo *models.Dat
 		dsInfo:     e.dsInfo,
 	}
 
+	argDatasource := &AzureResourceGraphDatasource{
+		httpClient: e.httpClient,
+		dsInfo:     e.dsInfo,
+	}
+
 	azResult, err := azDatasource.executeTimeSeriesQuery(ctx, azureMonitorQueries, *tsdbQuery.TimeRange)
 	if err != nil {
 		return plugins.DataResponse{}, err
@@ -121,6 +129,11 @@ func (e *AzureMonitorExecutor) DataQuery(ctx context.Context, dsInfo *models.Dat
 		return plugins.DataResponse{}, err
 	}
 
+	argResult, err := argDatasource.executeTimeSeriesQuery(ctx, azureResourceGraphQueries, *tsdbQuery.TimeRange)
+	if err != nil {
+		return plugins.DataResponse{}, err
+	}
+
 	for k, v := range aiResult.Results {
 		azResult.Results[k] = v
 	}
@@ -133,5 +146,9 @@ func (e *AzureMonitorExecutor) DataQuery(ctx context.Context, dsInfo *models.Dat
 		azResult.Results[k] = v
 	}
 
+	for k, v := range argResult.Results {
+		azResult.Results[k] = v
+	}
+
 	return azResult, nil
 }
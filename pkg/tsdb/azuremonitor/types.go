@@ -69,6 +69,35 @@ type AzureLogAnalyticsTable struct {
 	Rows [][]interface{} `json:"rows"`
 }
 
+// AzureResourceGraphResponse is the json response object from the Azure Resource Graph API.
+type AzureResourceGraphResponse struct {
+	TotalRecords    int64                   `json:"totalRecords"`
+	Count           int64                   `json:"count"`
+	Data            AzureResourceGraphTable `json:"data"`
+	SkipToken       string                  `json:"$skipToken"`
+	ResultTruncated string                  `json:"resultTruncated"`
+}
+
+// AzureResourceGraphTable is the table format of the Azure Resource Graph API.
+type AzureResourceGraphTable struct {
+	Columns []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"columns"`
+	Rows [][]interface{} `json:"rows"`
+}
+
+// argJSONQuery is the frontend JSON query model for an Azure Resource Graph query.
+type argJSONQuery struct {
+	Subscription       string   `json:"subscription"`
+	Subscriptions      []string `json:"subscriptions"`
+	AzureResourceGraph struct {
+		Query     string `json:"query"`
+		Top       int    `json:"top"`
+		SkipToken string `json:"skipToken"`
+	} `json:"azureResourceGraph"`
+}
+
 // azureMonitorJSONQuery is the frontend JSON query model for an Azure Monitor query.
 type azureMonitorJSONQuery struct {
 	AzureMonitor struct {
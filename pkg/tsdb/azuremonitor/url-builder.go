@@ -17,11 +17,7 @@ type urlBuilder struct {
 // Build checks the metric definition property to see which form of the url
 // should be returned
 func (ub *urlBuilder) Build() string {
-	subscription := ub.Subscription
-
-	if ub.Subscription == "" {
-		subscription = ub.DefaultSubscription
-	}
+	subscription := ub.subscription()
 
 	if strings.Count(ub.MetricDefinition, "/") > 1 {
 		rn := strings.Split(ub.ResourceName, "/")
@@ -33,3 +29,12 @@ func (ub *urlBuilder) Build() string {
 
 	return fmt.Sprintf("%s/resourceGroups/%s/providers/%s/%s/providers/microsoft.insights/metrics", subscription, ub.ResourceGroup, ub.MetricDefinition, ub.ResourceName)
 }
+
+// subscription returns the subscription of the query, or the default subscription of the data source
+// if the query doesn't have one
+func (ub *urlBuilder) subscription() string {
+	if ub.Subscription == "" {
+		return ub.DefaultSubscription
+	}
+	return ub.Subscription
+}
@@ -0,0 +1,134 @@
+// Package clickhouse implements a backend-only data source for ClickHouse. There is no plugin.json or
+// frontend for it, so data sources of type "clickhouse" are created through the data source HTTP API
+// rather than the UI, and are queried through /api/ds/query or alerting.
+package clickhouse
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/grafana/grafana/pkg/util"
+	"xorm.io/core"
+)
+
+var logger = log.New("tsdb.clickhouse")
+
+func NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
+	cnnstr, err := generateConnectionString(datasource)
+	if err != nil {
+		return nil, err
+	}
+
+	if setting.Env == setting.Dev {
+		logger.Debug("getEngine", "connection", cnnstr)
+	}
+
+	config := sqleng.DataPluginConfiguration{
+		DriverName:       "clickhouse",
+		BindVarFormat:    sqleng.QuestionMarkBindVar,
+		ConnectionString: cnnstr,
+		Datasource:       datasource,
+		MetricColumnTypes: []string{"String", "Nullable(String)", "LowCardinality(String)",
+			"LowCardinality(Nullable(String))"},
+	}
+
+	queryResultTransformer := clickhouseQueryResultTransformer{
+		log: logger,
+	}
+
+	return sqleng.NewDataPlugin(config, &queryResultTransformer, newClickhouseMacroEngine(), logger)
+}
+
+func generateConnectionString(datasource *models.DataSource) (string, error) {
+	addr, err := util.SplitHostPortDefault(strings.TrimPrefix(datasource.Url, "tcp://"), "localhost", "9000")
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("username", datasource.User)
+	params.Set("password", datasource.DecryptedPassword())
+	params.Set("database", datasource.Database)
+
+	jsonData := datasource.JsonData
+	if timeout := jsonData.Get("timeout").MustInt(0); timeout > 0 {
+		params.Set("timeout", fmt.Sprintf("%d", timeout))
+	}
+	if readTimeout := jsonData.Get("readTimeout").MustInt(0); readTimeout > 0 {
+		params.Set("read_timeout", fmt.Sprintf("%d", readTimeout))
+	}
+	if compress := jsonData.Get("compress").MustBool(false); compress {
+		params.Set("compress", "true")
+	}
+
+	if jsonData.Get("secure").MustBool(false) {
+		params.Set("secure", "true")
+		if jsonData.Get("tlsSkipVerify").MustBool(false) {
+			params.Set("skip_verify", "true")
+		}
+
+		tlsConfig, err := datasource.GetTLSConfig()
+		if err != nil {
+			return "", err
+		}
+		if tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) > 0 {
+			tlsConfigName := fmt.Sprintf("ds%d", datasource.Id)
+			if err := clickhouse.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+				return "", err
+			}
+			params.Set("tls_config", tlsConfigName)
+		}
+	}
+
+	u := url.URL{
+		Scheme:   "tcp",
+		Host:     fmt.Sprintf("%s:%s", addr.Host, addr.Port),
+		RawQuery: params.Encode(),
+	}
+	return u.String(), nil
+}
+
+type clickhouseQueryResultTransformer struct {
+	log log.Logger
+}
+
+func (t *clickhouseQueryResultTransformer) TransformQueryResult(columnTypes []*sql.ColumnType, rows *core.Rows) (
+	plugins.DataRowValues, error) {
+	values := make([]interface{}, len(columnTypes))
+	valuePtrs := make([]interface{}, len(columnTypes))
+
+	for i := range columnTypes {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+
+	for i := range values {
+		switch value := values[i].(type) {
+		case []byte:
+			values[i] = string(value)
+		case *string:
+			if value == nil {
+				values[i] = nil
+			} else {
+				values[i] = *value
+			}
+		}
+	}
+
+	return values, nil
+}
+
+func (t *clickhouseQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
@@ -0,0 +1,52 @@
+package clickhouse
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConnectionString(t *testing.T) {
+	t.Run("defaults the port", func(t *testing.T) {
+		ds := &models.DataSource{
+			Url:      "clickhouse.local",
+			User:     "grafana",
+			Database: "metrics",
+			JsonData: simplejson.New(),
+		}
+		cnnstr, err := generateConnectionString(ds)
+		require.NoError(t, err)
+
+		u, err := url.Parse(cnnstr)
+		require.NoError(t, err)
+		require.Equal(t, "tcp", u.Scheme)
+		require.Equal(t, "clickhouse.local:9000", u.Host)
+		require.Equal(t, "grafana", u.Query().Get("username"))
+		require.Equal(t, "metrics", u.Query().Get("database"))
+		require.Empty(t, u.Query().Get("secure"))
+	})
+
+	t.Run("secure connection settings", func(t *testing.T) {
+		ds := &models.DataSource{
+			Url: "tcp://clickhouse.local:9440",
+			JsonData: simplejson.NewFromAny(map[string]interface{}{
+				"secure":        true,
+				"tlsSkipVerify": true,
+				"readTimeout":   30,
+			}),
+		}
+		cnnstr, err := generateConnectionString(ds)
+		require.NoError(t, err)
+
+		u, err := url.Parse(cnnstr)
+		require.NoError(t, err)
+		require.Equal(t, "clickhouse.local:9440", u.Host)
+		require.Equal(t, "true", u.Query().Get("secure"))
+		require.Equal(t, "true", u.Query().Get("skip_verify"))
+		require.Equal(t, "30", u.Query().Get("read_timeout"))
+		require.Empty(t, u.Query().Get("tls_config"))
+	})
+}
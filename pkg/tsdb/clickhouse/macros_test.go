@@ -0,0 +1,52 @@
+package clickhouse
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroEngine(t *testing.T) {
+	engine := newClickhouseMacroEngine()
+	query := plugins.DataSubQuery{Model: simplejson.New()}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := plugins.DataTimeRange{From: "5m", Now: to, To: "now"}
+
+	testCases := []struct {
+		sql      string
+		expected string
+	}{
+		{"select $__time(time_column)", "select time_column AS time"},
+		{"select $__timeEpoch(time_column)", "select toUnixTimestamp(time_column) AS time"},
+		{"WHERE $__timeFilter(time_column)", fmt.Sprintf("WHERE time_column BETWEEN toDateTime(%d) AND toDateTime(%d)", from.Unix(), to.Unix())},
+		{"select $__timeFrom()", fmt.Sprintf("select toDateTime(%d)", from.Unix())},
+		{"select $__timeTo()", fmt.Sprintf("select toDateTime(%d)", to.Unix())},
+		{"GROUP BY $__timeGroup(time_column,'5m')", "GROUP BY intDiv(toUnixTimestamp(time_column), 300) * 300"},
+		{"select $__timeGroupAlias(time_column,'5m')", "select intDiv(toUnixTimestamp(time_column), 300) * 300 AS time"},
+		{"WHERE $__unixEpochFilter(time)", fmt.Sprintf("WHERE time >= %d AND time <= %d", from.Unix(), to.Unix())},
+		{"WHERE $__unixEpochNanoFilter(time)", fmt.Sprintf("WHERE time >= %d AND time <= %d", from.UnixNano(), to.UnixNano())},
+		{"select $__unixEpochGroup(time_column,'5m')", "select intDiv(time_column, 300) * 300"},
+		{"select $__unixEpochGroupAlias(time_column,'5m')", "select intDiv(time_column, 300) * 300 AS time"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.sql, func(t *testing.T) {
+			sql, err := engine.Interpolate(query, timeRange, tc.sql)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, sql)
+		})
+	}
+
+	t.Run("fill mode is set up by $__timeGroup", func(t *testing.T) {
+		fillQuery := plugins.DataSubQuery{Model: simplejson.New()}
+		_, err := engine.Interpolate(fillQuery, timeRange, "GROUP BY $__timeGroup(time_column,'5m', NULL)")
+		require.NoError(t, err)
+		require.True(t, fillQuery.Model.Get("fill").MustBool())
+		require.Equal(t, "null", fillQuery.Model.Get("fillMode").MustString())
+	})
+}
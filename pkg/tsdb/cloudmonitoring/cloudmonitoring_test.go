@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/assert"
@@ -893,6 +894,37 @@ func TestCloudMonitoring(t *testing.T) {
 				assert.Equal(t, "select_slo_compliance(\"projects/test-proj/services/test-service/serviceLevelObjectives/test-slo\")", frames[0].Fields[1].Name)
 			})
 		})
+
+		t.Run("when data from query returns slo", func(t *testing.T) {
+			response, err := loadTestFile("./test-data/6-series-response-slo.json")
+			require.NoError(t, err)
+
+			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			query := &cloudMonitoringTimeSeriesFilter{
+				Params:      url.Values{},
+				RefID:       "A",
+				ProjectName: "test-proj",
+				Selector:    "select_slo_compliance",
+				Service:     "test-service",
+				Slo:         "test-slo",
+			}
+			err = query.parseResponse(res, response, "")
+			require.NoError(t, err)
+			frames, err := res.Dataframes.Decoded()
+			require.NoError(t, err)
+			require.Len(t, frames, 1)
+			assert.Equal(t, "A", frames[0].RefID)
+			assert.Equal(t, data.TimeSeriesTimeFieldName, frames[0].Fields[0].Name)
+			assert.Equal(t, data.Labels{
+				"project":                    "test-proj",
+				"service":                    "test-service",
+				"slo":                        "test-slo",
+				"selector":                   "select_slo_compliance",
+				"resource.type":              "gce_instance",
+				"resource.label.instance_id": "114250375703598695",
+				"resource.label.project_id":  "test-proj",
+			}, frames[0].Fields[1].Labels)
+		})
 	})
 
 	t.Run("Parse cloud monitoring unit", func(t *testing.T) {
@@ -992,6 +1024,39 @@ func TestCloudMonitoring(t *testing.T) {
 				assert.Equal(t, "test-proj - asia-northeast1-c - 6724404429462225363", frames[0].Fields[1].Name)
 			})
 		})
+
+		t.Run("when data from query returns MQL with multiple point descriptors", func(t *testing.T) {
+			response, err := loadTestFile("./test-data/8-series-response-mql-multiple-points.json")
+			require.NoError(t, err)
+
+			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			query := &cloudMonitoringTimeSeriesQuery{RefID: "A", ProjectName: "test-proj", Query: "test-query"}
+			err = query.parseResponse(res, response, "test-query")
+			require.NoError(t, err)
+			frames, err := res.Dataframes.Decoded()
+			require.NoError(t, err)
+			require.Len(t, frames, 2)
+
+			assert.Equal(t, data.TimeSeriesTimeFieldName, frames[0].Fields[0].Name)
+			assert.Equal(t, data.Labels{
+				"resource.label.zone":        "europe-west1-b",
+				"metric.label.response_code": "500",
+				"metric.name":                "value.request_count",
+			}, frames[0].Fields[1].Labels)
+			assert.Equal(t, 2, frames[0].Fields[1].Len())
+			assert.Equal(t, float64(3), frames[0].Fields[1].At(0))
+			assert.Equal(t, float64(12), frames[0].Fields[1].At(1))
+			assert.Equal(t, "test-query", frames[0].Meta.ExecutedQueryString)
+
+			assert.Equal(t, data.Labels{
+				"resource.label.zone":        "europe-west1-b",
+				"metric.label.response_code": "500",
+				"metric.name":                "value.latency_mean",
+			}, frames[1].Fields[1].Labels)
+			assert.Equal(t, 2, frames[1].Fields[1].Len())
+			assert.Equal(t, 0.5, frames[1].Fields[1].At(0))
+			assert.Equal(t, 0.25, frames[1].Fields[1].At(1))
+		})
 	})
 
 	t.Run("when interpolating filter wildcards", func(t *testing.T) {
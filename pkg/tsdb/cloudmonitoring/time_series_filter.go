@@ -90,6 +90,7 @@ func (timeSeriesFilter *cloudMonitoringTimeSeriesFilter) parseResponse(queryRes
 		seriesLabels["resource.type"] = series.Resource.Type
 
 		frame := data.NewFrameOfFieldTypes("", len(series.Points), data.FieldTypeTime, data.FieldTypeFloat64)
+		frame.Fields[0].Name = data.TimeSeriesTimeFieldName
 		frame.RefID = timeSeriesFilter.RefID
 		frame.Meta = &data.FrameMeta{
 			ExecutedQueryString: executedQueryString,
@@ -119,6 +120,12 @@ func (timeSeriesFilter *cloudMonitoringTimeSeriesFilter) parseResponse(queryRes
 			}
 		}
 
+		// SLO series carry no labels that identify the objective, so add them to
+		// keep series of different objectives apart, e.g. when alerting on them
+		for key, value := range timeSeriesFilter.sloLabels() {
+			seriesLabels[key] = value
+		}
+
 		for labelType, labelTypeValues := range series.MetaData {
 			for labelKey, labelValue := range labelTypeValues {
 				key := toSnakeCase(fmt.Sprintf("metadata.%s.%s", labelType, labelKey))
@@ -272,6 +279,19 @@ func (timeSeriesFilter *cloudMonitoringTimeSeriesFilter) handleNonDistributionSe
 	setDisplayNameAsFieldName(dataField)
 }
 
+func (timeSeriesFilter *cloudMonitoringTimeSeriesFilter) sloLabels() data.Labels {
+	if timeSeriesFilter.Slo == "" {
+		return nil
+	}
+
+	return data.Labels{
+		"project":  timeSeriesFilter.ProjectName,
+		"service":  timeSeriesFilter.Service,
+		"slo":      timeSeriesFilter.Slo,
+		"selector": timeSeriesFilter.Selector,
+	}
+}
+
 func (timeSeriesFilter *cloudMonitoringTimeSeriesFilter) parseToAnnotations(queryRes *plugins.DataQueryResult,
 	response cloudMonitoringResponse, title string, text string, tags string) error {
 	frames := data.Frames{}
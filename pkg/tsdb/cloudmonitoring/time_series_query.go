@@ -99,34 +99,19 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseResponse(queryRes *pl
 	labels := make(map[string]map[string]bool)
 	frames := data.Frames{}
 	for _, series := range response.TimeSeriesData {
-		seriesLabels := make(map[string]string)
-		frame := data.NewFrameOfFieldTypes("", len(series.PointData), data.FieldTypeTime, data.FieldTypeFloat64)
-		frame.RefID = timeSeriesQuery.RefID
-		frame.Meta = &data.FrameMeta{
-			ExecutedQueryString: executedQueryString,
-		}
-
+		seriesLabels := data.Labels{}
 		for n, d := range response.TimeSeriesDescriptor.LabelDescriptors {
-			key := toSnakeCase(d.Key)
-			key = strings.Replace(key, ".", ".label.", 1)
+			if n >= len(series.LabelValues) {
+				break
+			}
+			key := mqlLabelKey(d.Key)
 			if _, ok := labels[key]; !ok {
 				labels[key] = map[string]bool{}
 			}
 
-			labelValue := series.LabelValues[n]
-			switch d.ValueType {
-			case "BOOL":
-				strVal := strconv.FormatBool(labelValue.BoolValue)
-				labels[key][strVal] = true
-				seriesLabels[key] = strVal
-			case "INT64":
-				intVal := strconv.FormatInt(labelValue.Int64Value, 10)
-				labels[key][intVal] = true
-				seriesLabels[key] = intVal
-			default:
-				labels[key][labelValue.StringValue] = true
-				seriesLabels[key] = labelValue.StringValue
-			}
+			value := mqlLabelValue(d.ValueType, series.LabelValues[n])
+			labels[key][value] = true
+			seriesLabels[key] = value
 		}
 
 		for n, d := range response.TimeSeriesDescriptor.PointDescriptors {
@@ -134,14 +119,27 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseResponse(queryRes *pl
 				labels["metric.name"] = map[string]bool{}
 			}
 			labels["metric.name"][d.Key] = true
-			seriesLabels["metric.name"] = d.Key
+			// every point descriptor becomes its own series, so it gets its own copy of the labels
+			pointLabels := seriesLabels.Copy()
+			pointLabels["metric.name"] = d.Key
 			defaultMetricName := d.Key
 
 			// process non-distribution series
 			if d.ValueType != "DISTRIBUTION" {
+				timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []time.Time{})
+				valueField := data.NewField(data.TimeSeriesValueFieldName, pointLabels, []float64{})
+				frame := data.NewFrame("", timeField, valueField)
+				frame.RefID = timeSeriesQuery.RefID
+				frame.Meta = &data.FrameMeta{
+					ExecutedQueryString: executedQueryString,
+				}
+
 				// reverse the order to be ascending
 				for i := len(series.PointData) - 1; i >= 0; i-- {
 					point := series.PointData[i]
+					if n >= len(point.Values) {
+						continue
+					}
 					value := point.Values[n].DoubleValue
 
 					if d.ValueType == "INT64" {
@@ -157,17 +155,14 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseResponse(queryRes *pl
 						}
 					}
 
-					frame.SetRow(len(series.PointData)-1-i, series.PointData[i].TimeInterval.EndTime, value)
+					frame.AppendRow(point.TimeInterval.EndTime, value)
 				}
 
-				metricName := formatLegendKeys(d.Key, defaultMetricName, seriesLabels, nil,
+				valueField.Name = formatLegendKeys(d.Key, defaultMetricName, pointLabels, nil,
 					&cloudMonitoringTimeSeriesFilter{
 						ProjectName: timeSeriesQuery.ProjectName, AliasBy: timeSeriesQuery.AliasBy,
 					})
-				dataField := frame.Fields[1]
-				dataField.Name = metricName
-				dataField.Labels = seriesLabels
-				setDisplayNameAsFieldName(dataField)
+				setDisplayNameAsFieldName(valueField)
 
 				frames = append(frames, frame)
 				continue
@@ -178,7 +173,7 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseResponse(queryRes *pl
 			// reverse the order to be ascending
 			for i := len(series.PointData) - 1; i >= 0; i-- {
 				point := series.PointData[i]
-				if len(point.Values[n].DistributionValue.BucketCounts) == 0 {
+				if n >= len(point.Values) || len(point.Values[n].DistributionValue.BucketCounts) == 0 {
 					continue
 				}
 				maxKey := 0
@@ -198,7 +193,7 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseResponse(queryRes *pl
 
 						frameName := formatLegendKeys(d.Key, defaultMetricName, nil, additionalLabels, &cloudMonitoringTimeSeriesFilter{ProjectName: timeSeriesQuery.ProjectName, AliasBy: timeSeriesQuery.AliasBy})
 						valueField.Name = frameName
-						valueField.Labels = seriesLabels
+						valueField.Labels = pointLabels
 						setDisplayNameAsFieldName(valueField)
 
 						buckets[i] = &data.Frame{
@@ -224,9 +219,9 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseResponse(queryRes *pl
 						additionalLabels := data.Labels{"bucket": bucketBound}
 						timeField := data.NewField(data.TimeSeriesTimeFieldName, nil, []time.Time{})
 						valueField := data.NewField(data.TimeSeriesValueFieldName, nil, []float64{})
-						frameName := formatLegendKeys(d.Key, defaultMetricName, seriesLabels, additionalLabels, &cloudMonitoringTimeSeriesFilter{ProjectName: timeSeriesQuery.ProjectName, AliasBy: timeSeriesQuery.AliasBy})
+						frameName := formatLegendKeys(d.Key, defaultMetricName, pointLabels, additionalLabels, &cloudMonitoringTimeSeriesFilter{ProjectName: timeSeriesQuery.ProjectName, AliasBy: timeSeriesQuery.AliasBy})
 						valueField.Name = frameName
-						valueField.Labels = seriesLabels
+						valueField.Labels = pointLabels
 						setDisplayNameAsFieldName(valueField)
 
 						buckets[i] = &data.Frame{
@@ -273,19 +268,11 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) parseToAnnotations(queryRe
 		resourceLabels := make(map[string]string)
 
 		for n, d := range data.TimeSeriesDescriptor.LabelDescriptors {
-			key := toSnakeCase(d.Key)
-			labelValue := series.LabelValues[n]
-			value := ""
-			switch d.ValueType {
-			case "BOOL":
-				strVal := strconv.FormatBool(labelValue.BoolValue)
-				value = strVal
-			case "INT64":
-				intVal := strconv.FormatInt(labelValue.Int64Value, 10)
-				value = intVal
-			default:
-				value = labelValue.StringValue
+			if n >= len(series.LabelValues) {
+				break
 			}
+			key := toSnakeCase(d.Key)
+			value := mqlLabelValue(d.ValueType, series.LabelValues[n])
 			if strings.Index(key, "metric.") == 0 {
 				key = key[len("metric."):]
 				metricLabels[key] = value
@@ -379,3 +366,28 @@ func (timeSeriesQuery cloudMonitoringTimeSeriesQuery) getRefID() string {
 func (timeSeriesQuery *cloudMonitoringTimeSeriesQuery) getUnit() string {
 	return timeSeriesQuery.Unit
 }
+
+// mqlLabelKey maps an MQL label descriptor key onto the label naming used by
+// filter queries, e.g. resource.zone becomes resource.label.zone.
+func mqlLabelKey(key string) string {
+	key = toSnakeCase(key)
+	for _, prefix := range []string{"metric.", "resource."} {
+		if strings.HasPrefix(key, prefix) {
+			return prefix + "label." + key[len(prefix):]
+		}
+	}
+	return key
+}
+
+// mqlLabelValue returns the string representation of an MQL label value.
+// INT64 values are encoded as JSON strings by the API and are passed through as is.
+func mqlLabelValue(valueType string, labelValue timeSeriesLabelValue) string {
+	switch valueType {
+	case "BOOL":
+		return strconv.FormatBool(labelValue.BoolValue)
+	case "INT64":
+		return labelValue.Int64Value
+	default:
+		return labelValue.StringValue
+	}
+}
@@ -113,13 +113,15 @@ type timeSeriesDescriptor struct {
 	} `json:"pointDescriptors"`
 }
 
+type timeSeriesLabelValue struct {
+	BoolValue   bool   `json:"boolValue"`
+	Int64Value  string `json:"int64Value"`
+	StringValue string `json:"stringValue"`
+}
+
 type timeSeriesData []struct {
-	LabelValues []struct {
-		BoolValue   bool   `json:"boolValue"`
-		Int64Value  int64  `json:"int64Value"`
-		StringValue string `json:"stringValue"`
-	} `json:"labelValues"`
-	PointData []struct {
+	LabelValues []timeSeriesLabelValue `json:"labelValues"`
+	PointData   []struct {
 		Values []struct {
 			BoolValue         bool    `json:"boolValue"`
 			Int64Value        string  `json:"int64Value"`
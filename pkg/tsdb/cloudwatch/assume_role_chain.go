@@ -0,0 +1,105 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// assumeRole is a hop of an assume role chain.
+type assumeRole struct {
+	ARN        string
+	ExternalID string
+}
+
+type roleChainSession struct {
+	session    *session.Session
+	expiration time.Time
+}
+
+// roleChainSessionTTL is how long the sessions of assume role chains are cached.
+var roleChainSessionTTL = stscreds.DefaultDuration
+
+var roleChainSessionsLock sync.Mutex
+var roleChainSessions = make(map[string]roleChainSession)
+
+// STS credentials factory.
+//
+// Stubbable by tests.
+var newSTSCredentials = stscreds.NewCredentials
+
+// parseAssumeRoleChain returns the roles of the assumeRoleChain setting of the data source, which are
+// assumed in order after the assume role ARN, if any.
+func parseAssumeRoleChain(ds *models.DataSource) ([]assumeRole, error) {
+	rawChain := ds.JsonData.Get("assumeRoleChain")
+	var chain []assumeRole
+	for i := range rawChain.MustArray() {
+		role := rawChain.GetIndex(i)
+		arn := strings.TrimSpace(role.Get("arn").MustString())
+		if arn == "" {
+			return nil, fmt.Errorf("role %d of the assume role chain has no ARN", i+1)
+		}
+		chain = append(chain, assumeRole{
+			ARN:        arn,
+			ExternalID: strings.TrimSpace(role.Get("externalId").MustString()),
+		})
+	}
+	return chain, nil
+}
+
+// getRoleChainSession returns a session with the credentials of the last role of the chain, each role
+// being assumed with the credentials of the previous one. Sessions are cached like the base sessions,
+// the credentials of the roles are refreshed by the STS credential providers when they expire.
+func getRoleChainSession(sess *session.Session, ds *models.DataSource, region string,
+	chain []assumeRole) (*session.Session, error) {
+	bldr := strings.Builder{}
+	fmt.Fprintf(&bldr, "%d:%d:%s:%s", ds.Id, ds.Updated.UnixNano(), region, aws.StringValue(sess.Config.Endpoint))
+	for _, role := range chain {
+		fmt.Fprintf(&bldr, ":%s:%s", role.ARN, role.ExternalID)
+	}
+	cacheKey := bldr.String()
+
+	roleChainSessionsLock.Lock()
+	defer roleChainSessionsLock.Unlock()
+
+	now := time.Now().UTC()
+	evictExpiredRoleChainSessions(now)
+	if cached, ok := roleChainSessions[cacheKey]; ok {
+		return cached.session, nil
+	}
+
+	for _, role := range chain {
+		plog.Debug("Assuming role of chain in AWS", "arn", role.ARN)
+		externalID := role.ExternalID
+		sess = sess.Copy(&aws.Config{
+			Credentials: newSTSCredentials(sess, role.ARN, func(p *stscreds.AssumeRoleProvider) {
+				p.Duration = stscreds.DefaultDuration
+				if externalID != "" {
+					p.ExternalID = aws.String(externalID)
+				}
+			}),
+		})
+	}
+
+	roleChainSessions[cacheKey] = roleChainSession{
+		session:    sess,
+		expiration: now.Add(roleChainSessionTTL),
+	}
+	return sess, nil
+}
+
+// evictExpiredRoleChainSessions removes the expired sessions from the cache, including the sessions of data
+// sources that have since been updated or deleted, whose keys are not requested anymore. The lock must be held.
+func evictExpiredRoleChainSessions(now time.Time) {
+	for key, cached := range roleChainSessions {
+		if !cached.expiration.After(now) {
+			delete(roleChainSessions, key)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssumeRoleChain(t *testing.T) {
+	origNewSTSCredentials := newSTSCredentials
+	origRoleChainSessionTTL := roleChainSessionTTL
+	t.Cleanup(func() {
+		newSTSCredentials = origNewSTSCredentials
+		roleChainSessionTTL = origRoleChainSessionTTL
+		resetRoleChainSessions()
+	})
+
+	type assumedRole struct {
+		arn         string
+		externalID  string
+		credentials *credentials.Credentials
+	}
+	var assumed []assumedRole
+	newSTSCredentials = func(c client.ConfigProvider, roleARN string,
+		options ...func(*stscreds.AssumeRoleProvider)) *credentials.Credentials {
+		p := &stscreds.AssumeRoleProvider{}
+		for _, opt := range options {
+			opt(p)
+		}
+		externalID := ""
+		if p.ExternalID != nil {
+			externalID = *p.ExternalID
+		}
+		assumed = append(assumed, assumedRole{
+			arn:         roleARN,
+			externalID:  externalID,
+			credentials: c.(*session.Session).Config.Credentials,
+		})
+		return credentials.NewStaticCredentials(roleARN, "secret", "")
+	}
+
+	ds := fakeDataSource()
+	ds.JsonData.Set("assumeRoleChain", []interface{}{
+		map[string]interface{}{"arn": "arn:aws:iam::111111111111:role/hub"},
+		map[string]interface{}{"arn": "arn:aws:iam::222222222222:role/spoke", "externalId": "ext"},
+	})
+
+	// setup gives each test an empty session cache and no assumed roles
+	setup := func(t *testing.T) *cloudWatchExecutor {
+		t.Helper()
+		resetRoleChainSessions()
+		roleChainSessionTTL = origRoleChainSessionTTL
+		assumed = nil
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		executor.DataSource = ds
+		return executor
+	}
+
+	t.Run("Roles are assumed in order with the credentials of the previous role", func(t *testing.T) {
+		executor := setup(t)
+
+		sess, err := executor.newSession("us-east-1")
+		require.NoError(t, err)
+
+		require.Len(t, assumed, 2)
+		assert.Equal(t, "arn:aws:iam::111111111111:role/hub", assumed[0].arn)
+		assert.Empty(t, assumed[0].externalID)
+		assert.Nil(t, assumed[0].credentials)
+		assert.Equal(t, "arn:aws:iam::222222222222:role/spoke", assumed[1].arn)
+		assert.Equal(t, "ext", assumed[1].externalID)
+		require.NotNil(t, assumed[1].credentials)
+		creds, err := assumed[1].credentials.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "arn:aws:iam::111111111111:role/hub", creds.AccessKeyID)
+
+		creds, err = sess.Config.Credentials.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "arn:aws:iam::222222222222:role/spoke", creds.AccessKeyID)
+	})
+
+	t.Run("Sessions of a chain are cached", func(t *testing.T) {
+		executor := setup(t)
+		_, err := executor.newSession("us-east-1")
+		require.NoError(t, err)
+		require.Len(t, assumed, 2)
+
+		assumed = nil
+		_, err = executor.newSession("us-east-1")
+		require.NoError(t, err)
+		assert.Empty(t, assumed)
+	})
+
+	t.Run("Expired sessions of a chain are evicted", func(t *testing.T) {
+		executor := setup(t)
+		roleChainSessionTTL = 0
+		_, err := executor.newSession("us-east-1")
+		require.NoError(t, err)
+
+		// the expired session is evicted even though it is not requested again, e.g. after the data source was updated
+		updated := fakeDataSource()
+		updated.Id = ds.Id
+		updated.Updated = ds.Updated.Add(time.Minute)
+		updated.JsonData = ds.JsonData
+		executor.DataSource = updated
+		_, err = executor.newSession("us-east-1")
+		require.NoError(t, err)
+
+		roleChainSessionsLock.Lock()
+		defer roleChainSessionsLock.Unlock()
+		require.Len(t, roleChainSessions, 1)
+		for key := range roleChainSessions {
+			assert.Contains(t, key, fmt.Sprintf(":%d:", updated.Updated.UnixNano()))
+		}
+	})
+
+	t.Run("Chain is rejected when assume role is disabled", func(t *testing.T) {
+		setup(t)
+		cfg := newTestConfig()
+		cfg.AWSAssumeRoleEnabled = false
+		executor := newExecutor(nil, cfg, fakeSessionCache{})
+		executor.DataSource = ds
+
+		_, err := executor.newSession("us-east-1")
+		require.Error(t, err)
+	})
+
+	t.Run("Role without ARN is rejected", func(t *testing.T) {
+		setup(t)
+		ds := fakeDataSource()
+		ds.JsonData.Set("assumeRoleChain", []interface{}{map[string]interface{}{"externalId": "ext"}})
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		executor.DataSource = ds
+
+		_, err := executor.newSession("us-east-1")
+		require.EqualError(t, err, "role 1 of the assume role chain has no ARN")
+	})
+}
+
+func resetRoleChainSessions() {
+	roleChainSessionsLock.Lock()
+	defer roleChainSessionsLock.Unlock()
+	roleChainSessions = make(map[string]roleChainSession)
+}
@@ -88,7 +88,23 @@ type cloudWatchExecutor struct {
 func (e *cloudWatchExecutor) newSession(region string) (*session.Session, error) {
 	awsDatasourceSettings := e.getAWSDatasourceSettings(region)
 
-	return e.sessions.GetSession(region, *awsDatasourceSettings)
+	sess, err := e.sessions.GetSession(region, *awsDatasourceSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := parseAssumeRoleChain(e.DataSource)
+	if err != nil {
+		return nil, err
+	}
+	if len(chain) == 0 {
+		return sess, nil
+	}
+	if !e.cfg.AWSAssumeRoleEnabled {
+		return nil, fmt.Errorf("attempting to use an assume role chain while assume role is disabled in grafana.ini")
+	}
+
+	return getRoleChainSession(sess, e.DataSource, awsDatasourceSettings.Region, chain)
 }
 
 func (e *cloudWatchExecutor) getCWClient(region string) (cloudwatchiface.CloudWatchAPI, error) {
@@ -17,15 +17,25 @@ type cloudWatchQuery struct {
 	Period                  int
 	Alias                   string
 	MatchExact              bool
+	MetricQueryType         metricQueryType
+	SqlExpression           string
+	AccountId               string
 	UsedExpression          string
 	RequestExceededMaxLimit bool
 }
 
+func (q *cloudWatchQuery) isMetricInsightsQuery() bool {
+	return q.MetricQueryType == metricQueryTypeQuery
+}
+
 func (q *cloudWatchQuery) isMathExpression() bool {
-	return q.Expression != "" && !q.isUserDefinedSearchExpression()
+	return !q.isMetricInsightsQuery() && q.Expression != "" && !q.isUserDefinedSearchExpression()
 }
 
 func (q *cloudWatchQuery) isSearchExpression() bool {
+	if q.isMetricInsightsQuery() {
+		return false
+	}
 	return q.isUserDefinedSearchExpression() || q.isInferredSearchExpression()
 }
 
@@ -2,15 +2,19 @@ package cloudwatch
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 )
 
 func (e *cloudWatchExecutor) executeRequest(ctx context.Context, client cloudwatchiface.CloudWatchAPI,
-	metricDataInput *cloudwatch.GetMetricDataInput) ([]*cloudwatch.GetMetricDataOutput, error) {
+	metricDataInput *cloudwatch.GetMetricDataInput, opts ...request.Option) ([]*cloudwatch.GetMetricDataOutput, error) {
 	mdo := make([]*cloudwatch.GetMetricDataOutput, 0)
 
 	nextToken := ""
@@ -18,7 +22,7 @@ func (e *cloudWatchExecutor) executeRequest(ctx context.Context, client cloudwat
 		if nextToken != "" {
 			metricDataInput.NextToken = aws.String(nextToken)
 		}
-		resp, err := client.GetMetricDataWithContext(ctx, metricDataInput)
+		resp, err := client.GetMetricDataWithContext(ctx, metricDataInput, opts...)
 		if err != nil {
 			return mdo, err
 		}
@@ -34,3 +38,59 @@ func (e *cloudWatchExecutor) executeRequest(ctx context.Context, client cloudwat
 
 	return mdo, nil
 }
+
+// withAccountIDs returns a request option adding the account ID of cross-account queries to
+// GetMetricData requests, so that the metrics are read from the given monitoring source account.
+func withAccountIDs(queries map[string]*cloudWatchQuery) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			input, ok := r.Params.(*cloudwatch.GetMetricDataInput)
+			if !ok {
+				return
+			}
+
+			params := url.Values{}
+			for i, mdq := range input.MetricDataQueries {
+				query, ok := queries[aws.StringValue(mdq.Id)]
+				if !ok || query.AccountId == "" {
+					continue
+				}
+				params.Set(fmt.Sprintf("MetricDataQueries.member.%d.AccountId", i+1), query.AccountId)
+			}
+			addFormParams(r, params)
+		})
+	}
+}
+
+// withFormParams returns a request option adding the parameters to the request.
+func withFormParams(params url.Values) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			addFormParams(r, params)
+		})
+	}
+}
+
+// addFormParams adds parameters to the form encoded body built by the query protocol. It's used for
+// parameters of the CloudWatch API that the vendored AWS SDK doesn't know about yet, such as the
+// account ID of cross-account observability.
+func addFormParams(r *request.Request, params url.Values) {
+	if r.Error != nil || len(params) == 0 {
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.GetBody())
+	if err != nil {
+		r.Error = err
+		return
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		r.Error = err
+		return
+	}
+	for key, value := range params {
+		values[key] = value
+	}
+	r.SetBufferBody([]byte(values.Encode()))
+}
@@ -16,7 +16,10 @@ func (e *cloudWatchExecutor) buildMetricDataQuery(query *cloudWatchQuery) (*clou
 		ReturnData: aws.Bool(query.ReturnData),
 	}
 
-	if query.Expression != "" {
+	if query.isMetricInsightsQuery() {
+		mdq.Expression = aws.String(query.SqlExpression)
+		mdq.Period = aws.Int64(int64(query.Period))
+	} else if query.Expression != "" {
 		mdq.Expression = aws.String(query.Expression)
 	} else {
 		if query.isSearchExpression() {
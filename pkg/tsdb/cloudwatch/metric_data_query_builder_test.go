@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMetricDataQueryBuilder_buildSearchExpression(t *testing.T) {
@@ -212,3 +213,24 @@ func TestMetricDataQueryBuilder_buildSearchExpression(t *testing.T) {
 		assert.Contains(t, res, `lb4\"\"`, "Expected escape double quotes")
 	})
 }
+
+func TestMetricDataQueryBuilder_buildMetricDataQuery(t *testing.T) {
+	executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+
+	t.Run("Metrics Insights query uses the SQL expression and period", func(t *testing.T) {
+		query := &cloudWatchQuery{
+			Id:              "queryA",
+			Period:          300,
+			ReturnData:      true,
+			MetricQueryType: metricQueryTypeQuery,
+			SqlExpression:   `SELECT AVG(CPUUtilization) FROM "AWS/EC2"`,
+		}
+
+		mdq, err := executor.buildMetricDataQuery(query)
+		require.NoError(t, err)
+		assert.Nil(t, mdq.MetricStat)
+		assert.Equal(t, `SELECT AVG(CPUUtilization) FROM "AWS/EC2"`, *mdq.Expression)
+		assert.Equal(t, int64(300), *mdq.Period)
+		assert.Equal(t, `SELECT AVG(CPUUtilization) FROM "AWS/EC2"`, query.UsedExpression)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"sort"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
@@ -247,7 +249,9 @@ func (e *cloudWatchExecutor) executeMetricFindQuery(ctx context.Context, queryCo
 
 	parameters := firstQuery.Model
 	subType := firstQuery.Model.Get("subtype").MustString()
+	paginate := firstQuery.Model.Get("paginate").MustBool(false)
 	var data []suggestData
+	var nextToken string
 	var err error
 	switch subType {
 	case "regions":
@@ -257,9 +261,17 @@ func (e *cloudWatchExecutor) executeMetricFindQuery(ctx context.Context, queryCo
 	case "metrics":
 		data, err = e.handleGetMetrics(ctx, parameters, queryContext)
 	case "dimension_keys":
-		data, err = e.handleGetDimensions(ctx, parameters, queryContext)
+		if paginate {
+			data, nextToken, err = e.handleGetDimensionsPage(ctx, parameters)
+		} else {
+			data, err = e.handleGetDimensions(ctx, parameters, queryContext)
+		}
 	case "dimension_values":
-		data, err = e.handleGetDimensionValues(ctx, parameters, queryContext)
+		if paginate {
+			data, nextToken, err = e.handleGetDimensionValuesPage(ctx, parameters)
+		} else {
+			data, err = e.handleGetDimensionValues(ctx, parameters, queryContext)
+		}
 	case "ebs_volume_ids":
 		data, err = e.handleGetEbsVolumeIds(ctx, parameters, queryContext)
 	case "ec2_instance_attribute":
@@ -273,6 +285,9 @@ func (e *cloudWatchExecutor) executeMetricFindQuery(ctx context.Context, queryCo
 
 	queryResult := plugins.DataQueryResult{Meta: simplejson.New(), RefID: firstQuery.RefID}
 	transformToTable(data, &queryResult)
+	if nextToken != "" {
+		queryResult.Meta.Set("nextToken", nextToken)
+	}
 	result := plugins.DataResponse{
 		Results: map[string]plugins.DataQueryResult{
 			firstQuery.RefID: queryResult,
@@ -398,7 +413,7 @@ func (e *cloudWatchExecutor) handleGetMetrics(ctx context.Context, parameters *s
 		}
 	} else {
 		var err error
-		if namespaceMetrics, err = e.getMetricsForCustomMetrics(region, namespace); err != nil {
+		if namespaceMetrics, err = e.getMetricsForCustomMetrics(ctx, region, namespace); err != nil {
 			return nil, errutil.Wrap("unable to call AWS API", err)
 		}
 	}
@@ -422,9 +437,16 @@ func (e *cloudWatchExecutor) handleGetDimensions(ctx context.Context, parameters
 		if dimensionValues, exists = dimensionsMap[namespace]; !exists {
 			return nil, fmt.Errorf("unable to find dimension %q", namespace)
 		}
+	} else if opts := listMetricsOptions(parameters); len(opts) > 0 {
+		// the cache isn't per account
+		metrics, err := e.listMetrics(ctx, region, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}, opts...)
+		if err != nil {
+			return nil, errutil.Wrap("unable to call AWS API", err)
+		}
+		dimensionValues = dimensionKeys(metrics)
 	} else {
 		var err error
-		if dimensionValues, err = e.getDimensionsForCustomMetrics(region, namespace); err != nil {
+		if dimensionValues, err = e.getDimensionsForCustomMetrics(ctx, region, namespace); err != nil {
 			return nil, errutil.Wrap("unable to call AWS API", err)
 		}
 	}
@@ -439,10 +461,71 @@ func (e *cloudWatchExecutor) handleGetDimensions(ctx context.Context, parameters
 }
 
 func (e *cloudWatchExecutor) handleGetDimensionValues(ctx context.Context, parameters *simplejson.Json, queryContext plugins.DataQuery) ([]suggestData, error) {
+	region := parameters.Get("region").MustString()
+	dimensionKey := parameters.Get("dimensionKey").MustString()
+
+	metrics, err := e.listMetrics(ctx, region, dimensionValuesInput(parameters), listMetricsOptions(parameters)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return dimensionValues(metrics, dimensionKey), nil
+}
+
+// handleGetDimensionsPage returns the dimension keys of the metrics of a page of ListMetrics results,
+// starting at the page of the nextToken parameter, and the token of the next page if there's one.
+func (e *cloudWatchExecutor) handleGetDimensionsPage(ctx context.Context, parameters *simplejson.Json) (
+	[]suggestData, string, error) {
 	region := parameters.Get("region").MustString()
 	namespace := parameters.Get("namespace").MustString()
-	metricName := parameters.Get("metricName").MustString()
+
+	if !isCustomMetrics(namespace) {
+		result, err := e.handleGetDimensions(ctx, parameters, plugins.DataQuery{})
+		return result, "", err
+	}
+
+	params := &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}
+	if nextToken := parameters.Get("nextToken").MustString(); nextToken != "" {
+		params.NextToken = aws.String(nextToken)
+	}
+	metrics, nextToken, err := e.listMetricsPage(ctx, region, params, listMetricsOptions(parameters)...)
+	if err != nil {
+		return nil, "", errutil.Wrap("unable to call AWS API", err)
+	}
+
+	keys := dimensionKeys(metrics)
+	sort.Strings(keys)
+
+	result := make([]suggestData, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, suggestData{Text: key, Value: key})
+	}
+
+	return result, nextToken, nil
+}
+
+// handleGetDimensionValuesPage returns the dimension values of the metrics of a page of ListMetrics
+// results, starting at the page of the nextToken parameter, and the token of the next page if there's one.
+func (e *cloudWatchExecutor) handleGetDimensionValuesPage(ctx context.Context, parameters *simplejson.Json) (
+	[]suggestData, string, error) {
+	region := parameters.Get("region").MustString()
 	dimensionKey := parameters.Get("dimensionKey").MustString()
+
+	params := dimensionValuesInput(parameters)
+	if nextToken := parameters.Get("nextToken").MustString(); nextToken != "" {
+		params.NextToken = aws.String(nextToken)
+	}
+	metrics, nextToken, err := e.listMetricsPage(ctx, region, params, listMetricsOptions(parameters)...)
+	if err != nil {
+		return nil, "", errutil.Wrap("unable to call AWS API", err)
+	}
+
+	return dimensionValues(metrics, dimensionKey), nextToken, nil
+}
+
+func dimensionValuesInput(parameters *simplejson.Json) *cloudwatch.ListMetricsInput {
+	namespace := parameters.Get("namespace").MustString()
+	metricName := parameters.Get("metricName").MustString()
 	dimensionsJson := parameters.Get("dimensions").MustMap()
 
 	var dimensions []*cloudwatch.DimensionFilter
@@ -469,11 +552,36 @@ func (e *cloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 	if metricName != "" {
 		params.MetricName = aws.String(metricName)
 	}
-	metrics, err := e.listMetrics(region, params)
-	if err != nil {
-		return nil, err
+	return params
+}
+
+// listMetricsOptions returns the request options of the ListMetrics calls of a lookup. With the
+// accountId parameter, the metrics are the ones of that source account of cross-account observability.
+func listMetricsOptions(parameters *simplejson.Json) []request.Option {
+	accountID := parameters.Get("accountId").MustString()
+	if accountID == "" {
+		return nil
+	}
+	return []request.Option{withFormParams(url.Values{
+		"IncludeLinkedAccounts": []string{"true"},
+		"OwningAccount":         []string{accountID},
+	})}
+}
+
+func dimensionKeys(metrics []*cloudwatch.Metric) []string {
+	keys := make([]string, 0)
+	for _, metric := range metrics {
+		for _, dimension := range metric.Dimensions {
+			if isDuplicate(keys, *dimension.Name) {
+				continue
+			}
+			keys = append(keys, *dimension.Name)
+		}
 	}
+	return keys
+}
 
+func dimensionValues(metrics []*cloudwatch.Metric, dimensionKey string) []suggestData {
 	result := make([]suggestData, 0)
 	dupCheck := make(map[string]bool)
 	for _, metric := range metrics {
@@ -493,7 +601,7 @@ func (e *cloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 		return result[i].Text < result[j].Text
 	})
 
-	return result, nil
+	return result
 }
 
 func (e *cloudWatchExecutor) handleGetEbsVolumeIds(ctx context.Context, parameters *simplejson.Json,
@@ -638,7 +746,8 @@ func (e *cloudWatchExecutor) handleGetResourceArns(ctx context.Context, paramete
 	return result, nil
 }
 
-func (e *cloudWatchExecutor) listMetrics(region string, params *cloudwatch.ListMetricsInput) ([]*cloudwatch.Metric, error) {
+func (e *cloudWatchExecutor) listMetrics(ctx context.Context, region string, params *cloudwatch.ListMetricsInput,
+	opts ...request.Option) ([]*cloudwatch.Metric, error) {
 	client, err := e.getCWClient(region)
 	if err != nil {
 		return nil, err
@@ -648,7 +757,8 @@ func (e *cloudWatchExecutor) listMetrics(region string, params *cloudwatch.ListM
 	cloudWatchMetrics := []*cloudwatch.Metric{}
 
 	pageNum := 0
-	err = client.ListMetricsPages(params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+	err = client.ListMetricsPagesWithContext(ctx, params, func(page *cloudwatch.ListMetricsOutput,
+		lastPage bool) bool {
 		pageNum++
 		metrics.MAwsCloudWatchListMetrics.Inc()
 		metrics, err := awsutil.ValuesAtPath(page, "Metrics")
@@ -658,11 +768,30 @@ func (e *cloudWatchExecutor) listMetrics(region string, params *cloudwatch.ListM
 			}
 		}
 		return !lastPage && pageNum < e.cfg.AWSListMetricsPageLimit
-	})
+	}, opts...)
 
 	return cloudWatchMetrics, err
 }
 
+// listMetricsPage returns the metrics of a single page of ListMetrics results, and the token of the next
+// page if there's one.
+func (e *cloudWatchExecutor) listMetricsPage(ctx context.Context, region string, params *cloudwatch.ListMetricsInput,
+	opts ...request.Option) ([]*cloudwatch.Metric, string, error) {
+	client, err := e.getCWClient(region)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plog.Debug("Listing metrics page")
+	page, err := client.ListMetricsWithContext(ctx, params, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	metrics.MAwsCloudWatchListMetrics.Inc()
+
+	return page.Metrics, aws.StringValue(page.NextToken), nil
+}
+
 func (e *cloudWatchExecutor) ec2DescribeInstances(region string, filters []*ec2.Filter, instanceIds []*string) (*ec2.DescribeInstancesOutput, error) {
 	params := &ec2.DescribeInstancesInput{
 		Filters:     filters,
@@ -711,7 +840,7 @@ func (e *cloudWatchExecutor) resourceGroupsGetResources(region string, filters [
 
 var metricsCacheLock sync.Mutex
 
-func (e *cloudWatchExecutor) getMetricsForCustomMetrics(region, namespace string) ([]string, error) {
+func (e *cloudWatchExecutor) getMetricsForCustomMetrics(ctx context.Context, region, namespace string) ([]string, error) {
 	plog.Debug("Getting metrics for custom metrics", "region", region, "namespace", namespace)
 	metricsCacheLock.Lock()
 	defer metricsCacheLock.Unlock()
@@ -732,7 +861,7 @@ func (e *cloudWatchExecutor) getMetricsForCustomMetrics(region, namespace string
 	if customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][namespace].Expire.After(time.Now()) {
 		return customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][namespace].Cache, nil
 	}
-	metrics, err := e.listMetrics(region, &cloudwatch.ListMetricsInput{
+	metrics, err := e.listMetrics(ctx, region, &cloudwatch.ListMetricsInput{
 		Namespace: aws.String(namespace),
 	})
 
@@ -756,7 +885,7 @@ func (e *cloudWatchExecutor) getMetricsForCustomMetrics(region, namespace string
 
 var dimensionsCacheLock sync.Mutex
 
-func (e *cloudWatchExecutor) getDimensionsForCustomMetrics(region, namespace string) ([]string, error) {
+func (e *cloudWatchExecutor) getDimensionsForCustomMetrics(ctx context.Context, region, namespace string) ([]string, error) {
 	dimensionsCacheLock.Lock()
 	defer dimensionsCacheLock.Unlock()
 
@@ -777,7 +906,7 @@ func (e *cloudWatchExecutor) getDimensionsForCustomMetrics(region, namespace str
 		return customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][namespace].Cache, nil
 	}
 
-	metrics, err := e.listMetrics(region, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
+	metrics, err := e.listMetrics(ctx, region, &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)})
 	if err != nil {
 		return []string{}, err
 	}
@@ -530,7 +530,7 @@ func TestQuery_ListMetricsPagination(t *testing.T) {
 		client = FakeCWClient{Metrics: metrics, MetricsPerPage: 2}
 		executor := newExecutor(nil, &setting.Cfg{AWSListMetricsPageLimit: 3, AWSAllowedAuthProviders: []string{"default"}, AWSAssumeRoleEnabled: true}, fakeSessionCache{})
 		executor.DataSource = fakeDataSource()
-		response, err := executor.listMetrics("default", &cloudwatch.ListMetricsInput{})
+		response, err := executor.listMetrics(context.Background(), "default", &cloudwatch.ListMetricsInput{})
 		require.NoError(t, err)
 
 		expectedMetrics := client.MetricsPerPage * executor.cfg.AWSListMetricsPageLimit
@@ -541,9 +541,147 @@ func TestQuery_ListMetricsPagination(t *testing.T) {
 		client = FakeCWClient{Metrics: metrics, MetricsPerPage: 2}
 		executor := newExecutor(nil, &setting.Cfg{AWSListMetricsPageLimit: 1000, AWSAllowedAuthProviders: []string{"default"}, AWSAssumeRoleEnabled: true}, fakeSessionCache{})
 		executor.DataSource = fakeDataSource()
-		response, err := executor.listMetrics("default", &cloudwatch.ListMetricsInput{})
+		response, err := executor.listMetrics(context.Background(), "default", &cloudwatch.ListMetricsInput{})
 		require.NoError(t, err)
 
 		assert.Equal(t, len(metrics), len(response))
 	})
 }
+
+func TestQuery_DimensionsPage(t *testing.T) {
+	origNewCWClient := NewCWClient
+	t.Cleanup(func() {
+		NewCWClient = origNewCWClient
+	})
+
+	var client FakeCWClient
+
+	NewCWClient = func(sess *session.Session) cloudwatchiface.CloudWatchAPI {
+		return client
+	}
+
+	metrics := []*cloudwatch.Metric{
+		{MetricName: aws.String("Test_MetricName"), Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("Test_DimensionName1"), Value: aws.String("value1")},
+		}},
+		{MetricName: aws.String("Test_MetricName"), Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("Test_DimensionName1"), Value: aws.String("value2")},
+			{Name: aws.String("Test_DimensionName2"), Value: aws.String("value3")},
+		}},
+		{MetricName: aws.String("Test_MetricName"), Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("Test_DimensionName1"), Value: aws.String("value4")},
+		}},
+	}
+
+	query := func(t *testing.T, model map[string]interface{}) plugins.DataQueryResult {
+		t.Helper()
+
+		executor := newExecutor(nil, newTestConfig(), fakeSessionCache{})
+		resp, err := executor.DataQuery(context.Background(), fakeDataSource(), plugins.DataQuery{
+			Queries: []plugins.DataSubQuery{{RefID: "A", Model: simplejson.NewFromAny(model)}},
+		})
+		require.NoError(t, err)
+		return resp.Results["A"]
+	}
+
+	t.Run("Dimension values are returned a page at a time", func(t *testing.T) {
+		client = FakeCWClient{Metrics: metrics, MetricsPerPage: 2}
+
+		result := query(t, map[string]interface{}{
+			"type":         "metricFindQuery",
+			"subtype":      "dimension_values",
+			"region":       "us-east-1",
+			"namespace":    "custom",
+			"metricName":   "Test_MetricName",
+			"dimensionKey": "Test_DimensionName1",
+			"paginate":     true,
+		})
+		assert.Equal(t, []plugins.DataRowValues{{"value1", "value1"}, {"value2", "value2"}}, result.Tables[0].Rows)
+		nextToken := result.Meta.Get("nextToken").MustString()
+		require.Equal(t, "1", nextToken)
+
+		result = query(t, map[string]interface{}{
+			"type":         "metricFindQuery",
+			"subtype":      "dimension_values",
+			"region":       "us-east-1",
+			"namespace":    "custom",
+			"metricName":   "Test_MetricName",
+			"dimensionKey": "Test_DimensionName1",
+			"paginate":     true,
+			"nextToken":    nextToken,
+		})
+		assert.Equal(t, []plugins.DataRowValues{{"value4", "value4"}}, result.Tables[0].Rows)
+		_, hasNextToken := result.Meta.CheckGet("nextToken")
+		assert.False(t, hasNextToken)
+	})
+
+	t.Run("Dimension keys are returned a page at a time", func(t *testing.T) {
+		client = FakeCWClient{Metrics: metrics, MetricsPerPage: 2}
+
+		result := query(t, map[string]interface{}{
+			"type":      "metricFindQuery",
+			"subtype":   "dimension_keys",
+			"region":    "us-east-1",
+			"namespace": "custom",
+			"paginate":  true,
+		})
+		assert.Equal(t, []plugins.DataRowValues{
+			{"Test_DimensionName1", "Test_DimensionName1"},
+			{"Test_DimensionName2", "Test_DimensionName2"},
+		}, result.Tables[0].Rows)
+		assert.Equal(t, "1", result.Meta.Get("nextToken").MustString())
+	})
+}
+
+func TestQuery_DimensionValuesOfAccount(t *testing.T) {
+	srv, forms := newAWSStandIn(t, map[string]string{
+		"ListMetrics": `<ListMetricsResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <ListMetricsResult>
+    <Metrics>
+      <member>
+        <Namespace>AWS/EC2</Namespace>
+        <MetricName>CPUUtilization</MetricName>
+        <Dimensions>
+          <member>
+            <Name>InstanceId</Name>
+            <Value>i-1234</Value>
+          </member>
+        </Dimensions>
+      </member>
+    </Metrics>
+    <NextToken>page2</NextToken>
+  </ListMetricsResult>
+</ListMetricsResponse>`,
+	})
+
+	executor := newExecutor(nil, newTestConfig(), standInSessionCache{endpoint: srv.URL})
+	resp, err := executor.DataQuery(context.Background(), fakeDataSource(), plugins.DataQuery{
+		Queries: []plugins.DataSubQuery{
+			{
+				RefID: "A",
+				Model: simplejson.NewFromAny(map[string]interface{}{
+					"type":         "metricFindQuery",
+					"subtype":      "dimension_values",
+					"region":       "us-east-1",
+					"namespace":    "AWS/EC2",
+					"metricName":   "CPUUtilization",
+					"dimensionKey": "InstanceId",
+					"accountId":    "123456789012",
+					"paginate":     true,
+					"nextToken":    "page1",
+				}),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	form := <-forms
+	assert.Equal(t, "123456789012", form.Get("OwningAccount"))
+	assert.Equal(t, "true", form.Get("IncludeLinkedAccounts"))
+	assert.Equal(t, "page1", form.Get("NextToken"))
+	assert.Equal(t, "AWS/EC2", form.Get("Namespace"))
+
+	result := resp.Results["A"]
+	assert.Equal(t, []plugins.DataRowValues{{"i-1234", "i-1234"}}, result.Tables[0].Rows)
+	assert.Equal(t, "page2", result.Meta.Get("nextToken").MustString())
+}
@@ -21,6 +21,29 @@ func (e *cloudWatchExecutor) transformRequestQueriesToCloudWatchQueries(requestQ
 	plog.Debug("Transforming CloudWatch request queries")
 	cloudwatchQueries := make(map[string]*cloudWatchQuery)
 	for _, requestQuery := range requestQueries {
+		if requestQuery.MetricQueryType == metricQueryTypeQuery {
+			// the statistic is part of the SQL expression
+			id := requestQuery.Id
+			if id == "" {
+				id = fmt.Sprintf("query%s", requestQuery.RefId)
+			}
+			if _, ok := cloudwatchQueries[id]; ok {
+				return nil, fmt.Errorf("error in query %q - query ID %q is not unique", requestQuery.RefId, id)
+			}
+			cloudwatchQueries[id] = &cloudWatchQuery{
+				Id:              id,
+				RefId:           requestQuery.RefId,
+				Region:          requestQuery.Region,
+				Period:          requestQuery.Period,
+				Alias:           requestQuery.Alias,
+				ReturnData:      requestQuery.ReturnData,
+				MetricQueryType: requestQuery.MetricQueryType,
+				SqlExpression:   requestQuery.SqlExpression,
+				AccountId:       requestQuery.AccountId,
+			}
+			continue
+		}
+
 		for _, stat := range requestQuery.Statistics {
 			id := requestQuery.Id
 			if id == "" {
@@ -47,6 +70,7 @@ func (e *cloudWatchExecutor) transformRequestQueriesToCloudWatchQueries(requestQ
 				Expression: requestQuery.Expression,
 				ReturnData: requestQuery.ReturnData,
 				MatchExact: requestQuery.MatchExact,
+				AccountId:  requestQuery.AccountId,
 			}
 			cloudwatchQueries[id] = query
 		}
@@ -146,10 +170,6 @@ func (e *cloudWatchExecutor) transformQueryResponsesToQueryResult(cloudwatchResp
 // metric(s) for a given query row in the Query Editor.
 func buildDeepLink(refID string, requestQueries []*requestQuery, executedQueries []executedQuery, startTime time.Time,
 	endTime time.Time) (string, error) {
-	if isMathExpression(executedQueries) {
-		return "", nil
-	}
-
 	requestQuery := &requestQuery{}
 	for _, rq := range requestQueries {
 		if rq.RefId == refID {
@@ -158,6 +178,10 @@ func buildDeepLink(refID string, requestQueries []*requestQuery, executedQueries
 		}
 	}
 
+	if requestQuery.MetricQueryType != metricQueryTypeQuery && isMathExpression(executedQueries) {
+		return "", nil
+	}
+
 	metricItems := []interface{}{}
 	cloudWatchLinkProps := &cloudWatchLink{
 		Title:   refID,
@@ -170,7 +194,7 @@ func buildDeepLink(refID string, requestQueries []*requestQuery, executedQueries
 
 	expressions := []interface{}{}
 	for _, meta := range executedQueries {
-		if strings.Contains(meta.Expression, "SEARCH(") {
+		if requestQuery.MetricQueryType == metricQueryTypeQuery || strings.Contains(meta.Expression, "SEARCH(") {
 			expressions = append(expressions, &metricExpression{Expression: meta.Expression})
 		}
 	}
@@ -127,6 +127,29 @@ func TestQueryTransformer(t *testing.T) {
 		assert.Contains(t, res, "queryD_p46_32")
 	})
 
+	t.Run("One cloudwatchQuery is generated for a Metrics Insights query", func(t *testing.T) {
+		requestQueries := []*requestQuery{
+			{
+				RefId:           "D",
+				Region:          "us-east-1",
+				Period:          300,
+				MetricQueryType: metricQueryTypeQuery,
+				SqlExpression:   `SELECT AVG(CPUUtilization) FROM "AWS/EC2"`,
+				AccountId:       "123456789012",
+			},
+		}
+
+		res, err := executor.transformRequestQueriesToCloudWatchQueries(requestQueries)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		query := res["queryD"]
+		require.NotNil(t, query)
+		assert.True(t, query.isMetricInsightsQuery())
+		assert.False(t, query.isSearchExpression())
+		assert.False(t, query.isMathExpression())
+		assert.Equal(t, "123456789012", query.AccountId)
+	})
+
 	t.Run("should return an error if two queries have the same id", func(t *testing.T) {
 		requestQueries := []*requestQuery{
 			{
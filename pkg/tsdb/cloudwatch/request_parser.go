@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"regexp"
 	"sort"
@@ -14,6 +15,8 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 )
 
+var reAccountID = regexp.MustCompile(`^\d{12}$`)
+
 // Parses the json queries and returns a requestQuery. The requestQuery has a 1 to 1 mapping to a query editor row
 func (e *cloudWatchExecutor) parseQueries(queryContext plugins.DataQuery, startTime time.Time,
 	endTime time.Time) (map[string][]*requestQuery, error) {
@@ -46,13 +49,22 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 	if err != nil {
 		return nil, err
 	}
-	namespace, err := model.Get("namespace").String()
-	if err != nil {
-		return nil, err
+	mqt := metricQueryType(model.Get("metricQueryType").MustInt(int(metricQueryTypeSearch)))
+	sqlExpression := model.Get("sqlExpression").MustString("")
+	if mqt == metricQueryTypeQuery && strings.TrimSpace(sqlExpression) == "" {
+		return nil, errors.New("a Metrics Insights query requires a SQL expression")
 	}
-	metricName, err := model.Get("metricName").String()
-	if err != nil {
-		return nil, err
+
+	// Metrics Insights queries define the namespace and metric in the SQL expression
+	namespace := model.Get("namespace").MustString("")
+	metricName := model.Get("metricName").MustString("")
+	if mqt != metricQueryTypeQuery {
+		if namespace, err = model.Get("namespace").String(); err != nil {
+			return nil, err
+		}
+		if metricName, err = model.Get("metricName").String(); err != nil {
+			return nil, err
+		}
 	}
 	dimensions, err := parseDimensions(model)
 	if err != nil {
@@ -104,20 +116,27 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 	}
 
 	matchExact := model.Get("matchExact").MustBool(true)
+	accountID := strings.TrimSpace(model.Get("accountId").MustString(""))
+	if accountID != "" && !reAccountID.MatchString(accountID) {
+		return nil, fmt.Errorf("invalid account ID %q, expected 12 digits", accountID)
+	}
 
 	return &requestQuery{
-		RefId:      refId,
-		Region:     region,
-		Namespace:  namespace,
-		MetricName: metricName,
-		Dimensions: dimensions,
-		Statistics: aws.StringSlice(statistics),
-		Period:     period,
-		Alias:      alias,
-		Id:         id,
-		Expression: expression,
-		ReturnData: returnData,
-		MatchExact: matchExact,
+		RefId:           refId,
+		Region:          region,
+		Namespace:       namespace,
+		MetricName:      metricName,
+		Dimensions:      dimensions,
+		Statistics:      aws.StringSlice(statistics),
+		Period:          period,
+		Alias:           alias,
+		Id:              id,
+		Expression:      expression,
+		ReturnData:      returnData,
+		MatchExact:      matchExact,
+		MetricQueryType: mqt,
+		SqlExpression:   sqlExpression,
+		AccountId:       accountID,
 	}, nil
 }
 
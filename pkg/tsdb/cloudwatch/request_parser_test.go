@@ -86,6 +86,51 @@ func TestRequestParser(t *testing.T) {
 		assert.Equal(t, "Average", *res.Statistics[0])
 	})
 
+	t.Run("Metrics Insights query", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]interface{}{
+			"refId":           "ref1",
+			"region":          "us-east-1",
+			"metricQueryType": 1,
+			"sqlExpression":   `SELECT AVG(CPUUtilization) FROM SCHEMA("AWS/EC2", InstanceId) GROUP BY InstanceId`,
+			"accountId":       "123456789012",
+			"period":          "300",
+		})
+
+		res, err := parseRequestQuery(query, "ref1", from, to)
+		require.NoError(t, err)
+		assert.Equal(t, metricQueryTypeQuery, res.MetricQueryType)
+		assert.Equal(t, `SELECT AVG(CPUUtilization) FROM SCHEMA("AWS/EC2", InstanceId) GROUP BY InstanceId`, res.SqlExpression)
+		assert.Equal(t, "123456789012", res.AccountId)
+		assert.Empty(t, res.Namespace)
+		assert.Empty(t, res.MetricName)
+		assert.Equal(t, 300, res.Period)
+	})
+
+	t.Run("Metrics Insights query without SQL expression", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]interface{}{
+			"refId":           "ref1",
+			"region":          "us-east-1",
+			"metricQueryType": 1,
+		})
+
+		_, err := parseRequestQuery(query, "ref1", from, to)
+		require.EqualError(t, err, "a Metrics Insights query requires a SQL expression")
+	})
+
+	t.Run("Invalid account ID", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]interface{}{
+			"refId":      "ref1",
+			"region":     "us-east-1",
+			"namespace":  "ec2",
+			"metricName": "CPUUtilization",
+			"statistics": []interface{}{"Average"},
+			"accountId":  "prod",
+		})
+
+		_, err := parseRequestQuery(query, "ref1", from, to)
+		require.EqualError(t, err, `invalid account ID "prod", expected 12 digits`)
+	})
+
 	t.Run("Period defined in the editor by the user is being used when time range is short", func(t *testing.T) {
 		query := simplejson.NewFromAny(map[string]interface{}{
 			"refId":      "ref1",
@@ -193,6 +193,9 @@ func formatAlias(query *cloudWatchQuery, stat string, dimensions map[string]stri
 		stat = strings.Trim(query.Expression[sIndex+1:pIndex], " '")
 	}
 
+	if len(query.Alias) == 0 && query.isMetricInsightsQuery() {
+		return label
+	}
 	if len(query.Alias) == 0 && query.isMathExpression() {
 		return query.Id
 	}
@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -85,7 +86,8 @@ type FakeCWClient struct {
 	MetricsPerPage int
 }
 
-func (c FakeCWClient) ListMetricsPages(input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error {
+func (c FakeCWClient) ListMetricsPagesWithContext(ctx aws.Context, input *cloudwatch.ListMetricsInput,
+	fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
 	if c.MetricsPerPage == 0 {
 		c.MetricsPerPage = 1000
 	}
@@ -102,6 +104,32 @@ func (c FakeCWClient) ListMetricsPages(input *cloudwatch.ListMetricsInput, fn fu
 	return nil
 }
 
+// ListMetricsWithContext returns a page of MetricsPerPage metrics, the tokens being the page numbers.
+func (c FakeCWClient) ListMetricsWithContext(ctx aws.Context, input *cloudwatch.ListMetricsInput,
+	opts ...request.Option) (*cloudwatch.ListMetricsOutput, error) {
+	if c.MetricsPerPage == 0 {
+		c.MetricsPerPage = 1000
+	}
+	chunks := chunkSlice(c.Metrics, c.MetricsPerPage)
+
+	page := 0
+	if input.NextToken != nil {
+		var err error
+		if page, err = strconv.Atoi(*input.NextToken); err != nil {
+			return nil, err
+		}
+	}
+
+	output := &cloudwatch.ListMetricsOutput{}
+	if page < len(chunks) {
+		output.Metrics = chunks[page]
+	}
+	if page+1 < len(chunks) {
+		output.NextToken = aws.String(strconv.Itoa(page + 1))
+	}
+	return output, nil
+}
+
 type fakeEC2Client struct {
 	ec2iface.EC2API
 
@@ -75,7 +75,7 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, queryCo
 			}
 
 			cloudwatchResponses := make([]*cloudwatchResponse, 0)
-			mdo, err := e.executeRequest(ectx, client, metricDataInput)
+			mdo, err := e.executeRequest(ectx, client, metricDataInput, withAccountIDs(queries))
 			if err != nil {
 				for _, query := range requestQueries {
 					resultChan <- plugins.DataQueryResult{
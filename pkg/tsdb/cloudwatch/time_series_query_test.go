@@ -2,10 +2,19 @@ package cloudwatch
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTimeSeriesQuery(t *testing.T) {
@@ -25,3 +34,104 @@ func TestTimeSeriesQuery(t *testing.T) {
 		assert.EqualError(t, err, "invalid time range: start time must be before end time")
 	})
 }
+
+// standInSessionCache returns sessions of a local stand-in of the AWS API.
+type standInSessionCache struct {
+	endpoint string
+}
+
+func (s standInSessionCache) GetSession(region string, settings awsds.AWSDatasourceSettings) (*session.Session, error) {
+	return session.NewSession(&aws.Config{
+		Endpoint:    aws.String(s.endpoint),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+	})
+}
+
+// newAWSStandIn starts a local stand-in of the CloudWatch API, replying with the response of the action
+// of the request. The forms of the requests are sent to the returned channel.
+func newAWSStandIn(t *testing.T, responses map[string]string) (*httptest.Server, <-chan url.Values) {
+	t.Helper()
+
+	forms := make(chan url.Values, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		forms <- r.PostForm
+
+		response, ok := responses[r.PostForm.Get("Action")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_, err := w.Write([]byte(response))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, forms
+}
+
+func TestTimeSeriesQuery_MetricsInsights(t *testing.T) {
+	srv, forms := newAWSStandIn(t, map[string]string{
+		"GetMetricData": `<GetMetricDataResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <GetMetricDataResult>
+    <MetricDataResults>
+      <member>
+        <Id>queryA</Id>
+        <Label>i-1234</Label>
+        <StatusCode>Complete</StatusCode>
+        <Timestamps>
+          <member>2021-01-01T00:00:00Z</member>
+          <member>2021-01-01T00:05:00Z</member>
+        </Timestamps>
+        <Values>
+          <member>1.5</member>
+          <member>2.5</member>
+        </Values>
+      </member>
+    </MetricDataResults>
+  </GetMetricDataResult>
+</GetMetricDataResponse>`,
+	})
+
+	executor := newExecutor(nil, newTestConfig(), standInSessionCache{endpoint: srv.URL})
+	timeRange := plugins.NewDataTimeRange("now-1h", "now")
+	resp, err := executor.DataQuery(context.Background(), fakeDataSource(), plugins.DataQuery{
+		TimeRange: &timeRange,
+		Queries: []plugins.DataSubQuery{
+			{
+				RefID: "A",
+				Model: simplejson.NewFromAny(map[string]interface{}{
+					"type":            "timeSeriesQuery",
+					"region":          "us-east-1",
+					"metricQueryType": 1,
+					"sqlExpression":   `SELECT AVG(CPUUtilization) FROM SCHEMA("AWS/EC2", InstanceId) GROUP BY InstanceId`,
+					"accountId":       "123456789012",
+					"period":          "300",
+				}),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	form := <-forms
+	assert.Equal(t, "queryA", form.Get("MetricDataQueries.member.1.Id"))
+	assert.Equal(t, `SELECT AVG(CPUUtilization) FROM SCHEMA("AWS/EC2", InstanceId) GROUP BY InstanceId`,
+		form.Get("MetricDataQueries.member.1.Expression"))
+	assert.Equal(t, "300", form.Get("MetricDataQueries.member.1.Period"))
+	assert.Equal(t, "123456789012", form.Get("MetricDataQueries.member.1.AccountId"))
+
+	result := resp.Results["A"]
+	require.NoError(t, result.Error)
+	frames, err := result.Dataframes.Decoded()
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	assert.Equal(t, "i-1234", frames[0].Name)
+	require.Len(t, frames[0].Fields, 2)
+	assert.Equal(t, 2, frames[0].Fields[1].Len())
+	assert.Equal(t, 2.5, *frames[0].Fields[1].At(1).(*float64))
+}
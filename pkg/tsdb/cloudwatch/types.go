@@ -21,8 +21,19 @@ type requestQuery struct {
 	Period             int
 	Alias              string
 	MatchExact         bool
+	MetricQueryType    metricQueryType
+	SqlExpression      string
+	AccountId          string
 }
 
+// metricQueryType is the metricQueryType of a query model.
+type metricQueryType int
+
+const (
+	metricQueryTypeSearch metricQueryType = iota
+	metricQueryTypeQuery
+)
+
 type cloudwatchResponse struct {
 	DataFrames              data.Frames
 	Id                      string
@@ -0,0 +1,226 @@
+// Package files implements a backend-only data source for CSV, JSON lines and Parquet files. Without a
+// plugin.json or frontend it is not listed in the UI: data sources of type "files" are created through
+// the data source HTTP API, files are uploaded through /api/datasource-files and the data source is
+// queried through /api/ds/query or alerting.
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var flog = log.New("tsdb.files")
+
+const (
+	sourceUpload = "upload"
+	sourceLocal  = "local"
+
+	formatCSV     = "csv"
+	formatJSONL   = "jsonl"
+	formatParquet = "parquet"
+
+	// MaxFileSize is the maximum size of a file that can be uploaded or queried.
+	MaxFileSize = 64 * 1024 * 1024
+)
+
+var (
+	// ErrFileNotFound is returned when an uploaded file does not exist.
+	ErrFileNotFound = errors.New("file not found")
+	// ErrInvalidFileName is returned when the name of an uploaded file is not a plain file name with a
+	// supported extension.
+	ErrInvalidFileName = errors.New("invalid file name, expected a .csv, .jsonl, .ndjson or .parquet file")
+	// ErrFileTooLarge is returned when a file exceeds MaxFileSize.
+	ErrFileTooLarge = fmt.Errorf("file exceeds the maximum size of %d bytes", MaxFileSize)
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         "FileDataSourceService",
+		InitPriority: registry.Low,
+		Instance:     &Service{},
+	})
+}
+
+// Service reads the files queried through the data source and stores uploaded files.
+type Service struct {
+	Cfg *setting.Cfg `inject:""`
+}
+
+func (s *Service) Init() error {
+	return nil
+}
+
+type executor struct {
+	service *Service
+}
+
+// NewExecutor returns an executor for the files data source.
+func (s *Service) NewExecutor(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
+	return &executor{service: s}, nil
+}
+
+// fileQuery is the query model of the data source.
+type fileQuery struct {
+	Source     string   `json:"source"`
+	Path       string   `json:"path"`
+	Format     string   `json:"format"`
+	Columns    []string `json:"columns"`
+	TimeColumn string   `json:"timeColumn"`
+	// IgnoreTimeRange returns all rows instead of only the rows within the time range of the query.
+	IgnoreTimeRange bool `json:"ignoreTimeRange"`
+}
+
+func (e *executor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
+	tsdbQuery plugins.DataQuery) (plugins.DataResponse, error) {
+	result := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult, len(tsdbQuery.Queries)),
+	}
+
+	for _, query := range tsdbQuery.Queries {
+		queryResult := plugins.DataQueryResult{RefID: query.RefID}
+
+		frame, err := e.executeQuery(dsInfo, query, tsdbQuery.TimeRange)
+		if err != nil {
+			queryResult.Error = err
+		} else {
+			queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{frame})
+		}
+
+		result.Results[query.RefID] = queryResult
+	}
+
+	return result, nil
+}
+
+func (e *executor) executeQuery(dsInfo *models.DataSource, query plugins.DataSubQuery,
+	timeRange *plugins.DataTimeRange) (*data.Frame, error) {
+	model := fileQuery{}
+	if query.Model != nil {
+		raw, err := query.Model.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &model); err != nil {
+			return nil, fmt.Errorf("failed to parse query: %w", err)
+		}
+	}
+
+	path, err := e.service.resolvePath(dsInfo.OrgId, model.Source, model.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := model.Format
+	if format == "" {
+		format = formatFromName(path)
+	}
+
+	t, err := readFile(path, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if !model.IgnoreTimeRange && timeRange != nil {
+		from, err := timeRange.ParseFrom()
+		if err != nil {
+			return nil, err
+		}
+		to, err := timeRange.ParseTo()
+		if err != nil {
+			return nil, err
+		}
+		if err := t.filterTimeRange(model.TimeColumn, from, to); err != nil {
+			return nil, err
+		}
+	} else if model.TimeColumn != "" {
+		if _, err := t.timeColumn(model.TimeColumn); err != nil {
+			return nil, err
+		}
+	}
+
+	frame, err := t.toFrame(model.Columns)
+	if err != nil {
+		return nil, err
+	}
+	frame.Name = filepath.Base(path)
+	frame.RefID = query.RefID
+
+	return frame, nil
+}
+
+// resolvePath returns the path of the queried file. Uploaded files are looked up in the upload directory
+// of the organization, local files must be within one of the allowed paths of the configuration.
+func (s *Service) resolvePath(orgID int64, source string, path string) (string, error) {
+	if path == "" {
+		return "", errors.New("query has no file")
+	}
+
+	switch source {
+	case "", sourceUpload:
+		if !IsValidFileName(path) {
+			return "", ErrInvalidFileName
+		}
+		fullPath := filepath.Join(s.uploadDir(orgID), path)
+		if _, err := os.Stat(fullPath); err != nil {
+			if os.IsNotExist(err) {
+				return "", ErrFileNotFound
+			}
+			return "", err
+		}
+		return fullPath, nil
+	case sourceLocal:
+		return s.resolveLocalPath(path)
+	default:
+		return "", fmt.Errorf("unsupported source %q", source)
+	}
+}
+
+func (s *Service) resolveLocalPath(path string) (string, error) {
+	if len(s.Cfg.FileDataSourceAllowedPaths) == 0 {
+		return "", errors.New("local files are disabled, no allowed paths are configured")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("local file path %q must be absolute", path)
+	}
+
+	resolved, err := util.ResolvePathWithin(path, s.Cfg.FileDataSourceAllowedPaths)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrFileNotFound
+		}
+		if errors.Is(err, util.ErrPathNotAllowed) {
+			return "", fmt.Errorf("local file path %q is not within the allowed paths", path)
+		}
+		return "", err
+	}
+	return resolved, nil
+}
+
+func (s *Service) uploadDir(orgID int64) string {
+	return filepath.Join(s.Cfg.DataPath, "datasource-files", strconv.FormatInt(orgID, 10))
+}
+
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		return formatJSONL
+	case ".parquet":
+		return formatParquet
+	default:
+		return formatCSV
+	}
+}
@@ -0,0 +1,235 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const testCSV = `time,host,value,up
+2021-03-01T10:02:00Z,b,3.5,true
+2021-03-01T10:00:00Z,a,1,false
+2021-03-01T10:01:00Z,a,,true
+2021-03-01T11:00:00Z,c,4,true
+`
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	return &Service{Cfg: &setting.Cfg{DataPath: t.TempDir()}}
+}
+
+func query(t *testing.T, s *Service, model map[string]interface{}) plugins.DataQueryResult {
+	t.Helper()
+	plug, err := s.NewExecutor(&models.DataSource{OrgId: 1})
+	require.NoError(t, err)
+
+	timeRange := plugins.NewDataTimeRange("1614592800000", "1614594600000")
+	res, err := plug.DataQuery(context.Background(), &models.DataSource{OrgId: 1}, plugins.DataQuery{
+		TimeRange: &timeRange,
+		Queries:   []plugins.DataSubQuery{{RefID: "A", Model: simplejson.NewFromAny(model)}},
+	})
+	require.NoError(t, err)
+	return res.Results["A"]
+}
+
+func decodedFrame(t *testing.T, result plugins.DataQueryResult) *data.Frame {
+	t.Helper()
+	require.NoError(t, result.Error)
+	frames, err := result.Dataframes.Decoded()
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	return frames[0]
+}
+
+func TestCSVQuery(t *testing.T) {
+	s := newTestService(t)
+	_, err := s.SaveFile(1, "metrics.csv", strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	t.Run("infers types and filters by time range", func(t *testing.T) {
+		frame := decodedFrame(t, query(t, s, map[string]interface{}{"path": "metrics.csv"}))
+		assert.Equal(t, "metrics.csv", frame.Name)
+		assert.Equal(t, "A", frame.RefID)
+		require.Len(t, frame.Fields, 4)
+		require.Equal(t, 3, frame.Rows())
+
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		assert.Equal(t, data.FieldTypeNullableBool, frame.Fields[3].Type())
+
+		// rows are sorted by time, the row at 11:00 is outside of the time range
+		assert.Equal(t, time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		assert.Equal(t, "a", *frame.Fields[1].At(0).(*string))
+		assert.Nil(t, frame.Fields[2].At(1))
+		assert.Equal(t, 3.5, *frame.Fields[2].At(2).(*float64))
+	})
+
+	t.Run("selects columns and ignores the time range", func(t *testing.T) {
+		frame := decodedFrame(t, query(t, s, map[string]interface{}{
+			"path":            "metrics.csv",
+			"columns":         []string{"value", "host"},
+			"ignoreTimeRange": true,
+		}))
+		require.Len(t, frame.Fields, 2)
+		assert.Equal(t, "value", frame.Fields[0].Name)
+		assert.Equal(t, "host", frame.Fields[1].Name)
+		assert.Equal(t, 4, frame.Rows())
+	})
+
+	t.Run("fails on an unknown column", func(t *testing.T) {
+		result := query(t, s, map[string]interface{}{"path": "metrics.csv", "columns": []string{"cpu"}})
+		require.EqualError(t, result.Error, `column "cpu" not found`)
+	})
+
+	t.Run("fails on a missing file", func(t *testing.T) {
+		result := query(t, s, map[string]interface{}{"path": "missing.csv"})
+		require.True(t, errors.Is(result.Error, ErrFileNotFound))
+	})
+}
+
+func TestJSONLinesQuery(t *testing.T) {
+	s := newTestService(t)
+	_, err := s.SaveFile(1, "events.jsonl", strings.NewReader(
+		`{"ts":1614592800000,"level":"info","count":1}`+"\n"+
+			`{"ts":1614592860000,"level":"error","count":2,"tags":{"a":"b"}}`+"\n\n"+
+			`{"ts":1614592920000,"count":"many"}`+"\n"))
+	require.NoError(t, err)
+
+	frame := decodedFrame(t, query(t, s, map[string]interface{}{"path": "events.jsonl", "timeColumn": "ts"}))
+	require.Equal(t, 3, frame.Rows())
+
+	names := []string{}
+	for _, f := range frame.Fields {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"count", "level", "ts", "tags"}, names)
+
+	// counts of different types are kept as text
+	assert.Equal(t, "many", *frame.Fields[0].At(2).(*string))
+	assert.Nil(t, frame.Fields[1].At(2))
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 1, 0, 0, time.UTC), *frame.Fields[2].At(1).(*time.Time))
+	assert.Equal(t, `{"a":"b"}`, *frame.Fields[3].At(1).(*string))
+}
+
+type testParquetRecord struct {
+	Time  int64   `parquet:"name=time, type=TIMESTAMP_MILLIS"`
+	Host  string  `parquet:"name=host, type=UTF8"`
+	Value float64 `parquet:"name=value, type=DOUBLE"`
+	Count *int32  `parquet:"name=count, type=INT32, repetitiontype=OPTIONAL"`
+}
+
+// parquetWriteBuffer is a source.ParquetFile collecting the written file.
+type parquetWriteBuffer struct {
+	bytes.Buffer
+}
+
+func (b *parquetWriteBuffer) Seek(int64, int) (int64, error)            { return 0, nil }
+func (b *parquetWriteBuffer) Close() error                              { return nil }
+func (b *parquetWriteBuffer) Open(string) (source.ParquetFile, error)   { return b, nil }
+func (b *parquetWriteBuffer) Create(string) (source.ParquetFile, error) { return b, nil }
+
+func TestParquetQuery(t *testing.T) {
+	buf := &parquetWriteBuffer{}
+	pw, err := writer.NewParquetWriter(buf, new(testParquetRecord), 1)
+	require.NoError(t, err)
+	count := int32(7)
+	require.NoError(t, pw.Write(testParquetRecord{Time: 1614592800000, Host: "a", Value: 1.5, Count: &count}))
+	require.NoError(t, pw.Write(testParquetRecord{Time: 1614592860000, Host: "b", Value: 2.5}))
+	require.NoError(t, pw.WriteStop())
+
+	s := newTestService(t)
+	_, err = s.SaveFile(1, "metrics.parquet", bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	frame := decodedFrame(t, query(t, s, map[string]interface{}{"path": "metrics.parquet"}))
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, 2, frame.Rows())
+	assert.Equal(t, "time", frame.Fields[0].Name)
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 1, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+	assert.Equal(t, "b", *frame.Fields[1].At(1).(*string))
+	assert.Equal(t, 1.5, *frame.Fields[2].At(0).(*float64))
+	assert.Equal(t, 7.0, *frame.Fields[3].At(0).(*float64))
+	assert.Nil(t, frame.Fields[3].At(1))
+}
+
+func TestLocalFiles(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(allowed, "metrics.csv"), []byte(testCSV), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "secret.csv"), []byte(testCSV), 0600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.csv"), filepath.Join(allowed, "link.csv")))
+
+	s := newTestService(t)
+
+	t.Run("are disabled without allowed paths", func(t *testing.T) {
+		result := query(t, s, map[string]interface{}{"source": "local", "path": filepath.Join(allowed, "metrics.csv")})
+		require.EqualError(t, result.Error, "local files are disabled, no allowed paths are configured")
+	})
+
+	s.Cfg.FileDataSourceAllowedPaths = []string{allowed}
+
+	t.Run("are read within the allowed paths", func(t *testing.T) {
+		frame := decodedFrame(t, query(t, s, map[string]interface{}{"source": "local", "path": filepath.Join(allowed, "metrics.csv")}))
+		assert.Equal(t, 3, frame.Rows())
+	})
+
+	t.Run("are not read outside of the allowed paths", func(t *testing.T) {
+		for _, path := range []string{
+			filepath.Join(outside, "secret.csv"),
+			filepath.Join(allowed, "..", filepath.Base(outside), "secret.csv"),
+			filepath.Join(allowed, "link.csv"),
+		} {
+			result := query(t, s, map[string]interface{}{"source": "local", "path": path})
+			require.Error(t, result.Error, path)
+			assert.Contains(t, result.Error.Error(), "is not within the allowed paths")
+		}
+	})
+}
+
+func TestUploads(t *testing.T) {
+	s := newTestService(t)
+
+	for _, name := range []string{"", ".hidden.csv", "../metrics.csv", "dir/metrics.csv", "metrics.txt"} {
+		_, err := s.SaveFile(1, name, strings.NewReader(testCSV))
+		require.Equal(t, ErrInvalidFileName, err, name)
+	}
+
+	info, err := s.SaveFile(1, "b.csv", strings.NewReader(testCSV))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(testCSV)), info.Size)
+	_, err = s.SaveFile(1, "a.ndjson", strings.NewReader("{}\n"))
+	require.NoError(t, err)
+	_, err = s.SaveFile(2, "c.csv", strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	list, err := s.ListFiles(1)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a.ndjson", list[0].Name)
+	assert.Equal(t, "b.csv", list[1].Name)
+
+	require.NoError(t, s.DeleteFile(1, "b.csv"))
+	require.Equal(t, ErrFileNotFound, s.DeleteFile(1, "b.csv"))
+	require.Equal(t, ErrFileNotFound, s.DeleteFile(1, "c.csv"))
+
+	list, err = s.ListFiles(3)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,359 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// readFile reads a CSV, JSON lines or Parquet file into a table.
+func readFile(path string, format string) (*table, error) {
+	// nolint:gosec
+	// The path is either within the upload directory or within an allowed path of the configuration.
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			flog.Warn("Failed to close file", "path", path, "error", err)
+		}
+	}()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() > MaxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	switch format {
+	case formatCSV:
+		return readCSV(f)
+	case formatJSONL:
+		return readJSONLines(f)
+	case formatParquet:
+		content, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return readParquet(content)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// readCSV reads a CSV file with a header row, inferring the type of each column.
+func readCSV(r io.Reader) (*table, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.TrimLeadingSpace = true
+	csvReader.ReuseRecord = true
+
+	record, err := csvReader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file is empty")
+		}
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	// records are reused, so keep a copy of the header
+	header := append([]string(nil), record...)
+
+	texts := make([][]string, len(header))
+	rows := 0
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if len(record) != len(header) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", rows+1, len(record), len(header))
+		}
+		for i, value := range record {
+			texts[i] = append(texts[i], strings.TrimSpace(value))
+		}
+		rows++
+	}
+
+	t := &table{rows: rows}
+	for i, name := range header {
+		t.columns = append(t.columns, &column{name: strings.TrimSpace(name), values: inferColumn(texts[i])})
+	}
+	return t, nil
+}
+
+// readJSONLines reads a file of JSON objects, one per line. Every key becomes a column. Nested objects
+// and arrays are kept as JSON text.
+func readJSONLines(r io.Reader) (*table, error) {
+	t := &table{}
+	columns := map[string]*column{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxFileSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var object map[string]interface{}
+		if err := json.Unmarshal(text, &object); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d: %w", line, err)
+		}
+
+		for key, value := range object {
+			c, ok := columns[key]
+			if !ok {
+				c = &column{name: key, values: make([]interface{}, t.rows)}
+				columns[key] = c
+				t.columns = append(t.columns, c)
+			}
+			switch value.(type) {
+			case nil, float64, bool, string:
+			default:
+				b, err := json.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+				value = string(b)
+			}
+			c.values = append(c.values, value)
+		}
+		t.rows++
+		for _, c := range t.columns {
+			if len(c.values) < t.rows {
+				c.values = append(c.values, nil)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+
+	// keys are unordered within an object, so order the columns to keep frames stable
+	sortColumnsByFirstValue(t)
+
+	for _, c := range t.columns {
+		unifyColumn(c)
+		inferTimes(c.values)
+	}
+	return t, nil
+}
+
+// sortColumnsByFirstValue orders the columns by the row of their first value, then by name.
+func sortColumnsByFirstValue(t *table) {
+	first := make(map[*column]int, len(t.columns))
+	for _, c := range t.columns {
+		first[c] = len(c.values)
+		for i, v := range c.values {
+			if v != nil {
+				first[c] = i
+				break
+			}
+		}
+	}
+	sort.SliceStable(t.columns, func(i, j int) bool {
+		a, b := t.columns[i], t.columns[j]
+		if first[a] != first[b] {
+			return first[a] < first[b]
+		}
+		return a.name < b.name
+	})
+}
+
+// unifyColumn converts all values of a column with values of different types to strings.
+func unifyColumn(c *column) {
+	var kind string
+	mixed := false
+	for _, v := range c.values {
+		if v == nil {
+			continue
+		}
+		k := fmt.Sprintf("%T", v)
+		if kind == "" {
+			kind = k
+		} else if kind != k {
+			mixed = true
+			break
+		}
+	}
+	if !mixed {
+		return
+	}
+
+	for i, v := range c.values {
+		if v != nil {
+			c.values[i] = fmt.Sprint(v)
+		}
+	}
+}
+
+// readParquet reads the flat columns of a Parquet file. Nested and repeated columns are not supported.
+func readParquet(content []byte) (*table, error) {
+	pr, err := reader.NewParquetColumnReader(&parquetBuffer{Reader: bytes.NewReader(content), content: content}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet file: %w", err)
+	}
+	defer pr.ReadStop()
+
+	rows := pr.GetNumRows()
+	t := &table{rows: int(rows)}
+	sh := pr.SchemaHandler
+	for i, inPath := range sh.ValueColumns {
+		element := sh.SchemaElements[sh.MapIndex[inPath]]
+		exPath := common.StrToPath(sh.InPathToExPath[inPath])
+		name := strings.Join(exPath[1:], ".")
+		if element.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED || len(exPath) > 2 {
+			return nil, fmt.Errorf("column %q is nested or repeated, only flat columns are supported", name)
+		}
+
+		values := []interface{}{}
+		if rows > 0 {
+			values, _, _, err = pr.ReadColumnByIndex(int64(i), rows)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read column %q: %w", name, err)
+			}
+		}
+		if int64(len(values)) != rows {
+			return nil, fmt.Errorf("column %q has %d values, expected %d", name, len(values), rows)
+		}
+
+		for j, v := range values {
+			values[j] = parquetValue(element, v)
+		}
+		t.columns = append(t.columns, &column{name: name, values: values})
+	}
+	return t, nil
+}
+
+// parquetValue converts a Parquet value to a table value, using the logical type of the column for
+// timestamps and decimals. Timestamps are read as UTC.
+func parquetValue(element *parquet.SchemaElement, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	if ts, ok := v.(int64); ok {
+		if lt := element.GetLogicalType(); lt != nil && lt.IsSetTIMESTAMP() {
+			switch unit := lt.TIMESTAMP.Unit; {
+			case unit.IsSetMILLIS():
+				return time.Unix(0, ts*int64(time.Millisecond)).UTC()
+			case unit.IsSetMICROS():
+				return time.Unix(0, ts*int64(time.Microsecond)).UTC()
+			case unit.IsSetNANOS():
+				return time.Unix(0, ts).UTC()
+			}
+		}
+		if element.IsSetConvertedType() {
+			switch element.GetConvertedType() {
+			case parquet.ConvertedType_TIMESTAMP_MILLIS:
+				return time.Unix(0, ts*int64(time.Millisecond)).UTC()
+			case parquet.ConvertedType_TIMESTAMP_MICROS:
+				return time.Unix(0, ts*int64(time.Microsecond)).UTC()
+			}
+		}
+	}
+	if element.IsSetConvertedType() && element.GetConvertedType() == parquet.ConvertedType_DECIMAL {
+		return parquetDecimal(element, v)
+	}
+
+	switch v := v.(type) {
+	case bool:
+		return v
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		if element.GetType() == parquet.Type_INT96 && len(v) == 12 {
+			return int96ToTime(v)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// int96ToTime converts a legacy INT96 timestamp, the nanoseconds of the day followed by the Julian day,
+// both little endian.
+func int96ToTime(v string) time.Time {
+	const unixEpochJulianDay = 2440588
+	b := []byte(v)
+	nanos := int64(binary.LittleEndian.Uint64(b[:8]))
+	day := int64(binary.LittleEndian.Uint32(b[8:]))
+	return time.Unix((day-unixEpochJulianDay)*24*60*60, nanos).UTC()
+}
+
+// parquetDecimal converts a decimal stored as an integer or as a big endian two's complement byte array.
+func parquetDecimal(element *parquet.SchemaElement, v interface{}) interface{} {
+	unscaled := new(big.Int)
+	switch v := v.(type) {
+	case int32:
+		unscaled.SetInt64(int64(v))
+	case int64:
+		unscaled.SetInt64(v)
+	case string:
+		b := []byte(v)
+		unscaled.SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(element.GetScale())), nil))
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(unscaled), scale).Float64()
+	return f
+}
+
+// parquetBuffer is a read only source.ParquetFile over the content of a file. The reader opens the file
+// again for every column, which returns a new reader over the same content.
+type parquetBuffer struct {
+	*bytes.Reader
+	content []byte
+}
+
+var _ source.ParquetFile = (*parquetBuffer)(nil)
+
+func (b *parquetBuffer) Open(string) (source.ParquetFile, error) {
+	return &parquetBuffer{Reader: bytes.NewReader(b.content), content: b.content}, nil
+}
+
+func (b *parquetBuffer) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet buffer is read only")
+}
+
+func (b *parquetBuffer) Write([]byte) (int, error) {
+	return 0, errors.New("parquet buffer is read only")
+}
+
+func (b *parquetBuffer) Close() error {
+	return nil
+}
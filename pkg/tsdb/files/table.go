@@ -0,0 +1,230 @@
+package files
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// table is a file read into columns. Values are nil, float64, bool, string or time.Time, and all non-nil
+// values of a column have the same type.
+type table struct {
+	columns []*column
+	rows    int
+}
+
+type column struct {
+	name   string
+	values []interface{}
+}
+
+func (t *table) column(name string) (*column, error) {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("column %q not found", name)
+}
+
+// timeColumn returns the time column of the table, converting it to times if needed. If name is empty,
+// the first column of times is used, and nil is returned if there is none. Numbers are converted as
+// milliseconds since the epoch.
+func (t *table) timeColumn(name string) (*column, error) {
+	if name == "" {
+		for _, c := range t.columns {
+			if columnType(c.values) == data.FieldTypeNullableTime {
+				return c, nil
+			}
+		}
+		return nil, nil
+	}
+
+	c, err := t.column(name)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range c.values {
+		switch v := v.(type) {
+		case float64:
+			c.values[i] = time.Unix(0, int64(v*float64(time.Millisecond))).UTC()
+		case string:
+			ts, err := parseTime(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse time %q of column %q: %w", v, name, err)
+			}
+			c.values[i] = ts
+		case bool:
+			return nil, fmt.Errorf("column %q is not a time column", name)
+		}
+	}
+	return c, nil
+}
+
+// filterTimeRange keeps the rows whose time is within the time range, sorted by time. Tables without
+// a time column are left as is.
+func (t *table) filterTimeRange(timeColumn string, from time.Time, to time.Time) error {
+	tc, err := t.timeColumn(timeColumn)
+	if err != nil || tc == nil {
+		return err
+	}
+
+	rows := make([]int, 0, t.rows)
+	for i, v := range tc.values {
+		ts, ok := v.(time.Time)
+		if ok && !ts.Before(from) && !ts.After(to) {
+			rows = append(rows, i)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return tc.values[rows[i]].(time.Time).Before(tc.values[rows[j]].(time.Time))
+	})
+
+	for _, c := range t.columns {
+		values := make([]interface{}, len(rows))
+		for i, row := range rows {
+			values[i] = c.values[row]
+		}
+		c.values = values
+	}
+	t.rows = len(rows)
+	return nil
+}
+
+// toFrame returns a frame with a field per column, or per selected column if any are given.
+func (t *table) toFrame(selected []string) (*data.Frame, error) {
+	columns := t.columns
+	if len(selected) > 0 {
+		columns = make([]*column, 0, len(selected))
+		for _, name := range selected {
+			c, err := t.column(name)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, c)
+		}
+	}
+
+	frame := data.NewFrame("")
+	for _, c := range columns {
+		field := data.NewFieldFromFieldType(columnType(c.values), len(c.values))
+		field.Name = c.name
+		for i, v := range c.values {
+			if v == nil {
+				continue
+			}
+			switch v := v.(type) {
+			case float64:
+				field.Set(i, &v)
+			case bool:
+				field.Set(i, &v)
+			case string:
+				field.Set(i, &v)
+			case time.Time:
+				field.Set(i, &v)
+			}
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame, nil
+}
+
+func columnType(values []interface{}) data.FieldType {
+	for _, v := range values {
+		switch v.(type) {
+		case float64:
+			return data.FieldTypeNullableFloat64
+		case bool:
+			return data.FieldTypeNullableBool
+		case string:
+			return data.FieldTypeNullableString
+		case time.Time:
+			return data.FieldTypeNullableTime
+		}
+	}
+	return data.FieldTypeNullableFloat64
+}
+
+// inferColumn converts a column of text values, as read from a CSV file, to numbers, booleans or times
+// when all its values are of that type. Empty values and "null" are nulls.
+func inferColumn(texts []string) []interface{} {
+	values := make([]interface{}, len(texts))
+	for _, parse := range []func(string) (interface{}, error){parseNumber, parseBool, parseTimeValue} {
+		ok := true
+		for i, text := range texts {
+			if text == "" || text == "null" {
+				values[i] = nil
+				continue
+			}
+			v, err := parse(text)
+			if err != nil {
+				ok = false
+				break
+			}
+			values[i] = v
+		}
+		if ok {
+			return values
+		}
+	}
+
+	for i, text := range texts {
+		if text == "" || text == "null" {
+			values[i] = nil
+		} else {
+			values[i] = text
+		}
+	}
+	return values
+}
+
+// inferTimes converts a column of strings to times when all of them are times in RFC3339 format.
+func inferTimes(values []interface{}) {
+	times := make([]interface{}, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			if v != nil {
+				return
+			}
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return
+		}
+		times[i] = ts
+	}
+	copy(values, times)
+}
+
+func parseNumber(s string) (interface{}, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseBool(s string) (interface{}, error) {
+	switch s {
+	case "true", "TRUE", "True":
+		return true, nil
+	case "false", "FALSE", "False":
+		return false, nil
+	default:
+		return nil, fmt.Errorf("invalid boolean %q", s)
+	}
+}
+
+func parseTimeValue(s string) (interface{}, error) {
+	return parseTime(s)
+}
+
+// parseTime parses a time in RFC3339 format or in milliseconds since the epoch, like the CSV scenarios of
+// the TestData data source.
+func parseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(ms/int64(1e+3), (ms%int64(1e+3))*int64(1e+6)).UTC(), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,114 @@
+package files
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileInfo describes an uploaded file.
+type FileInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Updated time.Time `json:"updated"`
+}
+
+// IsValidFileName reports whether name is a plain file name with a supported extension.
+func IsValidFileName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return false
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".jsonl", ".ndjson", ".parquet":
+		return true
+	default:
+		return false
+	}
+}
+
+// SaveFile stores an uploaded file of the organization, replacing any file with the same name.
+func (s *Service) SaveFile(orgID int64, name string, content io.Reader) (FileInfo, error) {
+	if !IsValidFileName(name) {
+		return FileInfo{}, ErrInvalidFileName
+	}
+
+	dir := s.uploadDir(orgID)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return FileInfo{}, err
+	}
+
+	// write to a temporary file first, so that queries never read a partially written file
+	tmp, err := ioutil.TempFile(dir, ".upload-")
+	if err != nil {
+		return FileInfo{}, err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !os.IsNotExist(err) {
+			flog.Warn("Failed to remove temporary file", "path", tmp.Name(), "error", err)
+		}
+	}()
+
+	size, err := io.Copy(tmp, io.LimitReader(content, MaxFileSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if size > MaxFileSize {
+		return FileInfo{}, ErrFileTooLarge
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return FileInfo{}, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{Name: name, Size: stat.Size(), Updated: stat.ModTime()}, nil
+}
+
+// ListFiles returns the uploaded files of the organization, sorted by name.
+func (s *Service) ListFiles(orgID int64) ([]FileInfo, error) {
+	entries, err := ioutil.ReadDir(s.uploadDir(orgID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []FileInfo{}, nil
+		}
+		return nil, err
+	}
+
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !IsValidFileName(entry.Name()) {
+			continue
+		}
+		files = append(files, FileInfo{Name: entry.Name(), Size: entry.Size(), Updated: entry.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	return files, nil
+}
+
+// DeleteFile removes an uploaded file of the organization.
+func (s *Service) DeleteFile(orgID int64, name string) error {
+	if !IsValidFileName(name) {
+		return ErrInvalidFileName
+	}
+
+	if err := os.Remove(filepath.Join(s.uploadDir(orgID), name)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrFileNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+const annotationQueryType = "annotations"
+
+func isAnnotationQuery(query plugins.DataSubQuery) bool {
+	return query.QueryType == annotationQueryType ||
+		query.Model.Get("queryType").MustString("") == annotationQueryType
+}
+
+// annotationQuery fetches Graphite events matching the query's tags and returns them
+// as a single frame with time, title, text and tags fields.
+func (e *GraphiteExecutor) annotationQuery(ctx context.Context, dsInfo *models.DataSource, httpClient *http.Client,
+	query plugins.DataSubQuery, from, until string) (plugins.DataQueryResult, error) {
+	params := url.Values{
+		"from":  []string{from},
+		"until": []string{until},
+	}
+	if tags := query.Model.Get("tags").MustString(""); tags != "" {
+		params.Set("tags", strings.Join(strings.FieldsFunc(tags, func(r rune) bool {
+			return r == ',' || r == ' '
+		}), " "))
+	}
+
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+	u.Path = path.Join(u.Path, "events", "get_data")
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return plugins.DataQueryResult{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if dsInfo.BasicAuth {
+		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			glog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+	if res.StatusCode/100 != 2 {
+		glog.Info("Request failed", "status", res.Status, "body", string(body))
+		return plugins.DataQueryResult{}, fmt.Errorf("request failed, status: %s", res.Status)
+	}
+
+	var events []EventDTO
+	if err := json.Unmarshal(body, &events); err != nil {
+		glog.Info("Failed to unmarshal graphite events response", "error", err, "status", res.Status, "body", string(body))
+		return plugins.DataQueryResult{}, err
+	}
+
+	return plugins.DataQueryResult{
+		Dataframes: plugins.NewDecodedDataFrames(data.Frames{eventsToFrame(events)}),
+	}, nil
+}
+
+func eventsToFrame(events []EventDTO) *data.Frame {
+	times := make([]time.Time, 0, len(events))
+	titles := make([]string, 0, len(events))
+	texts := make([]string, 0, len(events))
+	tags := make([]string, 0, len(events))
+
+	for _, event := range events {
+		times = append(times, time.Unix(0, int64(event.When*float64(time.Second))).UTC())
+		titles = append(titles, event.What)
+		texts = append(texts, event.Data)
+		tags = append(tags, strings.Join(eventTags(event.Tags), ","))
+	}
+
+	return data.NewFrame("annotations",
+		data.NewField("time", nil, times),
+		data.NewField("title", nil, titles),
+		data.NewField("text", nil, texts),
+		data.NewField("tags", nil, tags),
+	)
+}
+
+func eventTags(tags interface{}) []string {
+	switch t := tags.(type) {
+	case string:
+		return strings.Fields(t)
+	case []interface{}:
+		result := make([]string, 0, len(t))
+		for _, tag := range t {
+			result = append(result, fmt.Sprint(tag))
+		}
+		return result
+	default:
+		return []string{}
+	}
+}
@@ -12,9 +12,11 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context/ctxhttp"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -30,6 +32,8 @@ func NewExecutor(*models.DataSource) (plugins.DataPlugin, error) {
 	return &GraphiteExecutor{}, nil
 }
 
+const defaultMaxDataPoints = 500
+
 var glog = log.New("tsdb.graphite")
 
 func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsdbQuery plugins.DataQuery) (
@@ -47,18 +51,33 @@ func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 		}
 	}
 
-	var target string
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return plugins.DataResponse{}, err
+	}
 
-	formData := url.Values{
-		"from":          []string{from},
-		"until":         []string{until},
-		"format":        []string{"json"},
-		"maxDataPoints": []string{"500"},
+	result := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult),
 	}
 
 	emptyQueries := make([]string, 0)
 	for _, query := range tsdbQuery.Queries {
 		glog.Debug("graphite", "query", query.Model)
+		refID := query.RefID
+		if refID == "" {
+			refID = "A"
+		}
+
+		if isAnnotationQuery(query) {
+			queryRes, err := e.annotationQuery(ctx, dsInfo, httpClient, query, from, until)
+			if err != nil {
+				return plugins.DataResponse{}, err
+			}
+			queryRes.RefID = refID
+			result.Results[refID] = queryRes
+			continue
+		}
+
 		currTarget := ""
 		if fullTarget, err := query.Model.Get("targetFull").String(); err == nil {
 			currTarget = fullTarget
@@ -70,34 +89,47 @@ func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", query.Model))
 			continue
 		}
-		target = fixIntervalFormat(currTarget)
+		target := consolidateTarget(fixIntervalFormat(currTarget), query.Model.Get("consolidateBy").MustString(""))
+
+		formData := url.Values{
+			"from":          []string{from},
+			"until":         []string{until},
+			"format":        []string{"json"},
+			"maxDataPoints": []string{strconv.FormatInt(maxDataPoints(query), 10)},
+			"target":        []string{target},
+		}
+
+		queryRes, err := e.renderQuery(ctx, dsInfo, httpClient, formData)
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
+		queryRes.RefID = refID
+		result.Results[refID] = queryRes
 	}
 
-	if target == "" {
+	if len(result.Results) == 0 {
 		glog.Error("No targets in query model", "models without targets", strings.Join(emptyQueries, "\n"))
 		return plugins.DataResponse{}, errors.New("no query target found for the alert rule")
 	}
 
-	formData["target"] = []string{target}
+	return result, nil
+}
 
+func (e *GraphiteExecutor) renderQuery(ctx context.Context, dsInfo *models.DataSource, httpClient *http.Client,
+	formData url.Values) (plugins.DataQueryResult, error) {
 	if setting.Env == setting.Dev {
 		glog.Debug("Graphite request", "params", formData)
 	}
 
 	req, err := e.createRequest(dsInfo, formData)
 	if err != nil {
-		return plugins.DataResponse{}, err
-	}
-
-	httpClient, err := dsInfo.GetHttpClient()
-	if err != nil {
-		return plugins.DataResponse{}, err
+		return plugins.DataQueryResult{}, err
 	}
 
 	span, ctx := opentracing.StartSpanFromContext(ctx, "graphite query")
-	span.SetTag("target", target)
-	span.SetTag("from", from)
-	span.SetTag("until", until)
+	span.SetTag("target", formData.Get("target"))
+	span.SetTag("from", formData.Get("from"))
+	span.SetTag("until", formData.Get("until"))
 	span.SetTag("datasource_id", dsInfo.Id)
 	span.SetTag("org_id", dsInfo.OrgId)
 
@@ -107,36 +139,87 @@ func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 		span.Context(),
 		opentracing.HTTPHeaders,
 		opentracing.HTTPHeadersCarrier(req.Header)); err != nil {
-		return plugins.DataResponse{}, err
+		return plugins.DataQueryResult{}, err
 	}
 
 	res, err := ctxhttp.Do(ctx, httpClient, req)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return plugins.DataQueryResult{}, err
 	}
 
-	data, err := e.parseResponse(res)
+	series, err := e.parseResponse(res)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return plugins.DataQueryResult{}, err
 	}
 
-	result := plugins.DataResponse{
-		Results: make(map[string]plugins.DataQueryResult),
-	}
-	queryRes := plugins.DataQueryResult{}
-	for _, series := range data {
-		queryRes.Series = append(queryRes.Series, plugins.DataTimeSeries{
-			Name:   series.Target,
-			Points: series.DataPoints,
-		})
+	frames := make(data.Frames, 0, len(series))
+	for _, s := range series {
+		frames = append(frames, toDataFrame(s))
 
 		if setting.Env == setting.Dev {
-			glog.Debug("Graphite response", "target", series.Target, "datapoints", len(series.DataPoints))
+			glog.Debug("Graphite response", "target", s.Target, "datapoints", len(s.DataPoints))
 		}
 	}
 
-	result.Results["A"] = queryRes
-	return result, nil
+	return plugins.DataQueryResult{
+		Dataframes: plugins.NewDecodedDataFrames(frames),
+	}, nil
+}
+
+// toDataFrame converts a Graphite series into a data frame. Tags of tagged series are
+// returned as labels on the value field, so that they can be grouped by in expressions
+// and alerting.
+func toDataFrame(series TargetResponseDTO) *data.Frame {
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(series.DataPoints))
+	timeField.Name = data.TimeSeriesTimeFieldName
+	valueField := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, len(series.DataPoints))
+	valueField.Name = data.TimeSeriesValueFieldName
+	valueField.Labels = parseTags(series.Tags)
+	valueField.Config = &data.FieldConfig{DisplayNameFromDS: series.Target}
+
+	for i, point := range series.DataPoints {
+		timeField.Set(i, time.Unix(0, int64(point[1].Float64)*int64(time.Millisecond)).UTC())
+		if point[0].Valid {
+			value := point[0].Float64
+			valueField.Set(i, &value)
+		}
+	}
+
+	return data.NewFrame(series.Target, timeField, valueField)
+}
+
+func parseTags(tags map[string]interface{}) data.Labels {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	labels := make(data.Labels, len(tags))
+	for k, v := range tags {
+		switch value := v.(type) {
+		case string:
+			labels[k] = value
+		default:
+			labels[k] = fmt.Sprint(value)
+		}
+	}
+	return labels
+}
+
+// maxDataPoints returns the number of points Graphite should consolidate a series to.
+func maxDataPoints(query plugins.DataSubQuery) int64 {
+	if query.MaxDataPoints > 0 {
+		return query.MaxDataPoints
+	}
+	return query.Model.Get("maxDataPoints").MustInt64(defaultMaxDataPoints)
+}
+
+// consolidateTarget wraps target in consolidateBy so Graphite uses the given function
+// when a series has more points than maxDataPoints.
+func consolidateTarget(target string, consolidateBy string) string {
+	if consolidateBy == "" || strings.Contains(target, "consolidateBy(") {
+		return target
+	}
+	return fmt.Sprintf("consolidateBy(%s, '%s')", target, consolidateBy)
 }
 
 func (e *GraphiteExecutor) parseResponse(res *http.Response) ([]TargetResponseDTO, error) {
@@ -1,9 +1,20 @@
 package graphite
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFormatTimeRange(t *testing.T) {
@@ -59,3 +70,109 @@ func TestFixIntervalFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestConsolidateTarget(t *testing.T) {
+	assert.Equal(t, "a.b.c", consolidateTarget("a.b.c", ""))
+	assert.Equal(t, "consolidateBy(a.b.c, 'max')", consolidateTarget("a.b.c", "max"))
+	assert.Equal(t, "consolidateBy(a.b.c, 'sum')", consolidateTarget("consolidateBy(a.b.c, 'sum')", "max"))
+}
+
+func TestDataQuery(t *testing.T) {
+	var renderForm url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/render":
+			body, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			renderForm, err = url.ParseQuery(string(body))
+			require.NoError(t, err)
+			_, err = rw.Write([]byte(`[{
+				"target": "cpu;host=a",
+				"tags": {"name": "cpu", "host": "a"},
+				"datapoints": [[1.5, 1600000000], [null, 1600000060]]
+			}]`))
+			require.NoError(t, err)
+		case "/events/get_data":
+			assert.Equal(t, "deploy prod", req.URL.Query().Get("tags"))
+			_, err := rw.Write([]byte(`[
+				{"when": 1600000000, "what": "deploy", "data": "v1", "tags": ["deploy", "prod"]},
+				{"when": 1600000060, "what": "rollback", "data": "v0", "tags": "deploy prod"}
+			]`))
+			require.NoError(t, err)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	dsInfo := &models.DataSource{Url: srv.URL, JsonData: simplejson.New()}
+	timeRange := plugins.NewDataTimeRange("1h", "now")
+
+	t.Run("tagged series are returned as frames with labels", func(t *testing.T) {
+		query := plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{
+					RefID:         "B",
+					MaxDataPoints: 100,
+					Model:         simplejson.NewFromAny(map[string]interface{}{"target": "seriesByTag('name=cpu')", "consolidateBy": "max"}),
+				},
+			},
+		}
+
+		res, err := (&GraphiteExecutor{}).DataQuery(context.Background(), dsInfo, query)
+		require.NoError(t, err)
+		assert.Equal(t, "100", renderForm.Get("maxDataPoints"))
+		assert.Equal(t, "consolidateBy(seriesByTag('name=cpu'), 'max')", renderForm.Get("target"))
+
+		frames, err := res.Results["B"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Equal(t, "cpu;host=a", frame.Name)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, time.Unix(1600000000, 0).UTC(), frame.Fields[0].At(0))
+		assert.Equal(t, 1.5, *frame.Fields[1].At(0).(*float64))
+		assert.Nil(t, frame.Fields[1].At(1))
+		assert.Equal(t, data.Labels{"name": "cpu", "host": "a"}, frame.Fields[1].Labels)
+		assert.Equal(t, "cpu;host=a", frame.Fields[1].Config.DisplayNameFromDS)
+	})
+
+	t.Run("annotation queries return events as a frame", func(t *testing.T) {
+		query := plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{
+					RefID:     "Anno",
+					QueryType: annotationQueryType,
+					Model:     simplejson.NewFromAny(map[string]interface{}{"tags": "deploy, prod"}),
+				},
+			},
+		}
+
+		res, err := (&GraphiteExecutor{}).DataQuery(context.Background(), dsInfo, query)
+		require.NoError(t, err)
+
+		frames, err := res.Results["Anno"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "deploy", frame.Fields[1].At(0))
+		assert.Equal(t, "v0", frame.Fields[2].At(1))
+		assert.Equal(t, "deploy,prod", frame.Fields[3].At(0))
+		assert.Equal(t, "deploy,prod", frame.Fields[3].At(1))
+	})
+
+	t.Run("queries without target fail", func(t *testing.T) {
+		query := plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", Model: simplejson.New()},
+			},
+		}
+
+		_, err := (&GraphiteExecutor{}).DataQuery(context.Background(), dsInfo, query)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,33 @@
+package graphite
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+// Service exposes the Graphite metric and tag lookup endpoints as data source resources.
+type Service struct {
+	BackendPluginManager backendplugin.Manager `inject:""`
+}
+
+func (s *Service) Init() error {
+	mux := http.NewServeMux()
+	registerRoutes(mux)
+	coreplugin.RegisterResourceHandler(s.BackendPluginManager, "graphite", mux, glog)
+	return nil
+}
+
+// registerRoutes registers the resources used by the Graphite query editor: the autocompletion of the tags and
+// their values of the tag queries, and the metric tree of the metric queries, proxied to the Graphite API.
+func registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/tags/autoComplete/tags", coreplugin.ProxyResourceHandler("tags/autoComplete/tags", glog))
+	mux.HandleFunc("/tags/autoComplete/values", coreplugin.ProxyResourceHandler("tags/autoComplete/values", glog))
+	mux.HandleFunc("/metrics/find", coreplugin.ProxyResourceHandler("metrics/find", glog))
+}
@@ -5,4 +5,15 @@ import "github.com/grafana/grafana/pkg/plugins"
 type TargetResponseDTO struct {
 	Target     string                       `json:"target"`
 	DataPoints plugins.DataTimeSeriesPoints `json:"datapoints"`
+	// Tags is set by Graphite 1.1+ for tagged series (e.g. seriesByTag).
+	Tags map[string]interface{} `json:"tags"`
+}
+
+// EventDTO is a single entry of the /events/get_data response.
+type EventDTO struct {
+	When float64 `json:"when"`
+	What string  `json:"what"`
+	Data string  `json:"data"`
+	// Tags is a list of strings in Graphite 1.x and a space separated string in older versions.
+	Tags interface{} `json:"tags"`
 }
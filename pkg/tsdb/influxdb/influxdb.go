@@ -10,6 +10,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -51,36 +52,67 @@ func (e *Executor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsd
 
 	glog.Debug("Making a non-Flux type query")
 
-	// NOTE: the following path is currently only called from alerting queries
-	// In dashboards, the request runs through proxy and are managed in the frontend
-
-	query, err := e.getQuery(dsInfo, tsdbQuery)
-	if err != nil {
-		return plugins.DataResponse{}, err
+	if len(tsdbQuery.Queries) == 0 {
+		return plugins.DataResponse{}, fmt.Errorf("query request contains no queries")
 	}
 
-	rawQuery, err := query.Build(tsdbQuery)
-	if err != nil {
-		return plugins.DataResponse{}, err
+	result := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult, len(tsdbQuery.Queries)),
 	}
+	for _, subQuery := range tsdbQuery.Queries {
+		refID := subQuery.RefID
+		if refID == "" {
+			refID = "A"
+		}
+
+		query, err := e.QueryParser.Parse(subQuery.Model, dsInfo)
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
+
+		rawQuery, err := query.Build(tsdbQuery)
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
+
+		if setting.Env == setting.Dev {
+			glog.Debug("Influxdb query", "raw query", rawQuery)
+		}
+
+		response, err := e.executeQuery(ctx, dsInfo, rawQuery)
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
 
-	if setting.Env == setting.Dev {
-		glog.Debug("Influxdb query", "raw query", rawQuery)
+		queryRes := e.ResponseParser.Parse(response, query)
+		queryRes.RefID = refID
+		if frames, err := queryRes.Dataframes.Decoded(); err == nil {
+			for _, frame := range frames {
+				frame.RefID = refID
+				frame.Meta = &data.FrameMeta{ExecutedQueryString: rawQuery}
+			}
+		}
+		result.Results[refID] = queryRes
 	}
 
+	return result, nil
+}
+
+// executeQuery runs a raw InfluxQL query against the data source.
+func (e *Executor) executeQuery(ctx context.Context, dsInfo *models.DataSource, rawQuery string) (*Response, error) {
 	req, err := e.createRequest(ctx, dsInfo, rawQuery)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	httpClient, err := dsInfo.GetHttpClient()
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -88,36 +120,20 @@ func (e *Executor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsd
 		}
 	}()
 	if resp.StatusCode/100 != 2 {
-		return plugins.DataResponse{}, fmt.Errorf("InfluxDB returned error status: %s", resp.Status)
+		return nil, fmt.Errorf("InfluxDB returned error status: %s", resp.Status)
 	}
 
 	var response Response
 	dec := json.NewDecoder(resp.Body)
 	dec.UseNumber()
 	if err := dec.Decode(&response); err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 	if response.Err != nil {
-		return plugins.DataResponse{}, response.Err
-	}
-
-	result := plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{
-			"A": e.ResponseParser.Parse(&response, query),
-		},
-	}
-
-	return result, nil
-}
-
-func (e *Executor) getQuery(dsInfo *models.DataSource, query plugins.DataQuery) (*Query, error) {
-	if len(query.Queries) == 0 {
-		return nil, fmt.Errorf("query request contains no queries")
+		return nil, response.Err
 	}
 
-	// The model supports multiple queries, but right now this is only used from
-	// alerting so we only needed to support batch executing 1 query at a time.
-	return e.QueryParser.Parse(query.Queries[0].Model, dsInfo)
+	return &response, nil
 }
 
 func (e *Executor) createRequest(ctx context.Context, dsInfo *models.DataSource, query string) (*http.Request, error) {
@@ -3,11 +3,15 @@ package influxdb
 import (
 	"context"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -61,3 +65,57 @@ func TestExecutor_createRequest(t *testing.T) {
 		require.EqualError(t, err, ErrInvalidHttpMode.Error())
 	})
 }
+
+func TestExecutor_DataQuery(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.Query().Get("q"))
+		_, err := rw.Write([]byte(`{"results":[{"series":[{"name":"cpu","tags":{"host":"a"},` +
+			`"columns":["time","mean","max"],"values":[[60,1.5,2],[120,null,3]]}]}]}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	datasource := &models.DataSource{
+		Url:      server.URL,
+		Database: "db",
+		JsonData: simplejson.New(),
+	}
+	e := &Executor{
+		QueryParser:    &InfluxdbQueryParser{},
+		ResponseParser: &ResponseParser{},
+	}
+	newQuery := func(refID, rawQuery string) plugins.DataSubQuery {
+		return plugins.DataSubQuery{
+			RefID: refID,
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"rawQuery":     true,
+				"query":        rawQuery,
+				"resultFormat": "time_series",
+			}),
+		}
+	}
+
+	timeRange := plugins.NewDataTimeRange("5m", "now")
+	res, err := e.DataQuery(context.Background(), datasource, plugins.DataQuery{
+		TimeRange: &timeRange,
+		Queries: []plugins.DataSubQuery{
+			newQuery("A", "SELECT mean, max FROM cpu GROUP BY host"),
+			newQuery("B", "SELECT mean FROM mem"),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"SELECT mean, max FROM cpu GROUP BY host", "SELECT mean FROM mem"}, queries)
+	require.Len(t, res.Results, 2)
+
+	frames, err := res.Results["B"].Dataframes.Decoded()
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Equal(t, "B", frames[0].RefID)
+	require.Equal(t, "SELECT mean FROM mem", frames[0].Meta.ExecutedQueryString)
+	require.Len(t, frames[0].Fields, 3)
+	require.Equal(t, data.Labels{"host": "a"}, frames[0].Fields[1].Labels)
+	require.Equal(t, 1.5, *frames[0].Fields[1].At(0).(*float64))
+	require.Nil(t, frames[0].Fields[1].At(1))
+	require.Equal(t, 3.0, *frames[0].Fields[2].At(1).(*float64))
+}
@@ -0,0 +1,278 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+// Service exposes InfluxQL template variable and annotation queries as data source resources.
+type Service struct {
+	BackendPluginManager backendplugin.Manager `inject:""`
+}
+
+func (s *Service) Init() error {
+	mux := http.NewServeMux()
+	registerRoutes(mux, &Executor{
+		QueryParser:    &InfluxdbQueryParser{},
+		ResponseParser: &ResponseParser{},
+	})
+	coreplugin.RegisterResourceHandler(s.BackendPluginManager, "influxdb", mux, glog)
+	return nil
+}
+
+func registerRoutes(mux *http.ServeMux, e *Executor) {
+	mux.HandleFunc("/metric-find-query", e.handleMetricFindQuery)
+	mux.HandleFunc("/annotations", e.handleAnnotations)
+}
+
+// MetricFindValue is a template variable value returned by the metric-find-query resource.
+type MetricFindValue struct {
+	Text string `json:"text"`
+}
+
+// Annotation is an event returned by the annotations resource. Time and TimeEnd are in
+// milliseconds since the epoch.
+type Annotation struct {
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd,omitempty"`
+	Title   string   `json:"title"`
+	Text    string   `json:"text"`
+	Tags    []string `json:"tags"`
+}
+
+// handleMetricFindQuery runs the InfluxQL query of the "query" parameter, e.g. SHOW TAG VALUES,
+// and returns the distinct values of the result. SHOW TAG VALUES returns key/value pairs, so
+// its values are read from the second column, all other queries from the first.
+func (e *Executor) handleMetricFindQuery(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := req.URL.Query().Get("query")
+	if query == "" {
+		http.Error(rw, "missing query", http.StatusBadRequest)
+		return
+	}
+
+	response, ok := e.executeResourceQuery(rw, req, query)
+	if !ok {
+		return
+	}
+
+	writeJSON(rw, metricFindValues(response, query))
+}
+
+func metricFindValues(response *Response, query string) []MetricFindValue {
+	valueIndex := 0
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SHOW TAG VALUES") {
+		valueIndex = 1
+	}
+
+	values := []MetricFindValue{}
+	seen := map[string]struct{}{}
+	for _, result := range response.Results {
+		for _, row := range result.Series {
+			for _, valuePair := range row.Values {
+				if valueIndex >= len(valuePair) {
+					continue
+				}
+				text, ok := stringify(valuePair[valueIndex])
+				if !ok {
+					continue
+				}
+				if _, exists := seen[text]; exists {
+					continue
+				}
+				seen[text] = struct{}{}
+				values = append(values, MetricFindValue{Text: text})
+			}
+		}
+	}
+	return values
+}
+
+// handleAnnotations runs the InfluxQL query of the "query" parameter for the time range given by
+// the "from" and "to" parameters, in milliseconds since the epoch, and converts the rows into
+// annotations. The titleColumn, textColumn, tagsColumn and timeEndColumn parameters select the
+// columns holding the annotation fields. tagsColumn may be a comma separated list of columns or
+// tag keys. $timeFilter in the query is replaced with a filter on the time range.
+func (e *Executor) handleAnnotations(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := req.URL.Query()
+	query := params.Get("query")
+	if query == "" {
+		http.Error(rw, "missing query", http.StatusBadRequest)
+		return
+	}
+	from, err := strconv.ParseInt(params.Get("from"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseInt(params.Get("to"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid to parameter", http.StatusBadRequest)
+		return
+	}
+
+	query = strings.ReplaceAll(query, "$timeFilter", fmt.Sprintf("time >= %dms and time <= %dms", from, to))
+
+	response, ok := e.executeResourceQuery(rw, req, query)
+	if !ok {
+		return
+	}
+
+	writeJSON(rw, e.annotations(response, annotationColumns{
+		title:   params.Get("titleColumn"),
+		text:    params.Get("textColumn"),
+		tags:    params.Get("tagsColumn"),
+		timeEnd: params.Get("timeEndColumn"),
+	}))
+}
+
+type annotationColumns struct {
+	title   string
+	text    string
+	tags    string
+	timeEnd string
+}
+
+func (e *Executor) annotations(response *Response, columns annotationColumns) []Annotation {
+	var tagColumns []string
+	for _, column := range strings.Split(columns.tags, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			tagColumns = append(tagColumns, column)
+		}
+	}
+
+	annotations := []Annotation{}
+	for _, result := range response.Results {
+		for _, row := range result.Series {
+			columnIndex := make(map[string]int, len(row.Columns))
+			for i, column := range row.Columns {
+				columnIndex[column] = i
+			}
+			columnValue := func(valuePair []interface{}, column string) (string, bool) {
+				i, ok := columnIndex[column]
+				if !ok || i >= len(valuePair) {
+					return "", false
+				}
+				return stringify(valuePair[i])
+			}
+
+			timeIndex, ok := columnIndex["time"]
+			if !ok {
+				continue
+			}
+
+			for _, valuePair := range row.Values {
+				timestamp, err := e.ResponseParser.parseTimestamp(valuePair[timeIndex])
+				if err != nil {
+					continue
+				}
+				annotation := Annotation{
+					Time: timestamp.UnixNano() / int64(time.Millisecond),
+					Tags: []string{},
+				}
+
+				if columns.timeEnd != "" {
+					if i, ok := columnIndex[columns.timeEnd]; ok && i < len(valuePair) {
+						if timeEnd, err := e.ResponseParser.parseTimestamp(valuePair[i]); err == nil {
+							annotation.TimeEnd = timeEnd.UnixNano() / int64(time.Millisecond)
+						}
+					}
+				}
+				if title, ok := columnValue(valuePair, columns.title); ok {
+					annotation.Title = title
+				}
+				if text, ok := columnValue(valuePair, columns.text); ok {
+					annotation.Text = text
+				}
+				for _, column := range tagColumns {
+					if tag, ok := row.Tags[column]; ok && tag != "" {
+						annotation.Tags = append(annotation.Tags, tag)
+						continue
+					}
+					if tag, ok := columnValue(valuePair, column); ok && tag != "" {
+						annotation.Tags = append(annotation.Tags, tag)
+					}
+				}
+
+				annotations = append(annotations, annotation)
+			}
+		}
+	}
+	return annotations
+}
+
+// executeResourceQuery runs the query against the data source of the resource call. It writes
+// an error response and returns false if the query fails.
+func (e *Executor) executeResourceQuery(rw http.ResponseWriter, req *http.Request, query string) (*Response, bool) {
+	dsInfo, err := coreplugin.DataSourceFromContext(req)
+	if err != nil {
+		glog.Error("Failed to get data source", "error", err)
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	if dsInfo.JsonData.Get("version").MustString("") == "Flux" {
+		http.Error(rw, "InfluxQL resources are not supported for Flux data sources", http.StatusBadRequest)
+		return nil, false
+	}
+
+	response, err := e.executeQuery(req.Context(), dsInfo, query)
+	if err != nil {
+		glog.Error("InfluxDB resource query failed", "error", err)
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return nil, false
+	}
+	for _, result := range response.Results {
+		if result.Err != nil {
+			http.Error(rw, result.Err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	return response, true
+}
+
+func stringify(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+func writeJSON(rw http.ResponseWriter, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	if _, err := rw.Write(body); err != nil {
+		glog.Error("Failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricFindValues(t *testing.T) {
+	response := &Response{
+		Results: []Result{
+			{
+				Series: []Row{
+					{
+						Name:    "cpu",
+						Columns: []string{"key", "value"},
+						Values: [][]interface{}{
+							{"host", "server1"},
+							{"host", "server2"},
+						},
+					},
+					{
+						Name:    "mem",
+						Columns: []string{"key", "value"},
+						Values: [][]interface{}{
+							{"host", "server2"},
+							{"host", nil},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("SHOW TAG VALUES reads the value column", func(t *testing.T) {
+		values := metricFindValues(response, "show tag values with key = host")
+		require.Equal(t, []MetricFindValue{{Text: "server1"}, {Text: "server2"}}, values)
+	})
+
+	t.Run("other queries read the first column", func(t *testing.T) {
+		values := metricFindValues(response, "SHOW TAG KEYS")
+		require.Equal(t, []MetricFindValue{{Text: "host"}}, values)
+	})
+}
+
+func TestAnnotations(t *testing.T) {
+	e := &Executor{ResponseParser: &ResponseParser{}}
+	response := &Response{
+		Results: []Result{
+			{
+				Series: []Row{
+					{
+						Name:    "events",
+						Columns: []string{"time", "title", "text", "end", "kind"},
+						Tags:    map[string]string{"host": "server1"},
+						Values: [][]interface{}{
+							{json.Number("10"), "deploy", "v1.2", json.Number("20"), "release"},
+							{"invalid", "skipped", "", nil, nil},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	annotations := e.annotations(response, annotationColumns{
+		title:   "title",
+		text:    "text",
+		tags:    "host, kind",
+		timeEnd: "end",
+	})
+	require.Equal(t, []Annotation{
+		{
+			Time:    10000,
+			TimeEnd: 20000,
+			Title:   "deploy",
+			Text:    "v1.2",
+			Tags:    []string{"server1", "release"},
+		},
+	}, annotations)
+}
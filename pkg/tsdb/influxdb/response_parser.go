@@ -6,7 +6,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/plugins"
 )
@@ -21,43 +23,111 @@ func init() {
 	legendFormat = regexp.MustCompile(`\[\[(\w+)(\.\w+)*\]\]*|\$\s*(\w+?)*`)
 }
 
+// Parse converts the InfluxQL response into data frames. Each series of the response becomes
+// a frame with a time field and a field per column, labeled with the series tags.
 func (rp *ResponseParser) Parse(response *Response, query *Query) plugins.DataQueryResult {
 	var queryRes plugins.DataQueryResult
+	frames := data.Frames{}
 
 	for _, result := range response.Results {
-		queryRes.Series = append(queryRes.Series, rp.transformRows(result.Series, queryRes, query)...)
+		frames = append(frames, rp.transformRows(result.Series, query)...)
 		if result.Err != nil {
 			queryRes.Error = result.Err
 		}
 	}
 
+	queryRes.Dataframes = plugins.NewDecodedDataFrames(frames)
 	return queryRes
 }
 
-func (rp *ResponseParser) transformRows(rows []Row, queryResult plugins.DataQueryResult, query *Query) plugins.DataTimeSeriesSlice {
-	var result plugins.DataTimeSeriesSlice
+func (rp *ResponseParser) transformRows(rows []Row, query *Query) data.Frames {
+	frames := make(data.Frames, 0, len(rows))
 	for _, row := range rows {
-		for columnIndex, column := range row.Columns {
+		timeIndex := -1
+		for i, column := range row.Columns {
 			if column == "time" {
+				timeIndex = i
+				break
+			}
+		}
+
+		// rows without a valid timestamp are skipped
+		times := make([]time.Time, 0, len(row.Values))
+		values := make([][]interface{}, 0, len(row.Values))
+		for _, valuePair := range row.Values {
+			if timeIndex == -1 {
+				values = append(values, valuePair)
 				continue
 			}
+			timestamp, err := rp.parseTimestamp(valuePair[timeIndex])
+			if err != nil {
+				continue
+			}
+			times = append(times, timestamp)
+			values = append(values, valuePair)
+		}
 
-			var points plugins.DataTimeSeriesPoints
-			for _, valuePair := range row.Values {
-				point, err := rp.parseTimepoint(valuePair, columnIndex)
-				if err == nil {
-					points = append(points, point)
-				}
+		var labels data.Labels
+		if len(row.Tags) > 0 {
+			labels = data.Labels(row.Tags).Copy()
+		}
+
+		fields := make([]*data.Field, 0, len(row.Columns))
+		if timeIndex != -1 {
+			fields = append(fields, data.NewField("time", nil, times))
+		}
+		for columnIndex, column := range row.Columns {
+			if columnIndex == timeIndex {
+				continue
 			}
-			result = append(result, plugins.DataTimeSeries{
-				Name:   rp.formatSeriesName(row, column, query),
-				Points: points,
-				Tags:   row.Tags,
-			})
+
+			field := rp.newValueField(values, columnIndex)
+			field.Name = column
+			field.Labels = labels
+			field.Config = &data.FieldConfig{DisplayNameFromDS: rp.formatSeriesName(row, column, query)}
+			fields = append(fields, field)
 		}
+
+		frames = append(frames, data.NewFrame(row.Name, fields...))
+	}
+
+	return frames
+}
+
+// newValueField returns a nullable float64 field for numeric columns, a nullable bool field
+// for boolean columns and a nullable string field otherwise.
+func (rp *ResponseParser) newValueField(values [][]interface{}, columnIndex int) *data.Field {
+	fieldType := data.FieldTypeNullableFloat64
+	for _, valuePair := range values {
+		switch valuePair[columnIndex].(type) {
+		case string:
+			fieldType = data.FieldTypeNullableString
+		case bool:
+			fieldType = data.FieldTypeNullableBool
+		default:
+			continue
+		}
+		break
 	}
 
-	return result
+	field := data.NewFieldFromFieldType(fieldType, len(values))
+	for i, valuePair := range values {
+		switch fieldType {
+		case data.FieldTypeNullableString:
+			if v, ok := valuePair[columnIndex].(string); ok {
+				field.Set(i, &v)
+			}
+		case data.FieldTypeNullableBool:
+			if v, ok := valuePair[columnIndex].(bool); ok {
+				field.Set(i, &v)
+			}
+		default:
+			if v := rp.parseValue(valuePair[columnIndex]); v.Valid {
+				field.Set(i, &v.Float64)
+			}
+		}
+	}
+	return field
 }
 
 func (rp *ResponseParser) formatSeriesName(row Row, column string, query *Query) string {
@@ -115,19 +185,18 @@ func (rp *ResponseParser) buildSeriesNameFromQuery(row Row, column string) strin
 	return fmt.Sprintf("%s.%s%s", row.Name, column, tagText)
 }
 
-func (rp *ResponseParser) parseTimepoint(valuePair []interface{}, valuePosition int) (plugins.DataTimePoint, error) {
-	value := rp.parseValue(valuePair[valuePosition])
-
-	timestampNumber, ok := valuePair[0].(json.Number)
+// parseTimestamp parses a timestamp in seconds, as requested with epoch=s.
+func (rp *ResponseParser) parseTimestamp(value interface{}) (time.Time, error) {
+	timestampNumber, ok := value.(json.Number)
 	if !ok {
-		return plugins.DataTimePoint{}, fmt.Errorf("valuePair[0] has invalid type: %#v", valuePair[0])
+		return time.Time{}, fmt.Errorf("timestamp has invalid type: %#v", value)
 	}
 	timestamp, err := timestampNumber.Float64()
 	if err != nil {
-		return plugins.DataTimePoint{}, err
+		return time.Time{}, err
 	}
 
-	return plugins.DataTimePoint{value, null.FloatFrom(timestamp)}, nil
+	return time.Unix(0, int64(timestamp*float64(time.Second))).UTC(), nil
 }
 
 func (rp *ResponseParser) parseValue(value interface{}) null.Float {
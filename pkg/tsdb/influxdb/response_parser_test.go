@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -42,28 +45,40 @@ func TestInfluxdbResponseParser(t *testing.T) {
 			query := &Query{}
 
 			result := parser.Parse(response, query)
-
-			Convey("can parse all series", func() {
-				So(len(result.Series), ShouldEqual, 2)
+			frames := decodedFrames(result)
+
+			Convey("can parse all series into one frame", func() {
+				So(len(frames), ShouldEqual, 1)
+				So(frames[0].Name, ShouldEqual, "cpu")
+				So(len(frames[0].Fields), ShouldEqual, 3)
+				So(frames[0].Fields[1].Name, ShouldEqual, "mean")
+				So(frames[0].Fields[2].Name, ShouldEqual, "sum")
 			})
 
 			Convey("can parse all points", func() {
-				So(len(result.Series[0].Points), ShouldEqual, 3)
-				So(len(result.Series[1].Points), ShouldEqual, 3)
+				So(frames[0].Rows(), ShouldEqual, 3)
+				So(frames[0].Fields[0].At(0), ShouldEqual, time.Unix(111, 0).UTC())
 			})
 
 			Convey("can parse multi row result", func() {
-				So(result.Series[0].Points[1][0].Float64, ShouldEqual, float64(222))
-				So(result.Series[1].Points[1][0].Float64, ShouldEqual, float64(333))
+				So(*frames[0].Fields[1].At(1).(*float64), ShouldEqual, float64(222))
+				So(*frames[0].Fields[2].At(1).(*float64), ShouldEqual, float64(333))
 			})
 
 			Convey("can parse null points", func() {
-				So(result.Series[0].Points[2][0].Valid, ShouldBeFalse)
+				So(frames[0].Fields[1].At(2), ShouldBeNil)
+			})
+
+			Convey("returns tags as labels", func() {
+				So(frames[0].Fields[1].Labels, ShouldResemble, data.Labels{"datacenter": "America"})
+				So(frames[0].Fields[2].Labels, ShouldResemble, data.Labels{"datacenter": "America"})
 			})
 
 			Convey("can format series names", func() {
-				So(result.Series[0].Name, ShouldEqual, "cpu.mean { datacenter: America }")
-				So(result.Series[1].Name, ShouldEqual, "cpu.sum { datacenter: America }")
+				So(displayNames(frames), ShouldResemble, []string{
+					"cpu.mean { datacenter: America }",
+					"cpu.sum { datacenter: America }",
+				})
 			})
 		})
 
@@ -95,43 +110,43 @@ func TestInfluxdbResponseParser(t *testing.T) {
 					query := &Query{Alias: "series alias"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "series alias")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "series alias")
 				})
 
 				Convey("measurement alias", func() {
 					query := &Query{Alias: "alias $m $measurement", Measurement: "10m"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias 10m 10m")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias 10m 10m")
 				})
 
 				Convey("column alias", func() {
 					query := &Query{Alias: "alias $col", Measurement: "10m"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias mean")
-					So(result.Series[1].Name, ShouldEqual, "alias sum")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias mean")
+					So(displayNames(decodedFrames(result))[1], ShouldEqual, "alias sum")
 				})
 
 				Convey("tag alias", func() {
 					query := &Query{Alias: "alias $tag_datacenter"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias America")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias America")
 				})
 
 				Convey("segment alias", func() {
 					query := &Query{Alias: "alias $1"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias upc")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias upc")
 				})
 
 				Convey("segment position out of bound", func() {
 					query := &Query{Alias: "alias $5"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias $5")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias $5")
 				})
 			})
 
@@ -140,36 +155,36 @@ func TestInfluxdbResponseParser(t *testing.T) {
 					query := &Query{Alias: "series alias"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "series alias")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "series alias")
 				})
 
 				Convey("measurement alias", func() {
 					query := &Query{Alias: "alias [[m]] [[measurement]]", Measurement: "10m"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias 10m 10m")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias 10m 10m")
 				})
 
 				Convey("column alias", func() {
 					query := &Query{Alias: "alias [[col]]", Measurement: "10m"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias mean")
-					So(result.Series[1].Name, ShouldEqual, "alias sum")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias mean")
+					So(displayNames(decodedFrames(result))[1], ShouldEqual, "alias sum")
 				})
 
 				Convey("tag alias", func() {
 					query := &Query{Alias: "alias [[tag_datacenter]]"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias America")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias America")
 				})
 
 				Convey("tag alias with periods", func() {
 					query := &Query{Alias: "alias [[tag_dc.region.name]]"}
 					result := parser.Parse(response, query)
 
-					So(result.Series[0].Name, ShouldEqual, "alias Northeast")
+					So(displayNames(decodedFrames(result))[0], ShouldEqual, "alias Northeast")
 				})
 			})
 		})
@@ -208,14 +223,15 @@ func TestInfluxdbResponseParser(t *testing.T) {
 			query := &Query{}
 
 			result := parser.Parse(response, query)
+			frames := decodedFrames(result)
 
 			Convey("can parse all series", func() {
-				So(len(result.Series), ShouldEqual, 2)
+				So(len(frames), ShouldEqual, 1)
+				So(displayNames(frames), ShouldHaveLength, 2)
 			})
 
 			Convey("can parse all points", func() {
-				So(len(result.Series[0].Points), ShouldEqual, 3)
-				So(len(result.Series[1].Points), ShouldEqual, 3)
+				So(frames[0].Rows(), ShouldEqual, 3)
 			})
 
 			Convey("can parse errors ", func() {
@@ -225,3 +241,22 @@ func TestInfluxdbResponseParser(t *testing.T) {
 		})
 	})
 }
+
+func decodedFrames(result plugins.DataQueryResult) data.Frames {
+	frames, err := result.Dataframes.Decoded()
+	So(err, ShouldBeNil)
+	return frames
+}
+
+// displayNames returns the series names of the value fields of all frames.
+func displayNames(frames data.Frames) []string {
+	names := []string{}
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if field.Config != nil {
+				names = append(names, field.Config.DisplayNameFromDS)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,271 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/jmespath/go-jmespath"
+)
+
+const (
+	languageJSONPath = "jsonpath"
+	languageJMESPath = "jmespath"
+
+	fieldTypeString  = "string"
+	fieldTypeNumber  = "number"
+	fieldTypeTime    = "time"
+	fieldTypeBoolean = "boolean"
+
+	// timeFormatUnix and timeFormatUnixMs are the time formats of numeric timestamps, in seconds and
+	// milliseconds. Any other format is a Go time layout.
+	timeFormatUnix   = "unix"
+	timeFormatUnixMs = "unixms"
+)
+
+// fieldQuery describes how to extract a field of the frame from the response.
+type fieldQuery struct {
+	Name       string `json:"name"`
+	Path       string `json:"jsonPath"`
+	Language   string `json:"language"`
+	Type       string `json:"type"`
+	TimeFormat string `json:"timeFormat"`
+}
+
+// extractFields evaluates the field queries against the document and returns a frame with one typed
+// field per query. All field queries must yield the same number of values.
+func extractFields(doc interface{}, queries []fieldQuery) (*data.Frame, error) {
+	frame := data.NewFrame("")
+	for i, q := range queries {
+		values, err := evaluate(doc, q)
+		if err != nil {
+			return nil, err
+		}
+
+		name := q.Name
+		if name == "" {
+			name = q.Path
+		}
+
+		if i > 0 && len(values) != frame.Fields[0].Len() {
+			return nil, fmt.Errorf("field %q has %d values, expected %d", name, len(values), frame.Fields[0].Len())
+		}
+
+		field, err := newField(name, q, values)
+		if err != nil {
+			return nil, err
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	return frame, nil
+}
+
+// evaluate runs the path of the field query and returns its values. A path yielding a single value
+// returns a single row.
+func evaluate(doc interface{}, q fieldQuery) ([]interface{}, error) {
+	if q.Path == "" {
+		return nil, fmt.Errorf("field %q has no path", q.Name)
+	}
+
+	var result interface{}
+	var err error
+	switch q.Language {
+	case "", languageJSONPath:
+		result, err = jsonpath.Get(q.Path, doc)
+	case languageJMESPath:
+		result, err = jmespath.Search(q.Path, doc)
+	default:
+		return nil, fmt.Errorf("unsupported language %q", q.Language)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", q.Path, err)
+	}
+
+	switch v := result.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		return v, nil
+	default:
+		return []interface{}{v}, nil
+	}
+}
+
+func newField(name string, q fieldQuery, values []interface{}) (*data.Field, error) {
+	fieldType := q.Type
+	if fieldType == "" {
+		fieldType = detectType(values)
+	}
+
+	switch fieldType {
+	case fieldTypeNumber:
+		vals := make([]*float64, len(values))
+		for i, v := range values {
+			f, err := toFloat(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+			vals[i] = f
+		}
+		return data.NewField(name, nil, vals), nil
+	case fieldTypeTime:
+		vals := make([]*time.Time, len(values))
+		for i, v := range values {
+			t, err := toTime(v, q.TimeFormat)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+			vals[i] = t
+		}
+		return data.NewField(name, nil, vals), nil
+	case fieldTypeBoolean:
+		vals := make([]*bool, len(values))
+		for i, v := range values {
+			b, err := toBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+			vals[i] = b
+		}
+		return data.NewField(name, nil, vals), nil
+	case fieldTypeString:
+		vals := make([]*string, len(values))
+		for i, v := range values {
+			s, err := toString(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+			vals[i] = s
+		}
+		return data.NewField(name, nil, vals), nil
+	default:
+		return nil, fmt.Errorf("field %q has unsupported type %q", name, fieldType)
+	}
+}
+
+// detectType returns the field type matching the first non-null value.
+func detectType(values []interface{}) string {
+	for _, v := range values {
+		switch v.(type) {
+		case nil:
+			continue
+		case float64:
+			return fieldTypeNumber
+		case bool:
+			return fieldTypeBoolean
+		default:
+			return fieldTypeString
+		}
+	}
+	return fieldTypeString
+}
+
+func toFloat(v interface{}) (*float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return &v, nil
+	case bool:
+		f := 0.0
+		if v {
+			f = 1
+		}
+		return &f, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as number", v)
+		}
+		return &f, nil
+	default:
+		return nil, fmt.Errorf("unexpected value of type %T, expected number", v)
+	}
+}
+
+func toTime(v interface{}, format string) (*time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return epochToTime(v, format), nil
+	case string:
+		if format == timeFormatUnix || format == timeFormatUnixMs {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %q as epoch time", v)
+			}
+			return epochToTime(f, format), nil
+		}
+
+		layout := format
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time: %w", v, err)
+		}
+		return &t, nil
+	default:
+		return nil, fmt.Errorf("unexpected value of type %T, expected time", v)
+	}
+}
+
+// epochToTime converts an epoch timestamp, in milliseconds unless the format is seconds.
+func epochToTime(v float64, format string) *time.Time {
+	var t time.Time
+	if format == timeFormatUnix {
+		t = time.Unix(0, int64(v*float64(time.Second))).UTC()
+	} else {
+		t = time.Unix(0, int64(v*float64(time.Millisecond))).UTC()
+	}
+	return &t
+}
+
+func toBool(v interface{}) (*bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return &v, nil
+	case float64:
+		b := v != 0
+		return &b, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as boolean", v)
+		}
+		return &b, nil
+	default:
+		return nil, fmt.Errorf("unexpected value of type %T, expected boolean", v)
+	}
+}
+
+func toString(v interface{}) (*string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &v, nil
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		return &s, nil
+	case bool:
+		s := strconv.FormatBool(v)
+		return &s, nil
+	default:
+		// objects and arrays are kept as JSON
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		return &s, nil
+	}
+}
@@ -0,0 +1,259 @@
+// Package jsonapi implements a backend-only data source for JSON HTTP APIs. It has no plugin.json or
+// frontend and is not listed in the UI; data sources of type "jsonapi" are created through the data
+// source HTTP API and queried through /api/ds/query or alerting.
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/pluginproxy"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/proxyutil"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+var plog = log.New("tsdb.jsonapi")
+
+// maxResponseSize limits how much of a response body is read, so a misbehaving API cannot exhaust memory.
+const maxResponseSize = 10 * 1024 * 1024
+
+type jsonAPIExecutor struct {
+	httpClient *http.Client
+}
+
+// NewExecutor returns a jsonAPIExecutor. TLS settings and custom headers of the data source are applied
+// by its HTTP transport.
+func NewExecutor(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonAPIExecutor{
+		httpClient: httpClient,
+	}, nil
+}
+
+// jsonAPIQuery is the query model of the data source.
+type jsonAPIQuery struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Params  [][2]string       `json:"params"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	Fields  []fieldQuery      `json:"fields"`
+}
+
+func (e *jsonAPIExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
+	tsdbQuery plugins.DataQuery) (plugins.DataResponse, error) {
+	result := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult, len(tsdbQuery.Queries)),
+	}
+
+	for _, query := range tsdbQuery.Queries {
+		queryResult := plugins.DataQueryResult{RefID: query.RefID}
+
+		frame, err := e.executeQuery(ctx, dsInfo, query, tsdbQuery.TimeRange)
+		if err != nil {
+			queryResult.Error = err
+		} else {
+			queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{frame})
+		}
+
+		result.Results[query.RefID] = queryResult
+	}
+
+	return result, nil
+}
+
+func (e *jsonAPIExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, query plugins.DataSubQuery,
+	timeRange *plugins.DataTimeRange) (*data.Frame, error) {
+	model, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := e.createRequest(ctx, dsInfo, model, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := e.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse response as JSON: %w", err)
+	}
+
+	frame, err := extractFields(doc, model.Fields)
+	if err != nil {
+		return nil, err
+	}
+	frame.RefID = query.RefID
+	frame.Meta = &data.FrameMeta{
+		ExecutedQueryString: fmt.Sprintf("%s %s", req.Method, req.URL.RequestURI()),
+	}
+
+	return frame, nil
+}
+
+func parseQuery(query plugins.DataSubQuery) (jsonAPIQuery, error) {
+	model := jsonAPIQuery{}
+	if query.Model == nil {
+		return model, errors.New("query model is missing")
+	}
+
+	raw, err := query.Model.MarshalJSON()
+	if err != nil {
+		return model, err
+	}
+	if err := json.Unmarshal(raw, &model); err != nil {
+		return model, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	model.Method = strings.ToUpper(model.Method)
+	if model.Method == "" {
+		model.Method = http.MethodGet
+	}
+	if model.Method != http.MethodGet && model.Method != http.MethodPost {
+		return model, fmt.Errorf("unsupported method %q", model.Method)
+	}
+	if len(model.Fields) == 0 {
+		return model, errors.New("query has no fields")
+	}
+
+	return model, nil
+}
+
+// createRequest builds the request of a query relative to the data source URL. The path, parameters and body
+// may reference the time range of the query with $__from and $__to, in epoch milliseconds.
+func (e *jsonAPIExecutor) createRequest(ctx context.Context, dsInfo *models.DataSource, model jsonAPIQuery,
+	timeRange *plugins.DataTimeRange) (*http.Request, error) {
+	interpolate := func(s string) string { return s }
+	if timeRange != nil {
+		replacer := strings.NewReplacer(
+			"$__from", strconv.FormatInt(timeRange.GetFromAsMsEpoch(), 10),
+			"$__to", strconv.FormatInt(timeRange.GetToAsMsEpoch(), 10),
+		)
+		interpolate = replacer.Replace
+	}
+
+	queryPath, err := url.Parse(interpolate(model.Path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	// queries can only target the configured API, never another host
+	if queryPath.IsAbs() || queryPath.Host != "" {
+		return nil, fmt.Errorf("invalid path %q: must be relative to the data source URL", model.Path)
+	}
+
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data source URL: %w", err)
+	}
+	u.Path = util.JoinURLFragments(u.Path, queryPath.Path)
+
+	params := u.Query()
+	for key, values := range queryPath.Query() {
+		for _, value := range values {
+			params.Add(key, value)
+		}
+	}
+	for _, param := range model.Params {
+		params.Add(param[0], interpolate(param[1]))
+	}
+	u.RawQuery = params.Encode()
+
+	var body io.Reader
+	if model.Method == http.MethodPost {
+		body = strings.NewReader(interpolate(model.Body))
+	}
+
+	req, err := http.NewRequest(model.Method, u.String(), body)
+	if err != nil {
+		plog.Debug("Failed to create request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range model.Headers {
+		req.Header.Set(key, value)
+	}
+	if model.Method == http.MethodPost && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("Grafana/%s", setting.BuildVersion))
+	proxyutil.PrepareProxyRequest(req)
+
+	if dsInfo.BasicAuth {
+		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+	}
+
+	applyPluginRoute(ctx, req, dsInfo, queryPath.Path)
+
+	return req, nil
+}
+
+// applyPluginRoute applies the auth and headers of the plugin route matching the path, if the plugin defines any.
+func applyPluginRoute(ctx context.Context, req *http.Request, dsInfo *models.DataSource, queryPath string) {
+	plugin, ok := manager.DataSources[dsInfo.Type]
+	if !ok {
+		return
+	}
+
+	proxyPath := strings.TrimPrefix(queryPath, "/")
+	for _, route := range plugin.Routes {
+		if !strings.HasPrefix(proxyPath, route.Path) || route.Method != "" && route.Method != req.Method {
+			continue
+		}
+
+		pluginproxy.ApplyRoute(ctx, req, proxyPath, route, dsInfo)
+		return
+	}
+}
+
+func (e *jsonAPIExecutor) doRequest(ctx context.Context, req *http.Request) ([]byte, error) {
+	res, err := ctxhttp.Do(ctx, e.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			plog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("response exceeds the maximum size of %d bytes", maxResponseSize)
+	}
+
+	if res.StatusCode/100 != 2 {
+		plog.Debug("Request failed", "status", res.Status, "body", string(body))
+		return nil, fmt.Errorf("request failed, status: %s, body: %s", res.Status, string(bytes.TrimSpace(body)))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,188 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataQuery(t *testing.T) {
+	var req *http.Request
+	var reqBody string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		req = r
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		reqBody = string(body)
+		_, err = rw.Write([]byte(`{"data":{"points":[` +
+			`{"ts":"2021-03-01T10:00:00Z","value":1.5,"host":"a","up":true},` +
+			`{"ts":"2021-03-01T10:01:00Z","value":null,"host":"b","up":false}]}}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	ds := &models.DataSource{
+		Url:               server.URL + "/api",
+		BasicAuth:         true,
+		BasicAuthUser:     "john",
+		BasicAuthPassword: "pass",
+		JsonData:          simplejson.New(),
+	}
+	plug, err := NewExecutor(ds)
+	require.NoError(t, err)
+
+	timeRange := plugins.NewDataTimeRange("1614592800000", "1614596400000")
+	query := func(model map[string]interface{}) plugins.DataQueryResult {
+		res, err := plug.DataQuery(context.Background(), ds, plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries:   []plugins.DataSubQuery{{RefID: "A", Model: simplejson.NewFromAny(model)}},
+		})
+		require.NoError(t, err)
+		return res.Results["A"]
+	}
+
+	t.Run("extracts JSONPath fields into a frame", func(t *testing.T) {
+		result := query(map[string]interface{}{
+			"path":   "/metrics?env=prod",
+			"params": [][]string{{"from", "$__from"}, {"to", "$__to"}},
+			"fields": []map[string]interface{}{
+				{"name": "time", "jsonPath": "$.data.points[*].ts", "type": "time"},
+				{"name": "value", "jsonPath": "$.data.points[*].value", "type": "number"},
+				{"name": "host", "jsonPath": "$.data.points[*].host"},
+			},
+		})
+		require.NoError(t, result.Error)
+
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/api/metrics", req.URL.Path)
+		assert.Equal(t, "prod", req.URL.Query().Get("env"))
+		assert.Equal(t, "1614592800000", req.URL.Query().Get("from"))
+		assert.Equal(t, "1614596400000", req.URL.Query().Get("to"))
+		user, pass, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "john", user)
+		assert.Equal(t, "pass", pass)
+
+		frames, err := result.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 2, frame.Rows())
+
+		ts := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, ts, *frame.Fields[0].At(0).(*time.Time))
+		assert.Equal(t, 1.5, *frame.Fields[1].At(0).(*float64))
+		assert.Nil(t, frame.Fields[1].At(1))
+		assert.Equal(t, "b", *frame.Fields[2].At(1).(*string))
+	})
+
+	t.Run("extracts JMESPath fields and posts the body", func(t *testing.T) {
+		result := query(map[string]interface{}{
+			"method": "post",
+			"path":   "search",
+			"body":   `{"from":$__from}`,
+			"fields": []map[string]interface{}{
+				{"name": "up", "jsonPath": "data.points[].up", "language": "jmespath"},
+			},
+		})
+		require.NoError(t, result.Error)
+
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/api/search", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, `{"from":1614592800000}`, reqBody)
+
+		frames, err := result.Dataframes.Decoded()
+		require.NoError(t, err)
+		assert.Equal(t, true, *frames[0].Fields[0].At(0).(*bool))
+		assert.Equal(t, false, *frames[0].Fields[0].At(1).(*bool))
+	})
+
+	t.Run("rejects fields of different lengths", func(t *testing.T) {
+		result := query(map[string]interface{}{
+			"path": "/metrics",
+			"fields": []map[string]interface{}{
+				{"name": "host", "jsonPath": "$.data.points[*].host"},
+				{"name": "first", "jsonPath": "$.data.points[0].host"},
+			},
+		})
+		require.EqualError(t, result.Error, `field "first" has 1 values, expected 2`)
+	})
+
+	t.Run("rejects paths to another host", func(t *testing.T) {
+		result := query(map[string]interface{}{
+			"path":   "http://example.com/metrics",
+			"fields": []map[string]interface{}{{"jsonPath": "$"}},
+		})
+		require.EqualError(t, result.Error, `invalid path "http://example.com/metrics": must be relative to the data source URL`)
+	})
+}
+
+func TestPluginRoute(t *testing.T) {
+	origDataSources := manager.DataSources
+	t.Cleanup(func() { manager.DataSources = origDataSources })
+	manager.DataSources = map[string]*plugins.DataSourcePlugin{
+		"jsonapi": {
+			Routes: []*plugins.AppPluginRoute{{
+				Path:    "api",
+				Headers: []plugins.AppPluginRouteHeader{{Name: "X-API-Key", Content: "{{.SecureJsonData.apiKey}}"}},
+			}},
+		},
+	}
+
+	ds := &models.DataSource{
+		Type:           "jsonapi",
+		Url:            "http://localhost:3000",
+		JsonData:       simplejson.New(),
+		SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{"apiKey": "secret"}),
+	}
+	plug, err := NewExecutor(ds)
+	require.NoError(t, err)
+	executor := plug.(*jsonAPIExecutor)
+
+	req, err := executor.createRequest(context.Background(), ds, jsonAPIQuery{Method: http.MethodGet, Path: "/api/items"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", req.Header.Get("X-API-Key"))
+	assert.Equal(t, "/api/items", req.URL.Path)
+}
+
+func TestExtractFields(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"items":[`+
+		`{"t":1614592800,"ms":1614592800000,"day":"2021-03-01","n":"42","o":{"a":1}},`+
+		`{"t":1614592860,"ms":1614592860000,"day":"2021-03-02","n":"7","o":[1,2]}]}`), &doc))
+
+	frame, err := extractFields(doc, []fieldQuery{
+		{Name: "seconds", Path: "$.items[*].t", Type: fieldTypeTime, TimeFormat: timeFormatUnix},
+		{Name: "millis", Path: "$.items[*].ms", Type: fieldTypeTime},
+		{Name: "day", Path: "$.items[*].day", Type: fieldTypeTime, TimeFormat: "2006-01-02"},
+		{Name: "n", Path: "$.items[*].n", Type: fieldTypeNumber},
+		{Name: "o", Path: "$.items[*].o"},
+	})
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, ts, *frame.Fields[0].At(0).(*time.Time))
+	assert.Equal(t, ts, *frame.Fields[1].At(0).(*time.Time))
+	assert.Equal(t, time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC), *frame.Fields[2].At(1).(*time.Time))
+	assert.Equal(t, 42.0, *frame.Fields[3].At(0).(*float64))
+	assert.Equal(t, `{"a":1}`, *frame.Fields[4].At(0).(*string))
+	assert.Equal(t, `[1,2]`, *frame.Fields[4].At(1).(*string))
+
+	_, err = extractFields(doc, []fieldQuery{{Name: "n", Path: "$.items[*].day", Type: fieldTypeNumber}})
+	require.EqualError(t, err, `field "n": failed to parse "2021-03-01" as number`)
+}
@@ -3,11 +3,15 @@ package loki
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -33,6 +37,8 @@ func newExecutor() *LokiExecutor {
 	}
 }
 
+const defaultMaxLines = 1000
+
 var (
 	plog         = log.New("tsdb.loki")
 	legendFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
@@ -64,12 +70,10 @@ func (e *LokiExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
 		span.SetTag("stop_unixnano", query.End.UnixNano())
 		defer span.Finish()
 
-		//Currently hard coded as not used - applies to log queries
-		limit := 1000
 		//Currently hard coded as not used - applies to queries which produce a stream response
 		interval := time.Second * 1
 
-		value, err := client.QueryRange(query.Expr, limit, query.Start, query.End, logproto.BACKWARD, query.Step, interval, false)
+		value, err := client.QueryRange(query.Expr, query.MaxLines, query.Start, query.End, query.Direction, query.Step, interval, false)
 		if err != nil {
 			return plugins.DataResponse{}, err
 		}
@@ -113,6 +117,16 @@ func (e *LokiExecutor) parseQuery(dsInfo *models.DataSource, queryContext plugin
 
 		format := queryModel.Model.Get("legendFormat").MustString("")
 
+		maxLines, err := parseMaxLines(dsInfo, queryModel.Model)
+		if err != nil {
+			return nil, err
+		}
+
+		direction, err := parseDirection(queryModel.Model.Get("direction").MustString(""))
+		if err != nil {
+			return nil, err
+		}
+
 		start, err := queryContext.TimeRange.ParseFrom()
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse From: %v", err)
@@ -138,21 +152,69 @@ func (e *LokiExecutor) parseQuery(dsInfo *models.DataSource, queryContext plugin
 			Start:        start,
 			End:          end,
 			RefID:        queryModel.RefID,
+			MaxLines:     maxLines,
+			Direction:    direction,
 		})
 	}
 
 	return qs, nil
 }
 
-func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (plugins.DataQueryResult, error) {
-	var queryRes plugins.DataQueryResult
+// parseMaxLines returns the line limit for log queries. The query's maxLines takes
+// precedence over the data source's, which is stored as a string by the frontend.
+func parseMaxLines(dsInfo *models.DataSource, model *simplejson.Json) (int, error) {
+	if maxLines := model.Get("maxLines").MustInt(0); maxLines > 0 {
+		return maxLines, nil
+	}
+
+	if dsInfo.JsonData == nil {
+		return defaultMaxLines, nil
+	}
 
-	//We are currently processing only matrix results (for alerting)
-	data, ok := value.Data.Result.(loghttp.Matrix)
-	if !ok {
-		return queryRes, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
+	dsMaxLines := dsInfo.JsonData.Get("maxLines")
+	if v, err := dsMaxLines.Int(); err == nil && v > 0 {
+		return v, nil
 	}
+	if s := dsMaxLines.MustString(""); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse maxLines: %v", err)
+		}
+		if v > 0 {
+			return v, nil
+		}
+	}
+
+	return defaultMaxLines, nil
+}
 
+func parseDirection(direction string) (logproto.Direction, error) {
+	switch strings.ToLower(direction) {
+	case "", "backward":
+		return logproto.BACKWARD, nil
+	case "forward":
+		return logproto.FORWARD, nil
+	default:
+		return logproto.BACKWARD, fmt.Errorf("unsupported direction: %q", direction)
+	}
+}
+
+func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (plugins.DataQueryResult, error) {
+	switch data := value.Data.Result.(type) {
+	case loghttp.Matrix:
+		return parseMatrix(data, query), nil
+	case loghttp.Streams:
+		return plugins.DataQueryResult{
+			RefID:      query.RefID,
+			Dataframes: plugins.NewDecodedDataFrames(parseStreams(data, query)),
+		}, nil
+	default:
+		return plugins.DataQueryResult{}, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
+	}
+}
+
+func parseMatrix(data loghttp.Matrix, query *lokiQuery) plugins.DataQueryResult {
+	var queryRes plugins.DataQueryResult
 	for _, v := range data {
 		series := plugins.DataTimeSeries{
 			Name:   formatLegend(v.Metric, query),
@@ -173,5 +235,59 @@ func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (plugins.Data
 		queryRes.Series = append(queryRes.Series, series)
 	}
 
-	return queryRes, nil
+	return queryRes
+}
+
+// parseStreams converts a log stream result into one data frame per stream. Each
+// frame holds the entry timestamp, the log line and a stable id derived from the
+// stream labels, timestamp and line, so that the same entry yields the same id
+// across requests. The stream labels are attached to the line field.
+func parseStreams(streams loghttp.Streams, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(streams))
+
+	for _, stream := range streams {
+		labels := data.Labels(stream.Labels.Map())
+		labelsString := stream.Labels.String()
+
+		timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(stream.Entries))
+		timeField.Name = "ts"
+		lineField := data.NewFieldFromFieldType(data.FieldTypeString, len(stream.Entries))
+		lineField.Name = "line"
+		lineField.Labels = labels
+		idField := data.NewFieldFromFieldType(data.FieldTypeString, len(stream.Entries))
+		idField.Name = "id"
+
+		seen := make(map[string]int, len(stream.Entries))
+		for i, entry := range stream.Entries {
+			id := entryID(labelsString, entry)
+			// Loki can return identical entries within a stream, so disambiguate them.
+			if n := seen[id]; n > 0 {
+				seen[id] = n + 1
+				id = fmt.Sprintf("%s_%d", id, n)
+			} else {
+				seen[id] = 1
+			}
+
+			timeField.Set(i, entry.Timestamp)
+			lineField.Set(i, entry.Line)
+			idField.Set(i, id)
+		}
+
+		frame := data.NewFrame(labelsString, timeField, lineField, idField)
+		frame.RefID = query.RefID
+		frame.Meta = &data.FrameMeta{
+			ExecutedQueryString: query.Expr,
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+func entryID(labels string, entry loghttp.Entry) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(labels))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(entry.Line))
+	return fmt.Sprintf("%d_%016x", entry.Timestamp.UnixNano(), h.Sum64())
 }
@@ -4,9 +4,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
 	p "github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
@@ -93,4 +96,134 @@ func TestLoki(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, time.Second*2, models[0].Step)
 	})
+	t.Run("parsing query model with maxLines and direction", func(t *testing.T) {
+		jsonModel, err := simplejson.NewJson([]byte(`{
+				"expr": "{app=\"backend\"}",
+				"maxLines": 20,
+				"direction": "FORWARD",
+				"refId": "A"
+			}`))
+		require.NoError(t, err)
+		timeRange := plugins.NewDataTimeRange("1h", "now")
+		queryContext := plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{Model: jsonModel},
+			},
+		}
+		exe := newExecutor()
+		models, err := exe.parseQuery(dsInfo, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, 20, models[0].MaxLines)
+		require.Equal(t, logproto.FORWARD, models[0].Direction)
+	})
+
+	t.Run("parsing query model falls back to data source maxLines", func(t *testing.T) {
+		jsonModel, err := simplejson.NewJson([]byte(`{"expr": "{app=\"backend\"}", "refId": "A"}`))
+		require.NoError(t, err)
+		timeRange := plugins.NewDataTimeRange("1h", "now")
+		queryContext := plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{Model: jsonModel},
+			},
+		}
+		exe := newExecutor()
+
+		queries, err := exe.parseQuery(dsInfo, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, defaultMaxLines, queries[0].MaxLines)
+		require.Equal(t, logproto.BACKWARD, queries[0].Direction)
+
+		dsJSON := simplejson.New()
+		dsJSON.Set("maxLines", "250")
+		queries, err = exe.parseQuery(&models.DataSource{JsonData: dsJSON}, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, 250, queries[0].MaxLines)
+	})
+
+	t.Run("parsing query model with invalid direction", func(t *testing.T) {
+		jsonModel, err := simplejson.NewJson([]byte(`{"expr": "{app=\"backend\"}", "direction": "sideways"}`))
+		require.NoError(t, err)
+		timeRange := plugins.NewDataTimeRange("1h", "now")
+		queryContext := plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{Model: jsonModel},
+			},
+		}
+		_, err = newExecutor().parseQuery(dsInfo, queryContext)
+		require.Error(t, err)
+	})
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Run("streams are converted to data frames", func(t *testing.T) {
+		ts := time.Unix(1600000000, 0).UTC()
+		value := &loghttp.QueryResponse{
+			Data: loghttp.QueryResponseData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: loghttp.Streams{
+					{
+						Labels: loghttp.LabelSet{"app": "backend", "level": "error"},
+						Entries: []loghttp.Entry{
+							{Timestamp: ts.Add(time.Second), Line: "second"},
+							{Timestamp: ts, Line: "first"},
+							{Timestamp: ts, Line: "first"},
+						},
+					},
+				},
+			},
+		}
+		query := &lokiQuery{Expr: `{app="backend"}`, RefID: "A"}
+
+		res, err := parseResponse(value, query)
+		require.NoError(t, err)
+		frames, err := res.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+
+		frame := frames[0]
+		require.Equal(t, "A", frame.RefID)
+		require.Equal(t, `{app="backend", level="error"}`, frame.Name)
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 3, frame.Rows())
+
+		require.Equal(t, "ts", frame.Fields[0].Name)
+		require.Equal(t, ts.Add(time.Second), frame.Fields[0].At(0))
+		require.Equal(t, "line", frame.Fields[1].Name)
+		require.Equal(t, "second", frame.Fields[1].At(0))
+		require.Equal(t, data.Labels{"app": "backend", "level": "error"}, frame.Fields[1].Labels)
+
+		require.Equal(t, "id", frame.Fields[2].Name)
+		firstID := frame.Fields[2].At(1).(string)
+		require.Equal(t, firstID+"_1", frame.Fields[2].At(2))
+
+		// ids are stable across responses
+		again, err := parseResponse(value, query)
+		require.NoError(t, err)
+		againFrames, err := again.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Equal(t, firstID, againFrames[0].Fields[2].At(1))
+	})
+
+	t.Run("matrix is converted to time series", func(t *testing.T) {
+		value := &loghttp.QueryResponse{
+			Data: loghttp.QueryResponseData{
+				ResultType: loghttp.ResultTypeMatrix,
+				Result: loghttp.Matrix{
+					{
+						Metric: p.Metric{"app": "backend"},
+						Values: []p.SamplePair{{Timestamp: p.Time(1000), Value: 4}},
+					},
+				},
+			},
+		}
+
+		res, err := parseResponse(value, &lokiQuery{RefID: "A"})
+		require.NoError(t, err)
+		require.Len(t, res.Series, 1)
+		require.Equal(t, "backend", res.Series[0].Tags["app"])
+		require.Equal(t, 4.0, res.Series[0].Points[0][0].Float64)
+	})
 }
@@ -32,6 +32,7 @@ func NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
 
 	config := sqleng.DataPluginConfiguration{
 		DriverName:        "mssql",
+		BindVarFormat:     sqleng.AtPBindVar,
 		ConnectionString:  cnnstr,
 		Datasource:        datasource,
 		MetricColumnTypes: []string{"VARCHAR", "CHAR", "NVARCHAR", "NCHAR"},
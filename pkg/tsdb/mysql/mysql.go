@@ -59,6 +59,7 @@ func NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
 
 	config := sqleng.DataPluginConfiguration{
 		DriverName:        "mysql",
+		BindVarFormat:     sqleng.QuestionMarkBindVar,
 		ConnectionString:  cnnstr,
 		Datasource:        datasource,
 		TimeColumnNames:   []string{"time", "time_sec"},
@@ -0,0 +1,84 @@
+package opentsdb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+const annotationQueryType = "annotations"
+
+func isAnnotationQuery(query plugins.DataSubQuery) bool {
+	return query.QueryType == annotationQueryType ||
+		query.Model.Get("queryType").MustString("") == annotationQueryType
+}
+
+// annotationQuery fetches the annotations of the metric in the query's target. Global
+// annotations, which aren't bound to a time series, are returned instead if isGlobal is set.
+// The annotations are returned as a single frame with time, timeEnd, text and tsuid fields.
+func (e *OpenTsdbExecutor) annotationQuery(ctx context.Context, dsInfo *models.DataSource, httpClient *http.Client,
+	query plugins.DataSubQuery, start, end int64) (plugins.DataQueryResult, error) {
+	metric := query.Model.Get("target").MustString("")
+	if metric == "" {
+		metric = query.Model.Get("metric").MustString("")
+	}
+	isGlobal := query.Model.Get("isGlobal").MustBool(false)
+
+	responses, err := e.query(ctx, dsInfo, httpClient, OpenTsdbQuery{
+		Start: start,
+		End:   end,
+		Queries: []map[string]interface{}{
+			{"metric": metric, "aggregator": "sum"},
+		},
+		GlobalAnnotations: isGlobal,
+	})
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+
+	var annotations []OpenTsdbAnnotation
+	if isGlobal {
+		// global annotations are repeated for every series
+		if len(responses) > 0 {
+			annotations = responses[0].GlobalAnnotations
+		}
+	} else {
+		for _, res := range responses {
+			annotations = append(annotations, res.Annotations...)
+		}
+	}
+
+	return plugins.DataQueryResult{
+		Dataframes: plugins.NewDecodedDataFrames(data.Frames{annotationsToFrame(annotations)}),
+	}, nil
+}
+
+func annotationsToFrame(annotations []OpenTsdbAnnotation) *data.Frame {
+	times := make([]time.Time, 0, len(annotations))
+	timeEnds := make([]*time.Time, 0, len(annotations))
+	texts := make([]string, 0, len(annotations))
+	tsuids := make([]string, 0, len(annotations))
+
+	for _, annotation := range annotations {
+		times = append(times, time.Unix(annotation.StartTime, 0).UTC())
+		if annotation.EndTime > 0 {
+			timeEnd := time.Unix(annotation.EndTime, 0).UTC()
+			timeEnds = append(timeEnds, &timeEnd)
+		} else {
+			timeEnds = append(timeEnds, nil)
+		}
+		texts = append(texts, annotation.Description)
+		tsuids = append(tsuids, annotation.TSUID)
+	}
+
+	return data.NewFrame("annotations",
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, timeEnds),
+		data.NewField("text", nil, texts),
+		data.NewField("tsuid", nil, tsuids),
+	)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context/ctxhttp"
 
@@ -14,7 +17,8 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -30,18 +34,50 @@ func NewExecutor(*models.DataSource) (plugins.DataPlugin, error) {
 
 var (
 	plog = log.New("tsdb.opentsdb")
+
+	aliasTagRegExp = regexp.MustCompile(`\$tag_([\w.-]+)`)
 )
 
 func (e *OpenTsdbExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
 	queryContext plugins.DataQuery) (plugins.DataResponse, error) {
-	var tsdbQuery OpenTsdbQuery
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return plugins.DataResponse{}, err
+	}
 
+	result := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult),
+	}
+
+	var tsdbQuery OpenTsdbQuery
 	tsdbQuery.Start = queryContext.TimeRange.GetFromAsMsEpoch()
 	tsdbQuery.End = queryContext.TimeRange.GetToAsMsEpoch()
+	tsdbQuery.MsResolution = true
+	// the sub query index in the response maps series back to their query
+	tsdbQuery.ShowQuery = true
 
+	var metricQueries []plugins.DataSubQuery
 	for _, query := range queryContext.Queries {
-		metric := e.buildMetric(query)
+		if isAnnotationQuery(query) {
+			queryRes, err := e.annotationQuery(ctx, dsInfo, httpClient, query, tsdbQuery.Start, tsdbQuery.End)
+			if err != nil {
+				return plugins.DataResponse{}, err
+			}
+			queryRes.RefID = refIDOrDefault(query)
+			result.Results[queryRes.RefID] = queryRes
+			continue
+		}
+
+		metric, err := e.buildMetric(query)
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
 		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+		metricQueries = append(metricQueries, query)
+	}
+
+	if len(metricQueries) == 0 {
+		return result, nil
 	}
 
 	// TODO: Don't use global variable
@@ -49,29 +85,61 @@ func (e *OpenTsdbExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 		plog.Debug("OpenTsdb request", "params", tsdbQuery)
 	}
 
-	req, err := e.createRequest(dsInfo, tsdbQuery)
+	responses, err := e.query(ctx, dsInfo, httpClient, tsdbQuery)
 	if err != nil {
 		return plugins.DataResponse{}, err
 	}
 
-	httpClient, err := dsInfo.GetHttpClient()
-	if err != nil {
-		return plugins.DataResponse{}, err
+	frames := make(map[string]data.Frames, len(metricQueries))
+	for _, query := range metricQueries {
+		frames[refIDOrDefault(query)] = data.Frames{}
 	}
+	for _, val := range responses {
+		query := metricQueries[0]
+		if val.Query != nil && val.Query.Index >= 0 && val.Query.Index < len(metricQueries) {
+			query = metricQueries[val.Query.Index]
+		}
+		refID := refIDOrDefault(query)
 
-	res, err := ctxhttp.Do(ctx, httpClient, req)
+		frame, err := toDataFrame(val, query.Model.Get("alias").MustString(""))
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
+		frame.RefID = refID
+		frames[refID] = append(frames[refID], frame)
+	}
+
+	for refID, refFrames := range frames {
+		result.Results[refID] = plugins.DataQueryResult{
+			RefID:      refID,
+			Dataframes: plugins.NewDecodedDataFrames(refFrames),
+		}
+	}
+
+	return result, nil
+}
+
+func refIDOrDefault(query plugins.DataSubQuery) string {
+	if query.RefID == "" {
+		return "A"
+	}
+	return query.RefID
+}
+
+// query sends the query to the /api/query endpoint and returns the parsed response.
+func (e *OpenTsdbExecutor) query(ctx context.Context, dsInfo *models.DataSource, httpClient *http.Client,
+	tsdbQuery OpenTsdbQuery) ([]OpenTsdbResponse, error) {
+	req, err := e.createRequest(dsInfo, tsdbQuery)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	queryResult, err := e.parseResponse(tsdbQuery, res)
+	res, err := ctxhttp.Do(ctx, httpClient, req)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	return plugins.DataResponse{
-		Results: queryResult,
-	}, nil
+	return e.parseResponse(res)
 }
 
 func (e *OpenTsdbExecutor) createRequest(dsInfo *models.DataSource, data OpenTsdbQuery) (*http.Request, error) {
@@ -101,10 +169,7 @@ func (e *OpenTsdbExecutor) createRequest(dsInfo *models.DataSource, data OpenTsd
 	return req, nil
 }
 
-func (e *OpenTsdbExecutor) parseResponse(query OpenTsdbQuery, res *http.Response) (map[string]plugins.DataQueryResult, error) {
-	queryResults := make(map[string]plugins.DataQueryResult)
-	queryRes := plugins.DataQueryResult{}
-
+func (e *OpenTsdbExecutor) parseResponse(res *http.Response) ([]OpenTsdbResponse, error) {
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
@@ -120,37 +185,65 @@ func (e *OpenTsdbExecutor) parseResponse(query OpenTsdbQuery, res *http.Response
 		return nil, fmt.Errorf("request failed, status: %s", res.Status)
 	}
 
-	var data []OpenTsdbResponse
-	err = json.Unmarshal(body, &data)
+	var responses []OpenTsdbResponse
+	err = json.Unmarshal(body, &responses)
 	if err != nil {
 		plog.Info("Failed to unmarshal opentsdb response", "error", err, "status", res.Status, "body", string(body))
 		return nil, err
 	}
 
-	for _, val := range data {
-		series := plugins.DataTimeSeries{
-			Name: val.Metric,
+	return responses, nil
+}
+
+// toDataFrame converts a series into a frame with a time and a value field. The series tags
+// become labels of the value field. The alias names the series and may reference tag values
+// with $tag_<key>, otherwise the metric name is used.
+func toDataFrame(val OpenTsdbResponse, alias string) (*data.Frame, error) {
+	type point struct {
+		timestamp float64
+		value     *float64
+	}
+	points := make([]point, 0, len(val.DataPoints))
+	for timeString, value := range val.DataPoints {
+		timestamp, err := strconv.ParseFloat(timeString, 64)
+		if err != nil {
+			plog.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
+			return nil, err
 		}
+		points = append(points, point{timestamp: timestamp, value: value})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].timestamp < points[j].timestamp
+	})
+
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(points))
+	timeField.Name = data.TimeSeriesTimeFieldName
+	valueField := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, len(points))
+	valueField.Name = data.TimeSeriesValueFieldName
+	if len(val.Tags) > 0 {
+		valueField.Labels = data.Labels(val.Tags).Copy()
+	}
 
-		for timeString, value := range val.DataPoints {
-			timestamp, err := strconv.ParseFloat(timeString, 64)
-			if err != nil {
-				plog.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
-				return nil, err
+	name := val.Metric
+	if alias != "" {
+		name = aliasTagRegExp.ReplaceAllStringFunc(alias, func(match string) string {
+			if value, ok := val.Tags[aliasTagRegExp.FindStringSubmatch(match)[1]]; ok {
+				return value
 			}
-			series.Points = append(series.Points, plugins.DataTimePoint{
-				null.FloatFrom(value), null.FloatFrom(timestamp),
-			})
-		}
+			return match
+		})
+	}
+	valueField.Config = &data.FieldConfig{DisplayNameFromDS: name}
 
-		queryRes.Series = append(queryRes.Series, series)
+	for i, p := range points {
+		timeField.Set(i, time.Unix(0, int64(p.timestamp)*int64(time.Millisecond)).UTC())
+		valueField.Set(i, p.value)
 	}
 
-	queryResults["A"] = queryRes
-	return queryResults, nil
+	return data.NewFrame(val.Metric, timeField, valueField), nil
 }
 
-func (e *OpenTsdbExecutor) buildMetric(query plugins.DataSubQuery) map[string]interface{} {
+func (e *OpenTsdbExecutor) buildMetric(query plugins.DataSubQuery) (map[string]interface{}, error) {
 	metric := make(map[string]interface{})
 
 	// Setting metric and aggregator
@@ -178,34 +271,164 @@ func (e *OpenTsdbExecutor) buildMetric(query plugins.DataSubQuery) map[string]in
 		rateOptions := make(map[string]interface{})
 		rateOptions["counter"] = query.Model.Get("isCounter").MustBool()
 
-		counterMax, counterMaxCheck := query.Model.CheckGet("counterMax")
+		counterMax, counterMaxCheck, err := modelNumber(query.Model, "counterMax")
+		if err != nil {
+			return nil, err
+		}
 		if counterMaxCheck {
-			rateOptions["counterMax"] = counterMax.MustFloat64()
+			rateOptions["counterMax"] = counterMax
 		}
 
-		resetValue, resetValueCheck := query.Model.CheckGet("counterResetValue")
+		resetValue, resetValueCheck, err := modelNumber(query.Model, "counterResetValue")
+		if err != nil {
+			return nil, err
+		}
 		if resetValueCheck {
-			rateOptions["resetValue"] = resetValue.MustFloat64()
+			rateOptions["resetValue"] = resetValue
 		}
 
-		if !counterMaxCheck && (!resetValueCheck || resetValue.MustFloat64() == 0) {
+		if !counterMaxCheck && (!resetValueCheck || resetValue == 0) {
 			rateOptions["dropResets"] = true
 		}
 
 		metric["rateOptions"] = rateOptions
 	}
 
-	// Setting tags
-	tags, tagsCheck := query.Model.CheckGet("tags")
-	if tagsCheck && len(tags.MustMap()) > 0 {
+	// Setting filters, which replace tags as in OpenTSDB 2.2+
+	filters, err := parseFilters(query.Model)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) > 0 {
+		metric["filters"] = filters
+	} else if tags, tagsCheck := query.Model.CheckGet("tags"); tagsCheck && len(tags.MustMap()) > 0 {
 		metric["tags"] = tags.MustMap()
 	}
 
-	// Setting filters
-	filters, filtersCheck := query.Model.CheckGet("filters")
-	if filtersCheck && len(filters.MustArray()) > 0 {
-		metric["filters"] = filters.MustArray()
+	if query.Model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
+	}
+
+	percentiles, err := parsePercentiles(query.Model)
+	if err != nil {
+		return nil, err
+	}
+	if len(percentiles) > 0 {
+		metric["percentiles"] = percentiles
 	}
 
-	return metric
+	return metric, nil
+}
+
+// Filter is a tag filter of an OpenTSDB 2.2+ sub query.
+type Filter struct {
+	Type    string `json:"type"`
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+var filterTypes = map[string]bool{
+	"wildcard":        true,
+	"iwildcard":       true,
+	"regexp":          true,
+	"literal_or":      true,
+	"iliteral_or":     true,
+	"not_literal_or":  true,
+	"not_iliteral_or": true,
+	"not_key":         true,
+}
+
+func parseFilters(model *simplejson.Json) ([]Filter, error) {
+	filtersJSON, ok := model.CheckGet("filters")
+	if !ok {
+		return nil, nil
+	}
+
+	var filters []Filter
+	for i := range filtersJSON.MustArray() {
+		f := filtersJSON.GetIndex(i)
+		filter := Filter{
+			Type:    f.Get("type").MustString(),
+			Tagk:    f.Get("tagk").MustString(),
+			Filter:  f.Get("filter").MustString(),
+			GroupBy: f.Get("groupBy").MustBool(),
+		}
+		if !filterTypes[filter.Type] {
+			return nil, fmt.Errorf("unsupported filter type %q", filter.Type)
+		}
+		if filter.Tagk == "" {
+			return nil, fmt.Errorf("filter of type %q is missing a tag key", filter.Type)
+		}
+		if filter.Filter == "" && filter.Type != "not_key" {
+			return nil, fmt.Errorf("filter of type %q on tag %q is missing a value", filter.Type, filter.Tagk)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// parsePercentiles reads the percentiles of a histogram query, given as a list of numbers or
+// a comma separated string.
+func parsePercentiles(model *simplejson.Json) ([]float64, error) {
+	percentilesJSON, ok := model.CheckGet("percentiles")
+	if !ok {
+		return nil, nil
+	}
+
+	var values []interface{}
+	if s, err := percentilesJSON.String(); err == nil {
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	} else {
+		values = percentilesJSON.MustArray()
+	}
+
+	percentiles := make([]float64, 0, len(values))
+	for _, v := range values {
+		p, err := toFloat(v)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %v, must be a number greater than 0 and at most 100", v)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+// modelNumber reads a number of the query model that may be stored as a string by the query
+// editor. Empty strings are treated as unset.
+func modelNumber(model *simplejson.Json, key string) (float64, bool, error) {
+	value, ok := model.CheckGet(key)
+	if !ok {
+		return 0, false, nil
+	}
+	if s, err := value.String(); err == nil && strings.TrimSpace(s) == "" {
+		return 0, false, nil
+	}
+
+	f, err := toFloat(value.Interface())
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, true, nil
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch value := v.(type) {
+	case json.Number:
+		return value.Float64()
+	case float64:
+		return value, nil
+	case int:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	default:
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
 }
@@ -1,9 +1,16 @@
 package opentsdb
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/require"
 )
@@ -23,7 +30,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		query.Model.Set("downsampleAggregator", "avg")
 		query.Model.Set("downsampleFillPolicy", "none")
 
-		metric := exec.buildMetric(query)
+		metric, err := exec.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 3)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -43,7 +51,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		query.Model.Set("downsampleAggregator", "avg")
 		query.Model.Set("downsampleFillPolicy", "none")
 
-		metric := exec.buildMetric(query)
+		metric, err := exec.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 2)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -62,7 +71,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		query.Model.Set("downsampleAggregator", "sum")
 		query.Model.Set("downsampleFillPolicy", "null")
 
-		metric := exec.buildMetric(query)
+		metric, err := exec.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 3)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -87,7 +97,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		tags.Set("app", "grafana")
 		query.Model.Set("tags", tags.MustMap())
 
-		metric := exec.buildMetric(query)
+		metric, err := exec.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 3)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -117,7 +128,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		tags.Set("app", "grafana")
 		query.Model.Set("tags", tags.MustMap())
 
-		metric := exec.buildMetric(query)
+		metric, err := exec.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 5)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -151,7 +163,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		tags.Set("app", "grafana")
 		query.Model.Set("tags", tags.MustMap())
 
-		metric := exec.buildMetric(query)
+		metric, err := exec.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 5)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -171,3 +184,161 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
 }
+
+func TestBuildMetricOptions(t *testing.T) {
+	exec := &OpenTsdbExecutor{}
+	newQuery := func(model map[string]interface{}) plugins.DataSubQuery {
+		model["metric"] = "cpu"
+		model["aggregator"] = "sum"
+		model["disableDownsampling"] = true
+		return plugins.DataSubQuery{Model: simplejson.NewFromAny(model)}
+	}
+
+	t.Run("filters replace tags", func(t *testing.T) {
+		metric, err := exec.buildMetric(newQuery(map[string]interface{}{
+			"tags": map[string]interface{}{"env": "prod"},
+			"filters": []interface{}{
+				map[string]interface{}{"type": "wildcard", "tagk": "host", "filter": "web*", "groupBy": true},
+				map[string]interface{}{"type": "not_literal_or", "tagk": "dc", "filter": "lga|sjc"},
+			},
+			"explicitTags": true,
+		}))
+		require.NoError(t, err)
+		require.Nil(t, metric["tags"])
+		require.True(t, metric["explicitTags"].(bool))
+		require.Equal(t, []Filter{
+			{Type: "wildcard", Tagk: "host", Filter: "web*", GroupBy: true},
+			{Type: "not_literal_or", Tagk: "dc", Filter: "lga|sjc"},
+		}, metric["filters"])
+	})
+
+	t.Run("invalid filters fail", func(t *testing.T) {
+		_, err := exec.buildMetric(newQuery(map[string]interface{}{
+			"filters": []interface{}{
+				map[string]interface{}{"type": "fuzzy", "tagk": "host", "filter": "web"},
+			},
+		}))
+		require.Error(t, err)
+
+		_, err = exec.buildMetric(newQuery(map[string]interface{}{
+			"filters": []interface{}{
+				map[string]interface{}{"type": "regexp", "tagk": "host"},
+			},
+		}))
+		require.Error(t, err)
+	})
+
+	t.Run("rate options set by the query editor as strings", func(t *testing.T) {
+		metric, err := exec.buildMetric(newQuery(map[string]interface{}{
+			"shouldComputeRate": true,
+			"isCounter":         true,
+			"counterMax":        "1000",
+			"counterResetValue": "",
+		}))
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"counter":    true,
+			"counterMax": float64(1000),
+		}, metric["rateOptions"])
+
+		_, err = exec.buildMetric(newQuery(map[string]interface{}{
+			"shouldComputeRate": true,
+			"counterMax":        "max",
+		}))
+		require.Error(t, err)
+	})
+
+	t.Run("percentiles", func(t *testing.T) {
+		metric, err := exec.buildMetric(newQuery(map[string]interface{}{
+			"percentiles": "99.9, 95",
+		}))
+		require.NoError(t, err)
+		require.Equal(t, []float64{99.9, 95}, metric["percentiles"])
+
+		metric, err = exec.buildMetric(newQuery(map[string]interface{}{
+			"percentiles": []interface{}{50, 75.5},
+		}))
+		require.NoError(t, err)
+		require.Equal(t, []float64{50, 75.5}, metric["percentiles"])
+
+		_, err = exec.buildMetric(newQuery(map[string]interface{}{
+			"percentiles": []interface{}{101},
+		}))
+		require.Error(t, err)
+	})
+}
+
+func TestDataQuery(t *testing.T) {
+	var requests []OpenTsdbQuery
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var query OpenTsdbQuery
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&query))
+		requests = append(requests, query)
+
+		var body string
+		if query.GlobalAnnotations {
+			body = `[{"metric":"cpu","dps":{},"globalAnnotations":[` +
+				`{"description":"deploy","startTime":1500000000,"endTime":1500000060}]}]`
+		} else {
+			body = `[{"metric":"mem","tags":{"host":"b"},"query":{"index":1},"dps":{"1500000060000":2,"1500000000000":null}},` +
+				`{"metric":"cpu","tags":{"host":"a"},"query":{"index":0},"dps":{"1500000000000":1}}]`
+		}
+		_, err := rw.Write([]byte(body))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	exec := &OpenTsdbExecutor{}
+	dsInfo := &models.DataSource{Url: server.URL, JsonData: simplejson.New()}
+	timeRange := plugins.NewDataTimeRange("1500000000000", "1500000600000")
+
+	t.Run("series are returned as frames of their query", func(t *testing.T) {
+		res, err := exec.DataQuery(context.Background(), dsInfo, plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", Model: simplejson.NewFromAny(map[string]interface{}{"metric": "cpu", "aggregator": "sum"})},
+				{RefID: "B", Model: simplejson.NewFromAny(map[string]interface{}{
+					"metric": "mem", "aggregator": "sum", "alias": "mem $tag_host",
+				})},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, requests[len(requests)-1].ShowQuery)
+		require.True(t, requests[len(requests)-1].MsResolution)
+
+		frames, err := res.Results["B"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "B", frames[0].RefID)
+		require.Equal(t, data.Labels{"host": "b"}, frames[0].Fields[1].Labels)
+		require.Equal(t, "mem b", frames[0].Fields[1].Config.DisplayNameFromDS)
+		require.Equal(t, time.Unix(1500000000, 0).UTC(), frames[0].Fields[0].At(0))
+		require.Nil(t, frames[0].Fields[1].At(0))
+		require.Equal(t, 2.0, *frames[0].Fields[1].At(1).(*float64))
+
+		frames, err = res.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "cpu", frames[0].Fields[1].Config.DisplayNameFromDS)
+	})
+
+	t.Run("global annotations are returned as a frame", func(t *testing.T) {
+		res, err := exec.DataQuery(context.Background(), dsInfo, plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{RefID: "Anno", QueryType: annotationQueryType, Model: simplejson.NewFromAny(map[string]interface{}{
+					"target": "cpu", "isGlobal": true,
+				})},
+			},
+		})
+		require.NoError(t, err)
+		require.True(t, requests[len(requests)-1].GlobalAnnotations)
+
+		frames, err := res.Results["Anno"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, 1, frames[0].Rows())
+		require.Equal(t, time.Unix(1500000000, 0).UTC(), frames[0].Fields[0].At(0))
+		require.Equal(t, "deploy", frames[0].Fields[2].At(0))
+	})
+}
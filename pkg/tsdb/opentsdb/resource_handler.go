@@ -0,0 +1,32 @@
+package opentsdb
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+func init() {
+	registry.RegisterService(&Service{})
+}
+
+// Service exposes the OpenTSDB suggest and lookup endpoints as data source resources.
+type Service struct {
+	BackendPluginManager backendplugin.Manager `inject:""`
+}
+
+func (s *Service) Init() error {
+	mux := http.NewServeMux()
+	registerRoutes(mux)
+	coreplugin.RegisterResourceHandler(s.BackendPluginManager, "opentsdb", mux, plog)
+	return nil
+}
+
+// registerRoutes registers the resources used by the OpenTSDB query editor: the suggestions of metric names, tag
+// keys and tag values, and the lookup of the tag values of a metric, proxied to the OpenTSDB HTTP API.
+func registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/suggest", coreplugin.ProxyResourceHandler("api/suggest", plog))
+	mux.HandleFunc("/api/search/lookup", coreplugin.ProxyResourceHandler("api/search/lookup", plog))
+}
@@ -1,12 +1,34 @@
 package opentsdb
 
 type OpenTsdbQuery struct {
-	Start   int64                    `json:"start"`
-	End     int64                    `json:"end"`
-	Queries []map[string]interface{} `json:"queries"`
+	Start             int64                    `json:"start"`
+	End               int64                    `json:"end"`
+	Queries           []map[string]interface{} `json:"queries"`
+	MsResolution      bool                     `json:"msResolution,omitempty"`
+	ShowQuery         bool                     `json:"showQuery,omitempty"`
+	GlobalAnnotations bool                     `json:"globalAnnotations,omitempty"`
 }
 
 type OpenTsdbResponse struct {
-	Metric     string             `json:"metric"`
-	DataPoints map[string]float64 `json:"dps"`
+	Metric            string               `json:"metric"`
+	Tags              map[string]string    `json:"tags"`
+	AggregateTags     []string             `json:"aggregateTags"`
+	DataPoints        map[string]*float64  `json:"dps"`
+	Query             *OpenTsdbSubQuery    `json:"query,omitempty"`
+	Annotations       []OpenTsdbAnnotation `json:"annotations,omitempty"`
+	GlobalAnnotations []OpenTsdbAnnotation `json:"globalAnnotations,omitempty"`
+}
+
+// OpenTsdbSubQuery is the sub query echoed back in a response when showQuery is set.
+type OpenTsdbSubQuery struct {
+	Index int `json:"index"`
+}
+
+type OpenTsdbAnnotation struct {
+	TSUID       string                 `json:"tsuid,omitempty"`
+	Description string                 `json:"description"`
+	Notes       string                 `json:"notes"`
+	Custom      map[string]interface{} `json:"custom,omitempty"`
+	StartTime   int64                  `json:"startTime"`
+	EndTime     int64                  `json:"endTime"`
 }
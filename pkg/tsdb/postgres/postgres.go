@@ -51,6 +51,7 @@ func (s *PostgresService) NewExecutor(datasource *models.DataSource) (plugins.Da
 
 	config := sqleng.DataPluginConfiguration{
 		DriverName:        "postgres",
+		BindVarFormat:     sqleng.DollarBindVar,
 		ConnectionString:  cnnstr,
 		Datasource:        datasource,
 		MetricColumnTypes: []string{"UNKNOWN", "TEXT", "VARCHAR", "CHAR"},
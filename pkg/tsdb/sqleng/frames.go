@@ -0,0 +1,368 @@
+package sqleng
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/plugins"
+	"xorm.io/core"
+)
+
+// useDataFrames returns true if the query asks for its result as data frames instead of
+// legacy time series and tables.
+func useDataFrames(query plugins.DataSubQuery) bool {
+	return query.Model.Get("dataFrames").MustBool(false)
+}
+
+// transformToDataFrame reads the rows into a data frame. Time columns become time fields,
+// numeric columns nullable float64 fields and all other columns nullable string fields.
+// For the time_series format the frame is converted from long to wide format, so that
+// string columns become labels of the numeric fields and each numeric column becomes a
+// separate field. Fill modes are applied to the wide frame.
+func (e *dataPlugin) transformToDataFrame(query plugins.DataSubQuery, rows *core.Rows,
+	result *plugins.DataQueryResult, queryContext plugins.DataQuery, format string) error {
+	frame, timeIndex, err := e.rowsToFrame(rows, format == "time_series")
+	if err != nil {
+		return err
+	}
+	frame.RefID = query.RefID
+	frame.Meta = &data.FrameMeta{
+		ExecutedQueryString: result.Meta.Get(MetaKeyExecutedQueryString).MustString(),
+	}
+	result.Meta.Set("rowCount", frame.Rows())
+
+	if format == "time_series" {
+		if timeIndex == -1 {
+			return fmt.Errorf("found no column named %q", strings.Join(e.timeColumnNames, " or "))
+		}
+
+		frame, err = toWideFrame(frame, timeIndex, query, queryContext)
+		if err != nil {
+			return err
+		}
+	}
+
+	result.Dataframes = plugins.NewDecodedDataFrames(data.Frames{frame})
+	return nil
+}
+
+// rowsToFrame returns the frame and the index of the time field, which is -1 if the
+// query has no time column. Time series require a time value in every row.
+func (e *dataPlugin) rowsToFrame(rows *core.Rows, timeRequired bool) (*data.Frame, int, error) {
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return nil, -1, err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, -1, err
+	}
+
+	timeIndex := -1
+	for i, name := range columnNames {
+		for _, tc := range e.timeColumnNames {
+			if name == tc && timeIndex == -1 {
+				timeIndex = i
+			}
+		}
+	}
+
+	values := make([][]interface{}, len(columnNames))
+	rowCount := 0
+	for ; rows.Next(); rowCount++ {
+		if rowCount > rowLimit {
+			return nil, -1, fmt.Errorf("query row limit exceeded, limit %d", rowLimit)
+		}
+
+		row, err := e.queryResultTransformer.TransformQueryResult(columnTypes, rows)
+		if err != nil {
+			return nil, -1, err
+		}
+		for i := range columnNames {
+			values[i] = append(values[i], row[i])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, -1, err
+	}
+
+	frame := data.NewFrame("")
+	for i, name := range columnNames {
+		var field *data.Field
+		if i == timeIndex {
+			field, err = timeField(name, values[i], !timeRequired)
+		} else {
+			field, err = valueField(name, values[i])
+		}
+		if err != nil {
+			return nil, -1, err
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	return frame, timeIndex, nil
+}
+
+func timeField(name string, values []interface{}, nullable bool) (*data.Field, error) {
+	fieldType := data.FieldTypeTime
+	if nullable {
+		fieldType = data.FieldTypeNullableTime
+	}
+	field := data.NewFieldFromFieldType(fieldType, len(values))
+	field.Name = name
+
+	for i, v := range values {
+		epoch := plugins.DataRowValues{v}
+		ConvertSqlTimeColumnToEpochMs(epoch, 0)
+		ms, err := ConvertSqlValueColumnToFloat(name, epoch[0])
+		if err != nil || !ms.Valid && !nullable {
+			return nil, fmt.Errorf("invalid type for column time, must be of type timestamp or unix timestamp, got: %T %v", v, v)
+		}
+		if !ms.Valid {
+			continue
+		}
+
+		t := time.Unix(0, int64(ms.Float64*float64(time.Millisecond))).UTC()
+		if nullable {
+			field.Set(i, &t)
+		} else {
+			field.Set(i, t)
+		}
+	}
+	return field, nil
+}
+
+func valueField(name string, values []interface{}) (*data.Field, error) {
+	numeric := true
+	for _, v := range values {
+		if _, err := ConvertSqlValueColumnToFloat(name, v); err != nil {
+			numeric = false
+			break
+		}
+	}
+
+	if numeric {
+		field := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, len(values))
+		field.Name = name
+		for i, v := range values {
+			f, _ := ConvertSqlValueColumnToFloat(name, v)
+			if f.Valid {
+				value := f.Float64
+				field.Set(i, &value)
+			}
+		}
+		return field, nil
+	}
+
+	field := data.NewFieldFromFieldType(data.FieldTypeNullableString, len(values))
+	field.Name = name
+	for i, v := range values {
+		if s, ok := stringValue(v); ok {
+			field.Set(i, &s)
+		}
+	}
+	return field, nil
+}
+
+func stringValue(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case *string:
+		if value == nil {
+			return "", false
+		}
+		return *value, true
+	case []byte:
+		return string(value), true
+	case *[]byte:
+		if value == nil {
+			return "", false
+		}
+		return string(*value), true
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano), true
+	case *time.Time:
+		if value == nil {
+			return "", false
+		}
+		return value.UTC().Format(time.RFC3339Nano), true
+	default:
+		return fmt.Sprint(value), true
+	}
+}
+
+// toWideFrame converts a long frame, where string columns hold the series identity, into
+// a wide frame with one field per series and numeric column.
+func toWideFrame(frame *data.Frame, timeIndex int, query plugins.DataSubQuery,
+	queryContext plugins.DataQuery) (*data.Frame, error) {
+	// time series require a time index sorted ascending
+	sortByTime(frame, timeIndex)
+
+	fillMissing := frameFillMissing(query)
+
+	if frame.TimeSeriesSchema().Type == data.TimeSeriesTypeLong {
+		wide, err := data.LongToWide(frame, fillMissing)
+		if err != nil {
+			return nil, err
+		}
+		frame = wide
+	}
+
+	setDisplayNames(frame)
+
+	if fillMissing != nil && queryContext.TimeRange != nil {
+		interval := time.Duration(query.Model.Get("fillInterval").MustFloat64() * float64(time.Second))
+		if interval > 0 {
+			var err error
+			frame, err = fillFrame(frame, fillMissing, interval,
+				queryContext.TimeRange.MustGetFrom(), queryContext.TimeRange.MustGetTo())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return frame, nil
+}
+
+func sortByTime(frame *data.Frame, timeIndex int) {
+	rowLen := frame.Rows()
+	order := make([]int, rowLen)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ta := frame.Fields[timeIndex].At(order[a]).(time.Time)
+		tb := frame.Fields[timeIndex].At(order[b]).(time.Time)
+		return ta.Before(tb)
+	})
+
+	for _, field := range frame.Fields {
+		values := make([]interface{}, rowLen)
+		for i, idx := range order {
+			values[i] = field.At(idx)
+		}
+		for i, v := range values {
+			field.Set(i, v)
+		}
+	}
+}
+
+// setDisplayNames keeps series names compatible with the legacy metric column convention,
+// where the metric column names the series and prefixes the column name if the query has
+// more than one value column.
+func setDisplayNames(frame *data.Frame) {
+	valueColumns := map[string]struct{}{}
+	for _, field := range frame.Fields {
+		if field.Type() != data.FieldTypeTime {
+			valueColumns[field.Name] = struct{}{}
+		}
+	}
+
+	for _, field := range frame.Fields {
+		metric, ok := field.Labels["metric"]
+		if !ok {
+			continue
+		}
+		name := metric
+		if len(valueColumns) > 1 {
+			name = metric + " " + field.Name
+		}
+		field.Config = &data.FieldConfig{DisplayNameFromDS: name}
+	}
+}
+
+func frameFillMissing(query plugins.DataSubQuery) *data.FillMissing {
+	if !query.Model.Get("fill").MustBool(false) {
+		return nil
+	}
+
+	switch query.Model.Get("fillMode").MustString() {
+	case "previous":
+		return &data.FillMissing{Mode: data.FillModePrevious}
+	case "value":
+		return &data.FillMissing{Mode: data.FillModeValue, Value: query.Model.Get("fillValue").MustFloat64()}
+	default:
+		return &data.FillMissing{Mode: data.FillModeNull}
+	}
+}
+
+// fillFrame adds a row for each interval between from and to that has no row in the wide
+// frame, with values according to the fill mode.
+func fillFrame(frame *data.Frame, fillMissing *data.FillMissing, interval time.Duration,
+	from, to time.Time) (*data.Frame, error) {
+	tsSchema := frame.TimeSeriesSchema()
+	if tsSchema.Type != data.TimeSeriesTypeWide {
+		return frame, nil
+	}
+	timeIdx := tsSchema.TimeIndex
+
+	intervalMs := float64(interval.Milliseconds())
+	bucket := func(t time.Time) int64 {
+		return int64(math.Floor(float64(t.UnixNano()/int64(time.Millisecond)) / intervalMs))
+	}
+
+	filled := frame.EmptyCopy()
+	previous := make([]interface{}, len(frame.Fields))
+	appendFill := func(t time.Time) {
+		row := make([]interface{}, len(frame.Fields))
+		for i, field := range frame.Fields {
+			if i == timeIdx {
+				row[i] = t
+				continue
+			}
+			row[i] = fillValue(field.Type(), fillMissing, previous[i])
+		}
+		filled.AppendRow(row...)
+	}
+
+	next := bucket(from)
+	for rowIdx := 0; rowIdx < frame.Rows(); rowIdx++ {
+		t := frame.Fields[timeIdx].At(rowIdx).(time.Time)
+		for b := next; b < bucket(t); b++ {
+			appendFill(time.Unix(0, int64(float64(b)*intervalMs)*int64(time.Millisecond)).UTC())
+		}
+
+		row := frame.RowCopy(rowIdx)
+		for i := range row {
+			if i != timeIdx {
+				previous[i] = row[i]
+			}
+		}
+		filled.AppendRow(row...)
+		next = bucket(t) + 1
+	}
+
+	for b := next; float64(b)*intervalMs < float64(to.UnixNano()/int64(time.Millisecond)); b++ {
+		appendFill(time.Unix(0, int64(float64(b)*intervalMs)*int64(time.Millisecond)).UTC())
+	}
+
+	return filled, nil
+}
+
+func fillValue(fieldType data.FieldType, fillMissing *data.FillMissing, previous interface{}) interface{} {
+	if fieldType != data.FieldTypeNullableFloat64 {
+		return nil
+	}
+
+	switch fillMissing.Mode {
+	case data.FillModePrevious:
+		if p, ok := previous.(*float64); ok && p != nil {
+			value := *p
+			return &value
+		}
+		return nil
+	case data.FillModeValue:
+		value := fillMissing.Value
+		return &value
+	default:
+		return nil
+	}
+}
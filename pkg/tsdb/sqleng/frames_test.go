@@ -0,0 +1,120 @@
+package sqleng
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataFrames(t *testing.T) {
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := plugins.DataTimeRange{From: "5m", To: "now", Now: to}
+	queryContext := plugins.DataQuery{TimeRange: &timeRange}
+
+	strPtr := func(s string) *string { return &s }
+	floatPtr := func(f float64) *float64 { return &f }
+
+	t.Run("values are converted to typed fields", func(t *testing.T) {
+		ts, err := timeField("time", []interface{}{int64(1523556000000), from}, false)
+		require.NoError(t, err)
+		require.Equal(t, from, ts.At(0))
+		require.Equal(t, from, ts.At(1))
+
+		_, err = timeField("time", []interface{}{nil}, false)
+		require.Error(t, err)
+
+		nullable, err := timeField("time", []interface{}{nil}, true)
+		require.NoError(t, err)
+		require.Nil(t, nullable.At(0))
+
+		values, err := valueField("value", []interface{}{int64(1), nil, float32(2.5)})
+		require.NoError(t, err)
+		require.Equal(t, data.FieldTypeNullableFloat64, values.Type())
+		require.Equal(t, 1.0, *values.At(0).(*float64))
+		require.Nil(t, values.At(1))
+
+		names, err := valueField("host", []interface{}{"a", []byte("b"), nil})
+		require.NoError(t, err)
+		require.Equal(t, data.FieldTypeNullableString, names.Type())
+		require.Equal(t, "b", *names.At(1).(*string))
+		require.Nil(t, names.At(2))
+	})
+
+	t.Run("long frames are converted to wide frames with labels", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("time", nil, []time.Time{from.Add(time.Minute), from, from, from.Add(time.Minute)}),
+			data.NewField("host", nil, []*string{strPtr("b"), strPtr("a"), strPtr("b"), strPtr("a")}),
+			data.NewField("cpu", nil, []*float64{floatPtr(4), floatPtr(1), floatPtr(3), floatPtr(2)}),
+			data.NewField("mem", nil, []*float64{floatPtr(40), floatPtr(10), floatPtr(30), floatPtr(20)}),
+		)
+		query := plugins.DataSubQuery{Model: simplejson.New()}
+
+		wide, err := toWideFrame(frame, 0, query, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, data.TimeSeriesTypeWide, wide.TimeSeriesSchema().Type)
+		require.Len(t, wide.Fields, 5)
+		require.Equal(t, 2, wide.Rows())
+		require.Equal(t, from, wide.Fields[0].At(0))
+
+		for _, field := range wide.Fields[1:] {
+			require.Contains(t, []string{"cpu", "mem"}, field.Name)
+			require.Contains(t, []string{"a", "b"}, field.Labels["host"])
+		}
+		require.Equal(t, data.Labels{"host": "a"}, wide.Fields[1].Labels)
+		require.Equal(t, 1.0, *wide.Fields[1].At(0).(*float64))
+		require.Equal(t, 2.0, *wide.Fields[1].At(1).(*float64))
+	})
+
+	t.Run("metric column names the series", func(t *testing.T) {
+		frame := data.NewFrame("",
+			data.NewField("time", nil, []time.Time{from}),
+			data.NewField("metric", nil, []*string{strPtr("cpu")}),
+			data.NewField("value", nil, []*float64{floatPtr(1)}),
+		)
+		wide, err := toWideFrame(frame, 0, plugins.DataSubQuery{Model: simplejson.New()}, queryContext)
+		require.NoError(t, err)
+		require.Equal(t, "cpu", wide.Fields[1].Config.DisplayNameFromDS)
+	})
+
+	t.Run("fill modes are applied to the wide frame", func(t *testing.T) {
+		newFrame := func() *data.Frame {
+			return data.NewFrame("",
+				data.NewField("time", nil, []time.Time{from.Add(time.Minute), from.Add(3 * time.Minute)}),
+				data.NewField("value", nil, []*float64{floatPtr(1), floatPtr(3)}),
+			)
+		}
+		newQuery := func(mode string, value float64) plugins.DataSubQuery {
+			return plugins.DataSubQuery{Model: simplejson.NewFromAny(map[string]interface{}{
+				"fill":         true,
+				"fillInterval": 60,
+				"fillMode":     mode,
+				"fillValue":    value,
+			})}
+		}
+
+		wide, err := toWideFrame(newFrame(), 0, newQuery("null", 0), queryContext)
+		require.NoError(t, err)
+		require.Equal(t, 5, wide.Rows())
+		require.Equal(t, from, wide.Fields[0].At(0))
+		require.Nil(t, wide.Fields[1].At(0))
+		require.Equal(t, 1.0, *wide.Fields[1].At(1).(*float64))
+		require.Nil(t, wide.Fields[1].At(2))
+		require.Equal(t, from.Add(4*time.Minute), wide.Fields[0].At(4))
+
+		wide, err = toWideFrame(newFrame(), 0, newQuery("previous", 0), queryContext)
+		require.NoError(t, err)
+		require.Nil(t, wide.Fields[1].At(0))
+		require.Equal(t, 1.0, *wide.Fields[1].At(2).(*float64))
+		require.Equal(t, 3.0, *wide.Fields[1].At(4).(*float64))
+
+		wide, err = toWideFrame(newFrame(), 0, newQuery("value", 5), queryContext)
+		require.NoError(t, err)
+		require.Equal(t, 5.0, *wide.Fields[1].At(0).(*float64))
+		require.Equal(t, 5.0, *wide.Fields[1].At(2).(*float64))
+	})
+}
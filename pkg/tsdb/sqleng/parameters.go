@@ -0,0 +1,181 @@
+package sqleng
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// BindVarFormat returns the bind parameter placeholder for the n-th (1-based) argument of a query.
+type BindVarFormat func(n int) string
+
+// QuestionMarkBindVar formats bind parameters as used by MySQL.
+func QuestionMarkBindVar(int) string {
+	return "?"
+}
+
+// DollarBindVar formats bind parameters as used by PostgreSQL.
+func DollarBindVar(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// AtPBindVar formats bind parameters as used by Microsoft SQL Server.
+func AtPBindVar(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+
+// paramMarker delimits references to bound arguments until the final placeholders are known.
+// It can't occur in a valid query, so macro engines pass it through untouched.
+const paramMarker = "\x00"
+
+var (
+	paramMarkerRegExp = regexp.MustCompile(paramMarker + `(\d+)` + paramMarker)
+	timeFromRegExp    = regexp.MustCompile(`\$__timeFrom\(\)`)
+	timeToRegExp      = regexp.MustCompile(`\$__timeTo\(\)`)
+	timeFilterRegExp  = regexp.MustCompile(`\$__timeFilter\(([^\)]*)\)`)
+	variableRegExp    = regexp.MustCompile(`'?(?:\$\{(\w+)(?::\w+)?\}|\[\[(\w+)(?::\w+)?\]\]|\$(\w+))'?`)
+)
+
+// isParameterized returns true if the query asks for template variables and the time range
+// to be bound as driver parameters instead of being interpolated into the SQL.
+func isParameterized(query plugins.DataSubQuery) bool {
+	return query.Model.Get("parameterized").MustBool(false)
+}
+
+// parameterizedQuery holds the values bound to a query while it's being built.
+type parameterizedQuery struct {
+	args []interface{}
+}
+
+func (p *parameterizedQuery) bind(value interface{}) string {
+	p.args = append(p.args, value)
+	return fmt.Sprintf("%s%d%s", paramMarker, len(p.args)-1, paramMarker)
+}
+
+// bindParameters replaces $__timeFrom(), $__timeTo(), $__timeFilter(column) and the template
+// variables of the query model with bound arguments. Variables are read from the query's
+// "variables" object, mapping a variable name to a string or a list of strings. Multi-value
+// variables expand into a comma separated list of parameters, so they can be used in IN (...).
+// Quotes around a variable reference are dropped, since the value is no longer part of the SQL, and a
+// reference within a string literal is an error.
+func (p *parameterizedQuery) bindParameters(query plugins.DataSubQuery, timeRange plugins.DataTimeRange,
+	sql string) (string, error) {
+	variables, err := parseVariables(query.Model.Get("variables"))
+	if err != nil {
+		return "", err
+	}
+
+	from := timeRange.GetFromAsTimeUTC()
+	to := timeRange.GetToAsTimeUTC()
+
+	sql = timeFilterRegExp.ReplaceAllStringFunc(sql, func(match string) string {
+		column := strings.TrimSpace(timeFilterRegExp.FindStringSubmatch(match)[1])
+		return fmt.Sprintf("%s BETWEEN %s AND %s", column, p.bind(from), p.bind(to))
+	})
+	sql = timeFromRegExp.ReplaceAllStringFunc(sql, func(string) string {
+		return p.bind(from)
+	})
+	sql = timeToRegExp.ReplaceAllStringFunc(sql, func(string) string {
+		return p.bind(to)
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range variableRegExp.FindAllStringSubmatchIndex(sql, -1) {
+		match := sql[loc[0]:loc[1]]
+		name := submatch(sql, loc, 1) + submatch(sql, loc, 2) + submatch(sql, loc, 3)
+		values, exists := variables[name]
+		if !exists {
+			continue
+		}
+		if len(values) == 0 {
+			return "", fmt.Errorf("template variable %q has no values", name)
+		}
+
+		// a bind parameter within a string literal would be part of the string, as in 'prefix-$a'
+		quoted := strings.HasPrefix(match, "'") && strings.HasSuffix(match, "'") && len(match) > 1
+		outsideLiteral := strings.Count(sql[:loc[0]], "'")%2 == 0
+		if !outsideLiteral || (!quoted && (strings.HasPrefix(match, "'") || strings.HasSuffix(match, "'"))) {
+			return "", fmt.Errorf("template variable %q cannot be used within a string literal of a parameterized query",
+				name)
+		}
+
+		placeholders := make([]string, 0, len(values))
+		for _, v := range values {
+			placeholders = append(placeholders, p.bind(v))
+		}
+
+		b.WriteString(sql[last:loc[0]])
+		b.WriteString(strings.Join(placeholders, ", "))
+		last = loc[1]
+	}
+	b.WriteString(sql[last:])
+
+	return b.String(), nil
+}
+
+// submatch returns the n-th submatch of a match of FindAllStringSubmatchIndex, or "" if it didn't participate.
+func submatch(s string, loc []int, n int) string {
+	if loc[2*n] < 0 {
+		return ""
+	}
+	return s[loc[2*n]:loc[2*n+1]]
+}
+
+// finalize replaces the argument markers with the placeholders of the driver and returns the
+// arguments in the order they appear in the query.
+func (p *parameterizedQuery) finalize(sql string, format BindVarFormat) (string, []interface{}, error) {
+	args := make([]interface{}, 0, len(p.args))
+	var finalizeErr error
+
+	sql = paramMarkerRegExp.ReplaceAllStringFunc(sql, func(match string) string {
+		idx, err := strconv.Atoi(strings.Trim(match, paramMarker))
+		if err != nil || idx >= len(p.args) {
+			finalizeErr = fmt.Errorf("invalid query parameter reference")
+			return match
+		}
+		args = append(args, p.args[idx])
+		return format(len(args))
+	})
+	if finalizeErr != nil {
+		return "", nil, finalizeErr
+	}
+	if strings.Contains(sql, paramMarker) {
+		return "", nil, fmt.Errorf("template variables are not supported in macro arguments of parameterized queries")
+	}
+
+	return sql, args, nil
+}
+
+func parseVariables(j *simplejson.Json) (map[string][]string, error) {
+	m, err := j.Map()
+	if err != nil {
+		// no variables
+		return map[string][]string{}, nil
+	}
+
+	variables := make(map[string][]string, len(m))
+	for name, v := range m {
+		switch value := v.(type) {
+		case string:
+			variables[name] = []string{value}
+		case []interface{}:
+			values := make([]string, 0, len(value))
+			for _, item := range value {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("template variable %q must be a string or a list of strings", name)
+				}
+				values = append(values, s)
+			}
+			variables[name] = values
+		default:
+			return nil, fmt.Errorf("template variable %q must be a string or a list of strings", name)
+		}
+	}
+	return variables, nil
+}
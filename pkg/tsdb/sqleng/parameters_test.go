@@ -0,0 +1,105 @@
+package sqleng
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParameterizedQuery(t *testing.T) {
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := plugins.DataTimeRange{From: "5m", To: "now", Now: to}
+
+	newQuery := func(variables map[string]interface{}) plugins.DataSubQuery {
+		return plugins.DataSubQuery{
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"parameterized": true,
+				"variables":     variables,
+			}),
+		}
+	}
+
+	t.Run("binds time range macros", func(t *testing.T) {
+		p := &parameterizedQuery{}
+		sql, err := p.bindParameters(newQuery(nil), timeRange,
+			"SELECT * FROM t WHERE $__timeFilter(created) AND updated > $__timeFrom() AND updated < $__timeTo()")
+		require.NoError(t, err)
+
+		sql, args, err := p.finalize(sql, DollarBindVar)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM t WHERE created BETWEEN $1 AND $2 AND updated > $3 AND updated < $4", sql)
+		require.Equal(t, []interface{}{from, to, from, to}, args)
+	})
+
+	t.Run("binds single and multi-value variables", func(t *testing.T) {
+		p := &parameterizedQuery{}
+		query := newQuery(map[string]interface{}{
+			"host": []interface{}{"a", "b'; DROP TABLE t; --"},
+			"env":  "prod",
+		})
+		sql, err := p.bindParameters(query, timeRange,
+			"SELECT * FROM t WHERE env = '$env' AND host IN (${host}) AND region = [[env]] AND $unknown")
+		require.NoError(t, err)
+
+		sql, args, err := p.finalize(sql, QuestionMarkBindVar)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM t WHERE env = ? AND host IN (?, ?) AND region = ? AND $unknown", sql)
+		require.Equal(t, []interface{}{"prod", "a", "b'; DROP TABLE t; --", "prod"}, args)
+	})
+
+	t.Run("binds quoted variables next to string literals", func(t *testing.T) {
+		p := &parameterizedQuery{}
+		sql, err := p.bindParameters(newQuery(map[string]interface{}{"a": "x", "b": "y"}), timeRange,
+			"SELECT '$a' || '-' || '$b', 'it''s', '$unknown' FROM t")
+		require.NoError(t, err)
+
+		sql, args, err := p.finalize(sql, DollarBindVar)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT $1 || '-' || $2, 'it''s', '$unknown' FROM t", sql)
+		require.Equal(t, []interface{}{"x", "y"}, args)
+	})
+
+	t.Run("fails for a variable within a string literal", func(t *testing.T) {
+		for _, sql := range []string{
+			"SELECT * FROM t WHERE host = 'prefix-$a'",
+			"SELECT * FROM t WHERE host = '$a-suffix'",
+			"SELECT * FROM t WHERE host = 'prefix-${a}-suffix'",
+			"SELECT * FROM t WHERE host LIKE '%[[a]]%'",
+		} {
+			p := &parameterizedQuery{}
+			_, err := p.bindParameters(newQuery(map[string]interface{}{"a": "x"}), timeRange, sql)
+			require.Error(t, err, sql)
+			require.Contains(t, err.Error(), "within a string literal", sql)
+		}
+	})
+
+	t.Run("orders arguments by their position after macro expansion", func(t *testing.T) {
+		p := &parameterizedQuery{}
+		first := p.bind("first")
+		second := p.bind("second")
+
+		// a macro engine may move references around
+		sql, args, err := p.finalize("SELECT "+second+", "+first, AtPBindVar)
+		require.NoError(t, err)
+		require.Equal(t, "SELECT @p1, @p2", sql)
+		require.Equal(t, []interface{}{"second", "first"}, args)
+	})
+
+	t.Run("fails for a variable without values", func(t *testing.T) {
+		p := &parameterizedQuery{}
+		_, err := p.bindParameters(newQuery(map[string]interface{}{"host": []interface{}{}}), timeRange,
+			"SELECT * FROM t WHERE host IN ($host)")
+		require.Error(t, err)
+	})
+
+	t.Run("fails for a variable with invalid values", func(t *testing.T) {
+		p := &parameterizedQuery{}
+		_, err := p.bindParameters(newQuery(map[string]interface{}{"host": 1}), timeRange,
+			"SELECT * FROM t WHERE host = $host")
+		require.Error(t, err)
+	})
+}
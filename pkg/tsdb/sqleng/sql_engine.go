@@ -70,6 +70,7 @@ type dataPlugin struct {
 	engine                 *xorm.Engine
 	timeColumnNames        []string
 	metricColumnTypes      []string
+	bindVarFormat          BindVarFormat
 	log                    log.Logger
 }
 
@@ -79,6 +80,8 @@ type DataPluginConfiguration struct {
 	ConnectionString  string
 	TimeColumnNames   []string
 	MetricColumnTypes []string
+	// BindVarFormat enables parameterized queries, see isParameterized.
+	BindVarFormat BindVarFormat
 }
 
 // NewDataPlugin returns a new plugins.DataPlugin
@@ -88,6 +91,7 @@ func NewDataPlugin(config DataPluginConfiguration, queryResultTransformer SqlQue
 		queryResultTransformer: queryResultTransformer,
 		macroEngine:            macroEngine,
 		timeColumnNames:        []string{"time"},
+		bindVarFormat:          config.BindVarFormat,
 		log:                    log,
 	}
 
@@ -160,6 +164,25 @@ func (e *dataPlugin) DataQuery(ctx context.Context, dsInfo *models.DataSource,
 				panic("Query model property rawSql should not be empty at this point")
 			}
 
+			// bind template variables and time range as parameters
+			var params *parameterizedQuery
+			if isParameterized(query) {
+				if e.bindVarFormat == nil {
+					queryResult.Error = fmt.Errorf("parameterized queries are not supported by this data source")
+					ch <- queryResult
+					return
+				}
+
+				params = &parameterizedQuery{}
+				var err error
+				rawSQL, err = params.bindParameters(query, timeRange, rawSQL)
+				if err != nil {
+					queryResult.Error = err
+					ch <- queryResult
+					return
+				}
+			}
+
 			// global substitutions
 			rawSQL, err := Interpolate(query, timeRange, rawSQL)
 			if err != nil {
@@ -176,15 +199,26 @@ func (e *dataPlugin) DataQuery(ctx context.Context, dsInfo *models.DataSource,
 				return
 			}
 
+			var args []interface{}
+			if params != nil {
+				rawSQL, args, err = params.finalize(rawSQL, e.bindVarFormat)
+				if err != nil {
+					queryResult.Error = err
+					ch <- queryResult
+					return
+				}
+			}
+
 			queryResult.Meta.Set(MetaKeyExecutedQueryString, rawSQL)
 
 			session := e.engine.NewSession()
 			defer session.Close()
 			db := session.DB()
 
-			rows, err := db.Query(rawSQL)
+			rows, err := db.Query(rawSQL, args...)
 			if err != nil {
 				queryResult.Error = e.queryResultTransformer.TransformQueryError(err)
+				ch <- queryResult
 				return
 			}
 			defer func() {
@@ -195,17 +229,27 @@ func (e *dataPlugin) DataQuery(ctx context.Context, dsInfo *models.DataSource,
 
 			format := query.Model.Get("format").MustString("time_series")
 
+			if useDataFrames(query) {
+				if err := e.transformToDataFrame(query, rows, &queryResult, queryContext, format); err != nil {
+					queryResult.Error = err
+				}
+				ch <- queryResult
+				return
+			}
+
 			switch format {
 			case "time_series":
 				err := e.transformToTimeSeries(query, rows, &queryResult, queryContext)
 				if err != nil {
 					queryResult.Error = err
+					ch <- queryResult
 					return
 				}
 			case "table":
 				err := e.transformToTable(query, rows, &queryResult, queryContext)
 				if err != nil {
 					queryResult.Error = err
+					ch <- queryResult
 					return
 				}
 			}
@@ -300,6 +344,9 @@ func (e *dataPlugin) transformToTable(query plugins.DataSubQuery, rows *core.Row
 		ConvertSqlTimeColumnToEpochMs(values, timeEndIndex)
 		table.Rows = append(table.Rows, values)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
 	result.Tables = append(result.Tables, table)
 	result.Meta.Set("rowCount", rowCount)
@@ -393,6 +440,9 @@ func (e *dataPlugin) transformToTimeSeries(query plugins.DataSubQuery, rows *cor
 			return err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
 	for elem := cfg.seriesByQueryOrder.Front(); elem != nil; elem = elem.Next() {
 		key := elem.Value.(string)
@@ -0,0 +1,197 @@
+// Package sqlite implements a backend-only data source for SQLite database files. It has no plugin.json
+// or frontend, so it is not listed in the UI: data sources of type "sqlite" are created through the data
+// source HTTP API and queried through /api/ds/query or alerting.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/grafana/grafana/pkg/util"
+
+	"github.com/mattn/go-sqlite3"
+	"xorm.io/core"
+)
+
+// driverName is the name of the sqlite3 driver of the data source, which does not allow attaching databases.
+const driverName = "sqlite3_datasource"
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         "SQLiteService",
+		InitPriority: registry.Low,
+		Instance:     &SQLiteService{},
+	})
+
+	// ATTACH DATABASE would open any file the server can read, bypassing the checks of the database path
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.SetLimit(sqlite3.SQLITE_LIMIT_ATTACHED, 0)
+			return nil
+		},
+	})
+	core.RegisterDriver(driverName, &sqliteDriver{})
+}
+
+// SQLiteService creates the executors of the SQLite data source. The database files are restricted to the
+// allowed paths of the files data source, and the data directory and database of Grafana are never opened.
+type SQLiteService struct {
+	Cfg    *setting.Cfg `inject:""`
+	logger log.Logger
+}
+
+func (s *SQLiteService) Init() error {
+	s.logger = log.New("tsdb.sqlite")
+	return nil
+}
+
+// NewExecutor returns an executor for SQLite database files. The database is always opened read-only.
+func (s *SQLiteService) NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
+	cnnstr, err := s.generateConnectionString(datasource)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Cfg.Env == setting.Dev {
+		s.logger.Debug("getEngine", "connection", cnnstr)
+	}
+
+	config := sqleng.DataPluginConfiguration{
+		DriverName:        driverName,
+		BindVarFormat:     sqleng.QuestionMarkBindVar,
+		ConnectionString:  cnnstr,
+		Datasource:        datasource,
+		MetricColumnTypes: []string{"TEXT", "VARCHAR", "CHAR", "NVARCHAR", "NCHAR", "CLOB"},
+	}
+
+	queryResultTransformer := sqliteQueryResultTransformer{
+		log: s.logger,
+	}
+
+	return sqleng.NewDataPlugin(config, &queryResultTransformer, newSqliteMacroEngine(), s.logger)
+}
+
+// generateConnectionString returns a read-only DSN for the database file, which is taken from
+// the path setting and falls back to the data source URL.
+func (s *SQLiteService) generateConnectionString(datasource *models.DataSource) (string, error) {
+	path := datasource.Url
+	if datasource.JsonData != nil {
+		path = datasource.JsonData.Get("path").MustString(path)
+	}
+	path = strings.TrimPrefix(path, "file:")
+	if path == "" {
+		return "", errors.New("missing path to SQLite database file")
+	}
+
+	resolved, err := s.resolveDatabasePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	busyTimeout := 5000
+	if datasource.JsonData != nil {
+		busyTimeout = datasource.JsonData.Get("busyTimeout").MustInt(busyTimeout)
+	}
+
+	params := url.Values{}
+	params.Set("mode", "ro")
+	params.Set("_busy_timeout", fmt.Sprintf("%d", busyTimeout))
+
+	return fmt.Sprintf("file:%s?%s", resolved, params.Encode()), nil
+}
+
+// resolveDatabasePath resolves the symbolic links of the path of a database file and checks that it is within the
+// allowed paths, and not within the data directory of Grafana or its database.
+func (s *SQLiteService) resolveDatabasePath(path string) (string, error) {
+	if len(s.Cfg.FileDataSourceAllowedPaths) == 0 {
+		return "", errors.New("SQLite database files are disabled, no allowed paths are configured")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("SQLite database path %q must be absolute", path)
+	}
+
+	resolved, err := util.ResolvePathWithin(path, s.Cfg.FileDataSourceAllowedPaths)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("SQLite database file %q does not exist", path)
+		}
+		if errors.Is(err, util.ErrPathNotAllowed) {
+			return "", fmt.Errorf("SQLite database path %q is not within the allowed paths", path)
+		}
+		return "", err
+	}
+
+	for _, denied := range s.deniedPaths() {
+		if util.IsPathWithin(resolved, denied) {
+			return "", fmt.Errorf("SQLite database path %q is not allowed", path)
+		}
+	}
+	return resolved, nil
+}
+
+// deniedPaths returns the data directory and the database file of Grafana, which may be within an allowed path.
+func (s *SQLiteService) deniedPaths() []string {
+	denied := []string{s.Cfg.DataPath}
+	if s.Cfg.Raw != nil {
+		// the database path is relative to the data directory, as in the sqlstore
+		dbPath := s.Cfg.Raw.Section("database").Key("path").MustString("data/grafana.db")
+		if !filepath.IsAbs(dbPath) {
+			dbPath = filepath.Join(s.Cfg.DataPath, dbPath)
+		}
+		denied = append(denied, dbPath)
+	}
+	return denied
+}
+
+// sqliteDriver satisfies the xorm.io/core.Driver interface, parsing the connection strings as the sqlite3 driver.
+type sqliteDriver struct{}
+
+func (d *sqliteDriver) Parse(driverName, dataSourceName string) (*core.Uri, error) {
+	driver := core.QueryDriver("sqlite3")
+	if driver == nil {
+		return nil, errors.New("could not find driver with name sqlite3")
+	}
+	return driver.Parse(driverName, dataSourceName)
+}
+
+type sqliteQueryResultTransformer struct {
+	log log.Logger
+}
+
+func (t *sqliteQueryResultTransformer) TransformQueryResult(columnTypes []*sql.ColumnType, rows *core.Rows) (
+	plugins.DataRowValues, error) {
+	values := make([]interface{}, len(columnTypes))
+	valuePtrs := make([]interface{}, len(columnTypes))
+
+	for i := range columnTypes {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+
+	// text affinity may be returned as []byte
+	for i := range values {
+		if value, ok := values[i].([]byte); ok {
+			values[i] = string(value)
+		}
+	}
+
+	return values, nil
+}
+
+func (t *sqliteQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
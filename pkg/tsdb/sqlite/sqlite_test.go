@@ -0,0 +1,211 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE metric (time TEXT, host TEXT, value REAL)`)
+	require.NoError(t, err)
+
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	for i, host := range []string{"a", "b", "a", "b"} {
+		_, err = db.Exec(`INSERT INTO metric VALUES (?, ?, ?)`,
+			from.Add(time.Duration(i/2)*time.Minute).Format(time.RFC3339), host, float64(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	dsInfo := &models.DataSource{
+		Id:       1,
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"path": path}),
+	}
+	timeRange := plugins.DataTimeRange{From: "5m", To: "now", Now: from.Add(5 * time.Minute)}
+
+	service := newTestService(t, dir)
+	exe, err := service.NewExecutor(dsInfo)
+	require.NoError(t, err)
+
+	query := func(model map[string]interface{}) plugins.DataQueryResult {
+		t.Helper()
+		res, err := exe.DataQuery(context.Background(), dsInfo, plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", DataSource: dsInfo, Model: simplejson.NewFromAny(model)},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, res.Results["A"].Error)
+		return res.Results["A"]
+	}
+
+	t.Run("time series query with macros", func(t *testing.T) {
+		res := query(map[string]interface{}{
+			"format": "time_series",
+			"rawSql": "SELECT $__timeGroupAlias(time, '1m'), host AS metric, SUM(value) AS value FROM metric " +
+				"WHERE $__timeFilter(time) GROUP BY 1, 2 ORDER BY 1",
+		})
+		require.Len(t, res.Series, 2)
+		require.Equal(t, "a", res.Series[0].Name)
+		require.Len(t, res.Series[0].Points, 2)
+		require.Equal(t, 2.0, res.Series[0].Points[1][0].Float64)
+		require.Equal(t, float64(from.Add(time.Minute).Unix()*1000), res.Series[0].Points[1][1].Float64)
+	})
+
+	t.Run("parameterized query returning data frames", func(t *testing.T) {
+		res := query(map[string]interface{}{
+			"format":        "time_series",
+			"parameterized": true,
+			"dataFrames":    true,
+			"variables":     map[string]interface{}{"host": []interface{}{"b"}},
+			"rawSql":        "SELECT $__timeEpoch(time), host, value FROM metric WHERE host IN ($host) ORDER BY 1",
+		})
+		frames, err := res.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, 2, frames[0].Rows())
+		require.Equal(t, "b", frames[0].Fields[1].Labels["host"])
+		require.Equal(t, 3.0, *frames[0].Fields[1].At(1).(*float64))
+	})
+
+	t.Run("database is opened read-only", func(t *testing.T) {
+		res, err := exe.DataQuery(context.Background(), dsInfo, plugins.DataQuery{
+			TimeRange: &timeRange,
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", DataSource: dsInfo, Model: simplejson.NewFromAny(map[string]interface{}{
+					"format": "table",
+					"rawSql": "DELETE FROM metric",
+				})},
+			},
+		})
+		require.NoError(t, err)
+		require.Error(t, res.Results["A"].Error)
+		require.Contains(t, res.Results["A"].Error.Error(), "readonly")
+	})
+
+	t.Run("the database of Grafana cannot be attached", func(t *testing.T) {
+		grafanaDB := filepath.Join(service.Cfg.DataPath, "grafana.db")
+		db, err := sql.Open("sqlite3", grafanaDB)
+		require.NoError(t, err)
+		_, err = db.Exec(`CREATE TABLE user (login TEXT, password TEXT)`)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		for _, rawSQL := range []string{
+			"ATTACH DATABASE '" + grafanaDB + "' AS g",
+			"ATTACH DATABASE '" + grafanaDB + "' AS g; SELECT * FROM g.user",
+		} {
+			res, err := exe.DataQuery(context.Background(), dsInfo, plugins.DataQuery{
+				TimeRange: &timeRange,
+				Queries: []plugins.DataSubQuery{
+					{RefID: "A", DataSource: dsInfo, Model: simplejson.NewFromAny(map[string]interface{}{
+						"format": "table",
+						"rawSql": rawSQL,
+					})},
+				},
+			})
+			require.NoError(t, err)
+			require.Error(t, res.Results["A"].Error, rawSQL)
+			require.Empty(t, res.Results["A"].Tables, rawSQL)
+		}
+	})
+}
+
+func TestSQLiteDatabasePath(t *testing.T) {
+	allowed := t.TempDir()
+	dataPath := filepath.Join(allowed, "data")
+	require.NoError(t, os.MkdirAll(dataPath, 0750))
+	outside := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(allowed, "metrics.db"),
+		filepath.Join(dataPath, "grafana.db"),
+		filepath.Join(outside, "secret.db"),
+	} {
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+	}
+	service := newTestService(t, allowed)
+	service.Cfg.DataPath = dataPath
+
+	newExecutor := func(path string) error {
+		_, err := service.NewExecutor(&models.DataSource{
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"path": path}),
+		})
+		return err
+	}
+
+	t.Run("a database within the allowed paths is opened", func(t *testing.T) {
+		require.NoError(t, newExecutor(filepath.Join(allowed, "metrics.db")))
+	})
+
+	t.Run("a database outside of the allowed paths is denied", func(t *testing.T) {
+		err := newExecutor(filepath.Join(outside, "secret.db"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not within the allowed paths")
+	})
+
+	t.Run("a relative path escaping the allowed paths is denied", func(t *testing.T) {
+		rel, err := filepath.Rel(allowed, filepath.Join(outside, "secret.db"))
+		require.NoError(t, err)
+		err = newExecutor(filepath.Join(allowed, rel))
+		require.Error(t, err)
+	})
+
+	t.Run("a symlink to a database outside of the allowed paths is denied", func(t *testing.T) {
+		link := filepath.Join(allowed, "link.db")
+		require.NoError(t, os.Symlink(filepath.Join(outside, "secret.db"), link))
+		err := newExecutor(link)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not within the allowed paths")
+	})
+
+	t.Run("the database of Grafana is denied even within the allowed paths", func(t *testing.T) {
+		err := newExecutor(filepath.Join(dataPath, "grafana.db"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not allowed")
+	})
+
+	t.Run("a configured database path outside of the data path is denied", func(t *testing.T) {
+		service := newTestService(t, allowed)
+		_, err := service.Cfg.Raw.Section("database").NewKey("path", filepath.Join(allowed, "metrics.db"))
+		require.NoError(t, err)
+		_, err = service.NewExecutor(&models.DataSource{
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"path": filepath.Join(allowed, "metrics.db")}),
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not allowed")
+	})
+
+	t.Run("databases are denied when no allowed paths are configured", func(t *testing.T) {
+		service := newTestService(t)
+		_, err := service.NewExecutor(&models.DataSource{
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"path": filepath.Join(allowed, "metrics.db")}),
+		})
+		require.Error(t, err)
+	})
+}
+
+func newTestService(t *testing.T, allowedPaths ...string) *SQLiteService {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.DataPath = t.TempDir()
+	cfg.FileDataSourceAllowedPaths = allowedPaths
+	service := &SQLiteService{Cfg: cfg}
+	require.NoError(t, service.Init())
+	return service
+}
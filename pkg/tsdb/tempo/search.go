@@ -0,0 +1,183 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+const defaultSearchLimit = 20
+
+type searchResponse struct {
+	Traces []searchTrace `json:"traces"`
+}
+
+type searchTrace struct {
+	TraceID           string      `json:"traceID"`
+	RootServiceName   string      `json:"rootServiceName"`
+	RootTraceName     string      `json:"rootTraceName"`
+	StartTimeUnixNano json.Number `json:"startTimeUnixNano"`
+	DurationMs        float64     `json:"durationMs"`
+}
+
+// searchQuery finds traces matching the service name, span name and tags of the query within
+// the time range, using the Tempo search API. The result is a frame with a row per trace.
+func (e *tempoExecutor) searchQuery(ctx context.Context, dsInfo *models.DataSource, query plugins.DataSubQuery,
+	timeRange *plugins.DataTimeRange) (plugins.DataQueryResult, error) {
+	params, err := searchParams(query, timeRange)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dsInfo.Url+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+	if dsInfo.BasicAuth {
+		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+	}
+	req.Header.Set("Accept", "application/json")
+
+	tlog.Debug("Tempo search request", "url", req.URL.String())
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return plugins.DataQueryResult{}, fmt.Errorf("failed get to tempo: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			tlog.Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return plugins.DataQueryResult{
+			ErrorString: fmt.Sprintf("failed to search traces Status: %s Body: %s", resp.Status, string(body)),
+		}, nil
+	}
+
+	var res searchResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return plugins.DataQueryResult{}, fmt.Errorf("failed to parse tempo search response: %w", err)
+	}
+
+	frame, err := searchFrame(res.Traces)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+	frame.RefID = query.RefID
+
+	return plugins.DataQueryResult{
+		Dataframes: plugins.NewDecodedDataFrames(data.Frames{frame}),
+	}, nil
+}
+
+// searchParams returns the parameters of the Tempo search API. The service and span name are
+// added to the tags of the "search" field, which are in logfmt, e.g. http.status_code=500.
+// Durations are passed as is, e.g. 100ms, and the time range is in seconds.
+func searchParams(query plugins.DataSubQuery, timeRange *plugins.DataTimeRange) (url.Values, error) {
+	var tags []string
+	if serviceName := query.Model.Get("serviceName").MustString(""); serviceName != "" {
+		tags = append(tags, logfmtPair("service.name", serviceName))
+	}
+	if spanName := query.Model.Get("spanName").MustString(""); spanName != "" {
+		tags = append(tags, logfmtPair("name", spanName))
+	}
+	if search := strings.TrimSpace(query.Model.Get("search").MustString("")); search != "" {
+		tags = append(tags, search)
+	}
+
+	params := url.Values{}
+	if len(tags) > 0 {
+		params.Set("tags", strings.Join(tags, " "))
+	}
+
+	for _, key := range []string{"minDuration", "maxDuration"} {
+		duration := query.Model.Get(key).MustString("")
+		if duration == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(duration); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", key, duration, err)
+		}
+		params.Set(key, duration)
+	}
+
+	limit := query.Model.Get("limit").MustInt(defaultSearchLimit)
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	params.Set("limit", strconv.Itoa(limit))
+
+	if timeRange != nil {
+		params.Set("start", strconv.FormatInt(timeRange.GetFromAsSecondsEpoch(), 10))
+		params.Set("end", strconv.FormatInt(timeRange.GetToAsSecondsEpoch(), 10))
+	}
+
+	return params, nil
+}
+
+func logfmtPair(key, value string) string {
+	if strings.ContainsAny(value, " =\"") {
+		value = strconv.Quote(value)
+	}
+	return key + "=" + value
+}
+
+func searchFrame(traces []searchTrace) (*data.Frame, error) {
+	startTimes := make([]time.Time, 0, len(traces))
+	for _, trace := range traces {
+		startTime, err := trace.StartTimeUnixNano.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid start time of trace %s: %w", trace.TraceID, err)
+		}
+		startTimes = append(startTimes, time.Unix(0, startTime).UTC())
+	}
+
+	// most recent traces first
+	order := make([]int, len(traces))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return startTimes[order[i]].After(startTimes[order[j]])
+	})
+
+	traceIDs := make([]string, 0, len(traces))
+	traceNames := make([]string, 0, len(traces))
+	sortedStartTimes := make([]time.Time, 0, len(traces))
+	durations := make([]float64, 0, len(traces))
+	for _, i := range order {
+		traceIDs = append(traceIDs, traces[i].TraceID)
+		traceNames = append(traceNames, traces[i].RootServiceName+": "+traces[i].RootTraceName)
+		sortedStartTimes = append(sortedStartTimes, startTimes[i])
+		durations = append(durations, traces[i].DurationMs)
+	}
+
+	durationField := data.NewField("duration", nil, durations)
+	durationField.Config = &data.FieldConfig{Unit: "ms"}
+
+	frame := data.NewFrame("Traces",
+		data.NewField("traceID", nil, traceIDs),
+		data.NewField("traceName", nil, traceNames),
+		data.NewField("startTime", nil, sortedStartTimes),
+		durationField,
+	)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	return frame, nil
+}
@@ -39,20 +39,58 @@ var (
 	tlog = log.New("tsdb.tempo")
 )
 
+const (
+	traceIDQueryType = "traceId"
+	searchQueryType  = "search"
+	uploadQueryType  = "upload"
+)
+
 func (e *tempoExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
 	queryContext plugins.DataQuery) (plugins.DataResponse, error) {
-	refID := queryContext.Queries[0].RefID
+	result := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult, len(queryContext.Queries)),
+	}
+
+	for _, query := range queryContext.Queries {
+		var queryResult plugins.DataQueryResult
+		var err error
+
+		queryType := query.Model.Get("queryType").MustString(traceIDQueryType)
+		switch queryType {
+		case traceIDQueryType:
+			queryResult, err = e.traceIDQuery(ctx, dsInfo, query)
+		case searchQueryType:
+			queryResult, err = e.searchQuery(ctx, dsInfo, query, queryContext.TimeRange)
+		case uploadQueryType:
+			queryResult, err = uploadQuery(query)
+		default:
+			err = fmt.Errorf("unsupported query type %q", queryType)
+		}
+		if err != nil {
+			return plugins.DataResponse{}, err
+		}
+
+		queryResult.RefID = query.RefID
+		result.Results[query.RefID] = queryResult
+	}
+
+	return result, nil
+}
+
+// traceIDQuery fetches the trace with the ID in the query from Tempo.
+func (e *tempoExecutor) traceIDQuery(ctx context.Context, dsInfo *models.DataSource,
+	query plugins.DataSubQuery) (plugins.DataQueryResult, error) {
 	queryResult := plugins.DataQueryResult{}
-	traceID := queryContext.Queries[0].Model.Get("query").MustString("")
+	traceID := query.Model.Get("query").MustString("")
 
 	req, err := e.createRequest(ctx, dsInfo, traceID)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return plugins.DataQueryResult{}, err
 	}
 
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed get to tempo: %w", err)
+		return plugins.DataQueryResult{}, fmt.Errorf("failed get to tempo: %w", err)
 	}
 
 	defer func() {
@@ -63,27 +101,34 @@ func (e *tempoExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return plugins.DataQueryResult{}, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		queryResult.ErrorString = fmt.Sprintf("failed to get trace with id: %s Status: %s Body: %s", traceID, resp.Status, string(body))
-		return plugins.DataResponse{
-			Results: map[string]plugins.DataQueryResult{
-				refID: queryResult,
-			},
-		}, nil
+		return queryResult, nil
 	}
 
 	otTrace := ot_pdata.NewTraces()
 	err = otTrace.FromOtlpProtoBytes(body)
 	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
+		return plugins.DataQueryResult{}, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
 	}
 
+	traceBytes, err := tracesToJSON(otTrace)
+	if err != nil {
+		return plugins.DataQueryResult{}, fmt.Errorf("failed to convert trace %q: %w", traceID, err)
+	}
+
+	queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{traceFrame(query.RefID, traceBytes)})
+	return queryResult, nil
+}
+
+// tracesToJSON converts OTLP traces to the Jaeger JSON format of trace frames.
+func tracesToJSON(otTrace ot_pdata.Traces) ([]byte, error) {
 	jaegerBatches, err := ot_jaeger.InternalTracesToJaegerProto(otTrace)
 	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed to translate to jaegerBatches %v: %w", traceID, err)
+		return nil, fmt.Errorf("failed to translate to jaegerBatches: %w", err)
 	}
 
 	jaegerTrace := &jaeger.Trace{
@@ -105,21 +150,16 @@ func (e *tempoExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource
 	}
 	jsonTrace := jaeger_json.FromDomain(jaegerTrace)
 
-	traceBytes, err := json.Marshal(jsonTrace)
-	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed to json.Marshal trace \"%s\" :%w", traceID, err)
-	}
+	return json.Marshal(jsonTrace)
+}
 
-	frames := []*data.Frame{
-		{Name: "Traces", RefID: refID, Fields: []*data.Field{data.NewField("trace", nil, []string{string(traceBytes)})}},
+// traceFrame returns the frame holding traces in the Jaeger JSON format, one trace per row.
+func traceFrame(refID string, traces ...[]byte) *data.Frame {
+	values := make([]string, 0, len(traces))
+	for _, trace := range traces {
+		values = append(values, string(trace))
 	}
-	queryResult.Dataframes = plugins.NewDecodedDataFrames(frames)
-
-	return plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{
-			refID: queryResult,
-		},
-	}, nil
+	return &data.Frame{Name: "Traces", RefID: refID, Fields: []*data.Field{data.NewField("trace", nil, values)}}
 }
 
 func (e *tempoExecutor) createRequest(ctx context.Context, dsInfo *models.DataSource, traceID string) (*http.Request, error) {
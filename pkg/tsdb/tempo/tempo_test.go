@@ -2,9 +2,17 @@ package tempo
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,3 +29,133 @@ func TestTempo(t *testing.T) {
 		assert.NotEqual(t, req.Header.Get("Authorization"), "")
 	})
 }
+
+func TestSearchQuery(t *testing.T) {
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/api/search", req.URL.Path)
+		params = req.URL.Query()
+		_, err := rw.Write([]byte(`{"traces":[` +
+			`{"traceID":"1","rootServiceName":"api","rootTraceName":"GET /","startTimeUnixNano":"1600000000000000000","durationMs":12},` +
+			`{"traceID":"2","rootServiceName":"db","rootTraceName":"query","startTimeUnixNano":"1600000060000000000","durationMs":3}]}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	plug, err := NewExecutor(&models.DataSource{Url: server.URL})
+	require.NoError(t, err)
+
+	timeRange := plugins.NewDataTimeRange("1600000000000", "1600000600000")
+	res, err := plug.DataQuery(context.Background(), &models.DataSource{Url: server.URL}, plugins.DataQuery{
+		TimeRange: &timeRange,
+		Queries: []plugins.DataSubQuery{{
+			RefID: "A",
+			Model: simplejson.NewFromAny(map[string]interface{}{
+				"queryType":   "search",
+				"serviceName": "api",
+				"spanName":    "GET /",
+				"search":      "http.status_code=500",
+				"minDuration": "10ms",
+				"limit":       5,
+			}),
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `service.name=api name="GET /" http.status_code=500`, params.Get("tags"))
+	assert.Equal(t, "10ms", params.Get("minDuration"))
+	assert.Equal(t, "", params.Get("maxDuration"))
+	assert.Equal(t, "5", params.Get("limit"))
+	assert.Equal(t, "1600000000", params.Get("start"))
+	assert.Equal(t, "1600000600", params.Get("end"))
+
+	frames, err := res.Results["A"].Dataframes.Decoded()
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Equal(t, 2, frames[0].Rows())
+	assert.Equal(t, "2", frames[0].Fields[0].At(0))
+	assert.Equal(t, "db: query", frames[0].Fields[1].At(0))
+	assert.Equal(t, time.Unix(1600000060, 0).UTC(), frames[0].Fields[2].At(0))
+	assert.Equal(t, 3.0, frames[0].Fields[3].At(0))
+
+	t.Run("invalid durations fail", func(t *testing.T) {
+		_, err := searchParams(plugins.DataSubQuery{
+			Model: simplejson.NewFromAny(map[string]interface{}{"maxDuration": "soon"}),
+		}, &timeRange)
+		require.Error(t, err)
+	})
+}
+
+func TestUploadQuery(t *testing.T) {
+	upload := func(t *testing.T, file string) []map[string]interface{} {
+		res, err := uploadQuery(plugins.DataSubQuery{
+			RefID: "A",
+			Model: simplejson.NewFromAny(map[string]interface{}{"queryType": "upload", "json": file}),
+		})
+		require.NoError(t, err)
+		frames, err := res.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+
+		traces := make([]map[string]interface{}, 0, frames[0].Rows())
+		for i := 0; i < frames[0].Rows(); i++ {
+			var trace map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(frames[0].Fields[0].At(i).(string)), &trace))
+			traces = append(traces, trace)
+		}
+		return traces
+	}
+	serviceNames := func(trace map[string]interface{}) []string {
+		var names []string
+		for _, p := range trace["processes"].(map[string]interface{}) {
+			names = append(names, p.(map[string]interface{})["serviceName"].(string))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("OTLP", func(t *testing.T) {
+		traces := upload(t, `{"resourceSpans":[{
+			"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},
+			"instrumentationLibrarySpans":[{"spans":[
+				{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","name":"GET /",
+				 "kind":"SPAN_KIND_SERVER","startTimeUnixNano":"1600000000000000000","endTimeUnixNano":"1600000001000000000",
+				 "attributes":[{"key":"http.status_code","value":{"intValue":"500"}}],"status":{"code":2}},
+				{"traceId":"W47/95gDgQPSabYzgT/GDA==","spanId":"7u8Zi+CwsXU=","parentSpanId":"eee19b7ec3c1b174",
+				 "name":"child","kind":3,"startTimeUnixNano":1600000000100000000,"endTimeUnixNano":1600000000200000000}
+			]}]}]}`)
+		require.Len(t, traces, 1)
+		assert.Equal(t, "5b8efff798038103d269b633813fc60c", traces[0]["traceID"])
+		assert.Len(t, traces[0]["spans"], 2)
+		assert.Equal(t, []string{"api"}, serviceNames(traces[0]))
+	})
+
+	t.Run("Zipkin", func(t *testing.T) {
+		traces := upload(t, `[
+			{"traceId":"463ac35c9f6413ad","id":"a2fb4a1d1a96d312","name":"get","kind":"SERVER",
+			 "timestamp":1600000000000000,"duration":2000,"localEndpoint":{"serviceName":"frontend"},"tags":{"http.path":"/"}},
+			{"traceId":"463ac35c9f6413ad","id":"b2fb4a1d1a96d312","parentId":"a2fb4a1d1a96d312","name":"query",
+			 "timestamp":1600000000000500,"duration":1000,"localEndpoint":{"serviceName":"db"}},
+			{"traceId":"563ac35c9f6413ad","id":"c2fb4a1d1a96d312","name":"other","timestamp":1600000000000000,"duration":10}
+		]`)
+		require.Len(t, traces, 2)
+		assert.Equal(t, "463ac35c9f6413ad", traces[0]["traceID"])
+		assert.Len(t, traces[0]["spans"], 2)
+		assert.Equal(t, []string{"db", "frontend"}, serviceNames(traces[0]))
+	})
+
+	t.Run("Jaeger", func(t *testing.T) {
+		traces := upload(t, `{"data":[{"traceID":"abc","spans":[
+			{"traceID":"abc","spanID":"def","operationName":"op","startTime":1600000000000000,"duration":10,"processID":"p1"}],
+			"processes":{"p1":{"serviceName":"svc","tags":[]}}}]}`)
+		require.Len(t, traces, 1)
+		assert.Equal(t, "abc", traces[0]["traceID"])
+		assert.Equal(t, []string{"svc"}, serviceNames(traces[0]))
+	})
+
+	t.Run("unknown formats fail", func(t *testing.T) {
+		_, err := uploadQuery(plugins.DataSubQuery{
+			Model: simplejson.NewFromAny(map[string]interface{}{"json": `{"foo":"bar"}`}),
+		})
+		require.Error(t, err)
+	})
+}
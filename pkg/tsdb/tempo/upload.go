@@ -0,0 +1,499 @@
+package tempo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/plugins"
+
+	jaeger_json_model "github.com/jaegertracing/jaeger/model/json"
+
+	ot_pdata "go.opentelemetry.io/collector/consumer/pdata"
+)
+
+// uploadQuery converts the trace file in the "json" field of the query to trace frames, without
+// querying Tempo. Jaeger, Zipkin and OTLP JSON files are supported. Each trace of the file is
+// returned as a row of the frame.
+func uploadQuery(query plugins.DataSubQuery) (plugins.DataQueryResult, error) {
+	content, err := uploadedContent(query)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+
+	traces, err := parseTraceFile(content)
+	if err != nil {
+		return plugins.DataQueryResult{}, err
+	}
+
+	return plugins.DataQueryResult{
+		Dataframes: plugins.NewDecodedDataFrames(data.Frames{traceFrame(query.RefID, traces...)}),
+	}, nil
+}
+
+// uploadedContent returns the file content, which the query editor passes either as a string or
+// as the parsed JSON document.
+func uploadedContent(query plugins.DataSubQuery) ([]byte, error) {
+	file, ok := query.Model.CheckGet("json")
+	if !ok {
+		return nil, fmt.Errorf("upload query is missing the trace file")
+	}
+	if s, err := file.String(); err == nil {
+		return []byte(s), nil
+	}
+	return file.MarshalJSON()
+}
+
+// parseTraceFile returns the traces of the file in the Jaeger JSON format.
+func parseTraceFile(content []byte) ([][]byte, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, fmt.Errorf("trace file is empty")
+	}
+
+	// Zipkin files are a list of spans, or a list of traces as returned by /api/v2/traces
+	if content[0] == '[' {
+		return parseZipkin(content)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse trace file: %w", err)
+	}
+
+	_, hasResourceSpans := doc["resourceSpans"]
+	_, hasBatches := doc["batches"]
+	_, hasData := doc["data"]
+	_, hasSpans := doc["spans"]
+	switch {
+	case hasResourceSpans || hasBatches:
+		return parseOTLP(content)
+	case hasData || hasSpans:
+		return parseJaeger(content, hasData)
+	default:
+		return nil, fmt.Errorf("unsupported trace file format, expected Jaeger, Zipkin or OTLP JSON")
+	}
+}
+
+func parseJaeger(content []byte, hasData bool) ([][]byte, error) {
+	var traces []jaeger_json_model.Trace
+	if hasData {
+		// export of the Jaeger UI or response of the Jaeger query API
+		var doc struct {
+			Data []jaeger_json_model.Trace `json:"data"`
+		}
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse Jaeger trace file: %w", err)
+		}
+		traces = doc.Data
+	} else {
+		var trace jaeger_json_model.Trace
+		if err := json.Unmarshal(content, &trace); err != nil {
+			return nil, fmt.Errorf("failed to parse Jaeger trace file: %w", err)
+		}
+		traces = []jaeger_json_model.Trace{trace}
+	}
+
+	if len(traces) == 0 {
+		return nil, fmt.Errorf("trace file contains no traces")
+	}
+
+	result := make([][]byte, 0, len(traces))
+	for _, trace := range traces {
+		traceBytes, err := json.Marshal(trace)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, traceBytes)
+	}
+	return result, nil
+}
+
+type otlpFile struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	// Tempo returns OTLP traces as batches
+	Batches []otlpResourceSpans `json:"batches"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	InstrumentationLibrarySpans []otlpScopeSpans `json:"instrumentationLibrarySpans"`
+	ScopeSpans                  []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId"`
+	Name              string          `json:"name"`
+	Kind              json.RawMessage `json:"kind"`
+	StartTimeUnixNano json.Number     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   json.Number     `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue  `json:"attributes"`
+	Events            []otlpEvent     `json:"events"`
+	Status            *otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano json.Number    `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes"`
+}
+
+type otlpStatus struct {
+	Code    json.RawMessage `json:"code"`
+	Message string          `json:"message"`
+}
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string         `json:"stringValue"`
+		BoolValue   *bool           `json:"boolValue"`
+		IntValue    *json.Number    `json:"intValue"`
+		DoubleValue *json.Number    `json:"doubleValue"`
+		ArrayValue  json.RawMessage `json:"arrayValue"`
+		KvlistValue json.RawMessage `json:"kvlistValue"`
+	} `json:"value"`
+}
+
+var otlpSpanKinds = map[string]ot_pdata.SpanKind{
+	"SPAN_KIND_INTERNAL": ot_pdata.SpanKindINTERNAL,
+	"SPAN_KIND_SERVER":   ot_pdata.SpanKindSERVER,
+	"SPAN_KIND_CLIENT":   ot_pdata.SpanKindCLIENT,
+	"SPAN_KIND_PRODUCER": ot_pdata.SpanKindPRODUCER,
+	"SPAN_KIND_CONSUMER": ot_pdata.SpanKindCONSUMER,
+}
+
+var otlpStatusCodes = map[string]ot_pdata.StatusCode{
+	"STATUS_CODE_UNSET": ot_pdata.StatusCodeUnset,
+	"STATUS_CODE_OK":    ot_pdata.StatusCodeOk,
+	"STATUS_CODE_ERROR": ot_pdata.StatusCodeError,
+}
+
+func parseOTLP(content []byte) ([][]byte, error) {
+	var file otlpFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse OTLP trace file: %w", err)
+	}
+
+	builder := newTraceBuilder()
+	for i, rs := range append(file.ResourceSpans, file.Batches...) {
+		for _, ils := range append(rs.InstrumentationLibrarySpans, rs.ScopeSpans...) {
+			for _, s := range ils.Spans {
+				traceID, err := parseTraceID(s.TraceID)
+				if err != nil {
+					return nil, err
+				}
+				span := builder.addSpan(traceID, i, func(attrs ot_pdata.AttributeMap) {
+					for _, attr := range rs.Resource.Attributes {
+						insertOTLPAttribute(attrs, attr)
+					}
+				})
+				if err := setOTLPSpan(span, traceID, s); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return builder.traces()
+}
+
+func setOTLPSpan(span ot_pdata.Span, traceID ot_pdata.TraceID, s otlpSpan) error {
+	spanID, err := parseSpanID(s.SpanID)
+	if err != nil {
+		return err
+	}
+	span.SetTraceID(traceID)
+	span.SetSpanID(spanID)
+	if s.ParentSpanID != "" {
+		parentSpanID, err := parseSpanID(s.ParentSpanID)
+		if err != nil {
+			return err
+		}
+		span.SetParentSpanID(parentSpanID)
+	}
+	span.SetName(s.Name)
+	span.SetKind(ot_pdata.SpanKind(parseEnum(s.Kind, func(name string) (int64, bool) {
+		kind, ok := otlpSpanKinds[name]
+		return int64(kind), ok
+	})))
+
+	start, err := parseUnixNano(s.StartTimeUnixNano)
+	if err != nil {
+		return err
+	}
+	end, err := parseUnixNano(s.EndTimeUnixNano)
+	if err != nil {
+		return err
+	}
+	span.SetStartTime(start)
+	span.SetEndTime(end)
+
+	for _, attr := range s.Attributes {
+		insertOTLPAttribute(span.Attributes(), attr)
+	}
+
+	span.Events().Resize(len(s.Events))
+	for i, e := range s.Events {
+		event := span.Events().At(i)
+		timestamp, err := parseUnixNano(e.TimeUnixNano)
+		if err != nil {
+			return err
+		}
+		event.SetTimestamp(timestamp)
+		event.SetName(e.Name)
+		for _, attr := range e.Attributes {
+			insertOTLPAttribute(event.Attributes(), attr)
+		}
+	}
+
+	if s.Status != nil {
+		span.Status().SetCode(ot_pdata.StatusCode(parseEnum(s.Status.Code, func(name string) (int64, bool) {
+			code, ok := otlpStatusCodes[name]
+			return int64(code), ok
+		})))
+		span.Status().SetMessage(s.Status.Message)
+	}
+	return nil
+}
+
+func insertOTLPAttribute(attrs ot_pdata.AttributeMap, attr otlpKeyValue) {
+	v := attr.Value
+	switch {
+	case v.StringValue != nil:
+		attrs.InsertString(attr.Key, *v.StringValue)
+	case v.BoolValue != nil:
+		attrs.InsertBool(attr.Key, *v.BoolValue)
+	case v.IntValue != nil:
+		if i, err := v.IntValue.Int64(); err == nil {
+			attrs.InsertInt(attr.Key, i)
+		}
+	case v.DoubleValue != nil:
+		if f, err := v.DoubleValue.Float64(); err == nil {
+			attrs.InsertDouble(attr.Key, f)
+		}
+	case v.ArrayValue != nil:
+		attrs.InsertString(attr.Key, string(v.ArrayValue))
+	case v.KvlistValue != nil:
+		attrs.InsertString(attr.Key, string(v.KvlistValue))
+	}
+}
+
+// parseEnum reads an OTLP enum, which is encoded either as its number or its name.
+func parseEnum(raw json.RawMessage, byName func(string) (int64, bool)) int64 {
+	if len(raw) == 0 {
+		return 0
+	}
+	var n int64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if value, ok := byName(name); ok {
+			return value
+		}
+	}
+	return 0
+}
+
+func parseUnixNano(n json.Number) (ot_pdata.Timestamp, error) {
+	if n == "" {
+		return 0, nil
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %w", n, err)
+	}
+	return ot_pdata.Timestamp(v), nil
+}
+
+type zipkinSpan struct {
+	TraceID       string `json:"traceId"`
+	ID            string `json:"id"`
+	ParentID      string `json:"parentId"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	Timestamp     int64  `json:"timestamp"`
+	Duration      int64  `json:"duration"`
+	LocalEndpoint *struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"localEndpoint"`
+	Tags        map[string]string `json:"tags"`
+	Annotations []struct {
+		Timestamp int64  `json:"timestamp"`
+		Value     string `json:"value"`
+	} `json:"annotations"`
+}
+
+var zipkinSpanKinds = map[string]ot_pdata.SpanKind{
+	"CLIENT":   ot_pdata.SpanKindCLIENT,
+	"SERVER":   ot_pdata.SpanKindSERVER,
+	"PRODUCER": ot_pdata.SpanKindPRODUCER,
+	"CONSUMER": ot_pdata.SpanKindCONSUMER,
+}
+
+func parseZipkin(content []byte) ([][]byte, error) {
+	var spans []zipkinSpan
+	if err := json.Unmarshal(content, &spans); err != nil {
+		var traces [][]zipkinSpan
+		if err := json.Unmarshal(content, &traces); err != nil {
+			return nil, fmt.Errorf("failed to parse Zipkin trace file: %w", err)
+		}
+		for _, trace := range traces {
+			spans = append(spans, trace...)
+		}
+	}
+
+	builder := newTraceBuilder()
+	for _, s := range spans {
+		traceID, err := parseTraceID(s.TraceID)
+		if err != nil {
+			return nil, err
+		}
+		serviceName := ""
+		if s.LocalEndpoint != nil {
+			serviceName = s.LocalEndpoint.ServiceName
+		}
+		span := builder.addSpan(traceID, serviceName, func(attrs ot_pdata.AttributeMap) {
+			attrs.InsertString("service.name", serviceName)
+		})
+
+		spanID, err := parseSpanID(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		span.SetTraceID(traceID)
+		span.SetSpanID(spanID)
+		if s.ParentID != "" {
+			parentID, err := parseSpanID(s.ParentID)
+			if err != nil {
+				return nil, err
+			}
+			span.SetParentSpanID(parentID)
+		}
+		span.SetName(s.Name)
+		span.SetKind(zipkinSpanKinds[s.Kind])
+		// Zipkin timestamps and durations are in microseconds
+		span.SetStartTime(ot_pdata.Timestamp(s.Timestamp * 1000))
+		span.SetEndTime(ot_pdata.Timestamp((s.Timestamp + s.Duration) * 1000))
+		for key, value := range s.Tags {
+			span.Attributes().InsertString(key, value)
+		}
+		span.Events().Resize(len(s.Annotations))
+		for i, annotation := range s.Annotations {
+			span.Events().At(i).SetTimestamp(ot_pdata.Timestamp(annotation.Timestamp * 1000))
+			span.Events().At(i).SetName(annotation.Value)
+		}
+	}
+
+	return builder.traces()
+}
+
+// traceBuilder groups spans by trace and resource, since trace frames hold a single trace.
+type traceBuilder struct {
+	order     []ot_pdata.TraceID
+	byTrace   map[ot_pdata.TraceID]ot_pdata.Traces
+	resources map[ot_pdata.TraceID]map[interface{}]ot_pdata.InstrumentationLibrarySpans
+}
+
+func newTraceBuilder() *traceBuilder {
+	return &traceBuilder{
+		byTrace:   map[ot_pdata.TraceID]ot_pdata.Traces{},
+		resources: map[ot_pdata.TraceID]map[interface{}]ot_pdata.InstrumentationLibrarySpans{},
+	}
+}
+
+// addSpan returns a new span of the trace with the given resource. The resource attributes are
+// set with setResource the first time the resource occurs in the trace.
+func (b *traceBuilder) addSpan(traceID ot_pdata.TraceID, resourceKey interface{},
+	setResource func(ot_pdata.AttributeMap)) ot_pdata.Span {
+	traces, ok := b.byTrace[traceID]
+	if !ok {
+		traces = ot_pdata.NewTraces()
+		b.byTrace[traceID] = traces
+		b.resources[traceID] = map[interface{}]ot_pdata.InstrumentationLibrarySpans{}
+		b.order = append(b.order, traceID)
+	}
+
+	ils, ok := b.resources[traceID][resourceKey]
+	if !ok {
+		rss := traces.ResourceSpans()
+		rss.Resize(rss.Len() + 1)
+		rs := rss.At(rss.Len() - 1)
+		setResource(rs.Resource().Attributes())
+		rs.InstrumentationLibrarySpans().Resize(1)
+		ils = rs.InstrumentationLibrarySpans().At(0)
+		b.resources[traceID][resourceKey] = ils
+	}
+
+	spans := ils.Spans()
+	spans.Resize(spans.Len() + 1)
+	return spans.At(spans.Len() - 1)
+}
+
+func (b *traceBuilder) traces() ([][]byte, error) {
+	if len(b.order) == 0 {
+		return nil, fmt.Errorf("trace file contains no traces")
+	}
+
+	result := make([][]byte, 0, len(b.order))
+	for _, traceID := range b.order {
+		traceBytes, err := tracesToJSON(b.byTrace[traceID])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert trace %s: %w", traceID.HexString(), err)
+		}
+		result = append(result, traceBytes)
+	}
+	return result, nil
+}
+
+// parseTraceID parses a trace ID in hex, as in Zipkin and the OTLP JSON encoding, or in base64,
+// as in OTLP exported with the protobuf JSON mapping. 64-bit IDs are padded to 128-bit.
+func parseTraceID(id string) (ot_pdata.TraceID, error) {
+	b, err := decodeID(id, 16)
+	if err != nil {
+		return ot_pdata.TraceID{}, fmt.Errorf("invalid trace ID %q: %w", id, err)
+	}
+	var traceID [16]byte
+	copy(traceID[16-len(b):], b)
+	return ot_pdata.NewTraceID(traceID), nil
+}
+
+func parseSpanID(id string) (ot_pdata.SpanID, error) {
+	b, err := decodeID(id, 8)
+	if err != nil {
+		return ot_pdata.SpanID{}, fmt.Errorf("invalid span ID %q: %w", id, err)
+	}
+	var spanID [8]byte
+	copy(spanID[8-len(b):], b)
+	return ot_pdata.NewSpanID(spanID), nil
+}
+
+func decodeID(id string, size int) ([]byte, error) {
+	if len(id)%2 == 0 && len(id) <= size*2 {
+		if b, err := hex.DecodeString(strings.ToLower(id)); err == nil && len(b) > 0 {
+			return b, nil
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || len(b) > size {
+		return nil, fmt.Errorf("expected at most %d bytes", size)
+	}
+	return b, nil
+}
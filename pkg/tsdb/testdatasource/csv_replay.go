@@ -0,0 +1,180 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const maxCSVReplayRows = 10000
+
+func (p *testDataPlugin) handleCSVReplayScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		frame, err := csvReplay(q, model)
+		if err != nil {
+			respD.Error = err
+		} else {
+			respD.Frames = append(respD.Frames, frame)
+		}
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+// csvRecording is a parsed CSV with its rows sorted by time.
+type csvRecording struct {
+	columns []string
+	times   []time.Time
+	rows    [][]string
+}
+
+// csvReplay shifts the recorded rows so that the last row is at the end of the time range and
+// returns the rows within the time range. With loop, the recording is repeated back in time,
+// with the recording's average row interval between the end of a repetition and the start of
+// the next.
+func csvReplay(query backend.DataQuery, model *simplejson.Json) (*data.Frame, error) {
+	options := model.Get("csvReplay")
+
+	recording, err := parseCSVRecording(options.Get("csvContent").MustString(""))
+	if err != nil {
+		return nil, err
+	}
+	loop := options.Get("loop").MustBool(false)
+
+	first := recording.times[0]
+	last := recording.times[len(recording.times)-1]
+	offset := query.TimeRange.To.Sub(last)
+
+	period := last.Sub(first)
+	if len(recording.times) > 1 {
+		period += period / time.Duration(len(recording.times)-1)
+	}
+	if period <= 0 {
+		period = query.Interval
+	}
+
+	var times []time.Time
+	var rows [][]string
+	for shift := offset; len(rows) < maxCSVReplayRows; shift -= period {
+		if last.Add(shift).Before(query.TimeRange.From) {
+			break
+		}
+		// repetitions are added from the most recent to the oldest
+		for i := len(recording.rows) - 1; i >= 0 && len(rows) < maxCSVReplayRows; i-- {
+			t := recording.times[i].Add(shift)
+			if t.Before(query.TimeRange.From) || t.After(query.TimeRange.To) {
+				continue
+			}
+			times = append(times, t)
+			rows = append(rows, recording.rows[i])
+		}
+		if !loop || period <= 0 {
+			break
+		}
+	}
+
+	// back to ascending order
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		times[i], times[j] = times[j], times[i]
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+
+	frame := newSeriesForQuery(query, model, 0)
+	frame.Fields = data.Fields{data.NewField(recording.columns[0], nil, times)}
+	for c := 1; c < len(recording.columns); c++ {
+		frame.Fields = append(frame.Fields, csvReplayField(recording.columns[c], rows, c))
+	}
+
+	return frame, nil
+}
+
+func parseCSVRecording(content string) (*csvRecording, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimSpace(content)))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %v", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("CSV must have a header and at least one row")
+	}
+	if len(records[0]) < 2 {
+		return nil, fmt.Errorf("CSV must have a time column and at least one value column")
+	}
+
+	recording := &csvRecording{columns: records[0]}
+	type timedRow struct {
+		time time.Time
+		row  []string
+	}
+	timedRows := make([]timedRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		t, err := parseCSVTime(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse time '%v' of row %d: %v", record[0], i+1, err)
+		}
+		timedRows = append(timedRows, timedRow{time: t, row: record})
+	}
+	sort.SliceStable(timedRows, func(i, j int) bool {
+		return timedRows[i].time.Before(timedRows[j].time)
+	})
+
+	for _, r := range timedRows {
+		recording.times = append(recording.times, r.time)
+		recording.rows = append(recording.rows, r.row)
+	}
+	return recording, nil
+}
+
+// parseCSVTime parses a time in RFC3339 format or in milliseconds since the epoch.
+func parseCSVTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(ms/int64(1e+3), (ms%int64(1e+3))*int64(1e+6)), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// csvReplayField returns a nullable float field if all values of the column are numbers or
+// empty, and a string field otherwise.
+func csvReplayField(name string, rows [][]string, column int) *data.Field {
+	numeric := true
+	values := make([]*float64, len(rows))
+	for i, row := range rows {
+		raw := strings.TrimSpace(row[column])
+		if raw == "" || raw == "null" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			numeric = false
+			break
+		}
+		values[i] = &value
+	}
+	if numeric {
+		return data.NewField(name, nil, values)
+	}
+
+	texts := make([]string, len(rows))
+	for i, row := range rows {
+		texts[i] = row[column]
+	}
+	return data.NewField(name, nil, texts)
+}
@@ -0,0 +1,237 @@
+package testdatasource
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	maxHighCardinalitySeries = 100000
+	maxLatencySizeValues     = 10000000
+)
+
+type labelDimension struct {
+	name  string
+	count int
+}
+
+func (p *testDataPlugin) handleHighCardinalityScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		frames, err := highCardinality(q, model)
+		if err != nil {
+			respD.Error = err
+		}
+		respD.Frames = append(respD.Frames, frames...)
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+// highCardinality returns a random walk frame per permutation of the label values, up to
+// seriesCount frames. The values of a label named host with a count of 3 are host-0 to host-2.
+func highCardinality(query backend.DataQuery, model *simplejson.Json) (data.Frames, error) {
+	options := model.Get("highCardinality")
+
+	seriesCount := options.Get("seriesCount").MustInt(100)
+	if seriesCount <= 0 || seriesCount > maxHighCardinalitySeries {
+		return nil, fmt.Errorf("seriesCount must be between 1 and %d", maxHighCardinalitySeries)
+	}
+
+	dimensions, err := parseLabelDimensions(options.Get("labels").MustString(""))
+	if err != nil {
+		return nil, err
+	}
+	if len(dimensions) == 0 {
+		dimensions = []labelDimension{{name: "series", count: seriesCount}}
+	}
+
+	permutations := 1
+	for _, dim := range dimensions {
+		permutations *= dim.count
+		if permutations >= seriesCount {
+			break
+		}
+	}
+	if permutations < seriesCount {
+		seriesCount = permutations
+	}
+
+	from := query.TimeRange.From.UnixNano() / int64(time.Millisecond)
+	to := query.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	step := query.Interval.Milliseconds()
+	if step <= 0 {
+		step = 1000
+	}
+	maxPoints := query.MaxDataPoints
+	if maxPoints <= 0 {
+		maxPoints = 1000
+	}
+
+	frames := make(data.Frames, 0, seriesCount)
+	for i := 0; i < seriesCount; i++ {
+		labels := make(data.Labels, len(dimensions))
+		n := i
+		for d := len(dimensions) - 1; d >= 0; d-- {
+			labels[dimensions[d].name] = dimensions[d].name + "-" + strconv.Itoa(n%dimensions[d].count)
+			n /= dimensions[d].count
+		}
+
+		timeVec := make([]time.Time, 0)
+		floatVec := make([]float64, 0)
+		walker := rand.Float64() * 100
+		for t, j := from, int64(0); t < to && j < maxPoints; t, j = t+step, j+1 {
+			timeVec = append(timeVec, time.Unix(t/int64(1e+3), (t%int64(1e+3))*int64(1e+6)))
+			floatVec = append(floatVec, walker)
+			walker += rand.Float64() - 0.5
+		}
+
+		frames = append(frames, data.NewFrame("",
+			data.NewField("time", nil, timeVec),
+			data.NewField(frameNameForQuery(query, model, 0), labels, floatVec),
+		))
+	}
+
+	return frames, nil
+}
+
+// parseLabelDimensions parses name=count pairs, e.g. "host=100,region=5".
+func parseLabelDimensions(text string) ([]labelDimension, error) {
+	text = strings.Trim(strings.TrimSpace(text), "{}")
+	if text == "" {
+		return nil, nil
+	}
+
+	var dimensions []labelDimension
+	for _, pair := range strings.Split(text, ",") {
+		idx := strings.Index(pair, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("failed to parse label '%v', expected name=count", pair)
+		}
+		name := strings.TrimSpace(pair[:idx])
+		count, err := strconv.Atoi(strings.Trim(strings.TrimSpace(pair[idx+1:]), "\""))
+		if err != nil || name == "" || count <= 0 {
+			return nil, fmt.Errorf("failed to parse label '%v', expected name=count", pair)
+		}
+		dimensions = append(dimensions, labelDimension{name: name, count: count})
+	}
+	return dimensions, nil
+}
+
+func (p *testDataPlugin) handleLatencySizeScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		frames, err := latencySize(ctx, q, model)
+		if err != nil {
+			respD.Error = err
+		}
+		respD.Frames = append(respD.Frames, frames...)
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+// latencySize waits for the latency plus a random jitter, then returns the given number of
+// frames, each with a time field and the given number of random float fields and rows. The rows
+// are spread evenly over the time range.
+func latencySize(ctx context.Context, query backend.DataQuery, model *simplejson.Json) (data.Frames, error) {
+	options := model.Get("latencySize")
+
+	latency, err := parseOptionalDuration(options.Get("latency").MustString(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse latency: %v", err)
+	}
+	jitter, err := parseOptionalDuration(options.Get("jitter").MustString(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jitter: %v", err)
+	}
+	frameCount := options.Get("frames").MustInt(1)
+	fieldCount := options.Get("fields").MustInt(1)
+	rowCount := options.Get("rows").MustInt(100)
+	if frameCount < 0 || fieldCount < 0 || rowCount < 0 {
+		return nil, fmt.Errorf("frames, fields and rows must not be negative")
+	}
+	if frameCount > maxLatencySizeValues || fieldCount > maxLatencySizeValues ||
+		frameCount*fieldCount > maxLatencySizeValues || rowCount > maxLatencySizeValues ||
+		frameCount*fieldCount*rowCount > maxLatencySizeValues {
+		return nil, fmt.Errorf("frames * fields * rows must not exceed %d", maxLatencySizeValues)
+	}
+
+	if jitter > 0 {
+		latency += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	from := query.TimeRange.From
+	timeRange := query.TimeRange.To.Sub(from)
+	timeVec := make([]time.Time, rowCount)
+	for i := range timeVec {
+		if rowCount > 1 {
+			timeVec[i] = from.Add(time.Duration(float64(timeRange) * float64(i) / float64(rowCount-1)))
+		} else {
+			timeVec[i] = from
+		}
+	}
+
+	frames := make(data.Frames, 0, frameCount)
+	for f := 0; f < frameCount; f++ {
+		frame := data.NewFrame(fmt.Sprintf("%s-frame%d", query.RefID, f),
+			data.NewField("time", nil, append([]time.Time(nil), timeVec...)))
+		for i := 0; i < fieldCount; i++ {
+			values := make([]float64, rowCount)
+			for r := range values {
+				values[r] = rand.Float64() * 100
+			}
+			frame.Fields = append(frame.Fields, data.NewField(fmt.Sprintf("value%d", i), nil, values))
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative")
+	}
+	return d, nil
+}
@@ -39,6 +39,10 @@ const (
 	serverError500Query               queryType = "server_error_500"
 	logsQuery                         queryType = "logs"
 	nodeGraphQuery                    queryType = "node_graph"
+	scriptedStatesQuery               queryType = "scripted_states"
+	highCardinalityQuery              queryType = "high_cardinality"
+	latencySizeQuery                  queryType = "latency_size"
+	csvReplayQuery                    queryType = "csv_replay"
 )
 
 type queryType string
@@ -188,6 +192,38 @@ Timestamps will line up evenly on timeStepSeconds (For example, 60 seconds means
 		Name: "Node Graph",
 	})
 
+	p.registerScenario(&Scenario{
+		ID:      string(scriptedStatesQuery),
+		Name:    "Scripted States",
+		handler: p.handleScriptedStatesScenario,
+		Description: `Scripted States returns series following a timeline of states, one every timeStep seconds.
+A state is a value, "nodata" for a missing point or "error" to fail the query while the state is current.
+Like Predictable Pulse, the timeline cycles based off of absolute time, so alert evaluations are repeatable.`,
+	})
+
+	p.registerScenario(&Scenario{
+		ID:      string(highCardinalityQuery),
+		Name:    "High Cardinality",
+		handler: p.handleHighCardinalityScenario,
+		Description: `High Cardinality returns up to seriesCount random walk series, labeled with the permutations
+of the label values. Labels are given as name=count pairs, e.g. "host=100,region=5".`,
+	})
+
+	p.registerScenario(&Scenario{
+		ID:          string(latencySizeQuery),
+		Name:        "Latency and Size",
+		handler:     p.handleLatencySizeScenario,
+		Description: `Latency and Size waits for the latency plus a random jitter and returns frames of the given size, for benchmarking.`,
+	})
+
+	p.registerScenario(&Scenario{
+		ID:      string(csvReplayQuery),
+		Name:    "CSV Replay",
+		handler: p.handleCSVReplayScenario,
+		Description: `CSV Replay returns a recorded CSV, where the first column is the time, shifted so that the
+recording ends now. With loop enabled, the recording repeats back in time to fill the time range.`,
+	})
+
 	p.queryMux.HandleFunc("", p.handleFallbackScenario)
 }
 
@@ -906,9 +942,12 @@ func newSeriesForQuery(query backend.DataQuery, model *simplejson.Json, index in
  * '{job="foo", instance="bar"} => {job: "foo", instance: "bar"}`
  */
 func parseLabels(model *simplejson.Json) data.Labels {
+	return parseLabelString(model.Get("labels").MustString(""))
+}
+
+func parseLabelString(labelText string) data.Labels {
 	tags := data.Labels{}
 
-	labelText := model.Get("labels").MustString("")
 	if labelText == "" {
 		return data.Labels{}
 	}
@@ -922,6 +961,9 @@ func parseLabels(model *simplejson.Json) data.Labels {
 
 	for _, keyval := range strings.Split(text, ",") {
 		idx := strings.Index(keyval, "=")
+		if idx < 0 {
+			continue
+		}
 		key := strings.TrimSpace(keyval[:idx])
 		val := strings.TrimSpace(keyval[idx+1:])
 		val = strings.Trim(val, "\"")
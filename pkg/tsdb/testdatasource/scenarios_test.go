@@ -212,3 +212,147 @@ func TestParseLabels(t *testing.T) {
 		assert.Equal(t, expectedTags, parseLabels(model), fmt.Sprintf("Actual tags in test case %d doesn't match expected tags", i+1))
 	}
 }
+
+func TestAlertingAndLoadScenarios(t *testing.T) {
+	p := &testDataPlugin{}
+	to := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	newRequest := func(t *testing.T, from time.Time, model map[string]interface{}) *backend.QueryDataRequest {
+		modelBytes, err := simplejson.NewFromAny(model).MarshalJSON()
+		require.NoError(t, err)
+		return &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:         "A",
+				TimeRange:     backend.TimeRange{From: from, To: to},
+				Interval:      time.Minute,
+				MaxDataPoints: 100,
+				JSON:          modelBytes,
+			}},
+		}
+	}
+
+	t.Run("scripted states", func(t *testing.T) {
+		model := map[string]interface{}{
+			"scriptedStates": map[string]interface{}{
+				"timeStep": 60,
+				"series": []interface{}{
+					map[string]interface{}{"labels": "host=a", "states": "1, nodata, 3, 4"},
+					map[string]interface{}{"labels": "host=b", "states": "error, 2"},
+				},
+			},
+		}
+
+		// 12:00 is step 0 of the timeline of host=a and host=b
+		resp, err := p.handleScriptedStatesScenario(context.Background(), newRequest(t, to.Add(-4*time.Minute), model))
+		require.NoError(t, err)
+		dResp := resp.Responses["A"]
+		require.EqualError(t, dResp.Error, "scripted error state of series host=b")
+		require.Len(t, dResp.Frames, 2)
+
+		frame := dResp.Frames[0]
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+		require.Equal(t, 3, frame.Rows())
+		values := []float64{}
+		for i := 0; i < frame.Rows(); i++ {
+			v, ok := frame.Fields[1].ConcreteAt(i)
+			require.True(t, ok)
+			values = append(values, v.(float64))
+		}
+		require.Equal(t, []float64{1, 3, 4}, values)
+		require.Equal(t, 2, dResp.Frames[1].Rows())
+
+		resp, err = p.handleScriptedStatesScenario(context.Background(), newRequest(t, to.Add(-4*time.Minute), map[string]interface{}{
+			"scriptedStates": map[string]interface{}{
+				"timeStep": 60,
+				"series":   []interface{}{map[string]interface{}{"states": "1,maybe"}},
+			},
+		}))
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+
+		// times before the epoch are clamped to the first state
+		beforeEpoch := time.Unix(0, 0).Add(-time.Minute)
+		req := newRequest(t, beforeEpoch.Add(-3*time.Minute), model)
+		req.Queries[0].TimeRange.To = beforeEpoch
+		resp, err = p.handleScriptedStatesScenario(context.Background(), req)
+		require.NoError(t, err)
+		dResp = resp.Responses["A"]
+		require.EqualError(t, dResp.Error, "scripted error state of series host=b")
+		require.Equal(t, 3, dResp.Frames[0].Rows())
+		require.Equal(t, 0, dResp.Frames[1].Rows())
+	})
+
+	t.Run("high cardinality", func(t *testing.T) {
+		resp, err := p.handleHighCardinalityScenario(context.Background(), newRequest(t, to.Add(-10*time.Minute), map[string]interface{}{
+			"highCardinality": map[string]interface{}{"seriesCount": 10, "labels": "region=2,host=3"},
+		}))
+		require.NoError(t, err)
+		dResp := resp.Responses["A"]
+		require.NoError(t, dResp.Error)
+		// only 6 permutations of the labels exist
+		require.Len(t, dResp.Frames, 6)
+		require.Equal(t, data.Labels{"region": "region-0", "host": "host-0"}, dResp.Frames[0].Fields[1].Labels)
+		require.Equal(t, data.Labels{"region": "region-1", "host": "host-2"}, dResp.Frames[5].Fields[1].Labels)
+		require.Equal(t, 10, dResp.Frames[0].Rows())
+
+		resp, err = p.handleHighCardinalityScenario(context.Background(), newRequest(t, to.Add(-10*time.Minute), map[string]interface{}{
+			"highCardinality": map[string]interface{}{"seriesCount": 3},
+		}))
+		require.NoError(t, err)
+		require.Len(t, resp.Responses["A"].Frames, 3)
+	})
+
+	t.Run("latency and size", func(t *testing.T) {
+		start := time.Now()
+		resp, err := p.handleLatencySizeScenario(context.Background(), newRequest(t, to.Add(-time.Hour), map[string]interface{}{
+			"latencySize": map[string]interface{}{"latency": "20ms", "frames": 2, "fields": 3, "rows": 50},
+		}))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+		dResp := resp.Responses["A"]
+		require.NoError(t, dResp.Error)
+		require.Len(t, dResp.Frames, 2)
+		require.Len(t, dResp.Frames[0].Fields, 4)
+		require.Equal(t, 50, dResp.Frames[0].Rows())
+		require.Equal(t, to, dResp.Frames[0].Fields[0].At(49))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		resp, err = p.handleLatencySizeScenario(ctx, newRequest(t, to.Add(-time.Hour), map[string]interface{}{
+			"latencySize": map[string]interface{}{"latency": "1h"},
+		}))
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, context.Canceled)
+	})
+
+	t.Run("CSV replay", func(t *testing.T) {
+		csvContent := "time,value,state\n" +
+			"2020-01-01T00:00:00Z,1,ok\n" +
+			"2020-01-01T00:01:00Z,,ok\n" +
+			"2020-01-01T00:02:00Z,3,alerting\n"
+
+		resp, err := p.handleCSVReplayScenario(context.Background(), newRequest(t, to.Add(-10*time.Minute), map[string]interface{}{
+			"csvReplay": map[string]interface{}{"csvContent": csvContent},
+		}))
+		require.NoError(t, err)
+		dResp := resp.Responses["A"]
+		require.NoError(t, dResp.Error)
+		require.Len(t, dResp.Frames, 1)
+		frame := dResp.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, to.Add(-2*time.Minute), frame.Fields[0].At(0))
+		require.Equal(t, to, frame.Fields[0].At(2))
+		require.Nil(t, frame.Fields[1].At(1))
+		require.Equal(t, "alerting", frame.Fields[2].At(2))
+
+		resp, err = p.handleCSVReplayScenario(context.Background(), newRequest(t, to.Add(-10*time.Minute), map[string]interface{}{
+			"csvReplay": map[string]interface{}{"csvContent": csvContent, "loop": true},
+		}))
+		require.NoError(t, err)
+		frame = resp.Responses["A"].Frames[0]
+		// a repetition every 3 minutes, from 11:50 to 12:00
+		require.Equal(t, 11, frame.Rows())
+		require.Equal(t, to.Add(-10*time.Minute), frame.Fields[0].At(0))
+		require.Equal(t, to, frame.Fields[0].At(10))
+	})
+}
@@ -0,0 +1,155 @@
+package testdatasource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	scriptedStateNoData = "nodata"
+	scriptedStateError  = "error"
+)
+
+// scriptedState is a step of a scripted timeline. A nil value with error unset means no data.
+type scriptedState struct {
+	value *float64
+	err   bool
+}
+
+type scriptedSeries struct {
+	labels data.Labels
+	states []scriptedState
+}
+
+func (p *testDataPlugin) handleScriptedStatesScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			continue
+		}
+
+		respD := resp.Responses[q.RefID]
+		frames, err := scriptedStates(q, model)
+		if err != nil {
+			respD.Error = err
+		}
+		respD.Frames = append(respD.Frames, frames...)
+		resp.Responses[q.RefID] = respD
+	}
+
+	return resp, nil
+}
+
+// scriptedStates returns a frame per scripted series. The error is set if a series is in the
+// error state at the end of the time range.
+func scriptedStates(query backend.DataQuery, model *simplejson.Json) (data.Frames, error) {
+	options := model.Get("scriptedStates")
+
+	timeStep, err := options.Get("timeStep").Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeStep value '%v' into integer: %v", options.Get("timeStep"), err)
+	}
+	if timeStep <= 0 {
+		return nil, fmt.Errorf("timeStep must be greater than 0")
+	}
+	timeStep *= 1000 // Seconds to Milliseconds
+
+	series, err := parseScriptedSeries(options.Get("series"))
+	if err != nil {
+		return nil, err
+	}
+
+	from := query.TimeRange.From.UnixNano() / int64(time.Millisecond)
+	to := query.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	maxPoints := 10000 // Don't return too many points
+
+	frames := make(data.Frames, 0, len(series))
+	var errorSeries []string
+	for i, s := range series {
+		// The timeline starts at the epoch, times before it are in the first state.
+		stateAt := func(ms int64) scriptedState {
+			if ms < 0 {
+				return s.states[0]
+			}
+			return s.states[(ms/timeStep)%int64(len(s.states))]
+		}
+
+		timeVec := make([]*time.Time, 0)
+		floatVec := make([]*float64, 0)
+		timeCursor := from - (from % timeStep) // Truncate Start
+		for j := 0; j < maxPoints && timeCursor < to; j++ {
+			if state := stateAt(timeCursor); state.value != nil {
+				t := time.Unix(timeCursor/int64(1e+3), (timeCursor%int64(1e+3))*int64(1e+6))
+				timeVec = append(timeVec, &t)
+				floatVec = append(floatVec, state.value)
+			}
+			timeCursor += timeStep
+		}
+
+		if stateAt(to).err {
+			errorSeries = append(errorSeries, s.labels.String())
+		}
+
+		frame := newSeriesForQuery(query, model, i)
+		frame.Fields = data.Fields{
+			data.NewField("time", nil, timeVec),
+			data.NewField("value", s.labels, floatVec),
+		}
+		frames = append(frames, frame)
+	}
+
+	if len(errorSeries) > 0 {
+		return frames, fmt.Errorf("scripted error state of series %s", strings.Join(errorSeries, ", "))
+	}
+	return frames, nil
+}
+
+func parseScriptedSeries(seriesJSON *simplejson.Json) ([]scriptedSeries, error) {
+	rawSeries := seriesJSON.MustArray()
+	if len(rawSeries) == 0 {
+		return nil, fmt.Errorf("scripted states require at least one series")
+	}
+
+	series := make([]scriptedSeries, 0, len(rawSeries))
+	for i := range rawSeries {
+		s := seriesJSON.GetIndex(i)
+
+		rawStates := strings.TrimRight(strings.TrimSpace(s.Get("states").MustString()), ",")
+		if rawStates == "" {
+			return nil, fmt.Errorf("series %d has no states", i)
+		}
+
+		var states []scriptedState
+		for _, rawState := range strings.Split(rawStates, ",") {
+			rawState = strings.ToLower(strings.TrimSpace(rawState))
+			switch rawState {
+			case scriptedStateNoData:
+				states = append(states, scriptedState{})
+			case scriptedStateError:
+				states = append(states, scriptedState{err: true})
+			default:
+				value, err := strconv.ParseFloat(rawState, 64)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse state '%v' of series %d, expected a number, %q or %q",
+						rawState, i, scriptedStateNoData, scriptedStateError)
+				}
+				states = append(states, scriptedState{value: &value})
+			}
+		}
+
+		series = append(series, scriptedSeries{
+			labels: parseLabelString(s.Get("labels").MustString("")),
+			states: states,
+		})
+	}
+	return series, nil
+}
@@ -6,11 +6,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // ErrWalkSkipDir is the Error returned when we want to skip descending into a directory
 var ErrWalkSkipDir = errors.New("skip this directory")
 
+// ErrPathNotAllowed is returned by ResolvePathWithin when a path is not within any of the allowed directories.
+var ErrPathNotAllowed = errors.New("path is not within the allowed directories")
+
 // WalkFunc is a callback function called for each path as a directory is walked
 // If resolvedPath != "", then we are following symbolic links.
 type WalkFunc func(resolvedPath string, info os.FileInfo, err error) error
@@ -129,3 +133,30 @@ func containsDistFolder(subFiles []subFile) bool {
 
 	return false
 }
+
+// ResolvePathWithin resolves the symbolic links of path and returns the resolved path if it is within one of dirs.
+// Resolving the links first means that a link inside an allowed directory cannot point outside of it.
+func ResolvePathWithin(path string, dirs []string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range dirs {
+		if IsPathWithin(resolved, dir) {
+			return resolved, nil
+		}
+	}
+	return "", ErrPathNotAllowed
+}
+
+// IsPathWithin returns whether the resolved path is dir or inside it. The symbolic links of dir are resolved, and a
+// dir that does not exist contains nothing.
+func IsPathWithin(resolvedPath string, dir string) bool {
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(resolvedDir, resolvedPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
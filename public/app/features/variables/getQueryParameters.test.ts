@@ -0,0 +1,49 @@
+import { TemplateSrv } from 'app/features/templating/template_srv';
+import { getQueryParameters } from './getQueryParameters';
+
+describe('getQueryParameters', () => {
+  const templateSrv = new TemplateSrv();
+  templateSrv.init([
+    { type: 'query', name: 'host', multi: true, current: { value: ['a', "b'; DROP TABLE t; --"] } },
+    { type: 'query', name: 'env', current: { value: 'prod' } },
+    {
+      type: 'query',
+      name: 'region',
+      includeAll: true,
+      current: { value: '$__all' },
+      options: [{ value: '$__all' }, { value: 'eu' }, { value: 'us' }],
+    },
+    { type: 'query', name: 'dc', includeAll: true, allValue: '%', current: { value: '$__all' } },
+  ]);
+
+  it('should return the values of the variables used in the query', () => {
+    const parameters = getQueryParameters(
+      "SELECT * FROM t WHERE host IN ($host) AND env = '${env}' AND region IN ([[region]]) AND dc LIKE $dc AND $unknown",
+      templateSrv
+    );
+
+    expect(parameters).toEqual({
+      host: ['a', "b'; DROP TABLE t; --"],
+      env: 'prod',
+      region: ['eu', 'us'],
+      dc: '%',
+    });
+  });
+
+  it('should return the values of scoped variables', () => {
+    const parameters = getQueryParameters('SELECT * FROM t WHERE name LIKE $__searchFilter', templateSrv, {
+      __searchFilter: { text: '', value: 'ab%' },
+    });
+
+    expect(parameters).toEqual({ __searchFilter: 'ab%' });
+  });
+
+  it('should leave the interval variables to the backend', () => {
+    const parameters = getQueryParameters('SELECT $__interval, $__interval_ms', templateSrv, {
+      __interval: { text: '1m', value: '1m' },
+      __interval_ms: { text: '60000', value: 60000 },
+    });
+
+    expect(parameters).toEqual({});
+  });
+});
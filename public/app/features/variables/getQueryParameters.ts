@@ -0,0 +1,49 @@
+import { ScopedVars } from '@grafana/data';
+import { TemplateSrv } from '@grafana/runtime';
+import { variableRegex } from './utils';
+
+// the interval variables are replaced by the macros of the backend
+const backendVariables = ['__interval', '__interval_ms'];
+
+/*
+ * Returns the values of the template variables used in a query, for the backend to bind them as query
+ * parameters instead of interpolating them into the query. Multi-value variables and "All" have a list of
+ * values, and variables that don't exist are left to the backend.
+ */
+export function getQueryParameters(
+  query: string,
+  templateSrv: TemplateSrv,
+  scopedVars?: ScopedVars
+): Record<string, string | string[]> {
+  const parameters: Record<string, string | string[]> = {};
+  if (!query) {
+    return parameters;
+  }
+
+  const regex = new RegExp(variableRegex.source, 'g');
+  let match: RegExpExecArray | null;
+  while ((match = regex.exec(query)) !== null) {
+    const name = match[1] || match[2] || match[4];
+    if (!name || parameters[name] !== undefined || backendVariables.indexOf(name) !== -1) {
+      continue;
+    }
+
+    const reference = '${' + name + '}';
+    let value: any;
+    const replaced = templateSrv.replace(reference, scopedVars, (v: any) => {
+      value = v;
+      return '';
+    });
+    if (value === undefined) {
+      if (replaced === reference) {
+        continue;
+      }
+      // custom all values are replaced without format
+      value = replaced;
+    }
+
+    parameters[name] = Array.isArray(value) ? value.map((v) => `${v}`) : `${value}`;
+  }
+
+  return parameters;
+}
@@ -8,12 +8,14 @@ import ResponseParser, { MssqlResponse } from './response_parser';
 import { getTemplateSrv, TemplateSrv } from 'app/features/templating/template_srv';
 import { getTimeSrv, TimeSrv } from 'app/features/dashboard/services/TimeSrv';
 import { MssqlQueryForInterpolation } from './types';
+import { getQueryParameters } from '../../../features/variables/getQueryParameters';
 
 export class MssqlDatasource {
   id: any;
   name: any;
   responseParser: ResponseParser;
   interval: string;
+  parameterizedQueries: boolean;
 
   constructor(
     instanceSettings: any,
@@ -24,6 +26,7 @@ export class MssqlDatasource {
     this.id = instanceSettings.id;
     this.responseParser = new ResponseParser();
     this.interval = (instanceSettings.jsonData || {}).timeInterval || '1m';
+    this.parameterizedQueries = !!(instanceSettings.jsonData || {}).parameterizedQueries;
   }
 
   interpolateVariable(value: any, variable: any) {
@@ -49,6 +52,17 @@ export class MssqlDatasource {
     return quotedValues.join(',');
   }
 
+  /**
+   * Returns the SQL of a query with its template variables interpolated or, with parameterized queries, left for
+   * the backend to bind their values as query parameters.
+   */
+  interpolateQuery(rawSql: string, scopedVars?: ScopedVars) {
+    if (this.parameterizedQueries) {
+      return { rawSql, parameterized: true, variables: getQueryParameters(rawSql, this.templateSrv, scopedVars) };
+    }
+    return { rawSql: this.templateSrv.replace(rawSql, scopedVars, this.interpolateVariable) };
+  }
+
   interpolateVariablesInQueries(
     queries: MssqlQueryForInterpolation[],
     scopedVars: ScopedVars
@@ -77,7 +91,7 @@ export class MssqlDatasource {
         intervalMs: options.intervalMs,
         maxDataPoints: options.maxDataPoints,
         datasourceId: this.id,
-        rawSql: this.templateSrv.replace(item.rawSql, options.scopedVars, this.interpolateVariable),
+        ...this.interpolateQuery(item.rawSql, options.scopedVars),
         format: item.format,
       };
     });
@@ -107,7 +121,7 @@ export class MssqlDatasource {
     const query = {
       refId: options.annotation.name,
       datasourceId: this.id,
-      rawSql: this.templateSrv.replace(options.annotation.rawQuery, options.scopedVars, this.interpolateVariable),
+      ...this.interpolateQuery(options.annotation.rawQuery, options.scopedVars),
       format: 'table',
     };
 
@@ -134,7 +148,7 @@ export class MssqlDatasource {
     const interpolatedQuery = {
       refId: refId,
       datasourceId: this.id,
-      rawSql: this.templateSrv.replace(query, {}, this.interpolateVariable),
+      ...this.interpolateQuery(query, {}),
       format: 'table',
     };
 
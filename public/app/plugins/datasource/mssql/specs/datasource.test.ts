@@ -333,4 +333,42 @@ describe('MSSQLDatasource', () => {
       expect(ctx.ds.targetContainsTemplate(query)).toBeFalsy();
     });
   });
+  describe('When performing a parameterized query', () => {
+    const templateSrv = new TemplateSrv();
+    templateSrv.init([
+      { type: 'query', name: 'host', multi: true, current: { value: ['a', "b'; DROP TABLE t; --"] } },
+    ]);
+    const ds = new MssqlDatasource(
+      { name: 'mssql', jsonData: { parameterizedQueries: true } },
+      templateSrv,
+      ctx.timeSrv
+    );
+    const options = {
+      range: {
+        from: dateTime(1432288354),
+        to: dateTime(1432288401),
+      },
+      targets: [
+        {
+          refId: 'A',
+          rawQuery: true,
+          rawSql: "SELECT * FROM t WHERE $__timeFilter(time) AND host IN ($host) AND env = 'prod'",
+          format: 'table',
+        },
+      ],
+    };
+
+    it('should send the SQL and the values of its variables', async () => {
+      fetchMock.mockImplementation(() => of(createFetchResponse({ results: {} })));
+      await ds.query(options).toPromise();
+
+      expect(fetchMock).toBeCalledTimes(1);
+      expect(fetchMock.mock.calls[0][0].data.queries[0]).toMatchObject({
+        refId: 'A',
+        rawSql: "SELECT * FROM t WHERE $__timeFilter(time) AND host IN ($host) AND env = 'prod'",
+        parameterized: true,
+        variables: { host: ['a', "b'; DROP TABLE t; --"] },
+      });
+    });
+  });
 });
@@ -9,6 +9,7 @@ import { MysqlMetricFindValue, MysqlQueryForInterpolation } from './types';
 import { getTemplateSrv, TemplateSrv } from 'app/features/templating/template_srv';
 import { getTimeSrv, TimeSrv } from 'app/features/dashboard/services/TimeSrv';
 import { getSearchFilterScopedVar } from '../../../features/variables/utils';
+import { getQueryParameters } from '../../../features/variables/getQueryParameters';
 
 export class MysqlDatasource {
   id: any;
@@ -16,6 +17,7 @@ export class MysqlDatasource {
   responseParser: ResponseParser;
   queryModel: MysqlQuery;
   interval: string;
+  parameterizedQueries: boolean;
 
   constructor(
     instanceSettings: any,
@@ -27,6 +29,7 @@ export class MysqlDatasource {
     this.responseParser = new ResponseParser();
     this.queryModel = new MysqlQuery({});
     this.interval = (instanceSettings.jsonData || {}).timeInterval || '1m';
+    this.parameterizedQueries = !!(instanceSettings.jsonData || {}).parameterizedQueries;
   }
 
   interpolateVariable = (value: string | string[] | number, variable: any) => {
@@ -49,6 +52,17 @@ export class MysqlDatasource {
     return quotedValues.join(',');
   };
 
+  /**
+   * Returns the SQL of a query with its template variables interpolated or, with parameterized queries, left for
+   * the backend to bind their values as query parameters.
+   */
+  interpolateQuery(rawSql: string, scopedVars?: ScopedVars) {
+    if (this.parameterizedQueries) {
+      return { rawSql, parameterized: true, variables: getQueryParameters(rawSql, this.templateSrv, scopedVars) };
+    }
+    return { rawSql: this.templateSrv.replace(rawSql, scopedVars, this.interpolateVariable) };
+  }
+
   interpolateVariablesInQueries(
     queries: MysqlQueryForInterpolation[],
     scopedVars: ScopedVars
@@ -79,7 +93,9 @@ export class MysqlDatasource {
         intervalMs: options.intervalMs,
         maxDataPoints: options.maxDataPoints,
         datasourceId: this.id,
-        rawSql: queryModel.render(this.interpolateVariable as any),
+        ...(this.parameterizedQueries
+          ? this.interpolateQuery(queryModel.render(false), options.scopedVars)
+          : { rawSql: queryModel.render(this.interpolateVariable as any) }),
         format: target.format,
       };
     });
@@ -111,7 +127,7 @@ export class MysqlDatasource {
     const query = {
       refId: options.annotation.name,
       datasourceId: this.id,
-      rawSql: this.templateSrv.replace(options.annotation.rawQuery, options.scopedVars, this.interpolateVariable),
+      ...this.interpolateQuery(options.annotation.rawQuery, options.scopedVars),
       format: 'table',
     };
 
@@ -135,16 +151,13 @@ export class MysqlDatasource {
       refId = optionalOptions.variable.name;
     }
 
-    const rawSql = this.templateSrv.replace(
-      query,
-      getSearchFilterScopedVar({ query, wildcardChar: '%', options: optionalOptions }),
-      this.interpolateVariable
-    );
-
     const interpolatedQuery = {
       refId: refId,
       datasourceId: this.id,
-      rawSql,
+      ...this.interpolateQuery(
+        query,
+        getSearchFilterScopedVar({ query, wildcardChar: '%', options: optionalOptions })
+      ),
       format: 'table',
     };
 
@@ -11,6 +11,7 @@ import { getTimeSrv, TimeSrv } from 'app/features/dashboard/services/TimeSrv';
 //Types
 import { PostgresMetricFindValue, PostgresQueryForInterpolation } from './types';
 import { getSearchFilterScopedVar } from '../../../features/variables/utils';
+import { getQueryParameters } from '../../../features/variables/getQueryParameters';
 
 export class PostgresDatasource {
   id: any;
@@ -19,6 +20,7 @@ export class PostgresDatasource {
   responseParser: ResponseParser;
   queryModel: PostgresQuery;
   interval: string;
+  parameterizedQueries: boolean;
 
   constructor(
     instanceSettings: { name: any; id?: any; jsonData?: any },
@@ -31,6 +33,7 @@ export class PostgresDatasource {
     this.responseParser = new ResponseParser();
     this.queryModel = new PostgresQuery({});
     this.interval = (instanceSettings.jsonData || {}).timeInterval || '1m';
+    this.parameterizedQueries = !!(instanceSettings.jsonData || {}).parameterizedQueries;
   }
 
   interpolateVariable = (value: string | string[], variable: { multi: any; includeAll: any }) => {
@@ -52,6 +55,17 @@ export class PostgresDatasource {
     return quotedValues.join(',');
   };
 
+  /**
+   * Returns the SQL of a query with its template variables interpolated or, with parameterized queries, left for
+   * the backend to bind their values as query parameters.
+   */
+  interpolateQuery(rawSql: string, scopedVars?: ScopedVars) {
+    if (this.parameterizedQueries) {
+      return { rawSql, parameterized: true, variables: getQueryParameters(rawSql, this.templateSrv, scopedVars) };
+    }
+    return { rawSql: this.templateSrv.replace(rawSql, scopedVars, this.interpolateVariable) };
+  }
+
   interpolateVariablesInQueries(
     queries: PostgresQueryForInterpolation[],
     scopedVars: ScopedVars
@@ -82,7 +96,9 @@ export class PostgresDatasource {
         intervalMs: options.intervalMs,
         maxDataPoints: options.maxDataPoints,
         datasourceId: this.id,
-        rawSql: queryModel.render(this.interpolateVariable),
+        ...(this.parameterizedQueries
+          ? this.interpolateQuery(queryModel.render(false), options.scopedVars)
+          : { rawSql: queryModel.render(this.interpolateVariable) }),
         format: target.format,
       };
     });
@@ -114,7 +130,7 @@ export class PostgresDatasource {
     const query = {
       refId: options.annotation.name,
       datasourceId: this.id,
-      rawSql: this.templateSrv.replace(options.annotation.rawQuery, options.scopedVars, this.interpolateVariable),
+      ...this.interpolateQuery(options.annotation.rawQuery, options.scopedVars),
       format: 'table',
     };
 
@@ -141,16 +157,13 @@ export class PostgresDatasource {
       refId = optionalOptions.variable.name;
     }
 
-    const rawSql = this.templateSrv.replace(
-      query,
-      getSearchFilterScopedVar({ query, wildcardChar: '%', options: optionalOptions }),
-      this.interpolateVariable
-    );
-
     const interpolatedQuery = {
       refId: refId,
       datasourceId: this.id,
-      rawSql,
+      ...this.interpolateQuery(
+        query,
+        getSearchFilterScopedVar({ query, wildcardChar: '%', options: optionalOptions })
+      ),
       format: 'table',
     };
 